@@ -0,0 +1,278 @@
+package dataio
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"xz_mcp/db/sqlutil"
+)
+
+// Endpoint 数据库连接及其方言
+type Endpoint struct {
+	DB      *sql.DB
+	Dialect string
+}
+
+// CopyOptions 跨库复制配置
+type CopyOptions struct {
+	Query        string   // 源查询
+	Table        string   // 目标表
+	Mode         string   // append/upsert/replace
+	KeyColumns   []string // upsert 冲突列及建表主键，默认使用目标表主键
+	CreateTable  bool
+	BatchSize    int
+	ErrorSamples int
+	Progress     func(rows int64) // 每写完一批回调一次
+}
+
+// CopyColumn 复制列及两端类型
+type CopyColumn struct {
+	Name       string  `json:"name"`
+	SourceType string  `json:"source_type"`
+	TargetType string  `json:"target_type,omitempty"`
+	Kind       SQLType `json:"-"`
+}
+
+// CopyResult 跨库复制结果
+type CopyResult struct {
+	Type            string       `json:"type"`
+	Table           string       `json:"table"`
+	Mode            string       `json:"mode"`
+	Columns         []CopyColumn `json:"columns"`
+	KeyColumns      []string     `json:"key_columns,omitempty"`
+	CreateStatement string       `json:"create_statement,omitempty"`
+	RowsRead        int64        `json:"rows_read"`
+	Written         int64        `json:"written"`
+	Failed          int64        `json:"failed"`
+	TargetBefore    int64        `json:"target_rows_before"`
+	TargetAfter     int64        `json:"target_rows_after"`
+	Mismatches      []string     `json:"mismatches"`
+	Errors          []RowError   `json:"errors"`
+	DurationMs      int64        `json:"duration_ms"`
+}
+
+// CopyTable 将源查询结果流式写入目标表，整个写入过程在目标库的单个事务中完成
+func CopyTable(ctx context.Context, src, dst Endpoint, opts CopyOptions) (*CopyResult, error) {
+	start := time.Now()
+	if opts.Query == "" || opts.Table == "" {
+		return nil, fmt.Errorf("source query and target table are required")
+	}
+	if opts.Mode == "" {
+		opts.Mode = ModeAppend
+	}
+	if opts.Mode != ModeAppend && opts.Mode != ModeUpsert && opts.Mode != ModeReplace {
+		return nil, fmt.Errorf("unsupported mode: %s (supported: append, upsert, replace)", opts.Mode)
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if opts.ErrorSamples <= 0 {
+		opts.ErrorSamples = DefaultErrorSamples
+	}
+
+	rows, err := src.DB.QueryContext(ctx, opts.Query)
+	if err != nil {
+		return nil, fmt.Errorf("source query failed: %v", err)
+	}
+	defer rows.Close()
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source column types: %v", err)
+	}
+
+	result := &CopyResult{
+		Type:       "copy_table",
+		Table:      opts.Table,
+		Mode:       opts.Mode,
+		Mismatches: []string{},
+		Errors:     []RowError{},
+	}
+	isKey := make(map[string]bool, len(opts.KeyColumns))
+	for _, k := range opts.KeyColumns {
+		isKey[strings.ToLower(k)] = true
+	}
+	columns := make([]CopyColumn, len(types))
+	for i, t := range types {
+		kind := ClassifyColumnType(t)
+		columns[i] = CopyColumn{
+			Name:       t.Name(),
+			SourceType: t.DatabaseTypeName(),
+			TargetType: RenderType(dst.Dialect, kind, isKey[strings.ToLower(t.Name())]),
+			Kind:       kind,
+		}
+	}
+
+	if opts.CreateTable {
+		stmt := copyCreateStatement(dst.Dialect, opts.Table, columns, opts.KeyColumns)
+		if _, err := dst.DB.ExecContext(ctx, stmt); err != nil {
+			return nil, fmt.Errorf("failed to create target table: %v", err)
+		}
+		result.CreateStatement = stmt
+	}
+
+	// 只复制两端都存在的列，其余列记录为不匹配
+	targetCols, err := sqlutil.TableColumns(ctx, dst.DB, dst.Dialect, opts.Table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read target table columns: %v", err)
+	}
+	targetSet := make(map[string]string, len(targetCols))
+	for _, c := range targetCols {
+		targetSet[strings.ToLower(c)] = c
+	}
+	var used []int
+	var names []string
+	sourceSet := make(map[string]bool, len(columns))
+	for i, c := range columns {
+		sourceSet[strings.ToLower(c.Name)] = true
+		if name, ok := targetSet[strings.ToLower(c.Name)]; ok {
+			used = append(used, i)
+			names = append(names, name)
+		} else {
+			result.Mismatches = append(result.Mismatches, fmt.Sprintf("source column %q does not exist in target table and was skipped", c.Name))
+		}
+	}
+	for _, c := range targetCols {
+		if !sourceSet[strings.ToLower(c)] {
+			result.Mismatches = append(result.Mismatches, fmt.Sprintf("target column %q is not provided by the source query", c))
+		}
+	}
+	if len(used) == 0 {
+		return nil, fmt.Errorf("no source column matches a column of %s", opts.Table)
+	}
+	for _, i := range used {
+		result.Columns = append(result.Columns, columns[i])
+	}
+	if limit := maxPlaceholders / len(used); opts.BatchSize > limit {
+		opts.BatchSize = limit
+	}
+
+	w := &tableWriter{
+		dialect: dst.Dialect,
+		table:   opts.Table,
+		columns: names,
+		onError: func(row int64, msg string) {
+			if len(result.Errors) < opts.ErrorSamples {
+				result.Errors = append(result.Errors, RowError{Row: row, Error: msg})
+			}
+		},
+	}
+	if opts.Mode == ModeUpsert {
+		w.upsert = true
+		w.keyColumns = opts.KeyColumns
+		if len(w.keyColumns) == 0 {
+			if w.keyColumns, err = sqlutil.PrimaryKey(ctx, dst.DB, dst.Dialect, opts.Table); err != nil {
+				return nil, fmt.Errorf("failed to look up primary key of %s: %v", opts.Table, err)
+			}
+		}
+		if len(w.keyColumns) == 0 && dst.Dialect != sqlutil.DialectMySQL {
+			return nil, fmt.Errorf("upsert needs key_columns or a primary key on %s", opts.Table)
+		}
+		result.KeyColumns = w.keyColumns
+	}
+
+	tx, err := dst.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	countSQL := "SELECT COUNT(*) FROM " + sqlutil.QuoteTableName(dst.Dialect, opts.Table)
+	if opts.Mode == ModeReplace {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+sqlutil.QuoteTableName(dst.Dialect, opts.Table)); err != nil {
+			return nil, fmt.Errorf("failed to clear target table: %v", err)
+		}
+	}
+	if err := tx.QueryRowContext(ctx, countSQL).Scan(&result.TargetBefore); err != nil {
+		return nil, fmt.Errorf("failed to count target rows: %v", err)
+	}
+
+	values := make([]interface{}, len(columns))
+	ptrs := make([]interface{}, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	batch := make([][]interface{}, 0, opts.BatchSize)
+	rowNums := make([]int64, 0, opts.BatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := w.writeBatch(ctx, tx, batch, rowNums); err != nil {
+			return err
+		}
+		batch, rowNums = batch[:0], rowNums[:0]
+		if opts.Progress != nil {
+			opts.Progress(result.RowsRead)
+		}
+		return nil
+	}
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, fmt.Errorf("failed to scan source row: %v", err)
+		}
+		result.RowsRead++
+		row := make([]interface{}, len(used))
+		for j, i := range used {
+			row[j] = copyValue(values[i], columns[i].Kind)
+		}
+		batch = append(batch, row)
+		rowNums = append(rowNums, result.RowsRead)
+		if len(batch) >= opts.BatchSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("source row iteration error: %v", err)
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	if err := tx.QueryRowContext(ctx, countSQL).Scan(&result.TargetAfter); err != nil {
+		return nil, fmt.Errorf("failed to count target rows: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit copy: %v", err)
+	}
+
+	result.Written, result.Failed = w.inserted, w.failed
+	if result.Failed > 0 {
+		result.Mismatches = append(result.Mismatches, fmt.Sprintf("%d source rows were rejected by the target", result.Failed))
+	}
+	if opts.Mode != ModeUpsert && result.TargetAfter != result.TargetBefore+result.Written {
+		result.Mismatches = append(result.Mismatches, fmt.Sprintf("target has %d rows, expected %d", result.TargetAfter, result.TargetBefore+result.Written))
+	}
+	result.DurationMs = time.Since(start).Milliseconds()
+	return result, nil
+}
+
+// copyCreateStatement 生成目标表建表语句
+func copyCreateStatement(dialect, table string, columns []CopyColumn, keyColumns []string) string {
+	defs := make([]string, 0, len(columns)+1)
+	for _, c := range columns {
+		defs = append(defs, sqlutil.QuoteIdent(dialect, c.Name)+" "+c.TargetType)
+	}
+	if len(keyColumns) > 0 {
+		keys := make([]string, len(keyColumns))
+		for i, k := range keyColumns {
+			keys[i] = sqlutil.QuoteIdent(dialect, k)
+		}
+		defs = append(defs, "PRIMARY KEY ("+strings.Join(keys, ", ")+")")
+	}
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", sqlutil.QuoteTableName(dialect, table), strings.Join(defs, ", "))
+}
+
+// copyValue 转换源值以便写入其他方言：非二进制列的 []byte 转为字符串
+func copyValue(v interface{}, kind SQLType) interface{} {
+	if b, ok := v.([]byte); ok {
+		if kind.Kind == "binary" {
+			return append([]byte(nil), b...)
+		}
+		return string(b)
+	}
+	return v
+}
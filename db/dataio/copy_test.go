@@ -0,0 +1,115 @@
+package dataio
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"xz_mcp/db/sqlutil"
+)
+
+func TestClassifyAndRenderType(t *testing.T) {
+	tests := []struct {
+		name              string
+		mysql, pg, sqlite string
+	}{
+		{"VARCHAR(64)", "VARCHAR(64)", "VARCHAR(64)", "VARCHAR(64)"},
+		{"DECIMAL(10,2)", "DECIMAL(10,2)", "NUMERIC(10,2)", "NUMERIC(10,2)"},
+		{"INT8", "BIGINT", "BIGINT", "INTEGER"},
+		{"UNSIGNED BIGINT", "DECIMAL(20,0)", "NUMERIC(20,0)", "NUMERIC(20,0)"},
+		{"BIGINT UNSIGNED", "DECIMAL(20,0)", "NUMERIC(20,0)", "NUMERIC(20,0)"},
+		{"TIMESTAMPTZ", "DATETIME(6)", "TIMESTAMPTZ", "TEXT"},
+		{"DATETIME", "DATETIME(6)", "TIMESTAMP", "TEXT"},
+		{"JSONB", "JSON", "JSONB", "TEXT"},
+		{"BYTEA", "LONGBLOB", "BYTEA", "BLOB"},
+		{"TEXT", "LONGTEXT", "TEXT", "TEXT"},
+		{"", "LONGTEXT", "TEXT", "TEXT"},
+	}
+	for _, tt := range tests {
+		kind := ClassifyTypeName(tt.name)
+		got := []string{
+			RenderType(sqlutil.DialectMySQL, kind, false),
+			RenderType(sqlutil.DialectPostgres, kind, false),
+			RenderType(sqlutil.DialectSQLite, kind, false),
+		}
+		if got[0] != tt.mysql || got[1] != tt.pg || got[2] != tt.sqlite {
+			t.Errorf("%q rendered as %v", tt.name, got)
+		}
+	}
+	if got := RenderType(sqlutil.DialectMySQL, ClassifyTypeName("TEXT"), true); got != "VARCHAR(255)" {
+		t.Errorf("TEXT key column rendered as %s", got)
+	}
+}
+
+func openEmptyDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestCopyTableModes(t *testing.T) {
+	ctx := context.Background()
+	src := Endpoint{DB: openTestDB(t), Dialect: sqlutil.DialectSQLite}
+	dst := Endpoint{DB: openEmptyDB(t), Dialect: sqlutil.DialectSQLite}
+
+	var progress []int64
+	result, err := CopyTable(ctx, src, dst, CopyOptions{
+		Query:       "SELECT id, name, score FROM users",
+		Table:       "users_copy",
+		KeyColumns:  []string{"id"},
+		CreateTable: true,
+		BatchSize:   2,
+		Progress:    func(n int64) { progress = append(progress, n) },
+	})
+	if err != nil {
+		t.Fatalf("CopyTable: %v", err)
+	}
+	if result.RowsRead != 3 || result.Written != 3 || result.TargetAfter != 3 || len(result.Mismatches) != 0 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if len(progress) != 2 || progress[1] != 3 {
+		t.Fatalf("progress = %v", progress)
+	}
+	wantCreate := `CREATE TABLE IF NOT EXISTS "users_copy" ("id" INTEGER, "name" TEXT, "score" REAL, PRIMARY KEY ("id"))`
+	if result.CreateStatement != wantCreate {
+		t.Fatalf("create statement = %s", result.CreateStatement)
+	}
+
+	// append 时主键冲突的行逐行失败
+	src.DB.Exec("UPDATE users SET name = 'ALICE' WHERE id = 1")
+	result, err = CopyTable(ctx, src, dst, CopyOptions{Query: "SELECT id, name, active FROM users", Table: "users_copy"})
+	if err != nil {
+		t.Fatalf("CopyTable append: %v", err)
+	}
+	if result.Written != 0 || result.Failed != 3 || len(result.Errors) != 3 {
+		t.Fatalf("unexpected append result: %+v", result)
+	}
+	if len(result.Mismatches) < 2 {
+		t.Fatalf("expected column and row mismatches, got %v", result.Mismatches)
+	}
+
+	// upsert 使用目标表主键更新已有行
+	result, err = CopyTable(ctx, src, dst, CopyOptions{Query: "SELECT id, name FROM users", Table: "users_copy", Mode: ModeUpsert})
+	if err != nil {
+		t.Fatalf("CopyTable upsert: %v", err)
+	}
+	var name string
+	dst.DB.QueryRow("SELECT name FROM users_copy WHERE id = 1").Scan(&name)
+	if result.Written != 3 || name != "ALICE" || len(result.KeyColumns) != 1 {
+		t.Fatalf("unexpected upsert result: %+v, name=%s", result, name)
+	}
+
+	// replace 先清空目标表
+	result, err = CopyTable(ctx, src, dst, CopyOptions{Query: "SELECT id, name FROM users WHERE id < 3", Table: "users_copy", Mode: ModeReplace})
+	if err != nil {
+		t.Fatalf("CopyTable replace: %v", err)
+	}
+	if result.TargetBefore != 0 || result.TargetAfter != 2 {
+		t.Fatalf("unexpected replace result: %+v", result)
+	}
+}
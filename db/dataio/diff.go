@@ -0,0 +1,704 @@
+package dataio
+
+import (
+	"context"
+	"crypto/md5"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"modernc.org/sqlite"
+
+	"xz_mcp/db/sqlutil"
+)
+
+// 比较策略
+const (
+	StrategyAuto     = "auto"
+	StrategyChecksum = "checksum" // 按键范围分块，在数据库端计算校验和，只拉取不一致的块
+	StrategyStream   = "stream"   // 两端按键排序流式读取并逐行比较（跨引擎时使用）
+)
+
+// 比较默认值
+const (
+	DefaultChunkSize   = 10000
+	DefaultSampleLimit = 10
+	DefaultMaxPending  = 200000
+)
+
+// sqliteRowHash SQLite 中用于计算行校验和的自定义函数名
+const sqliteRowHash = "xz_row_hash"
+
+func init() {
+	sqlite.MustRegisterDeterministicScalarFunction(sqliteRowHash, -1, func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		var buf strings.Builder
+		for _, a := range args {
+			switch v := a.(type) {
+			case nil:
+				buf.WriteString("N")
+			case []byte:
+				fmt.Fprintf(&buf, "%d:%s", len(v), v)
+			default:
+				text := fmt.Sprint(v)
+				fmt.Fprintf(&buf, "%d:%s", len(text), text)
+			}
+		}
+		sum := md5.Sum([]byte(buf.String()))
+		// 只取 40 位，保证按块求和时不会溢出 int64
+		return int64(binary.BigEndian.Uint64(sum[:8]) >> 24), nil
+	})
+}
+
+// DiffSource 比较的一端：表或查询
+type DiffSource struct {
+	Endpoint
+	Table string
+	Query string
+}
+
+// relation 返回可放在 FROM 后面的关系表达式
+func (s DiffSource) relation() string {
+	if s.Table != "" {
+		return sqlutil.QuoteTableName(s.Dialect, s.Table)
+	}
+	return "(" + sqlutil.TrimStatement(s.Query) + ") diff_src"
+}
+
+func (s DiffSource) describe() string {
+	if s.Table != "" {
+		return s.Dialect + ":" + s.Table
+	}
+	return s.Dialect + ":query"
+}
+
+// DiffOptions 数据比较配置
+type DiffOptions struct {
+	KeyColumns  []string
+	Columns     []string // 参与比较的列，默认两端共有的全部非键列
+	Strategy    string
+	ChunkSize   int
+	SampleLimit int
+	MaxPending  int // stream 策略最多暂存的未匹配行数，超过时报错而不是无限占用内存
+	Progress    func(done, total int64)
+}
+
+// ColumnDiff 单列差异
+type ColumnDiff struct {
+	Column string      `json:"column"`
+	Left   interface{} `json:"left"`
+	Right  interface{} `json:"right"`
+}
+
+// ChangedRow 键相同但内容不同的行
+type ChangedRow struct {
+	Key   map[string]interface{} `json:"key"`
+	Diffs []ColumnDiff           `json:"diffs"`
+}
+
+// DiffSamples 差异样本
+type DiffSamples struct {
+	OnlyLeft  []map[string]interface{} `json:"only_left"`
+	OnlyRight []map[string]interface{} `json:"only_right"`
+	Changed   []ChangedRow             `json:"changed"`
+}
+
+// DiffResult 数据比较结果
+type DiffResult struct {
+	Type           string      `json:"type"`
+	Left           string      `json:"left"`
+	Right          string      `json:"right"`
+	Strategy       string      `json:"strategy"`
+	KeyColumns     []string    `json:"key_columns"`
+	Columns        []string    `json:"columns"`
+	LeftRows       int64       `json:"left_rows"`
+	RightRows      int64       `json:"right_rows"`
+	OnlyLeft       int64       `json:"only_left"`
+	OnlyRight      int64       `json:"only_right"`
+	Changed        int64       `json:"changed"`
+	Identical      bool        `json:"identical"`
+	Chunks         int64       `json:"chunks,omitempty"`
+	ChunksDiffered int64       `json:"chunks_differed,omitempty"`
+	Samples        DiffSamples `json:"samples"`
+	Notes          []string    `json:"notes,omitempty"`
+	DurationMs     int64       `json:"duration_ms"`
+}
+
+// differ 单次比较的状态
+type differ struct {
+	left, right DiffSource
+	opts        DiffOptions
+	keys        []string
+	columns     []string
+	result      *DiffResult
+}
+
+// DiffData 按键列比较两个数据源
+func DiffData(ctx context.Context, left, right DiffSource, opts DiffOptions) (*DiffResult, error) {
+	start := time.Now()
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = DefaultChunkSize
+	}
+	if opts.SampleLimit <= 0 {
+		opts.SampleLimit = DefaultSampleLimit
+	}
+	if opts.MaxPending <= 0 {
+		opts.MaxPending = DefaultMaxPending
+	}
+	d := &differ{
+		left:  left,
+		right: right,
+		opts:  opts,
+		result: &DiffResult{
+			Type:  "diff_data",
+			Left:  left.describe(),
+			Right: right.describe(),
+			Samples: DiffSamples{
+				OnlyLeft:  []map[string]interface{}{},
+				OnlyRight: []map[string]interface{}{},
+				Changed:   []ChangedRow{},
+			},
+		},
+	}
+	if err := d.resolveColumns(ctx); err != nil {
+		return nil, err
+	}
+
+	strategy := opts.Strategy
+	if strategy == "" || strategy == StrategyAuto {
+		strategy = StrategyStream
+		if left.Dialect == right.Dialect {
+			strategy = StrategyChecksum
+		}
+	}
+	var err error
+	switch strategy {
+	case StrategyChecksum:
+		if left.Dialect != right.Dialect {
+			return nil, fmt.Errorf("checksum strategy requires both sides on the same engine")
+		}
+		err = d.runChecksum(ctx)
+	case StrategyStream:
+		err = d.runStream(ctx)
+	default:
+		return nil, fmt.Errorf("unsupported strategy: %s", strategy)
+	}
+	if err != nil {
+		return nil, err
+	}
+	d.result.Strategy = strategy
+	d.result.Identical = d.result.OnlyLeft == 0 && d.result.OnlyRight == 0 && d.result.Changed == 0
+	d.result.DurationMs = time.Since(start).Milliseconds()
+	return d.result, nil
+}
+
+// resolveColumns 确定键列与比较列
+func (d *differ) resolveColumns(ctx context.Context) error {
+	leftCols, err := relationColumns(ctx, d.left)
+	if err != nil {
+		return fmt.Errorf("left: %v", err)
+	}
+	rightCols, err := relationColumns(ctx, d.right)
+	if err != nil {
+		return fmt.Errorf("right: %v", err)
+	}
+
+	d.keys = d.opts.KeyColumns
+	if len(d.keys) == 0 {
+		if d.left.Table == "" {
+			return fmt.Errorf("key_columns is required when the left side is a query")
+		}
+		if d.keys, err = sqlutil.PrimaryKey(ctx, d.left.DB, d.left.Dialect, d.left.Table); err != nil {
+			return fmt.Errorf("failed to look up primary key of %s: %v", d.left.Table, err)
+		}
+		if len(d.keys) == 0 {
+			return fmt.Errorf("%s has no primary key, please pass key_columns", d.left.Table)
+		}
+	}
+
+	rightSet := make(map[string]bool, len(rightCols))
+	for _, c := range rightCols {
+		rightSet[strings.ToLower(c)] = true
+	}
+	leftSet := make(map[string]bool, len(leftCols))
+	for _, c := range leftCols {
+		leftSet[strings.ToLower(c)] = true
+	}
+	isKey := make(map[string]bool, len(d.keys))
+	for _, k := range d.keys {
+		if !leftSet[strings.ToLower(k)] || !rightSet[strings.ToLower(k)] {
+			return fmt.Errorf("key column %q must exist on both sides", k)
+		}
+		isKey[strings.ToLower(k)] = true
+	}
+
+	if len(d.opts.Columns) > 0 {
+		for _, c := range d.opts.Columns {
+			if !leftSet[strings.ToLower(c)] || !rightSet[strings.ToLower(c)] {
+				return fmt.Errorf("column %q must exist on both sides", c)
+			}
+		}
+		d.columns = d.opts.Columns
+	} else {
+		for _, c := range leftCols {
+			switch {
+			case isKey[strings.ToLower(c)]:
+			case rightSet[strings.ToLower(c)]:
+				d.columns = append(d.columns, c)
+			default:
+				d.result.Notes = append(d.result.Notes, fmt.Sprintf("column %q only exists on the left side and is not compared", c))
+			}
+		}
+		for _, c := range rightCols {
+			if !leftSet[strings.ToLower(c)] {
+				d.result.Notes = append(d.result.Notes, fmt.Sprintf("column %q only exists on the right side and is not compared", c))
+			}
+		}
+	}
+	d.result.KeyColumns = d.keys
+	d.result.Columns = d.columns
+	if d.result.Columns == nil {
+		d.result.Columns = []string{}
+	}
+	return nil
+}
+
+func relationColumns(ctx context.Context, s DiffSource) ([]string, error) {
+	rows, err := s.DB.QueryContext(ctx, "SELECT * FROM "+s.relation()+" WHERE 1 = 0")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return rows.Columns()
+}
+
+// selectList 键列在前、比较列在后的列清单
+func (d *differ) selectList(dialect string) string {
+	cols := make([]string, 0, len(d.keys)+len(d.columns))
+	for _, c := range append(append([]string{}, d.keys...), d.columns...) {
+		cols = append(cols, sqlutil.QuoteIdent(dialect, c))
+	}
+	return strings.Join(cols, ", ")
+}
+
+func (d *differ) keyList(dialect string) string {
+	keys := make([]string, len(d.keys))
+	for i, k := range d.keys {
+		keys[i] = sqlutil.QuoteIdent(dialect, k)
+	}
+	return strings.Join(keys, ", ")
+}
+
+// runStream 两端按键排序流式读取；键相同的行立即比较，未匹配的行暂存，最终剩余的即为单侧独有。
+// 一端读完后另一端的行只需与该端的暂存比较，不再暂存；两端顺序不一致（如排序规则不同）导致暂存超过
+// MaxPending 时报错
+func (d *differ) runStream(ctx context.Context) error {
+	query := func(s DiffSource) string {
+		return fmt.Sprintf("SELECT %s FROM %s ORDER BY %s", d.selectList(s.Dialect), s.relation(), d.keyList(s.Dialect))
+	}
+	leftRows, err := d.left.DB.QueryContext(ctx, query(d.left))
+	if err != nil {
+		return fmt.Errorf("left query failed: %v", err)
+	}
+	defer leftRows.Close()
+	rightRows, err := d.right.DB.QueryContext(ctx, query(d.right))
+	if err != nil {
+		return fmt.Errorf("right query failed: %v", err)
+	}
+	defer rightRows.Close()
+
+	pendingLeft := map[string][]interface{}{}
+	pendingRight := map[string][]interface{}{}
+	leftDone, rightDone := false, false
+	for !leftDone || !rightDone {
+		if !leftDone {
+			row, err := d.nextRow(leftRows)
+			if err != nil {
+				return fmt.Errorf("left: %v", err)
+			}
+			if row == nil {
+				leftDone = true
+			} else {
+				d.result.LeftRows++
+				d.match(row, pendingLeft, pendingRight, true, rightDone)
+				if d.opts.Progress != nil && d.result.LeftRows%int64(d.opts.ChunkSize) == 0 {
+					d.opts.Progress(d.result.LeftRows, 0)
+				}
+			}
+		}
+		if !rightDone {
+			row, err := d.nextRow(rightRows)
+			if err != nil {
+				return fmt.Errorf("right: %v", err)
+			}
+			if row == nil {
+				rightDone = true
+			} else {
+				d.result.RightRows++
+				d.match(row, pendingRight, pendingLeft, false, leftDone)
+			}
+		}
+		if len(pendingLeft)+len(pendingRight) > d.opts.MaxPending {
+			return fmt.Errorf("more than %d unmatched rows buffered; the two sides are probably not sorted the same way (e.g. different collations) or differ heavily, narrow the comparison with left_sql/right_sql or use the checksum strategy on the same engine", d.opts.MaxPending)
+		}
+	}
+	d.flushPending(pendingLeft, pendingRight)
+	return nil
+}
+
+// match 在对端暂存中查找同键行，找到则比较；否则对端已读完时直接计为本端独有，未读完时放入本端暂存
+func (d *differ) match(row []interface{}, own, other map[string][]interface{}, isLeft, otherDone bool) {
+	key := d.keyString(row)
+	if peer, ok := other[key]; ok {
+		delete(other, key)
+		if isLeft {
+			d.compare(row, peer)
+		} else {
+			d.compare(peer, row)
+		}
+		return
+	}
+	if !otherDone {
+		own[key] = row
+		return
+	}
+	if isLeft {
+		d.flushPending(map[string][]interface{}{key: row}, nil)
+	} else {
+		d.flushPending(nil, map[string][]interface{}{key: row})
+	}
+}
+
+func (d *differ) flushPending(left, right map[string][]interface{}) {
+	for _, row := range left {
+		d.result.OnlyLeft++
+		if len(d.result.Samples.OnlyLeft) < d.opts.SampleLimit {
+			d.result.Samples.OnlyLeft = append(d.result.Samples.OnlyLeft, d.rowMap(row))
+		}
+	}
+	for _, row := range right {
+		d.result.OnlyRight++
+		if len(d.result.Samples.OnlyRight) < d.opts.SampleLimit {
+			d.result.Samples.OnlyRight = append(d.result.Samples.OnlyRight, d.rowMap(row))
+		}
+	}
+}
+
+// compare 比较同键的两行
+func (d *differ) compare(left, right []interface{}) {
+	var diffs []ColumnDiff
+	n := len(d.keys)
+	for i, col := range d.columns {
+		l, r := left[n+i], right[n+i]
+		if canonicalValue(l) != canonicalValue(r) {
+			diffs = append(diffs, ColumnDiff{Column: col, Left: l, Right: r})
+		}
+	}
+	if len(diffs) == 0 {
+		return
+	}
+	d.result.Changed++
+	if len(d.result.Samples.Changed) < d.opts.SampleLimit {
+		key := make(map[string]interface{}, n)
+		for i, k := range d.keys {
+			key[k] = left[i]
+		}
+		d.result.Samples.Changed = append(d.result.Samples.Changed, ChangedRow{Key: key, Diffs: diffs})
+	}
+}
+
+func (d *differ) nextRow(rows *sql.Rows) ([]interface{}, error) {
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	values := make([]interface{}, len(d.keys)+len(d.columns))
+	ptrs := make([]interface{}, len(values))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	if err := rows.Scan(ptrs...); err != nil {
+		return nil, err
+	}
+	for i, v := range values {
+		if b, ok := v.([]byte); ok {
+			values[i] = string(b)
+		}
+	}
+	return values, nil
+}
+
+func (d *differ) keyString(row []interface{}) string {
+	parts := make([]string, len(d.keys))
+	for i := range d.keys {
+		parts[i] = canonicalValue(row[i])
+	}
+	return strings.Join(parts, "\x1f")
+}
+
+func (d *differ) rowMap(row []interface{}) map[string]interface{} {
+	m := make(map[string]interface{}, len(row))
+	for i, k := range d.keys {
+		m[k] = row[i]
+	}
+	for i, c := range d.columns {
+		m[c] = row[len(d.keys)+i]
+	}
+	return m
+}
+
+// runChecksum 以左侧的键划分区间，两端分别计算每个区间的行数和校验和，只对不一致的区间逐行比较。
+// 区间边界按键逐个定位，不会把左侧的键全部读到客户端
+func (d *differ) runChecksum(ctx context.Context) error {
+	var leftTotal int64
+	if err := d.left.DB.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s", d.left.relation())).Scan(&leftTotal); err != nil {
+		return fmt.Errorf("failed to count left rows: %v", err)
+	}
+	expected := (leftTotal + int64(d.opts.ChunkSize) - 1) / int64(d.opts.ChunkSize)
+	if expected == 0 {
+		expected = 1
+	}
+
+	var lower []interface{}
+	for i := int64(0); ; i++ {
+		upper, err := d.nextBound(ctx, lower)
+		if err != nil {
+			return err
+		}
+		where, args := d.rangePredicate(d.left.Dialect, lower, upper)
+
+		leftCount, leftSum, err := d.checksum(ctx, d.left, where, args)
+		if err != nil {
+			return fmt.Errorf("left checksum failed: %v", err)
+		}
+		rightCount, rightSum, err := d.checksum(ctx, d.right, where, args)
+		if err != nil {
+			return fmt.Errorf("right checksum failed: %v", err)
+		}
+		d.result.Chunks++
+		d.result.LeftRows += leftCount
+		d.result.RightRows += rightCount
+		if leftCount != rightCount || leftSum != rightSum {
+			d.result.ChunksDiffered++
+			if err := d.diffRange(ctx, where, args); err != nil {
+				return err
+			}
+		}
+		if d.opts.Progress != nil {
+			if i+1 > expected {
+				expected = i + 1
+			}
+			d.opts.Progress(i+1, expected)
+		}
+		if upper == nil {
+			break
+		}
+		lower = upper
+	}
+	if d.result.LeftRows == 0 {
+		d.result.Notes = append(d.result.Notes, "left side is empty, a single unbounded chunk was compared")
+	}
+	return nil
+}
+
+// nextBound 以键集分页定位 lower 之后第 ChunkSize 行的键，作为下一个区间的上界（不含）；
+// 剩余不足一个区间时返回 nil
+func (d *differ) nextBound(ctx context.Context, lower []interface{}) ([]interface{}, error) {
+	where, args := d.rangePredicate(d.left.Dialect, lower, nil)
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY %s LIMIT 1 OFFSET %d",
+		d.keyList(d.left.Dialect), d.left.relation(), where, d.keyList(d.left.Dialect), d.opts.ChunkSize)
+	rows, err := d.left.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read left keys: %v", err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	bound := make([]interface{}, len(d.keys))
+	ptrs := make([]interface{}, len(bound))
+	for i := range bound {
+		ptrs[i] = &bound[i]
+	}
+	if err := rows.Scan(ptrs...); err != nil {
+		return nil, err
+	}
+	for i, v := range bound {
+		if b, ok := v.([]byte); ok {
+			bound[i] = string(b)
+		}
+	}
+	return bound, rows.Err()
+}
+
+// rangePredicate 生成 lower <= key < upper 的条件，多列键使用行值比较
+func (d *differ) rangePredicate(dialect string, lower, upper []interface{}) (string, []interface{}) {
+	keyExpr := d.keyList(dialect)
+	if len(d.keys) > 1 {
+		keyExpr = "(" + keyExpr + ")"
+	}
+	var conds []string
+	var args []interface{}
+	tuple := func(values []interface{}) string {
+		ph := make([]string, len(values))
+		for i, v := range values {
+			args = append(args, v)
+			if dialect == sqlutil.DialectPostgres {
+				ph[i] = "$" + strconv.Itoa(len(args))
+			} else {
+				ph[i] = "?"
+			}
+		}
+		if len(ph) > 1 {
+			return "(" + strings.Join(ph, ", ") + ")"
+		}
+		return ph[0]
+	}
+	if lower != nil {
+		conds = append(conds, keyExpr+" >= "+tuple(lower))
+	}
+	if upper != nil {
+		conds = append(conds, keyExpr+" < "+tuple(upper))
+	}
+	if len(conds) == 0 {
+		return "1 = 1", nil
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// checksum 计算区间内的行数和校验和
+func (d *differ) checksum(ctx context.Context, s DiffSource, where string, args []interface{}) (int64, string, error) {
+	query := fmt.Sprintf("SELECT COUNT(*), %s FROM %s WHERE %s", checksumExpr(s.Dialect, append(append([]string{}, d.keys...), d.columns...)), s.relation(), where)
+	var count int64
+	var sum interface{}
+	if err := s.DB.QueryRowContext(ctx, query, args...).Scan(&count, &sum); err != nil {
+		return 0, "", err
+	}
+	return count, canonicalValue(sum), nil
+}
+
+// checksumExpr 各方言下的行哈希聚合表达式。每列编码为 长度:值，NULL 编码为 N，
+// 拼接结果无歧义：('a#', 'b') 与 ('a', '#b')、NULL 与空串的哈希不同
+func checksumExpr(dialect string, columns []string) string {
+	parts := make([]string, len(columns))
+	switch dialect {
+	case sqlutil.DialectMySQL:
+		for i, c := range columns {
+			text := fmt.Sprintf("CAST(%s AS CHAR)", sqlutil.QuoteIdent(dialect, c))
+			parts[i] = fmt.Sprintf("COALESCE(CONCAT(CHAR_LENGTH(%s), ':', %s), 'N')", text, text)
+		}
+		return fmt.Sprintf("COALESCE(BIT_XOR(CAST(CONV(SUBSTRING(MD5(CONCAT(%s)), 1, 15), 16, 10) AS UNSIGNED)), 0)", strings.Join(parts, ", "))
+	case sqlutil.DialectPostgres:
+		for i, c := range columns {
+			text := sqlutil.QuoteIdent(dialect, c) + "::text"
+			parts[i] = fmt.Sprintf("COALESCE(length(%s) || ':' || %s, 'N')", text, text)
+		}
+		return fmt.Sprintf("COALESCE(SUM(('x' || SUBSTR(MD5(%s), 1, 15))::bit(60)::bigint), 0)", strings.Join(parts, " || "))
+	}
+	for i, c := range columns {
+		parts[i] = sqlutil.QuoteIdent(dialect, c)
+	}
+	return fmt.Sprintf("COALESCE(SUM(%s(%s)), 0)", sqliteRowHash, strings.Join(parts, ", "))
+}
+
+// diffRange 拉取区间内两端的全部行并逐行比较
+func (d *differ) diffRange(ctx context.Context, where string, args []interface{}) error {
+	load := func(s DiffSource) (map[string][]interface{}, error) {
+		query := fmt.Sprintf("SELECT %s FROM %s WHERE %s", d.selectList(s.Dialect), s.relation(), where)
+		rows, err := s.DB.QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		out := map[string][]interface{}{}
+		for {
+			row, err := d.nextRow(rows)
+			if err != nil {
+				return nil, err
+			}
+			if row == nil {
+				return out, nil
+			}
+			out[d.keyString(row)] = row
+		}
+	}
+	left, err := load(d.left)
+	if err != nil {
+		return fmt.Errorf("failed to load left rows: %v", err)
+	}
+	right, err := load(d.right)
+	if err != nil {
+		return fmt.Errorf("failed to load right rows: %v", err)
+	}
+	for key, row := range left {
+		if peer, ok := right[key]; ok {
+			d.compare(row, peer)
+			delete(left, key)
+			delete(right, key)
+		}
+	}
+	d.flushPending(left, right)
+	return nil
+}
+
+// canonicalValue 将不同驱动返回的值规范化为可比较的文本：
+// 数字按数值、布尔按 0/1、时间按统一格式比较
+func canonicalValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "\x00NULL"
+	case []byte:
+		return canonicalString(string(val))
+	case string:
+		return canonicalString(val)
+	case bool:
+		if val {
+			return "1"
+		}
+		return "0"
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case int32:
+		return strconv.FormatInt(int64(val), 10)
+	case int:
+		return strconv.Itoa(val)
+	case uint64:
+		return strconv.FormatUint(val, 10)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(val), 'f', -1, 32)
+	case time.Time:
+		return val.UTC().Format(canonicalTimeLayout)
+	}
+	return fmt.Sprint(v)
+}
+
+const canonicalTimeLayout = "2006-01-02 15:04:05.999999999"
+
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999Z07:00", canonicalTimeLayout}
+
+func canonicalString(s string) string {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return strconv.FormatInt(n, 10)
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	switch strings.ToLower(s) {
+	case "true":
+		return "1"
+	case "false":
+		return "0"
+	}
+	if len(s) >= 19 && s[4] == '-' && s[7] == '-' {
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t.UTC().Format(canonicalTimeLayout)
+			}
+		}
+	}
+	return s
+}
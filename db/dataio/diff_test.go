@@ -0,0 +1,181 @@
+package dataio
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"xz_mcp/db/sqlutil"
+)
+
+func seedDiffDB(t *testing.T, stmts ...string) *sql.DB {
+	t.Helper()
+	db := openEmptyDB(t)
+	for _, s := range stmts {
+		if _, err := db.Exec(s); err != nil {
+			t.Fatalf("%s: %v", s, err)
+		}
+	}
+	return db
+}
+
+func TestDiffData(t *testing.T) {
+	ctx := context.Background()
+	left := seedDiffDB(t,
+		"CREATE TABLE t (id INTEGER PRIMARY KEY, name TEXT, price NUMERIC, only_left TEXT)",
+		"INSERT INTO t VALUES (1,'a',1.5,'x'),(2,'b',2,'x'),(3,'c',3,'x'),(4,'d',4,'x'),(5,'e',5,'x'),(6,'f',NULL,'x')",
+	)
+	right := seedDiffDB(t,
+		"CREATE TABLE t (id INTEGER PRIMARY KEY, name TEXT, price TEXT)",
+		"INSERT INTO t VALUES (1,'a','1.50'),(2,'B','2'),(4,'d','4'),(5,'e','5'),(6,'f','0'),(7,'g','7')",
+	)
+
+	for _, strategy := range []string{StrategyChecksum, StrategyStream} {
+		var progress [][2]int64
+		result, err := DiffData(ctx,
+			DiffSource{Endpoint: Endpoint{DB: left, Dialect: sqlutil.DialectSQLite}, Table: "t"},
+			DiffSource{Endpoint: Endpoint{DB: right, Dialect: sqlutil.DialectSQLite}, Table: "t"},
+			DiffOptions{Strategy: strategy, ChunkSize: 2, Progress: func(done, total int64) { progress = append(progress, [2]int64{done, total}) }},
+		)
+		if err != nil {
+			t.Fatalf("%s: DiffData: %v", strategy, err)
+		}
+		if result.OnlyLeft != 1 || result.OnlyRight != 1 || result.Changed != 2 || result.Identical {
+			t.Fatalf("%s: unexpected result: %+v", strategy, result)
+		}
+		if result.LeftRows != 6 || result.RightRows != 6 || len(result.KeyColumns) != 1 || len(result.Columns) != 2 {
+			t.Fatalf("%s: unexpected counts: %+v", strategy, result)
+		}
+		if result.Samples.OnlyLeft[0]["id"] != int64(3) || result.Samples.OnlyRight[0]["id"] != int64(7) {
+			t.Fatalf("%s: unexpected samples: %+v", strategy, result.Samples)
+		}
+		if len(result.Notes) != 1 {
+			t.Fatalf("%s: expected a note about only_left, got %v", strategy, result.Notes)
+		}
+		if strategy == StrategyChecksum && (result.Chunks != 3 || result.ChunksDiffered == 0) {
+			t.Fatalf("checksum: chunks=%d differed=%d", result.Chunks, result.ChunksDiffered)
+		}
+		if strategy == StrategyChecksum && (len(progress) != 3 || progress[2] != [2]int64{3, 3}) {
+			t.Fatalf("checksum: unexpected progress %v", progress)
+		}
+	}
+}
+
+func TestDiffDataCompositeKeyQuery(t *testing.T) {
+	ctx := context.Background()
+	left := seedDiffDB(t,
+		"CREATE TABLE s (a INTEGER, b TEXT, v INTEGER)",
+		"INSERT INTO s VALUES (1,'x',1),(1,'y',2),(2,'x',3),(2,'y',4)",
+	)
+	right := seedDiffDB(t,
+		"CREATE TABLE s (a INTEGER, b TEXT, v INTEGER)",
+		"INSERT INTO s VALUES (1,'x',1),(1,'y',2),(2,'x',30),(2,'y',4)",
+	)
+	result, err := DiffData(ctx,
+		DiffSource{Endpoint: Endpoint{DB: left, Dialect: sqlutil.DialectSQLite}, Query: "SELECT * FROM s"},
+		DiffSource{Endpoint: Endpoint{DB: right, Dialect: sqlutil.DialectSQLite}, Table: "s"},
+		DiffOptions{KeyColumns: []string{"a", "b"}, ChunkSize: 3},
+	)
+	if err != nil {
+		t.Fatalf("DiffData: %v", err)
+	}
+	if result.Strategy != StrategyChecksum || result.Changed != 1 || result.OnlyLeft != 0 || result.OnlyRight != 0 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	diff := result.Samples.Changed[0]
+	if diff.Key["a"] != int64(2) || diff.Key["b"] != "x" || diff.Diffs[0].Column != "v" {
+		t.Fatalf("unexpected changed sample: %+v", diff)
+	}
+
+	if _, err := DiffData(ctx,
+		DiffSource{Endpoint: Endpoint{DB: left, Dialect: sqlutil.DialectSQLite}, Query: "SELECT * FROM s"},
+		DiffSource{Endpoint: Endpoint{DB: right, Dialect: sqlutil.DialectSQLite}, Table: "s"},
+		DiffOptions{},
+	); err == nil {
+		t.Fatal("expected error without key columns for a query source")
+	}
+}
+
+func TestDiffDataChecksumSeparators(t *testing.T) {
+	ctx := context.Background()
+	// 不加长度前缀时 ('a#', 'b') 与 ('a', '#b') 拼接后相同，NULL 与空串也可能被混为一谈
+	left := seedDiffDB(t,
+		"CREATE TABLE t (id INTEGER PRIMARY KEY, x TEXT, y TEXT)",
+		"INSERT INTO t VALUES (1,'a#','b'),(2,NULL,'c'),(3,'N','d')",
+	)
+	right := seedDiffDB(t,
+		"CREATE TABLE t (id INTEGER PRIMARY KEY, x TEXT, y TEXT)",
+		"INSERT INTO t VALUES (1,'a','#b'),(2,'','c'),(3,NULL,'d')",
+	)
+	result, err := DiffData(ctx,
+		DiffSource{Endpoint: Endpoint{DB: left, Dialect: sqlutil.DialectSQLite}, Table: "t"},
+		DiffSource{Endpoint: Endpoint{DB: right, Dialect: sqlutil.DialectSQLite}, Table: "t"},
+		DiffOptions{Strategy: StrategyChecksum, ChunkSize: 1},
+	)
+	if err != nil {
+		t.Fatalf("DiffData: %v", err)
+	}
+	if result.Changed != 3 || result.ChunksDiffered != 3 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	for dialect, want := range map[string]string{
+		sqlutil.DialectMySQL:    "COALESCE(CONCAT(CHAR_LENGTH(CAST(`x` AS CHAR)), ':', CAST(`x` AS CHAR)), 'N')",
+		sqlutil.DialectPostgres: `COALESCE(length("x"::text) || ':' || "x"::text, 'N')`,
+	} {
+		if expr := checksumExpr(dialect, []string{"x"}); !strings.Contains(expr, want) {
+			t.Errorf("%s: checksum expression %s", dialect, expr)
+		}
+	}
+}
+
+func TestDiffDataStreamMaxPending(t *testing.T) {
+	ctx := context.Background()
+	left := seedDiffDB(t,
+		"CREATE TABLE t (id INTEGER PRIMARY KEY, v TEXT)",
+		"INSERT INTO t VALUES (1,'a'),(2,'b'),(3,'c'),(4,'d')",
+	)
+	right := seedDiffDB(t, "CREATE TABLE t (id INTEGER PRIMARY KEY, v TEXT)")
+	diff := func() (*DiffResult, error) {
+		return DiffData(ctx,
+			DiffSource{Endpoint: Endpoint{DB: left, Dialect: sqlutil.DialectSQLite}, Table: "t"},
+			DiffSource{Endpoint: Endpoint{DB: right, Dialect: sqlutil.DialectSQLite}, Table: "t"},
+			DiffOptions{Strategy: StrategyStream, KeyColumns: []string{"id"}, MaxPending: 2},
+		)
+	}
+
+	// 对端为空时单侧独有的行直接计数，不受暂存上限影响
+	result, err := diff()
+	if err != nil {
+		t.Fatalf("DiffData: %v", err)
+	}
+	if result.OnlyLeft != 4 || len(result.Samples.OnlyLeft) != 4 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	// 两端交错且互不匹配时暂存超过上限
+	if _, err := right.Exec("INSERT INTO t VALUES (11,'a'),(12,'b'),(13,'c'),(14,'d')"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := diff(); err == nil || !strings.Contains(err.Error(), "unmatched rows") {
+		t.Fatalf("expected pending limit error, got %v", err)
+	}
+}
+
+func TestCanonicalValue(t *testing.T) {
+	pairs := [][2]interface{}{
+		{"1.50", 1.5},
+		{int64(10), "10"},
+		{true, int64(1)},
+		{[]byte("2024-01-02 03:04:05"), "2024-01-02T03:04:05Z"},
+	}
+	for _, p := range pairs {
+		if canonicalValue(p[0]) != canonicalValue(p[1]) {
+			t.Errorf("%v and %v should compare equal", p[0], p[1])
+		}
+	}
+	if canonicalValue(nil) == canonicalValue("") {
+		t.Error("NULL and empty string should differ")
+	}
+}
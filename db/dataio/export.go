@@ -0,0 +1,622 @@
+package dataio
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// 支持的文件格式
+const (
+	FormatCSV      = "csv"
+	FormatJSONL    = "jsonl"
+	FormatParquet  = "parquet"
+	FormatMarkdown = "markdown"
+)
+
+// 列的逻辑类型
+const (
+	TypeString = "string"
+	TypeInt    = "int"
+	TypeFloat  = "float"
+	TypeBool   = "bool"
+)
+
+// DefaultPreviewRows 导出结果中默认返回的预览行数
+const DefaultPreviewRows = 5
+
+// parquetFlushRows Parquet 每写入多少行刷新一次行组，避免整个文件驻留内存
+const parquetFlushRows = 10000
+
+// Column 导出列定义
+type Column struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// CSVDialect CSV 格式配置
+type CSVDialect struct {
+	Delimiter string `json:"delimiter,omitempty"`  // 分隔符，默认逗号
+	NoHeader  bool   `json:"no_header,omitempty"`  // 不输出表头
+	NullValue string `json:"null_value,omitempty"` // NULL 的输出文本，默认为空
+	UseCRLF   bool   `json:"use_crlf,omitempty"`   // 使用 \r\n 换行
+}
+
+// ExportOptions 导出配置
+type ExportOptions struct {
+	Format      string
+	Path        string
+	Overwrite   bool
+	PreviewRows int
+	CSV         CSVDialect
+}
+
+// ExportResult 导出结果
+type ExportResult struct {
+	Type    string                   `json:"type"`
+	Format  string                   `json:"format"`
+	Path    string                   `json:"path"`
+	Rows    int64                    `json:"rows"`
+	Bytes   int64                    `json:"bytes"`
+	Columns []Column                 `json:"columns"`
+	Preview []map[string]interface{} `json:"preview"`
+}
+
+// rowWriter 按格式流式写出行
+type rowWriter interface {
+	writeRow(values []interface{}) error
+	close() error
+}
+
+// Exporter 流式导出器；先写入同目录的临时文件，完成后才替换目标文件，失败时目标文件保持不变
+type Exporter struct {
+	opts    ExportOptions
+	columns []Column
+	file    *os.File // 临时文件
+	writer  rowWriter
+	rows    int64
+	preview []map[string]interface{}
+}
+
+// NewExporter 创建导出文件并初始化对应格式的写出器。目标文件已存在且 opts.Overwrite 为 false 时报错；
+// 重名的列（如 SELECT a.id, b.id）依次改名为 id_2、id_3
+func NewExporter(opts ExportOptions, columns []Column) (*Exporter, error) {
+	if opts.Path == "" {
+		return nil, fmt.Errorf("export path is required")
+	}
+	if opts.Format == "" {
+		opts.Format = formatFromPath(opts.Path)
+	}
+	if opts.PreviewRows <= 0 {
+		opts.PreviewRows = DefaultPreviewRows
+	}
+	path, err := filepath.Abs(opts.Path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid export path: %v", err)
+	}
+	opts.Path = path
+
+	if info, err := os.Lstat(path); err == nil {
+		if !opts.Overwrite {
+			return nil, existsError(path)
+		}
+		if !info.Mode().IsRegular() {
+			return nil, fmt.Errorf("%s is not a regular file", path)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create export directory: %v", err)
+	}
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create export file: %v", err)
+	}
+	if err := file.Chmod(0644); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, fmt.Errorf("failed to create export file: %v", err)
+	}
+
+	columns = uniqueColumns(columns)
+	e := &Exporter{opts: opts, columns: columns, file: file}
+	switch opts.Format {
+	case FormatCSV:
+		e.writer, err = newCSVWriter(file, columns, opts.CSV)
+	case FormatJSONL:
+		e.writer = newJSONLWriter(file, columns)
+	case FormatParquet:
+		e.writer = newParquetWriter(file, columns)
+	case FormatMarkdown:
+		e.writer, err = newMarkdownWriter(file, columns)
+	default:
+		err = fmt.Errorf("unsupported export format: %s (supported: csv, jsonl, parquet, markdown)", opts.Format)
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, err
+	}
+	return e, nil
+}
+
+func existsError(path string) error {
+	return fmt.Errorf("file %s already exists (set overwrite to replace it)", path)
+}
+
+// uniqueColumns 重名的列追加 _2、_3 等后缀，避免在 JSONL/Parquet 中合并为同一个字段
+func uniqueColumns(columns []Column) []Column {
+	original := make(map[string]bool, len(columns))
+	for _, c := range columns {
+		original[c.Name] = true
+	}
+	used := make(map[string]bool, len(columns))
+	out := make([]Column, len(columns))
+	for i, c := range columns {
+		if used[c.Name] {
+			base := c.Name
+			for n := 2; used[c.Name] || original[c.Name]; n++ {
+				c.Name = fmt.Sprintf("%s_%d", base, n)
+			}
+		}
+		used[c.Name] = true
+		out[i] = c
+	}
+	return out
+}
+
+// Write 写入一行，值的顺序与列定义一致
+func (e *Exporter) Write(values []interface{}) error {
+	normalized := make([]interface{}, len(e.columns))
+	for i, col := range e.columns {
+		if i < len(values) {
+			normalized[i] = NormalizeValue(values[i], col.Type)
+		}
+	}
+	if err := e.writer.writeRow(normalized); err != nil {
+		return fmt.Errorf("failed to write row %d: %v", e.rows+1, err)
+	}
+	e.rows++
+	if len(e.preview) < e.opts.PreviewRows {
+		row := make(map[string]interface{}, len(e.columns))
+		for i, col := range e.columns {
+			row[col.Name] = normalized[i]
+		}
+		e.preview = append(e.preview, row)
+	}
+	return nil
+}
+
+// Abort 放弃导出并删除临时文件，目标文件保持不变
+func (e *Exporter) Abort() {
+	e.writer.close()
+	e.file.Close()
+	os.Remove(e.file.Name())
+}
+
+// Close 完成导出并返回统计信息
+func (e *Exporter) Close() (*ExportResult, error) {
+	if err := e.writer.close(); err != nil {
+		e.file.Close()
+		os.Remove(e.file.Name())
+		return nil, fmt.Errorf("failed to finish export: %v", err)
+	}
+	if err := e.file.Close(); err != nil {
+		os.Remove(e.file.Name())
+		return nil, fmt.Errorf("failed to close export file: %v", err)
+	}
+	if err := e.publish(); err != nil {
+		os.Remove(e.file.Name())
+		return nil, err
+	}
+	info, err := os.Stat(e.opts.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat export file: %v", err)
+	}
+	preview := e.preview
+	if preview == nil {
+		preview = []map[string]interface{}{}
+	}
+	return &ExportResult{
+		Type:    "export",
+		Format:  e.opts.Format,
+		Path:    e.opts.Path,
+		Rows:    e.rows,
+		Bytes:   info.Size(),
+		Columns: e.columns,
+		Preview: preview,
+	}, nil
+}
+
+// publish 把临时文件移动到目标路径；不覆盖时用硬链接，导出期间出现的同名文件也不会被替换
+func (e *Exporter) publish() error {
+	tmp, path := e.file.Name(), e.opts.Path
+	if e.opts.Overwrite {
+		return os.Rename(tmp, path)
+	}
+	err := os.Link(tmp, path)
+	switch {
+	case err == nil:
+		return os.Remove(tmp)
+	case os.IsExist(err):
+		return existsError(path)
+	}
+	// 文件系统不支持硬链接
+	if _, err := os.Lstat(path); err == nil {
+		return existsError(path)
+	}
+	return os.Rename(tmp, path)
+}
+
+// ExportRows 将SQL结果集流式写入文件
+func ExportRows(rows *sql.Rows, opts ExportOptions) (*ExportResult, error) {
+	columns, err := ColumnsOf(rows)
+	if err != nil {
+		return nil, err
+	}
+	exporter, err := NewExporter(opts, columns)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(columns))
+	valuePtrs := make([]interface{}, len(columns))
+	for i := range values {
+		valuePtrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(valuePtrs...); err != nil {
+			exporter.Abort()
+			return nil, fmt.Errorf("failed to scan row: %v", err)
+		}
+		if err := exporter.Write(values); err != nil {
+			exporter.Abort()
+			return nil, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		exporter.Abort()
+		return nil, fmt.Errorf("row iteration error: %v", err)
+	}
+	return exporter.Close()
+}
+
+// ColumnsOf 根据结果集的列类型推断导出列定义
+func ColumnsOf(rows *sql.Rows) ([]Column, error) {
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get column types: %v", err)
+	}
+	columns := make([]Column, len(types))
+	for i, t := range types {
+		columns[i] = Column{Name: t.Name(), Type: LogicalType(t.DatabaseTypeName())}
+	}
+	return columns, nil
+}
+
+// LogicalType 将数据库类型名映射为导出使用的逻辑类型
+func LogicalType(dbType string) string {
+	t := strings.ToUpper(dbType)
+	switch {
+	case t == "":
+		return TypeString
+	case strings.Contains(t, "UNSIGNED BIGINT"):
+		// 可能超出 int64 范围
+		return TypeString
+	case strings.Contains(t, "INT") || strings.Contains(t, "SERIAL"):
+		if strings.Contains(t, "INTERVAL") || strings.Contains(t, "POINT") {
+			return TypeString
+		}
+		return TypeInt
+	case strings.Contains(t, "FLOAT") || strings.Contains(t, "DOUBLE") || t == "REAL":
+		return TypeFloat
+	case strings.HasPrefix(t, "BOOL"):
+		return TypeBool
+	}
+	return TypeString
+}
+
+// NormalizeValue 将驱动返回的值转换为目标逻辑类型，无法转换时保留为字符串
+func NormalizeValue(v interface{}, typ string) interface{} {
+	switch val := v.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return NormalizeValue(string(val), typ)
+	case time.Time:
+		return val.Format(time.RFC3339Nano)
+	case string:
+		switch typ {
+		case TypeInt:
+			if n, err := strconv.ParseInt(val, 10, 64); err == nil {
+				return n
+			}
+		case TypeFloat:
+			if f, err := strconv.ParseFloat(val, 64); err == nil {
+				return f
+			}
+		case TypeBool:
+			if b, err := strconv.ParseBool(val); err == nil {
+				return b
+			}
+		}
+		return val
+	case int:
+		return normalizeInt(int64(val), typ)
+	case int32:
+		return normalizeInt(int64(val), typ)
+	case int64:
+		return normalizeInt(val, typ)
+	case uint64:
+		if typ == TypeInt && val <= 1<<63-1 {
+			return int64(val)
+		}
+		return strconv.FormatUint(val, 10)
+	case float32:
+		return normalizeFloat(float64(val), typ)
+	case float64:
+		return normalizeFloat(val, typ)
+	case bool:
+		if typ == TypeString {
+			return strconv.FormatBool(val)
+		}
+		return val
+	}
+	// 复合值(如Redis的hash/list)保持原样，由各格式自行编码为JSON
+	return v
+}
+
+func normalizeInt(n int64, typ string) interface{} {
+	switch typ {
+	case TypeString:
+		return strconv.FormatInt(n, 10)
+	case TypeFloat:
+		return float64(n)
+	case TypeBool:
+		return n != 0
+	}
+	return n
+}
+
+func normalizeFloat(f float64, typ string) interface{} {
+	switch typ {
+	case TypeString:
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	case TypeInt:
+		if f == float64(int64(f)) {
+			return int64(f)
+		}
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return f
+}
+
+// formatFromPath 根据文件扩展名推断导出格式
+func formatFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jsonl", ".ndjson":
+		return FormatJSONL
+	case ".parquet":
+		return FormatParquet
+	case ".md", ".markdown":
+		return FormatMarkdown
+	}
+	return FormatCSV
+}
+
+// textValue 将值转换为文本（CSV/Markdown 使用）
+func textValue(v interface{}, null string) string {
+	switch val := v.(type) {
+	case nil:
+		return null
+	case string:
+		return val
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
+
+// csvWriter CSV 写出器
+type csvWriter struct {
+	w    *csv.Writer
+	null string
+	buf  []string
+}
+
+func newCSVWriter(file *os.File, columns []Column, dialect CSVDialect) (*csvWriter, error) {
+	w := csv.NewWriter(file)
+	if dialect.Delimiter != "" {
+		if dialect.Delimiter == `\t` {
+			dialect.Delimiter = "\t"
+		}
+		runes := []rune(dialect.Delimiter)
+		if len(runes) != 1 {
+			return nil, fmt.Errorf("CSV delimiter must be a single character")
+		}
+		w.Comma = runes[0]
+	}
+	w.UseCRLF = dialect.UseCRLF
+	if !dialect.NoHeader {
+		header := make([]string, len(columns))
+		for i, c := range columns {
+			header[i] = c.Name
+		}
+		if err := w.Write(header); err != nil {
+			return nil, err
+		}
+	}
+	return &csvWriter{w: w, null: dialect.NullValue, buf: make([]string, len(columns))}, nil
+}
+
+func (c *csvWriter) writeRow(values []interface{}) error {
+	for i, v := range values {
+		c.buf[i] = textValue(v, c.null)
+	}
+	return c.w.Write(c.buf)
+}
+
+func (c *csvWriter) close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// jsonlWriter JSON Lines 写出器
+type jsonlWriter struct {
+	w       *bufio.Writer
+	columns []Column
+}
+
+func newJSONLWriter(file *os.File, columns []Column) *jsonlWriter {
+	return &jsonlWriter{w: bufio.NewWriter(file), columns: columns}
+}
+
+func (j *jsonlWriter) writeRow(values []interface{}) error {
+	// 按列顺序手动拼接，保证字段顺序稳定
+	j.w.WriteByte('{')
+	for i, col := range j.columns {
+		if i > 0 {
+			j.w.WriteByte(',')
+		}
+		key, _ := json.Marshal(col.Name)
+		j.w.Write(key)
+		j.w.WriteByte(':')
+		val, err := json.Marshal(values[i])
+		if err != nil {
+			val, _ = json.Marshal(fmt.Sprintf("%v", values[i]))
+		}
+		j.w.Write(val)
+	}
+	j.w.WriteByte('}')
+	return j.w.WriteByte('\n')
+}
+
+func (j *jsonlWriter) close() error {
+	return j.w.Flush()
+}
+
+// markdownWriter Markdown 表格写出器
+type markdownWriter struct {
+	w   *bufio.Writer
+	buf []string
+}
+
+func newMarkdownWriter(file *os.File, columns []Column) (*markdownWriter, error) {
+	m := &markdownWriter{w: bufio.NewWriter(file), buf: make([]string, len(columns))}
+	header := make([]string, len(columns))
+	sep := make([]string, len(columns))
+	for i, c := range columns {
+		header[i] = markdownEscape(c.Name)
+		sep[i] = "---"
+		if c.Type == TypeInt || c.Type == TypeFloat {
+			sep[i] = "---:"
+		}
+	}
+	_, err := fmt.Fprintf(m.w, "| %s |\n| %s |\n", strings.Join(header, " | "), strings.Join(sep, " | "))
+	return m, err
+}
+
+func (m *markdownWriter) writeRow(values []interface{}) error {
+	for i, v := range values {
+		m.buf[i] = markdownEscape(textValue(v, "NULL"))
+	}
+	_, err := fmt.Fprintf(m.w, "| %s |\n", strings.Join(m.buf, " | "))
+	return err
+}
+
+func (m *markdownWriter) close() error {
+	return m.w.Flush()
+}
+
+func markdownEscape(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	s = strings.ReplaceAll(s, "\r\n", "<br>")
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
+
+// parquetWriter Parquet 写出器
+type parquetWriter struct {
+	w       *parquet.Writer
+	columns []Column
+	pending int
+}
+
+// ParquetSchema 根据列定义构建 Parquet schema（所有列均可为空）
+func ParquetSchema(columns []Column) *parquet.Schema {
+	group := parquet.Group{}
+	for _, c := range columns {
+		var node parquet.Node
+		switch c.Type {
+		case TypeInt:
+			node = parquet.Int(64)
+		case TypeFloat:
+			node = parquet.Leaf(parquet.DoubleType)
+		case TypeBool:
+			node = parquet.Leaf(parquet.BooleanType)
+		default:
+			node = parquet.String()
+		}
+		group[c.Name] = parquet.Optional(node)
+	}
+	return parquet.NewSchema("export", group)
+}
+
+func newParquetWriter(file *os.File, columns []Column) *parquetWriter {
+	return &parquetWriter{
+		w:       parquet.NewWriter(file, ParquetSchema(columns)),
+		columns: columns,
+	}
+}
+
+func (p *parquetWriter) writeRow(values []interface{}) error {
+	row := make(map[string]interface{}, len(p.columns))
+	for i, c := range p.columns {
+		v := values[i]
+		// 无法转换为列类型的值（如 SQLite INTEGER 列中的文本）报错，而不是写成 NULL 丢失数据
+		ok := true
+		switch c.Type {
+		case TypeInt:
+			_, ok = v.(int64)
+		case TypeFloat:
+			_, ok = v.(float64)
+		case TypeBool:
+			_, ok = v.(bool)
+		default:
+			if v != nil {
+				v = textValue(v, "")
+			}
+		}
+		if v != nil && !ok {
+			return fmt.Errorf("column %s: value %v cannot be written as %s (CAST the column in the query)", c.Name, v, c.Type)
+		}
+		row[c.Name] = v
+	}
+	if err := p.w.Write(row); err != nil {
+		return err
+	}
+	p.pending++
+	if p.pending >= parquetFlushRows {
+		p.pending = 0
+		return p.w.Flush()
+	}
+	return nil
+}
+
+func (p *parquetWriter) close() error {
+	return p.w.Close()
+}
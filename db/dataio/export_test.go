@@ -0,0 +1,230 @@
+package dataio
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+	_ "modernc.org/sqlite"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	stmts := []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, score REAL, active BOOLEAN)",
+		"INSERT INTO users VALUES (1, 'alice', 9.5, 1), (2, 'bob|x', NULL, 0), (3, NULL, 7, 1)",
+	}
+	for _, s := range stmts {
+		if _, err := db.Exec(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func exportUsers(t *testing.T, db *sql.DB, opts ExportOptions) *ExportResult {
+	t.Helper()
+	rows, err := db.Query("SELECT id, name, score, active FROM users ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	result, err := ExportRows(rows, opts)
+	if err != nil {
+		t.Fatalf("ExportRows: %v", err)
+	}
+	if result.Rows != 3 {
+		t.Fatalf("rows = %d, want 3", result.Rows)
+	}
+	info, _ := os.Stat(result.Path)
+	if info == nil || info.Size() != result.Bytes {
+		t.Fatalf("bytes = %d does not match file size", result.Bytes)
+	}
+	return result
+}
+
+func TestExportCSV(t *testing.T) {
+	db := openTestDB(t)
+	path := filepath.Join(t.TempDir(), "users.csv")
+	exportUsers(t, db, ExportOptions{Path: path, CSV: CSVDialect{Delimiter: ";", NullValue: `\N`}})
+
+	data, _ := os.ReadFile(path)
+	want := "id;name;score;active\n1;alice;9.5;true\n2;bob|x;\\N;false\n3;\\N;7;true\n"
+	if string(data) != want {
+		t.Fatalf("csv output:\n%s\nwant:\n%s", data, want)
+	}
+
+	if _, err := NewExporter(ExportOptions{Path: path}, nil); err == nil {
+		t.Fatal("expected error when file exists and overwrite is false")
+	}
+}
+
+func TestExportJSONL(t *testing.T) {
+	db := openTestDB(t)
+	path := filepath.Join(t.TempDir(), "users.jsonl")
+	result := exportUsers(t, db, ExportOptions{Path: path, PreviewRows: 2})
+	if result.Format != FormatJSONL || len(result.Preview) != 2 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	f, _ := os.Open(path)
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Scan()
+	if got := scanner.Text(); got != `{"id":1,"name":"alice","score":9.5,"active":true}` {
+		t.Fatalf("first line = %s", got)
+	}
+	var row map[string]interface{}
+	scanner.Scan()
+	if err := json.Unmarshal(scanner.Bytes(), &row); err != nil || row["score"] != nil {
+		t.Fatalf("second line = %s", scanner.Text())
+	}
+}
+
+func TestExportMarkdown(t *testing.T) {
+	db := openTestDB(t)
+	path := filepath.Join(t.TempDir(), "users.md")
+	exportUsers(t, db, ExportOptions{Path: path})
+
+	data, _ := os.ReadFile(path)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if lines[0] != "| id | name | score | active |" || lines[1] != "| ---: | --- | ---: | --- |" {
+		t.Fatalf("unexpected header: %q", lines[:2])
+	}
+	if lines[3] != `| 2 | bob\|x | NULL | false |` {
+		t.Fatalf("unexpected row: %q", lines[3])
+	}
+}
+
+func TestExportParquet(t *testing.T) {
+	db := openTestDB(t)
+	path := filepath.Join(t.TempDir(), "users.parquet")
+	exportUsers(t, db, ExportOptions{Path: path})
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	info, _ := f.Stat()
+	pf, err := parquet.OpenFile(f, info.Size())
+	if err != nil {
+		t.Fatalf("open parquet: %v", err)
+	}
+	if pf.NumRows() != 3 {
+		t.Fatalf("parquet rows = %d, want 3", pf.NumRows())
+	}
+	reader := parquet.NewGenericReader[map[string]interface{}](f, pf.Schema())
+	defer reader.Close()
+	rows := make([]map[string]interface{}, 3)
+	for i := range rows {
+		rows[i] = map[string]interface{}{}
+	}
+	if n, _ := reader.Read(rows); n != 3 {
+		t.Fatalf("read %d rows", n)
+	}
+	if rows[0]["name"] != "alice" || rows[0]["id"] != int64(1) || rows[1]["score"] != nil {
+		t.Fatalf("unexpected parquet rows: %v", rows)
+	}
+}
+
+func TestExportParquetTypeMismatch(t *testing.T) {
+	db := openTestDB(t)
+	// SQLite 允许 INTEGER 列保存文本，这样的值不能静默写成 NULL
+	for _, stmt := range []string{
+		"CREATE TABLE scores (user_id INTEGER, points INTEGER)",
+		"INSERT INTO scores VALUES (1, 10), (2, 20), (3, 30), (4, 'n/a')",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rows, err := db.Query("SELECT user_id, points FROM scores ORDER BY user_id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	path := filepath.Join(t.TempDir(), "users.parquet")
+	_, err = ExportRows(rows, ExportOptions{Path: path})
+	if err == nil || !strings.Contains(err.Error(), "row 4") || !strings.Contains(err.Error(), "column points") {
+		t.Fatalf("expected error naming row and column, got %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("failed export left a file behind: %v", err)
+	}
+}
+
+func TestExportDuplicateColumns(t *testing.T) {
+	db := openTestDB(t)
+	rows, err := db.Query("SELECT a.id, b.id, a.name AS id_2 FROM users a JOIN users b ON b.id = a.id ORDER BY a.id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	result, err := ExportRows(rows, ExportOptions{Path: filepath.Join(t.TempDir(), "users.jsonl")})
+	if err != nil {
+		t.Fatalf("ExportRows: %v", err)
+	}
+	var names []string
+	for _, c := range result.Columns {
+		names = append(names, c.Name)
+	}
+	if strings.Join(names, ",") != "id,id_3,id_2" || len(result.Preview[0]) != 3 {
+		t.Fatalf("columns = %v, preview = %v", names, result.Preview[0])
+	}
+}
+
+func TestExportOverwrite(t *testing.T) {
+	db := openTestDB(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "users.csv")
+	if err := os.WriteFile(path, []byte("keep me"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	content := func() string {
+		data, _ := os.ReadFile(path)
+		return string(data)
+	}
+
+	rows, err := db.Query("SELECT id FROM users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ExportRows(rows, ExportOptions{Path: path}); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("expected existing file to be refused, got %v", err)
+	}
+	rows.Close()
+
+	// 覆盖时导出失败也不会截断原文件
+	rows, err = db.Query("SELECT id FROM users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ExportRows(rows, ExportOptions{Path: path, Overwrite: true, Format: "xml"}); err == nil {
+		t.Fatal("expected unsupported format error")
+	}
+	rows.Close()
+	if content() != "keep me" {
+		t.Fatalf("original file changed: %q", content())
+	}
+
+	exportUsers(t, db, ExportOptions{Path: path, Overwrite: true})
+	if !strings.HasPrefix(content(), "id,name,score,active") {
+		t.Fatalf("file not replaced: %q", content())
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Fatalf("temporary files left behind: %v", entries)
+	}
+}
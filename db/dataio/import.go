@@ -0,0 +1,504 @@
+package dataio
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+
+	"xz_mcp/db/sqlutil"
+)
+
+// 导入方式
+const (
+	MethodAuto     = "auto"
+	MethodInsert   = "insert"    // 批量多行 INSERT
+	MethodCopy     = "copy"      // PostgreSQL COPY FROM STDIN
+	MethodLoadData = "load_data" // MySQL LOAD DATA LOCAL INFILE
+)
+
+// 导入默认值
+const (
+	DefaultBatchSize    = 500
+	DefaultInferRows    = 1000
+	DefaultErrorSamples = 10
+	maxPlaceholders     = 65535
+)
+
+// ImportOptions 导入配置
+type ImportOptions struct {
+	Path         string
+	Format       string
+	CSV          CSVDialect
+	Table        string
+	Mapping      map[string]string // 源列名 -> 目标列名，为空时按源列名导入全部列
+	CreateTable  bool
+	Method       string
+	BatchSize    int
+	ErrorSamples int
+}
+
+// ImportColumn 导入列映射
+type ImportColumn struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Type   string `json:"type"`
+	index  int
+}
+
+// RowError 单行错误样本
+type RowError struct {
+	Row   int64  `json:"row"`
+	Error string `json:"error"`
+}
+
+// ImportResult 导入结果
+type ImportResult struct {
+	Type            string         `json:"type"`
+	Table           string         `json:"table"`
+	Method          string         `json:"method"`
+	Columns         []ImportColumn `json:"columns"`
+	CreateStatement string         `json:"create_statement,omitempty"`
+	RowsRead        int64          `json:"rows_read"`
+	Inserted        int64          `json:"inserted"`
+	Skipped         int64          `json:"skipped"`
+	Failed          int64          `json:"failed"`
+	Errors          []RowError     `json:"errors"`
+	Notes           []string       `json:"notes,omitempty"`
+}
+
+// importer 单次导入的状态
+type importer struct {
+	db      *sql.DB
+	dialect string
+	opts    ImportOptions
+	columns []ImportColumn
+	result  *ImportResult
+}
+
+// Import 将 CSV/JSONL/Parquet 文件导入到目标表
+func Import(ctx context.Context, db *sql.DB, dialect string, opts ImportOptions) (*ImportResult, error) {
+	if opts.Table == "" {
+		return nil, fmt.Errorf("target table is required")
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if opts.ErrorSamples <= 0 {
+		opts.ErrorSamples = DefaultErrorSamples
+	}
+	columns, err := planColumns(opts)
+	if err != nil {
+		return nil, err
+	}
+	if limit := maxPlaceholders / len(columns); opts.BatchSize > limit {
+		opts.BatchSize = limit
+	}
+
+	imp := &importer{
+		db:      db,
+		dialect: dialect,
+		opts:    opts,
+		columns: columns,
+		result: &ImportResult{
+			Type:    "import",
+			Table:   opts.Table,
+			Columns: columns,
+			Errors:  []RowError{},
+		},
+	}
+
+	if opts.CreateTable {
+		stmt := CreateTableStatement(dialect, opts.Table, columns)
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return nil, fmt.Errorf("failed to create table: %v", err)
+		}
+		imp.result.CreateStatement = stmt
+	}
+
+	method := opts.Method
+	if method == "" || method == MethodAuto {
+		method = imp.autoMethod(ctx)
+	}
+	switch method {
+	case MethodCopy:
+		if dialect != sqlutil.DialectPostgres {
+			return nil, fmt.Errorf("method copy is only supported on PostgreSQL")
+		}
+		err = imp.runCopy(ctx)
+	case MethodLoadData:
+		if dialect != sqlutil.DialectMySQL {
+			return nil, fmt.Errorf("method load_data is only supported on MySQL")
+		}
+		err = imp.runLoadData(ctx)
+	case MethodInsert:
+		err = imp.runInsert(ctx)
+	default:
+		return nil, fmt.Errorf("unsupported import method: %s", method)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return imp.result, nil
+}
+
+// planColumns 读取源文件表头和样本行，确定列映射和推断类型
+func planColumns(opts ImportOptions) ([]ImportColumn, error) {
+	reader, err := OpenRecords(opts.Path, opts.Format, opts.CSV)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	var columns []ImportColumn
+	for i, name := range reader.Columns() {
+		target := name
+		if len(opts.Mapping) > 0 {
+			mapped, ok := opts.Mapping[name]
+			if !ok {
+				continue
+			}
+			target = mapped
+		}
+		columns = append(columns, ImportColumn{Source: name, Target: target, index: i})
+	}
+	for source := range opts.Mapping {
+		found := false
+		for _, c := range columns {
+			found = found || c.Source == source
+		}
+		if !found {
+			return nil, fmt.Errorf("mapped column %q not found in source file (columns: %s)", source, strings.Join(reader.Columns(), ", "))
+		}
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no columns to import")
+	}
+
+	types := make([]string, len(reader.Columns()))
+	for n := 0; n < DefaultInferRows; n++ {
+		values, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		var recordErr *RecordError
+		if errors.As(err, &recordErr) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for i, v := range values {
+			types[i] = MergeType(types[i], InferType(v))
+		}
+	}
+	for i := range columns {
+		columns[i].Type = types[columns[i].index]
+		if columns[i].Type == "" {
+			columns[i].Type = TypeString
+		}
+	}
+	return columns, nil
+}
+
+// CreateTableStatement 根据推断的列类型生成建表语句
+func CreateTableStatement(dialect, table string, columns []ImportColumn) string {
+	defs := make([]string, len(columns))
+	for i, c := range columns {
+		defs[i] = sqlutil.QuoteIdent(dialect, c.Target) + " " + columnSQLType(dialect, c.Type)
+	}
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", sqlutil.QuoteTableName(dialect, table), strings.Join(defs, ", "))
+}
+
+// columnSQLType 逻辑类型到各方言列类型的映射
+func columnSQLType(dialect, typ string) string {
+	switch typ {
+	case TypeInt:
+		if dialect == sqlutil.DialectSQLite {
+			return "INTEGER"
+		}
+		return "BIGINT"
+	case TypeFloat:
+		switch dialect {
+		case sqlutil.DialectPostgres:
+			return "DOUBLE PRECISION"
+		case sqlutil.DialectSQLite:
+			return "REAL"
+		}
+		return "DOUBLE"
+	case TypeBool:
+		return "BOOLEAN"
+	}
+	return "TEXT"
+}
+
+// autoMethod 选择当前方言下最快的导入方式
+func (imp *importer) autoMethod(ctx context.Context) string {
+	switch imp.dialect {
+	case sqlutil.DialectPostgres:
+		return MethodCopy
+	case sqlutil.DialectMySQL:
+		var enabled sql.NullString
+		if err := imp.db.QueryRowContext(ctx, "SELECT @@GLOBAL.local_infile").Scan(&enabled); err == nil &&
+			(enabled.String == "1" || strings.EqualFold(enabled.String, "ON")) {
+			return MethodLoadData
+		}
+		imp.result.Notes = append(imp.result.Notes, "local_infile is disabled on the server, using batched INSERT")
+	}
+	return MethodInsert
+}
+
+// eachRow 逐行读取源文件，按列映射转换后回调；无法解析的行计入 skipped
+func (imp *importer) eachRow(fn func(row int64, values []interface{}) error) error {
+	reader, err := OpenRecords(imp.opts.Path, imp.opts.Format, imp.opts.CSV)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	var row int64
+	for {
+		values, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		row++
+		var recordErr *RecordError
+		if errors.As(err, &recordErr) {
+			imp.result.Skipped++
+			imp.addError(row, recordErr.Error())
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		imp.result.RowsRead++
+		mapped := make([]interface{}, len(imp.columns))
+		for i, c := range imp.columns {
+			mapped[i] = NormalizeValue(values[c.index], c.Type)
+		}
+		if err := fn(row, mapped); err != nil {
+			return err
+		}
+	}
+}
+
+func (imp *importer) addError(row int64, msg string) {
+	if len(imp.result.Errors) < imp.opts.ErrorSamples {
+		imp.result.Errors = append(imp.result.Errors, RowError{Row: row, Error: msg})
+	}
+}
+
+// runInsert 在事务中批量执行多行 INSERT，批次失败时借助 SAVEPOINT 逐行重试以定位失败行
+func (imp *importer) runInsert(ctx context.Context) error {
+	imp.result.Method = MethodInsert
+	tx, err := imp.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	targets := make([]string, len(imp.columns))
+	for i, c := range imp.columns {
+		targets[i] = c.Target
+	}
+	w := &tableWriter{dialect: imp.dialect, table: imp.opts.Table, columns: targets, onError: imp.addError}
+	var batch [][]interface{}
+	var rowNums []int64
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := w.writeBatch(ctx, tx, batch, rowNums)
+		batch, rowNums = batch[:0], rowNums[:0]
+		return err
+	}
+	err = imp.eachRow(func(row int64, values []interface{}) error {
+		batch = append(batch, values)
+		rowNums = append(rowNums, row)
+		if len(batch) >= imp.opts.BatchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit import: %v", err)
+	}
+	imp.result.Inserted, imp.result.Failed = w.inserted, w.failed
+	return nil
+}
+
+// runCopy 使用 COPY FROM STDIN 导入；COPY 失败时整批回滚并退回逐批 INSERT 以定位失败行
+func (imp *importer) runCopy(ctx context.Context) error {
+	imp.result.Method = MethodCopy
+	tx, err := imp.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	cols := make([]string, len(imp.columns))
+	for i, c := range imp.columns {
+		cols[i] = c.Target
+	}
+	schema, table := sqlutil.SplitTableName(imp.opts.Table)
+	copySQL := pq.CopyIn(table, cols...)
+	if schema != "" {
+		copySQL = pq.CopyInSchema(schema, table, cols...)
+	}
+	stmt, err := tx.PrepareContext(ctx, copySQL)
+	if err != nil {
+		return fmt.Errorf("failed to start COPY: %v", err)
+	}
+	err = imp.eachRow(func(row int64, values []interface{}) error {
+		_, err := stmt.ExecContext(ctx, values...)
+		return err
+	})
+	if err == nil {
+		_, err = stmt.ExecContext(ctx)
+	}
+	closeErr := stmt.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit import: %v", err)
+		}
+		imp.result.Inserted = imp.result.RowsRead
+		return nil
+	}
+
+	tx.Rollback()
+	note := fmt.Sprintf("COPY failed (%v), retried with batched INSERT", err)
+	imp.resetCounters()
+	if err := imp.runInsert(ctx); err != nil {
+		return err
+	}
+	imp.result.Notes = append(imp.result.Notes, note)
+	return nil
+}
+
+// loadDataSeq 为每次 LOAD DATA 生成唯一的 Reader 名称
+var loadDataSeq int64
+
+// runLoadData 使用 LOAD DATA LOCAL INFILE 导入；服务器拒绝时退回批量 INSERT
+func (imp *importer) runLoadData(ctx context.Context) error {
+	imp.result.Method = MethodLoadData
+	conn, err := imp.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %v", err)
+	}
+	defer conn.Close()
+
+	name := fmt.Sprintf("xz_mcp_import_%d", atomic.AddInt64(&loadDataSeq, 1))
+	pr, pw := io.Pipe()
+	mysql.RegisterReaderHandler(name, func() io.Reader { return pr })
+	defer mysql.DeregisterReaderHandler(name)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pw.CloseWithError(imp.eachRow(func(row int64, values []interface{}) error {
+			_, err := io.WriteString(pw, tsvLine(values))
+			return err
+		}))
+	}()
+
+	cols := make([]string, len(imp.columns))
+	for i, c := range imp.columns {
+		cols[i] = sqlutil.QuoteIdent(sqlutil.DialectMySQL, c.Target)
+	}
+	query := fmt.Sprintf("LOAD DATA LOCAL INFILE 'Reader::%s' INTO TABLE %s CHARACTER SET utf8mb4 "+
+		"FIELDS TERMINATED BY '\\t' ESCAPED BY '\\\\' LINES TERMINATED BY '\\n' (%s)",
+		name, sqlutil.QuoteTableName(sqlutil.DialectMySQL, imp.opts.Table), strings.Join(cols, ", "))
+	res, err := conn.ExecContext(ctx, query)
+	// 服务器未读取数据时关闭管道，让写入协程退出
+	pr.CloseWithError(io.ErrClosedPipe)
+	<-done
+	if err != nil {
+		note := fmt.Sprintf("LOAD DATA LOCAL INFILE failed (%v), retried with batched INSERT", err)
+		imp.resetCounters()
+		if err := imp.runInsert(ctx); err != nil {
+			return err
+		}
+		imp.result.Notes = append(imp.result.Notes, note)
+		return nil
+	}
+
+	affected, _ := res.RowsAffected()
+	imp.result.Inserted = affected
+	imp.result.Failed = imp.result.RowsRead - affected
+	if imp.result.Failed > 0 || affected == 0 {
+		// LOCAL 模式下数据错误会降级为警告，这里取前几条作为错误样本
+		rows, err := conn.QueryContext(ctx, fmt.Sprintf("SHOW WARNINGS LIMIT %d", imp.opts.ErrorSamples))
+		if err == nil {
+			defer rows.Close()
+			for rows.Next() {
+				var level, message string
+				var code int
+				if rows.Scan(&level, &code, &message) == nil {
+					imp.addError(0, fmt.Sprintf("%s %d: %s", level, code, message))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (imp *importer) resetCounters() {
+	imp.result.RowsRead, imp.result.Inserted, imp.result.Skipped, imp.result.Failed = 0, 0, 0, 0
+	imp.result.Errors = imp.result.Errors[:0]
+}
+
+// tsvLine 按 LOAD DATA 默认转义规则编码一行
+func tsvLine(values []interface{}) string {
+	var sb strings.Builder
+	for i, v := range values {
+		if i > 0 {
+			sb.WriteByte('\t')
+		}
+		switch val := v.(type) {
+		case nil:
+			sb.WriteString(`\N`)
+		case bool:
+			if val {
+				sb.WriteByte('1')
+			} else {
+				sb.WriteByte('0')
+			}
+		default:
+			s := textValue(val, "")
+			for _, r := range s {
+				switch r {
+				case '\\':
+					sb.WriteString(`\\`)
+				case '\t':
+					sb.WriteString(`\t`)
+				case '\n':
+					sb.WriteString(`\n`)
+				case '\r':
+					sb.WriteString(`\r`)
+				case 0:
+					sb.WriteString(`\0`)
+				default:
+					sb.WriteRune(r)
+				}
+			}
+		}
+	}
+	sb.WriteByte('\n')
+	return sb.String()
+}
@@ -0,0 +1,124 @@
+package dataio
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"xz_mcp/db/sqlutil"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestImportCSVCreateTable(t *testing.T) {
+	db := openTestDB(t)
+	path := writeFile(t, "items.csv", "id,name,price,active\n1,apple,1.5,true\n2,pear,2,false\n3,broken\n4,,3.25,true\n")
+
+	result, err := Import(context.Background(), db, sqlutil.DialectSQLite, ImportOptions{
+		Path:        path,
+		Table:       "items",
+		CreateTable: true,
+		BatchSize:   2,
+	})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if result.Method != MethodInsert || result.RowsRead != 3 || result.Inserted != 3 || result.Skipped != 1 || result.Failed != 0 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Row != 3 {
+		t.Fatalf("unexpected errors: %+v", result.Errors)
+	}
+	wantCreate := `CREATE TABLE IF NOT EXISTS "items" ("id" INTEGER, "name" TEXT, "price" REAL, "active" BOOLEAN)`
+	if result.CreateStatement != wantCreate {
+		t.Fatalf("create statement = %s", result.CreateStatement)
+	}
+
+	var name interface{}
+	var price float64
+	if err := db.QueryRow("SELECT name, price FROM items WHERE id = 4").Scan(&name, &price); err != nil {
+		t.Fatal(err)
+	}
+	if name != nil || price != 3.25 {
+		t.Fatalf("row 4 = %v, %v", name, price)
+	}
+}
+
+func TestImportJSONLMappingAndFailures(t *testing.T) {
+	db := openTestDB(t)
+	if _, err := db.Exec("CREATE TABLE people (uid INTEGER PRIMARY KEY, full_name TEXT NOT NULL)"); err != nil {
+		t.Fatal(err)
+	}
+	lines := []string{
+		`{"id": 1, "name": "alice", "extra": {"a": 1}}`,
+		`{"id": 2, "name": null}`,
+		`not json`,
+		`{"id": 1, "name": "dup"}`,
+		`{"id": 3, "name": "carol"}`,
+	}
+	path := writeFile(t, "people.jsonl", strings.Join(lines, "\n")+"\n")
+
+	result, err := Import(context.Background(), db, sqlutil.DialectSQLite, ImportOptions{
+		Path:    path,
+		Table:   "people",
+		Mapping: map[string]string{"id": "uid", "name": "full_name"},
+	})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if result.Inserted != 2 || result.Failed != 2 || result.Skipped != 1 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	rows := []int64{}
+	for _, e := range result.Errors {
+		rows = append(rows, e.Row)
+	}
+	if len(rows) != 3 || rows[0] != 3 || rows[1] != 2 || rows[2] != 4 {
+		t.Fatalf("unexpected error rows: %v", rows)
+	}
+
+	var count int
+	db.QueryRow("SELECT COUNT(*) FROM people").Scan(&count)
+	if count != 2 {
+		t.Fatalf("table has %d rows, want 2", count)
+	}
+}
+
+func TestImportParquetRoundTrip(t *testing.T) {
+	db := openTestDB(t)
+	path := filepath.Join(t.TempDir(), "users.parquet")
+	exportUsers(t, db, ExportOptions{Path: path})
+
+	result, err := Import(context.Background(), db, sqlutil.DialectSQLite, ImportOptions{
+		Path:        path,
+		Table:       "users_copy",
+		CreateTable: true,
+	})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if result.Inserted != 3 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	var same int
+	db.QueryRow(`SELECT COUNT(*) FROM users u JOIN users_copy c ON c.id = u.id AND c.name IS u.name AND c.score IS u.score`).Scan(&same)
+	if same != 3 {
+		t.Fatalf("%d rows match after round trip, want 3", same)
+	}
+}
+
+func TestTSVLine(t *testing.T) {
+	got := tsvLine([]interface{}{int64(1), nil, "a\tb\\c\nd", true})
+	if got != "1\t\\N\ta\\tb\\\\c\\nd\t1\n" {
+		t.Fatalf("tsvLine = %q", got)
+	}
+}
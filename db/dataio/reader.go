@@ -0,0 +1,355 @@
+package dataio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// maxJSONLineSize JSONL 单行允许的最大字节数
+const maxJSONLineSize = 64 << 20
+
+// RecordReader 按行读取源文件
+type RecordReader interface {
+	// Columns 源文件中的列名
+	Columns() []string
+	// Next 返回下一行，值的顺序与 Columns 一致；读完返回 io.EOF，
+	// 单行无法解析时返回 *RecordError，调用方可以跳过该行继续读取
+	Next() ([]interface{}, error)
+	Close() error
+}
+
+// RecordError 单行解析错误
+type RecordError struct {
+	Line int64
+	Err  error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// OpenRecords 按格式打开源文件
+func OpenRecords(path, format string, dialect CSVDialect) (RecordReader, error) {
+	if format == "" {
+		format = formatFromPath(path)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	var reader RecordReader
+	switch format {
+	case FormatCSV:
+		reader, err = newCSVReader(file, dialect)
+	case FormatJSONL:
+		reader, err = newJSONLReader(file)
+	case FormatParquet:
+		reader, err = newParquetReader(file)
+	default:
+		err = fmt.Errorf("unsupported import format: %s (supported: csv, jsonl, parquet)", format)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return reader, nil
+}
+
+// csvReader CSV 读取器
+type csvReader struct {
+	file    *os.File
+	r       *csv.Reader
+	columns []string
+	null    string
+}
+
+func newCSVReader(file *os.File, dialect CSVDialect) (*csvReader, error) {
+	r := csv.NewReader(bufio.NewReader(file))
+	r.FieldsPerRecord = -1
+	if dialect.Delimiter != "" {
+		if dialect.Delimiter == `\t` {
+			dialect.Delimiter = "\t"
+		}
+		runes := []rune(dialect.Delimiter)
+		if len(runes) != 1 {
+			return nil, fmt.Errorf("CSV delimiter must be a single character")
+		}
+		r.Comma = runes[0]
+	}
+	c := &csvReader{file: file, r: r, null: dialect.NullValue}
+
+	first, err := r.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("CSV file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %v", err)
+	}
+	if dialect.NoHeader {
+		for i := range first {
+			c.columns = append(c.columns, fmt.Sprintf("col%d", i+1))
+		}
+		// 没有表头时重新打开文件，首行作为数据读取
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		comma := r.Comma
+		c.r = csv.NewReader(bufio.NewReader(file))
+		c.r.FieldsPerRecord = -1
+		c.r.Comma = comma
+	} else {
+		first[0] = strings.TrimPrefix(first[0], "\ufeff")
+		c.columns = append(c.columns, first...)
+	}
+	return c, nil
+}
+
+func (c *csvReader) Columns() []string { return c.columns }
+
+func (c *csvReader) Next() ([]interface{}, error) {
+	record, err := c.r.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, &RecordError{Line: int64(parseErr.Line), Err: parseErr.Err}
+		}
+		return nil, err
+	}
+	if len(record) != len(c.columns) {
+		line, _ := c.r.FieldPos(0)
+		return nil, &RecordError{Line: int64(line), Err: fmt.Errorf("expected %d fields, got %d", len(c.columns), len(record))}
+	}
+	values := make([]interface{}, len(record))
+	for i, v := range record {
+		if v != c.null {
+			values[i] = v
+		}
+	}
+	return values, nil
+}
+
+func (c *csvReader) Close() error { return c.file.Close() }
+
+// jsonlReader JSON Lines 读取器，列名取自第一行对象的键
+type jsonlReader struct {
+	file    *os.File
+	scanner *bufio.Scanner
+	columns []string
+	line    int64
+	pending []byte
+}
+
+func newJSONLReader(file *os.File) (*jsonlReader, error) {
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxJSONLineSize)
+	j := &jsonlReader{file: file, scanner: scanner}
+	for scanner.Scan() {
+		j.line++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		keys, err := jsonObjectKeys(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", j.line, err)
+		}
+		j.columns = keys
+		j.pending = append([]byte(nil), line...)
+		return j, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("JSONL file is empty")
+}
+
+func (j *jsonlReader) Columns() []string { return j.columns }
+
+func (j *jsonlReader) Next() ([]interface{}, error) {
+	var line []byte
+	if j.pending != nil {
+		line, j.pending = j.pending, nil
+	} else {
+		for {
+			if !j.scanner.Scan() {
+				if err := j.scanner.Err(); err != nil {
+					return nil, err
+				}
+				return nil, io.EOF
+			}
+			j.line++
+			line = bytes.TrimSpace(j.scanner.Bytes())
+			if len(line) > 0 {
+				break
+			}
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	var obj map[string]interface{}
+	if err := dec.Decode(&obj); err != nil {
+		return nil, &RecordError{Line: j.line, Err: err}
+	}
+	if obj == nil {
+		return nil, &RecordError{Line: j.line, Err: fmt.Errorf("line is not a JSON object")}
+	}
+	values := make([]interface{}, len(j.columns))
+	for i, col := range j.columns {
+		values[i] = jsonValue(obj[col])
+	}
+	return values, nil
+}
+
+func (j *jsonlReader) Close() error { return j.file.Close() }
+
+// jsonObjectKeys 按出现顺序返回 JSON 对象的键
+func jsonObjectKeys(data []byte) ([]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, fmt.Errorf("line is not a JSON object")
+	}
+	var keys []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, tok.(string))
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// jsonValue 将 JSON 值转换为可写入数据库的值，嵌套对象和数组保留为 JSON 文本
+func jsonValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case json.Number:
+		if n, err := val.Int64(); err == nil {
+			return n
+		}
+		if f, err := val.Float64(); err == nil {
+			return f
+		}
+		return val.String()
+	case map[string]interface{}, []interface{}:
+		b, _ := json.Marshal(val)
+		return string(b)
+	}
+	return v
+}
+
+// parquetReader Parquet 读取器
+type parquetReader struct {
+	file    *os.File
+	r       *parquet.GenericReader[map[string]interface{}]
+	columns []string
+	buf     []map[string]interface{}
+}
+
+func newParquetReader(file *os.File) (*parquetReader, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	pf, err := parquet.OpenFile(file, info.Size())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open parquet file: %v", err)
+	}
+	p := &parquetReader{
+		file: file,
+		r:    parquet.NewGenericReader[map[string]interface{}](file, pf.Schema()),
+		buf:  make([]map[string]interface{}, 1),
+	}
+	for _, field := range pf.Schema().Fields() {
+		p.columns = append(p.columns, field.Name())
+	}
+	return p, nil
+}
+
+func (p *parquetReader) Columns() []string { return p.columns }
+
+func (p *parquetReader) Next() ([]interface{}, error) {
+	p.buf[0] = map[string]interface{}{}
+	n, err := p.r.Read(p.buf)
+	if n == 0 {
+		if err == nil {
+			err = io.EOF
+		}
+		return nil, err
+	}
+	values := make([]interface{}, len(p.columns))
+	for i, col := range p.columns {
+		switch v := p.buf[0][col].(type) {
+		case []byte:
+			values[i] = string(v)
+		case int32:
+			values[i] = int64(v)
+		case float32:
+			values[i] = float64(v)
+		default:
+			values[i] = v
+		}
+	}
+	return values, nil
+}
+
+func (p *parquetReader) Close() error {
+	p.r.Close()
+	return p.file.Close()
+}
+
+// InferType 根据单个值推断逻辑类型
+func InferType(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case int64, int32, int:
+		return TypeInt
+	case float64, float32:
+		return TypeFloat
+	case bool:
+		return TypeBool
+	case string:
+		if _, err := strconv.ParseInt(val, 10, 64); err == nil {
+			return TypeInt
+		}
+		if _, err := strconv.ParseFloat(val, 64); err == nil {
+			return TypeFloat
+		}
+		if lower := strings.ToLower(val); lower == "true" || lower == "false" {
+			return TypeBool
+		}
+	}
+	return TypeString
+}
+
+// MergeType 合并两个推断结果，取能同时容纳两者的类型
+func MergeType(a, b string) string {
+	switch {
+	case a == "":
+		return b
+	case b == "" || a == b:
+		return a
+	case (a == TypeInt && b == TypeFloat) || (a == TypeFloat && b == TypeInt):
+		return TypeFloat
+	}
+	return TypeString
+}
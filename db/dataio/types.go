@@ -0,0 +1,215 @@
+package dataio
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"xz_mcp/db/sqlutil"
+)
+
+// SQLType 与方言无关的列类型描述
+type SQLType struct {
+	Kind      string `json:"kind"` // bool/smallint/int/bigint/float/double/decimal/char/varchar/text/date/time/timestamp/timestamptz/json/binary/uuid
+	Length    int64  `json:"length,omitempty"`
+	Precision int64  `json:"precision,omitempty"`
+	Scale     int64  `json:"scale,omitempty"`
+}
+
+var typeArgs = regexp.MustCompile(`^\s*([A-Za-z0-9_ ]+?)\s*(?:\(\s*(\d+)\s*(?:,\s*(\d+)\s*)?\))?\s*(UNSIGNED)?\s*$`)
+
+// ClassifyColumnType 根据驱动返回的列类型信息归类
+func ClassifyColumnType(ct *sql.ColumnType) SQLType {
+	t := ClassifyTypeName(ct.DatabaseTypeName())
+	if length, ok := ct.Length(); ok && length > 0 && length < 1<<20 && (t.Kind == "varchar" || t.Kind == "char") {
+		t.Length = length
+	}
+	if precision, scale, ok := ct.DecimalSize(); ok && t.Kind == "decimal" && precision > 0 && precision <= 1000 {
+		t.Precision, t.Scale = precision, scale
+	}
+	return t
+}
+
+// ClassifyTypeName 解析类型名（可以带长度/精度，如 VARCHAR(64)、DECIMAL(10,2)）
+func ClassifyTypeName(name string) SQLType {
+	upper := strings.ToUpper(strings.TrimSpace(name))
+	var n1, n2 int64
+	base := upper
+	if m := typeArgs.FindStringSubmatch(upper); m != nil {
+		base = strings.TrimSpace(m[1])
+		n1, _ = strconv.ParseInt(m[2], 10, 64)
+		n2, _ = strconv.ParseInt(m[3], 10, 64)
+		if m[4] != "" {
+			base = "UNSIGNED " + base
+		}
+	}
+
+	switch base {
+	case "BOOL", "BOOLEAN":
+		return SQLType{Kind: "bool"}
+	case "TINYINT", "SMALLINT", "INT2", "YEAR", "UNSIGNED TINYINT":
+		return SQLType{Kind: "smallint"}
+	case "MEDIUMINT", "INT", "INTEGER", "INT4", "SERIAL", "UNSIGNED SMALLINT", "UNSIGNED MEDIUMINT":
+		return SQLType{Kind: "int"}
+	case "BIGINT", "INT8", "BIGSERIAL", "UNSIGNED INT", "BIT":
+		return SQLType{Kind: "bigint"}
+	case "UNSIGNED BIGINT":
+		return SQLType{Kind: "decimal", Precision: 20}
+	case "FLOAT", "FLOAT4", "REAL":
+		return SQLType{Kind: "float"}
+	case "DOUBLE", "DOUBLE PRECISION", "FLOAT8":
+		return SQLType{Kind: "double"}
+	case "DECIMAL", "NUMERIC":
+		return SQLType{Kind: "decimal", Precision: n1, Scale: n2}
+	case "CHAR", "BPCHAR", "CHARACTER", "NCHAR":
+		return SQLType{Kind: "char", Length: n1}
+	case "VARCHAR", "CHARACTER VARYING", "NVARCHAR", "ENUM", "SET":
+		return SQLType{Kind: "varchar", Length: n1}
+	case "DATE":
+		return SQLType{Kind: "date"}
+	case "TIME", "TIMETZ":
+		return SQLType{Kind: "time"}
+	case "DATETIME", "TIMESTAMP":
+		return SQLType{Kind: "timestamp"}
+	case "TIMESTAMPTZ":
+		return SQLType{Kind: "timestamptz"}
+	case "JSON", "JSONB":
+		return SQLType{Kind: "json"}
+	case "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "BINARY", "VARBINARY", "BYTEA":
+		return SQLType{Kind: "binary"}
+	case "UUID":
+		return SQLType{Kind: "uuid"}
+	}
+	return SQLType{Kind: "text"}
+}
+
+// RenderType 生成目标方言的列类型；key 为 true 时保证类型可以作为主键（MySQL 不允许 TEXT/BLOB 主键）
+func RenderType(dialect string, t SQLType, key bool) string {
+	switch dialect {
+	case sqlutil.DialectMySQL:
+		return renderMySQLType(t, key)
+	case sqlutil.DialectPostgres:
+		return renderPostgresType(t)
+	}
+	return renderSQLiteType(t)
+}
+
+func renderMySQLType(t SQLType, key bool) string {
+	switch t.Kind {
+	case "bool":
+		return "BOOLEAN"
+	case "smallint":
+		return "SMALLINT"
+	case "int":
+		return "INT"
+	case "bigint":
+		return "BIGINT"
+	case "float":
+		return "FLOAT"
+	case "double":
+		return "DOUBLE"
+	case "decimal":
+		return decimalType("DECIMAL", t, 65)
+	case "char":
+		if t.Length > 0 && t.Length <= 255 {
+			return fmt.Sprintf("CHAR(%d)", t.Length)
+		}
+	case "varchar":
+		if t.Length > 0 && t.Length <= 16383 {
+			return fmt.Sprintf("VARCHAR(%d)", t.Length)
+		}
+	case "date":
+		return "DATE"
+	case "time":
+		return "TIME"
+	case "timestamp", "timestamptz":
+		return "DATETIME(6)"
+	case "json":
+		return "JSON"
+	case "binary":
+		if key {
+			return "VARBINARY(255)"
+		}
+		return "LONGBLOB"
+	case "uuid":
+		return "CHAR(36)"
+	}
+	if key {
+		return "VARCHAR(255)"
+	}
+	return "LONGTEXT"
+}
+
+func renderPostgresType(t SQLType) string {
+	switch t.Kind {
+	case "bool":
+		return "BOOLEAN"
+	case "smallint":
+		return "SMALLINT"
+	case "int":
+		return "INTEGER"
+	case "bigint":
+		return "BIGINT"
+	case "float":
+		return "REAL"
+	case "double":
+		return "DOUBLE PRECISION"
+	case "decimal":
+		return decimalType("NUMERIC", t, 1000)
+	case "char":
+		if t.Length > 0 {
+			return fmt.Sprintf("CHAR(%d)", t.Length)
+		}
+	case "varchar":
+		if t.Length > 0 {
+			return fmt.Sprintf("VARCHAR(%d)", t.Length)
+		}
+	case "date":
+		return "DATE"
+	case "time":
+		return "TIME"
+	case "timestamp":
+		return "TIMESTAMP"
+	case "timestamptz":
+		return "TIMESTAMPTZ"
+	case "json":
+		return "JSONB"
+	case "binary":
+		return "BYTEA"
+	case "uuid":
+		return "UUID"
+	}
+	return "TEXT"
+}
+
+func renderSQLiteType(t SQLType) string {
+	switch t.Kind {
+	case "bool":
+		return "BOOLEAN"
+	case "smallint", "int", "bigint":
+		return "INTEGER"
+	case "float", "double":
+		return "REAL"
+	case "decimal":
+		return decimalType("NUMERIC", t, 1000)
+	case "binary":
+		return "BLOB"
+	case "varchar":
+		if t.Length > 0 {
+			return fmt.Sprintf("VARCHAR(%d)", t.Length)
+		}
+	}
+	return "TEXT"
+}
+
+func decimalType(name string, t SQLType, maxPrecision int64) string {
+	if t.Precision <= 0 {
+		return name
+	}
+	if t.Precision > maxPrecision {
+		return name
+	}
+	return fmt.Sprintf("%s(%d,%d)", name, t.Precision, t.Scale)
+}
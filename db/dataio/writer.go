@@ -0,0 +1,138 @@
+package dataio
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"xz_mcp/db/sqlutil"
+)
+
+// 写入模式
+const (
+	ModeAppend  = "append"  // 直接 INSERT
+	ModeUpsert  = "upsert"  // 主键冲突时更新
+	ModeReplace = "replace" // 先清空目标表再写入
+)
+
+// tableWriter 在事务中向目标表批量写入多行，批次失败时借助 SAVEPOINT 逐行重试以定位失败行
+type tableWriter struct {
+	dialect    string
+	table      string
+	columns    []string
+	upsert     bool
+	keyColumns []string
+	onError    func(row int64, msg string)
+
+	inserted int64
+	failed   int64
+}
+
+// writeBatch 写入一批数据，rowNums 为每行在源数据中的行号
+func (w *tableWriter) writeBatch(ctx context.Context, tx *sql.Tx, batch [][]interface{}, rowNums []int64) error {
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT batch_write"); err != nil {
+		return fmt.Errorf("failed to create savepoint: %v", err)
+	}
+	query, args := w.statement(batch)
+	if _, err := tx.ExecContext(ctx, query, args...); err == nil {
+		w.inserted += int64(len(batch))
+		_, err = tx.ExecContext(ctx, "RELEASE SAVEPOINT batch_write")
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT batch_write"); err != nil {
+		return fmt.Errorf("failed to roll back batch: %v", err)
+	}
+
+	for i, values := range batch {
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT row_write"); err != nil {
+			return fmt.Errorf("failed to create savepoint: %v", err)
+		}
+		query, args := w.statement([][]interface{}{values})
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			w.failed++
+			w.onError(rowNums[i], err.Error())
+			if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT row_write"); err != nil {
+				return fmt.Errorf("failed to roll back row: %v", err)
+			}
+			continue
+		}
+		w.inserted++
+		if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT row_write"); err != nil {
+			return err
+		}
+	}
+	_, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT batch_write")
+	return err
+}
+
+// statement 构造多行 INSERT（或 upsert）语句
+func (w *tableWriter) statement(batch [][]interface{}) (string, []interface{}) {
+	cols := make([]string, len(w.columns))
+	for i, c := range w.columns {
+		cols[i] = sqlutil.QuoteIdent(w.dialect, c)
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "INSERT INTO %s (%s) VALUES ", sqlutil.QuoteTableName(w.dialect, w.table), strings.Join(cols, ", "))
+	args := make([]interface{}, 0, len(batch)*len(cols))
+	for r, values := range batch {
+		if r > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteByte('(')
+		for i, v := range values {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			args = append(args, v)
+			if w.dialect == sqlutil.DialectPostgres {
+				sb.WriteString("$" + strconv.Itoa(len(args)))
+			} else {
+				sb.WriteByte('?')
+			}
+		}
+		sb.WriteByte(')')
+	}
+	if w.upsert {
+		sb.WriteString(w.upsertClause())
+	}
+	return sb.String(), args
+}
+
+// upsertClause 生成冲突时更新非主键列的子句
+func (w *tableWriter) upsertClause() string {
+	isKey := make(map[string]bool, len(w.keyColumns))
+	for _, k := range w.keyColumns {
+		isKey[strings.ToLower(k)] = true
+	}
+	var sets []string
+	for _, c := range w.columns {
+		if isKey[strings.ToLower(c)] {
+			continue
+		}
+		q := sqlutil.QuoteIdent(w.dialect, c)
+		if w.dialect == sqlutil.DialectMySQL {
+			sets = append(sets, fmt.Sprintf("%s = VALUES(%s)", q, q))
+		} else {
+			sets = append(sets, fmt.Sprintf("%s = excluded.%s", q, q))
+		}
+	}
+
+	if w.dialect == sqlutil.DialectMySQL {
+		if len(sets) == 0 {
+			// 只有主键列时保持原值不变
+			q := sqlutil.QuoteIdent(w.dialect, w.columns[0])
+			sets = append(sets, fmt.Sprintf("%s = %s", q, q))
+		}
+		return " ON DUPLICATE KEY UPDATE " + strings.Join(sets, ", ")
+	}
+	keys := make([]string, len(w.keyColumns))
+	for i, k := range w.keyColumns {
+		keys[i] = sqlutil.QuoteIdent(w.dialect, k)
+	}
+	if len(sets) == 0 {
+		return fmt.Sprintf(" ON CONFLICT (%s) DO NOTHING", strings.Join(keys, ", "))
+	}
+	return fmt.Sprintf(" ON CONFLICT (%s) DO UPDATE SET %s", strings.Join(keys, ", "), strings.Join(sets, ", "))
+}
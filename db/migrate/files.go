@@ -0,0 +1,163 @@
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// noTransaction 迁移文件中出现该标记时不使用事务执行（如 CREATE INDEX CONCURRENTLY）
+const noTransaction = "-- migrate:no-transaction"
+
+// 迁移文件名：<版本>_<名称>.up.sql / <版本>_<名称>.down.sql，也可以只有 <版本>_<名称>.sql（仅 up）
+var fileName = regexp.MustCompile(`^(\d+)_(.+?)(?:\.(up|down))?\.sql$`)
+
+// Migration 迁移目录中的一个版本
+type Migration struct {
+	Version  int64
+	Name     string
+	UpPath   string
+	DownPath string
+	Checksum string // up 文件内容的 SHA-256
+
+	prefix string // 文件名中的版本号原文（保留前导零）
+}
+
+// Load 读取迁移目录，按版本排序
+func Load(dir string) ([]*Migration, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations directory: %v", err)
+	}
+	byVersion := map[int64]*Migration{}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		m := fileName.FindStringSubmatch(e.Name())
+		if m == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %v", e.Name(), err)
+		}
+		mig := byVersion[version]
+		if mig == nil {
+			mig = &Migration{Version: version, Name: m[2], prefix: m[1]}
+			byVersion[version] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", version, mig.Name, m[2])
+		}
+		path := filepath.Join(dir, e.Name())
+		if m[3] == "down" {
+			mig.DownPath = path
+		} else if mig.UpPath != "" {
+			return nil, fmt.Errorf("migration %d has more than one up file", version)
+		} else {
+			mig.UpPath = path
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.UpPath == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", mig.Version, mig.Name)
+		}
+		body, _, err := readSQL(mig.UpPath)
+		if err != nil {
+			return nil, err
+		}
+		mig.Checksum = checksum(body)
+		migrations = append(migrations, mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// readSQL 读取迁移文件，返回内容以及是否需要在事务外执行
+func readSQL(path string) (string, bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	body := strings.ReplaceAll(string(data), "\r\n", "\n")
+	return body, strings.Contains(body, noTransaction), nil
+}
+
+// checksum 计算迁移内容的校验和（忽略首尾空白和换行符差异）
+func checksum(body string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(body)))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateResult 新建迁移的结果
+type CreateResult struct {
+	Type     string `json:"type"`
+	Version  int64  `json:"version"`
+	UpPath   string `json:"up_path"`
+	DownPath string `json:"down_path"`
+}
+
+var nameChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Create 在迁移目录中新建一对空的 up/down 文件；
+// 目录中已有的版本号看起来是时间戳或 timestamp 为 true 时使用 UTC 时间戳，否则沿用现有宽度递增编号
+func Create(dir, name string, timestamp bool) (*CreateResult, error) {
+	name = strings.Trim(nameChars.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return nil, fmt.Errorf("migration name is required")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create migrations directory: %v", err)
+	}
+	existing, err := Load(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var version int64
+	var prefix string
+	width := 4
+	for _, e := range existing {
+		if n := len(e.prefix); n > width {
+			width = n
+		}
+	}
+	if timestamp || width >= 12 {
+		version, _ = strconv.ParseInt(time.Now().UTC().Format("20060102150405"), 10, 64)
+		if n := len(existing); n > 0 && existing[n-1].Version >= version {
+			version = existing[n-1].Version + 1
+		}
+		prefix = strconv.FormatInt(version, 10)
+	} else {
+		version = 1
+		if n := len(existing); n > 0 {
+			version = existing[n-1].Version + 1
+		}
+		prefix = fmt.Sprintf("%0*d", width, version)
+	}
+
+	result := &CreateResult{
+		Type:     "migrate_create",
+		Version:  version,
+		UpPath:   filepath.Join(dir, prefix+"_"+name+".up.sql"),
+		DownPath: filepath.Join(dir, prefix+"_"+name+".down.sql"),
+	}
+	for _, f := range []struct{ path, direction string }{{result.UpPath, "up"}, {result.DownPath, "down"}} {
+		file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s: %v", f.path, err)
+		}
+		fmt.Fprintf(file, "-- %s_%s (%s)\n", prefix, name, f.direction)
+		file.Close()
+	}
+	return result, nil
+}
@@ -0,0 +1,511 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+
+	"xz_mcp/db/schema"
+	"xz_mcp/db/sqlutil"
+)
+
+// 默认值
+const (
+	DefaultTable       = "schema_migrations"
+	DefaultLockTimeout = 30 * time.Second
+)
+
+// 迁移状态
+const (
+	StateApplied  = "applied"
+	StatePending  = "pending"
+	StateModified = "modified" // 已执行的迁移文件被修改过（校验和不一致）
+	StateMissing  = "missing"  // 已执行但目录中找不到文件
+)
+
+// Migrator 在一个数据库连接上执行迁移目录中的迁移
+type Migrator struct {
+	DB          *sql.DB
+	Dialect     string
+	Dir         string
+	Table       string
+	LockTimeout time.Duration
+}
+
+// MigrationStatus 单个迁移的状态
+type MigrationStatus struct {
+	Version   int64  `json:"version"`
+	Name      string `json:"name"`
+	State     string `json:"state"`
+	AppliedAt string `json:"applied_at,omitempty"`
+	HasDown   bool   `json:"has_down"`
+}
+
+// StatusResult migrate_status 结果
+type StatusResult struct {
+	Type       string            `json:"type"`
+	Dir        string            `json:"dir"`
+	Table      string            `json:"table"`
+	Current    int64             `json:"current_version"`
+	Applied    int               `json:"applied"`
+	Pending    int               `json:"pending"`
+	Migrations []MigrationStatus `json:"migrations"`
+	Problems   []string          `json:"problems,omitempty"`
+}
+
+// RunOptions up/down 的执行范围
+type RunOptions struct {
+	Steps     int   // 最多执行多少个迁移，0 表示 up 全部、down 一个
+	ToVersion int64 // up 执行到该版本（含），down 回滚到该版本（不含，即回滚后当前版本为它）
+	DryRun    bool  // 只返回执行计划
+}
+
+// StepResult 单个迁移的执行结果
+type StepResult struct {
+	Version       int64  `json:"version"`
+	Name          string `json:"name"`
+	Statements    int    `json:"statements"`
+	Transactional bool   `json:"transactional"`
+	DurationMs    int64  `json:"duration_ms"`
+	Error         string `json:"error,omitempty"`
+}
+
+// RunResult migrate_up/migrate_down 结果
+type RunResult struct {
+	Type     string       `json:"type"`
+	DryRun   bool         `json:"dry_run,omitempty"`
+	From     int64        `json:"from_version"`
+	To       int64        `json:"to_version"`
+	Executed int          `json:"executed"`
+	Steps    []StepResult `json:"steps"`
+	Error    string       `json:"error,omitempty"`
+}
+
+// appliedRow 跟踪表中的一行
+type appliedRow struct {
+	name      string
+	checksum  string
+	appliedAt string
+}
+
+func (m *Migrator) table() string {
+	if m.Table == "" {
+		return DefaultTable
+	}
+	return m.Table
+}
+
+// placeholder 返回第 n 个参数占位符
+func (m *Migrator) placeholder(n int) string {
+	if m.Dialect == sqlutil.DialectPostgres {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
+
+// ensureTable 创建跟踪表
+func (m *Migrator) ensureTable(ctx context.Context, conn *sql.Conn) error {
+	var appliedAt string
+	switch m.Dialect {
+	case sqlutil.DialectMySQL:
+		appliedAt = "DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)"
+	case sqlutil.DialectPostgres:
+		appliedAt = "TIMESTAMPTZ NOT NULL DEFAULT now()"
+	default:
+		appliedAt = "TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP"
+	}
+	stmt := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		version BIGINT NOT NULL PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		checksum CHAR(64) NOT NULL,
+		applied_at %s,
+		execution_ms BIGINT NOT NULL
+	)`, sqlutil.QuoteTableName(m.Dialect, m.table()), appliedAt)
+	if _, err := conn.ExecContext(ctx, stmt); err != nil {
+		return fmt.Errorf("failed to create migration table %s: %v", m.table(), err)
+	}
+	return nil
+}
+
+// applied 读取已执行的迁移
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]appliedRow, error) {
+	rows, err := conn.QueryContext(ctx, fmt.Sprintf("SELECT version, name, checksum, applied_at FROM %s", sqlutil.QuoteTableName(m.Dialect, m.table())))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migration table: %v", err)
+	}
+	defer rows.Close()
+	result := map[int64]appliedRow{}
+	for rows.Next() {
+		var version int64
+		var row appliedRow
+		var at interface{}
+		if err := rows.Scan(&version, &row.name, &row.checksum, &at); err != nil {
+			return nil, err
+		}
+		switch v := at.(type) {
+		case time.Time:
+			row.appliedAt = v.Format(time.RFC3339)
+		case []byte:
+			row.appliedAt = string(v)
+		case string:
+			row.appliedAt = v
+		}
+		result[version] = row
+	}
+	return result, rows.Err()
+}
+
+// withLock 获取独占连接和迁移锁后执行 fn：MySQL 使用 GET_LOCK，PostgreSQL 使用 pg_advisory_lock，
+// SQLite 没有会话级锁，使用进程内互斥锁并依赖事务的写锁
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %v", err)
+	}
+	defer conn.Close()
+
+	timeout := m.LockTimeout
+	if timeout <= 0 {
+		timeout = DefaultLockTimeout
+	}
+	lockName := "xz_mcp_migrate:" + m.table()
+	switch m.Dialect {
+	case sqlutil.DialectMySQL:
+		var got sql.NullInt64
+		if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lockName, int(timeout.Seconds())).Scan(&got); err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %v", err)
+		}
+		if got.Int64 != 1 {
+			return fmt.Errorf("another migration is running (lock %s not acquired within %s)", lockName, timeout)
+		}
+		defer conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", lockName)
+	case sqlutil.DialectPostgres:
+		h := fnv.New64a()
+		h.Write([]byte(lockName))
+		key := int64(h.Sum64())
+		deadline := time.Now().Add(timeout)
+		for {
+			var got bool
+			if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&got); err != nil {
+				return fmt.Errorf("failed to acquire migration lock: %v", err)
+			}
+			if got {
+				break
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("another migration is running (advisory lock %d not acquired within %s)", key, timeout)
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(200 * time.Millisecond):
+			}
+		}
+		defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", key)
+	default:
+		var seq int
+		var name, file string
+		if err := conn.QueryRowContext(ctx, "PRAGMA database_list").Scan(&seq, &name, &file); err != nil {
+			return fmt.Errorf("failed to identify database: %v", err)
+		}
+		mu := sqliteLock(file + ":" + m.table())
+		mu.Lock()
+		defer mu.Unlock()
+	}
+
+	if err := m.ensureTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+var sqliteLocks sync.Map
+
+func sqliteLock(key string) *sync.Mutex {
+	mu, _ := sqliteLocks.LoadOrStore(key, &sync.Mutex{})
+	return mu.(*sync.Mutex)
+}
+
+// Status 对比迁移目录和跟踪表
+func (m *Migrator) Status(ctx context.Context) (*StatusResult, error) {
+	migrations, err := Load(m.Dir)
+	if err != nil {
+		return nil, err
+	}
+	result := &StatusResult{Type: "migrate_status", Dir: m.Dir, Table: m.table(), Migrations: []MigrationStatus{}}
+	err = m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		result.Migrations, result.Problems = m.compare(migrations, applied)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range result.Migrations {
+		switch s.State {
+		case StatePending:
+			result.Pending++
+		default:
+			result.Applied++
+			if s.Version > result.Current {
+				result.Current = s.Version
+			}
+		}
+	}
+	return result, nil
+}
+
+// compare 合并目录和跟踪表中的迁移，返回按版本排序的状态以及需要处理的问题
+func (m *Migrator) compare(migrations []*Migration, applied map[int64]appliedRow) ([]MigrationStatus, []string) {
+	var statuses []MigrationStatus
+	var problems []string
+	seen := map[int64]bool{}
+	var maxApplied int64
+	for v := range applied {
+		if v > maxApplied {
+			maxApplied = v
+		}
+	}
+	for _, mig := range migrations {
+		seen[mig.Version] = true
+		s := MigrationStatus{Version: mig.Version, Name: mig.Name, State: StatePending, HasDown: mig.DownPath != ""}
+		if row, ok := applied[mig.Version]; ok {
+			s.State = StateApplied
+			s.AppliedAt = row.appliedAt
+			if row.checksum != mig.Checksum {
+				s.State = StateModified
+				problems = append(problems, fmt.Sprintf("migration %d_%s was modified after it was applied (checksum mismatch)", mig.Version, mig.Name))
+			}
+		} else if mig.Version < maxApplied {
+			problems = append(problems, fmt.Sprintf("migration %d_%s is older than the current version %d and has not been applied", mig.Version, mig.Name, maxApplied))
+		}
+		statuses = append(statuses, s)
+	}
+	for v, row := range applied {
+		if !seen[v] {
+			statuses = append(statuses, MigrationStatus{Version: v, Name: row.name, State: StateMissing, AppliedAt: row.appliedAt})
+			problems = append(problems, fmt.Sprintf("migration %d_%s is recorded as applied but its file is missing", v, row.name))
+		}
+	}
+	sortStatuses(statuses)
+	return statuses, problems
+}
+
+func sortStatuses(statuses []MigrationStatus) {
+	for i := 1; i < len(statuses); i++ {
+		for j := i; j > 0 && statuses[j].Version < statuses[j-1].Version; j-- {
+			statuses[j], statuses[j-1] = statuses[j-1], statuses[j]
+		}
+	}
+}
+
+// Up 按版本顺序执行未执行的迁移；已执行的迁移被修改过时拒绝执行
+func (m *Migrator) Up(ctx context.Context, opts RunOptions) (*RunResult, error) {
+	migrations, err := Load(m.Dir)
+	if err != nil {
+		return nil, err
+	}
+	result := &RunResult{Type: "migrate_up", DryRun: opts.DryRun, Steps: []StepResult{}}
+	err = m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		_, problems := m.compare(migrations, applied)
+		for _, p := range problems {
+			if strings.Contains(p, "checksum mismatch") {
+				return fmt.Errorf("%s; restore the original file or record the change as a new migration", p)
+			}
+		}
+		result.From = currentVersion(applied)
+		result.To = result.From
+
+		var plan []*Migration
+		for _, mig := range migrations {
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			if opts.ToVersion > 0 && mig.Version > opts.ToVersion {
+				break
+			}
+			if opts.Steps > 0 && len(plan) >= opts.Steps {
+				break
+			}
+			plan = append(plan, mig)
+		}
+		return m.run(ctx, conn, plan, true, opts.DryRun, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Down 按版本倒序回滚已执行的迁移，默认回滚一个
+func (m *Migrator) Down(ctx context.Context, opts RunOptions) (*RunResult, error) {
+	migrations, err := Load(m.Dir)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int64]*Migration, len(migrations))
+	for _, mig := range migrations {
+		byVersion[mig.Version] = mig
+	}
+	result := &RunResult{Type: "migrate_down", DryRun: opts.DryRun, Steps: []StepResult{}}
+	err = m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		result.From = currentVersion(applied)
+		result.To = result.From
+
+		versions := make([]MigrationStatus, 0, len(applied))
+		for v := range applied {
+			versions = append(versions, MigrationStatus{Version: v})
+		}
+		sortStatuses(versions)
+		steps := opts.Steps
+		if steps <= 0 && opts.ToVersion == 0 {
+			steps = 1
+		}
+		var plan []*Migration
+		for i := len(versions) - 1; i >= 0; i-- {
+			v := versions[i].Version
+			if opts.ToVersion > 0 && v <= opts.ToVersion {
+				break
+			}
+			if steps > 0 && len(plan) >= steps {
+				break
+			}
+			mig := byVersion[v]
+			if mig == nil {
+				return fmt.Errorf("cannot roll back migration %d: its file is missing", v)
+			}
+			if mig.DownPath == "" {
+				return fmt.Errorf("cannot roll back migration %d_%s: no down file", v, mig.Name)
+			}
+			plan = append(plan, mig)
+		}
+		return m.run(ctx, conn, plan, false, opts.DryRun, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func currentVersion(applied map[int64]appliedRow) int64 {
+	var current int64
+	for v := range applied {
+		if v > current {
+			current = v
+		}
+	}
+	return current
+}
+
+// run 依次执行计划中的迁移，遇到失败立即停止并把错误写入结果
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, plan []*Migration, up, dryRun bool, result *RunResult) error {
+	for i, mig := range plan {
+		path := mig.UpPath
+		if !up {
+			path = mig.DownPath
+		}
+		body, noTx, err := readSQL(path)
+		if err != nil {
+			return err
+		}
+		stmts := schema.SplitStatements(body, m.Dialect)
+		step := StepResult{
+			Version:       mig.Version,
+			Name:          mig.Name,
+			Statements:    len(stmts),
+			Transactional: !noTx && m.Dialect != sqlutil.DialectMySQL,
+		}
+		if dryRun {
+			result.Steps = append(result.Steps, step)
+			continue
+		}
+
+		start := time.Now()
+		err = m.apply(ctx, conn, mig, stmts, step.Transactional, up, start)
+		step.DurationMs = time.Since(start).Milliseconds()
+		if err != nil {
+			step.Error = err.Error()
+			result.Steps = append(result.Steps, step)
+			result.Error = fmt.Sprintf("migration %d_%s failed: %v", mig.Version, mig.Name, err)
+			if !step.Transactional {
+				result.Error += " (not transactional: it may be partially applied)"
+			}
+			return nil
+		}
+		result.Steps = append(result.Steps, step)
+		result.Executed++
+		if up {
+			result.To = mig.Version
+		} else if i+1 < len(plan) {
+			result.To = plan[i+1].Version
+		} else {
+			result.To = 0
+		}
+	}
+	if !up && !dryRun && result.Executed > 0 {
+		// 回滚后的当前版本为剩余已执行迁移中的最大版本
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		result.To = currentVersion(applied)
+	}
+	return nil
+}
+
+// execer 连接或事务
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// apply 执行单个迁移并更新跟踪表；支持事务性 DDL 的方言在同一事务中完成
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, mig *Migration, stmts []string, transactional, up bool, start time.Time) error {
+	var target execer = conn
+	var tx *sql.Tx
+	if transactional {
+		var err error
+		if tx, err = conn.BeginTx(ctx, nil); err != nil {
+			return fmt.Errorf("failed to begin transaction: %v", err)
+		}
+		defer tx.Rollback()
+		target = tx
+	}
+	for i, stmt := range stmts {
+		if _, err := target.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("statement %d: %v", i+1, err)
+		}
+	}
+
+	table := sqlutil.QuoteTableName(m.Dialect, m.table())
+	var err error
+	if up {
+		_, err = target.ExecContext(ctx,
+			fmt.Sprintf("INSERT INTO %s (version, name, checksum, execution_ms) VALUES (%s, %s, %s, %s)",
+				table, m.placeholder(1), m.placeholder(2), m.placeholder(3), m.placeholder(4)),
+			mig.Version, mig.Name, mig.Checksum, time.Since(start).Milliseconds())
+	} else {
+		_, err = target.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE version = %s", table, m.placeholder(1)), mig.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update migration table: %v", err)
+	}
+	if tx != nil {
+		return tx.Commit()
+	}
+	return nil
+}
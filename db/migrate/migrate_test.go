@@ -0,0 +1,98 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"xz_mcp/db/sqlutil"
+
+	_ "modernc.org/sqlite"
+)
+
+func writeFile(t *testing.T, path, body string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrateSQLite(t *testing.T) {
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "migrations")
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "app.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	first, err := Create(dir, "Create Users", false)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if filepath.Base(first.UpPath) != "0001_create_users.up.sql" {
+		t.Fatalf("up path = %s", first.UpPath)
+	}
+	writeFile(t, first.UpPath, "CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT);\nCREATE INDEX idx_users_email ON users (email);\n")
+	writeFile(t, first.DownPath, "DROP TABLE users;\n")
+	second, err := Create(dir, "add_name", false)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	writeFile(t, second.UpPath, "ALTER TABLE users ADD COLUMN name TEXT;\n")
+	writeFile(t, second.DownPath, "ALTER TABLE users DROP COLUMN name;\n")
+	// 第三个迁移失败，事务回滚后不应留下表
+	writeFile(t, filepath.Join(dir, "0003_broken.up.sql"), "CREATE TABLE posts (id INTEGER);\nINSERT INTO missing VALUES (1);\n")
+
+	m := &Migrator{DB: db, Dialect: sqlutil.DialectSQLite, Dir: dir}
+	plan, err := m.Up(ctx, RunOptions{DryRun: true})
+	if err != nil || len(plan.Steps) != 3 || plan.Executed != 0 || plan.Steps[0].Statements != 2 {
+		t.Fatalf("dry run = %+v, %v", plan, err)
+	}
+
+	up, err := m.Up(ctx, RunOptions{})
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if up.Executed != 2 || up.To != 2 || !strings.Contains(up.Error, "3_broken") || up.Steps[2].Error == "" {
+		t.Fatalf("up = %+v", up)
+	}
+	var n int
+	db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'posts'").Scan(&n)
+	if n != 0 {
+		t.Fatal("failed migration was not rolled back")
+	}
+	os.Remove(filepath.Join(dir, "0003_broken.up.sql"))
+
+	status, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if status.Current != 2 || status.Applied != 2 || status.Pending != 0 || len(status.Problems) != 0 {
+		t.Fatalf("status = %+v", status)
+	}
+
+	// 修改已执行的迁移后拒绝继续执行
+	writeFile(t, first.UpPath, "CREATE TABLE users (id INTEGER PRIMARY KEY);\n")
+	if status, _ = m.Status(ctx); status.Migrations[0].State != StateModified {
+		t.Fatalf("status = %+v", status.Migrations)
+	}
+	if _, err := m.Up(ctx, RunOptions{}); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("Up with modified migration: %v", err)
+	}
+
+	down, err := m.Down(ctx, RunOptions{})
+	if err != nil || down.Executed != 1 || down.From != 2 || down.To != 1 {
+		t.Fatalf("down = %+v, %v", down, err)
+	}
+	if down, err = m.Down(ctx, RunOptions{Steps: 5}); err != nil || down.Executed != 1 || down.To != 0 {
+		t.Fatalf("down = %+v, %v", down, err)
+	}
+	db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'users'").Scan(&n)
+	if n != 0 {
+		t.Fatal("users table still exists after rolling back all migrations")
+	}
+}
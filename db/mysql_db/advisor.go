@@ -0,0 +1,75 @@
+package mysql_db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"xz_mcp/db/sqlutil"
+)
+
+// TableIndexes 从 INFORMATION_SCHEMA.STATISTICS 读取表上的索引
+func TableIndexes(table string) ([]sqlutil.IndexInfo, error) {
+	if !IsConnected() {
+		return nil, fmt.Errorf("database not connected")
+	}
+	schema, name := sqlutil.SplitTableName(table)
+	query := `SELECT INDEX_NAME, COLUMN_NAME, NON_UNIQUE
+		FROM INFORMATION_SCHEMA.STATISTICS
+		WHERE TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) AND TABLE_NAME = ?
+		ORDER BY INDEX_NAME, SEQ_IN_INDEX`
+	rows, err := db.DB.Query(query, schema, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query index statistics: %v", err)
+	}
+	defer rows.Close()
+
+	var indexes []sqlutil.IndexInfo
+	byName := map[string]int{}
+	for rows.Next() {
+		var indexName string
+		var column sql.NullString
+		var nonUnique int
+		if err := rows.Scan(&indexName, &column, &nonUnique); err != nil {
+			return nil, fmt.Errorf("failed to scan index statistics: %v", err)
+		}
+		pos, ok := byName[indexName]
+		if !ok {
+			pos = len(indexes)
+			byName[indexName] = pos
+			indexes = append(indexes, sqlutil.IndexInfo{
+				Name:    indexName,
+				Unique:  nonUnique == 0,
+				Primary: indexName == "PRIMARY",
+			})
+		}
+		// 函数索引的 COLUMN_NAME 为空，用表达式占位
+		col := column.String
+		if !column.Valid {
+			col = "(expression)"
+		}
+		indexes[pos].Columns = append(indexes[pos].Columns, col)
+	}
+	return indexes, rows.Err()
+}
+
+// SuggestIndexes 基于执行计划和已有索引给出索引建议
+func SuggestIndexes(queries []string) (*sqlutil.IndexReport, error) {
+	if !IsConnected() {
+		return nil, fmt.Errorf("database not connected")
+	}
+	return sqlutil.SuggestIndexes(sqlutil.AdvisorSource{
+		Dialect: sqlutil.DialectMySQL,
+		Explain: func(query string) (*sqlutil.PlanSummary, error) {
+			return Explain(query, false, false)
+		},
+		TableIndexes: TableIndexes,
+		ExplainGeneric: func(query string) (*sqlutil.PlanSummary, error) {
+			summary, err := Explain(query, false, false, sqlutil.SamplePlaceholderArgs(query)...)
+			if err != nil {
+				return nil, err
+			}
+			summary.Notes = append(summary.Notes, "placeholders were bound to sample values; actual plans may differ for specific values")
+			return summary, nil
+		},
+	}, queries)
+}
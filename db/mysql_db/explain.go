@@ -0,0 +1,87 @@
+package mysql_db
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"xz_mcp/db/sqlutil"
+)
+
+// Explain 获取MySQL执行计划（EXPLAIN FORMAT=JSON，可选 EXPLAIN ANALYZE）并转换为统一结构
+func Explain(query string, analyze bool, includeRaw bool, args ...interface{}) (*sqlutil.PlanSummary, error) {
+	if !IsConnected() {
+		return nil, fmt.Errorf("database not connected")
+	}
+	query = sqlutil.TrimStatement(query)
+
+	if analyze {
+		return explainAnalyze(query, includeRaw, args...)
+	}
+
+	var planJSON string
+	if err := db.DB.QueryRow("EXPLAIN FORMAT=JSON "+query, args...).Scan(&planJSON); err != nil {
+		return nil, fmt.Errorf("EXPLAIN failed: %v", err)
+	}
+	nodes, raw, err := sqlutil.ParseMySQLExplainJSON(planJSON)
+	if err != nil {
+		return nil, err
+	}
+	summary := sqlutil.Summarize("mysql", nodes)
+	if includeRaw {
+		summary.Raw = raw
+	}
+	return summary, nil
+}
+
+// explainAnalyze 执行 EXPLAIN ANALYZE（需要 MySQL 8.0.18+，会真正执行查询，因此只允许只读语句）
+func explainAnalyze(query string, includeRaw bool, args ...interface{}) (*sqlutil.PlanSummary, error) {
+	var version string
+	if err := db.DB.QueryRow("SELECT VERSION()").Scan(&version); err != nil {
+		return nil, fmt.Errorf("failed to get server version: %v", err)
+	}
+	if !supportsExplainAnalyze(version) {
+		return nil, fmt.Errorf("EXPLAIN ANALYZE requires MySQL 8.0.18 or later (server version: %s)", version)
+	}
+	upper := strings.ToUpper(query)
+	if !strings.HasPrefix(upper, "SELECT") && !strings.HasPrefix(upper, "WITH") && !strings.HasPrefix(upper, "TABLE") {
+		return nil, fmt.Errorf("EXPLAIN ANALYZE executes the statement; only SELECT queries are allowed")
+	}
+
+	var tree string
+	if err := db.DB.QueryRow("EXPLAIN ANALYZE "+query, args...).Scan(&tree); err != nil {
+		return nil, fmt.Errorf("EXPLAIN ANALYZE failed: %v", err)
+	}
+	summary := sqlutil.Summarize("mysql", sqlutil.ParseMySQLExplainAnalyze(tree))
+	summary.Analyzed = true
+	if includeRaw {
+		summary.Raw = tree
+	}
+	return summary, nil
+}
+
+// supportsExplainAnalyze 判断服务器版本是否支持 EXPLAIN ANALYZE
+func supportsExplainAnalyze(version string) bool {
+	if strings.Contains(strings.ToLower(version), "mariadb") {
+		return false
+	}
+	parts := strings.SplitN(strings.SplitN(version, "-", 2)[0], ".", 3)
+	if len(parts) < 3 {
+		return false
+	}
+	nums := make([]int, 3)
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return false
+		}
+		nums[i] = n
+	}
+	if nums[0] != 8 {
+		return nums[0] > 8
+	}
+	if nums[1] != 0 {
+		return true
+	}
+	return nums[2] >= 18
+}
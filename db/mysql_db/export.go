@@ -0,0 +1,20 @@
+package mysql_db
+
+import (
+	"fmt"
+
+	"xz_mcp/db/dataio"
+)
+
+// Export 执行查询并将结果集流式写入文件（CSV/JSONL/Parquet/Markdown）
+func Export(query string, opts dataio.ExportOptions, args ...interface{}) (*dataio.ExportResult, error) {
+	if !IsConnected() {
+		return nil, fmt.Errorf("database not connected")
+	}
+	rows, err := db.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %v", err)
+	}
+	defer rows.Close()
+	return dataio.ExportRows(rows, opts)
+}
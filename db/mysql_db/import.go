@@ -0,0 +1,17 @@
+package mysql_db
+
+import (
+	"context"
+	"fmt"
+
+	"xz_mcp/db/dataio"
+	"xz_mcp/db/sqlutil"
+)
+
+// Import 将 CSV/JSONL/Parquet 文件导入到表中，服务器允许时使用 LOAD DATA LOCAL INFILE
+func Import(opts dataio.ImportOptions) (*dataio.ImportResult, error) {
+	if !IsConnected() {
+		return nil, fmt.Errorf("database not connected")
+	}
+	return dataio.Import(context.Background(), db.DB, sqlutil.DialectMySQL, opts)
+}
@@ -6,7 +6,7 @@ import (
 	"time"
 
 	"github.com/Xuzan9396/zmysql/smysql"
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 )
 
 var db *smysql.MySQLClient
@@ -77,6 +77,34 @@ func InitDB(config ConnectionConfig) error {
 	return nil
 }
 
+// OpenDSN 按 DSN（如 user:pass@tcp(host:3306)/db）打开一个独立于全局连接的连接，用于跨实例工具，
+// 与全局连接一样解析时间类型；调用方负责关闭
+func OpenDSN(dsn string) (*sql.DB, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MySQL DSN: %v", err)
+	}
+	cfg.ParseTime = true
+	conn, err := sql.Open("mysql", cfg.FormatDSN())
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to connect to MySQL: %v", err)
+	}
+	return conn, nil
+}
+
+// DSNAddr DSN 中的服务器地址（不含用户名和密码），用于在结果中标识连接
+func DSNAddr(dsn string) string {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return ""
+	}
+	return cfg.Addr
+}
+
 // CloseDB 关闭数据库连接
 func CloseDB() error {
 	if rawDB != nil {
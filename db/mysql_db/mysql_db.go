@@ -1,8 +1,11 @@
 package mysql_db
 
 import (
+	"context"
 	"fmt"
 	"strings"
+
+	"xz_mcp/db/sqlutil"
 )
 
 // QueryResult 查询结果
@@ -166,6 +169,31 @@ func ExecWithLastID(sql string, args ...interface{}) (*ExecResult, error) {
 	}, nil
 }
 
+// DryRun 在事务中预演DML语句，采集影响行数和前后样本后回滚
+func DryRun(query string, sampleRows int, args ...interface{}) (*sqlutil.DryRunResult, error) {
+	if !IsConnected() {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	ctx := context.Background()
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	result, err := sqlutil.DryRun(ctx, tx, sqlutil.DialectMySQL, query, args, sampleRows)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Rollback(); err != nil {
+		result.Notes = append(result.Notes, fmt.Sprintf("rollback failed: %v", err))
+	} else {
+		result.RolledBack = true
+	}
+	return result, nil
+}
+
 // CallProcedure 调用存储过程，支持动态数量的结果集
 func CallProcedure(procName string, args ...interface{}) (*QueryResult, error) {
 	if !IsConnected() || rawDB == nil {
@@ -0,0 +1,68 @@
+package pgsql_db
+
+import (
+	"context"
+	"fmt"
+
+	"xz_mcp/db/sqlutil"
+)
+
+// TableIndexes 从 pg_index 读取表上的索引，主键按 indisprimary 识别
+func (p *PgClient) TableIndexes(ctx context.Context, tableName, schema string) ([]sqlutil.IndexInfo, error) {
+	query := `
+		SELECT c.relname AS index_name,
+			pg_get_indexdef(i.indexrelid) AS index_definition,
+			i.indisprimary AS is_primary
+		FROM pg_index i
+		JOIN pg_class c ON c.oid = i.indexrelid
+		JOIN pg_class t ON t.oid = i.indrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		WHERE t.relname = $1 AND n.nspname = $2
+		ORDER BY c.relname
+	`
+	result, err := p.Query(ctx, query, tableName, schema)
+	if err != nil {
+		return nil, err
+	}
+	indexes := make([]sqlutil.IndexInfo, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		name, _ := row["index_name"].(string)
+		def, _ := row["index_definition"].(string)
+		primary, _ := row["is_primary"].(bool)
+		unique, columns := sqlutil.ParsePostgresIndexDef(def)
+		indexes = append(indexes, sqlutil.IndexInfo{
+			Name:    name,
+			Columns: columns,
+			Unique:  unique,
+			Primary: primary,
+		})
+	}
+	return indexes, nil
+}
+
+// SuggestIndexes 基于执行计划和已有索引给出索引建议
+func (p *PgClient) SuggestIndexes(ctx context.Context, queries []string, schema string) (*sqlutil.IndexReport, error) {
+	if schema == "" {
+		schema = "public"
+	}
+	return sqlutil.SuggestIndexes(sqlutil.AdvisorSource{
+		Dialect: sqlutil.DialectPostgres,
+		Explain: func(query string) (*sqlutil.PlanSummary, error) {
+			return p.Explain(ctx, query, false, false, false)
+		},
+		ExplainGeneric: func(query string) (*sqlutil.PlanSummary, error) {
+			return p.ExplainGeneric(ctx, query)
+		},
+		TableIndexes: func(table string) ([]sqlutil.IndexInfo, error) {
+			tableSchema, name := sqlutil.SplitTableName(table)
+			if tableSchema == "" {
+				tableSchema = schema
+			}
+			indexes, err := p.TableIndexes(ctx, name, tableSchema)
+			if err != nil {
+				return nil, fmt.Errorf("获取索引失败: %w", err)
+			}
+			return indexes, nil
+		},
+	}, queries)
+}
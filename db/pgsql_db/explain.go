@@ -0,0 +1,100 @@
+package pgsql_db
+
+import (
+	"context"
+	"fmt"
+
+	"xz_mcp/db/sqlutil"
+)
+
+// Explain 获取PostgreSQL执行计划（FORMAT JSON）并转换为统一结构
+// analyze 为 true 时在事务中执行并回滚，DML 语句不会真正修改数据。
+// PostgreSQL 13 之前 BUFFERS 必须和 ANALYZE 一起使用，这些版本上不带 ANALYZE 时忽略 BUFFERS
+func (p *PgClient) Explain(ctx context.Context, query string, analyze, buffers, includeRaw bool) (*sqlutil.PlanSummary, error) {
+	options := "FORMAT JSON"
+	if analyze {
+		options += ", ANALYZE"
+	}
+	var skipped string
+	if buffers && !analyze {
+		version, err := p.serverVersionNum(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if version < 130000 {
+			buffers = false
+			skipped = fmt.Sprintf("BUFFERS was ignored: it requires ANALYZE before PostgreSQL 13 (server_version_num: %d)", version)
+		}
+	}
+	if buffers {
+		options += ", BUFFERS"
+	}
+	summary, err := p.explain(ctx, query, options, analyze, includeRaw)
+	if err != nil {
+		return nil, err
+	}
+	if skipped != "" {
+		summary.Notes = append(summary.Notes, skipped)
+	}
+	return summary, nil
+}
+
+// serverVersionNum 返回服务器的 server_version_num，例如 130004
+func (p *PgClient) serverVersionNum(ctx context.Context) (int, error) {
+	var version int
+	if err := p.db.GetContext(ctx, &version, "SELECT current_setting('server_version_num')::int"); err != nil {
+		return 0, fmt.Errorf("获取服务器版本失败: %w", err)
+	}
+	return version, nil
+}
+
+// ExplainGeneric 以 EXPLAIN (GENERIC_PLAN) 获取含 $1 等参数占位符的查询的通用执行计划，需要 PostgreSQL 16+
+func (p *PgClient) ExplainGeneric(ctx context.Context, query string) (*sqlutil.PlanSummary, error) {
+	version, err := p.serverVersionNum(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if version < 160000 {
+		return nil, fmt.Errorf("query has $n placeholders and EXPLAIN (GENERIC_PLAN) requires PostgreSQL 16 or later (server_version_num: %d); replace them with representative values", version)
+	}
+	summary, err := p.explain(ctx, query, "FORMAT JSON, GENERIC_PLAN", false, false)
+	if err != nil {
+		return nil, err
+	}
+	summary.Notes = append(summary.Notes, "generic plan for a parameterized query; actual plans may differ for specific values")
+	return summary, nil
+}
+
+// explain 执行 EXPLAIN (options) 并解析 JSON 格式的计划
+func (p *PgClient) explain(ctx context.Context, query, options string, analyze, includeRaw bool) (*sqlutil.PlanSummary, error) {
+	explainSQL := fmt.Sprintf("EXPLAIN (%s) %s", options, sqlutil.TrimStatement(query))
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("开始事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	var planJSON string
+	if err := tx.QueryRowContext(ctx, explainSQL).Scan(&planJSON); err != nil {
+		return nil, fmt.Errorf("EXPLAIN执行失败: %w", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		return nil, fmt.Errorf("回滚失败: %w", err)
+	}
+
+	nodes, execTime, raw, err := sqlutil.ParsePostgresExplainJSON(planJSON)
+	if err != nil {
+		return nil, err
+	}
+	summary := sqlutil.Summarize("postgres", nodes)
+	summary.Analyzed = analyze
+	summary.ExecutionTime = execTime
+	if analyze {
+		summary.Notes = append(summary.Notes, "ANALYZE ran inside a transaction that was rolled back")
+	}
+	if includeRaw {
+		summary.Raw = raw
+	}
+	return summary, nil
+}
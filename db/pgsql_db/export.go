@@ -0,0 +1,18 @@
+package pgsql_db
+
+import (
+	"context"
+	"fmt"
+
+	"xz_mcp/db/dataio"
+)
+
+// Export 执行查询并将结果集流式写入文件（CSV/JSONL/Parquet/Markdown）
+func (p *PgClient) Export(ctx context.Context, query string, opts dataio.ExportOptions, args ...interface{}) (*dataio.ExportResult, error) {
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询失败: %w", err)
+	}
+	defer rows.Close()
+	return dataio.ExportRows(rows, opts)
+}
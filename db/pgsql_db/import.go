@@ -0,0 +1,13 @@
+package pgsql_db
+
+import (
+	"context"
+
+	"xz_mcp/db/dataio"
+	"xz_mcp/db/sqlutil"
+)
+
+// Import 将 CSV/JSONL/Parquet 文件导入到表中，默认使用 COPY FROM STDIN
+func (p *PgClient) Import(ctx context.Context, opts dataio.ImportOptions) (*dataio.ImportResult, error) {
+	return dataio.Import(ctx, p.db.DB, sqlutil.DialectPostgres, opts)
+}
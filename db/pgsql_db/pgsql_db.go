@@ -2,12 +2,16 @@ package pgsql_db
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"net/url"
 	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
+
+	"xz_mcp/db/sqlutil"
 )
 
 // PgConfig PostgreSQL配置结构
@@ -70,6 +74,37 @@ func NewPgClient(config PgConfig) (*PgClient, error) {
 	}, nil
 }
 
+// OpenDSN 按连接串（postgres:// URL 或 key=value 形式）打开一个独立于当前客户端的连接，用于跨实例工具；
+// 调用方负责关闭
+func OpenDSN(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PostgreSQL DSN: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("连接PostgreSQL失败: %w", err)
+	}
+	return db, nil
+}
+
+// DSNHost 连接串中的主机（不含用户名和密码），用于在结果中标识连接
+func DSNHost(dsn string) string {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return ""
+		}
+		return u.Host
+	}
+	for _, field := range strings.Fields(dsn) {
+		if host, ok := strings.CutPrefix(field, "host="); ok {
+			return strings.Trim(host, "'")
+		}
+	}
+	return ""
+}
+
 // Close 关闭PostgreSQL连接
 func (p *PgClient) Close() error {
 	if p.db != nil {
@@ -78,6 +113,11 @@ func (p *PgClient) Close() error {
 	return nil
 }
 
+// SQLDB 返回底层 *sql.DB，供跨库工具使用
+func (p *PgClient) SQLDB() *sql.DB {
+	return p.db.DB
+}
+
 // Ping 测试PostgreSQL连接
 func (p *PgClient) Ping(ctx context.Context) error {
 	return p.db.PingContext(ctx)
@@ -224,6 +264,26 @@ func (p *PgClient) ExecWithLastInsertId(ctx context.Context, query string, args
 	return p.Exec(ctx, query, args...)
 }
 
+// DryRun 在事务中预演DML语句，通过RETURNING采集变更后的行，最终回滚
+func (p *PgClient) DryRun(ctx context.Context, query string, sampleRows int, args ...interface{}) (*sqlutil.DryRunResult, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("开始事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := sqlutil.DryRun(ctx, tx, sqlutil.DialectPostgres, query, args, sampleRows)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Rollback(); err != nil {
+		result.Notes = append(result.Notes, fmt.Sprintf("回滚失败: %v", err))
+	} else {
+		result.RolledBack = true
+	}
+	return result, nil
+}
+
 // BeginTx 开始事务
 func (p *PgClient) BeginTx(ctx context.Context) (*sqlx.Tx, error) {
 	return p.db.BeginTxx(ctx, nil)
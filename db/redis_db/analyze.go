@@ -0,0 +1,407 @@
+package redis_db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 默认值
+const (
+	DefaultAnalyzeTopN      = 10
+	DefaultAnalyzeDuration  = 30 * time.Second
+	DefaultAnalyzeOpsPerSec = 5000
+	maxPrefixStats          = 50
+)
+
+// AnalyzeOptions redis_analyze_keyspace 参数
+type AnalyzeOptions struct {
+	Pattern       string
+	Cursor        string        // 从上次中断的位置继续，格式同 ScanOptions.Cursor
+	Count         int64         // 每次 SCAN 的 COUNT 提示
+	TopN          int           // 每类排行保留的键数量
+	MaxKeys       int           // 最多分析的键数量，0 表示不限制
+	MaxDuration   time.Duration // 时间预算
+	MaxOpsPerSec  int           // 发送给服务器的命令速率上限
+	MemorySamples int           // MEMORY USAGE 的 SAMPLES 参数
+	Separator     string        // 前缀分隔符
+	PrefixDepth   int           // 按前几段聚合内存
+	Progress      func(scanned int, total int64)
+}
+
+// BigKey 大键排行中的一项
+type BigKey struct {
+	Key         string `json:"key"`
+	Elements    int64  `json:"elements"` // string 为字节数
+	MemoryBytes int64  `json:"memory_bytes"`
+	TTLMs       int64  `json:"ttl_ms"`
+}
+
+// HotKey 热键排行中的一项
+type HotKey struct {
+	Key       string `json:"key"`
+	Type      string `json:"type"`
+	Frequency int64  `json:"lfu_frequency"`
+}
+
+// TypeSummary 单个类型的汇总
+type TypeSummary struct {
+	Keys          int      `json:"keys"`
+	Elements      int64    `json:"elements"`
+	MemoryBytes   int64    `json:"memory_bytes"`
+	TopByElements []BigKey `json:"top_by_elements"`
+	TopByMemory   []BigKey `json:"top_by_memory"`
+}
+
+// TTLBucket TTL 分布区间
+type TTLBucket struct {
+	Bucket      string `json:"bucket"`
+	Keys        int    `json:"keys"`
+	MemoryBytes int64  `json:"memory_bytes"`
+}
+
+// PrefixStat 前缀内存汇总
+type PrefixStat struct {
+	Prefix      string `json:"prefix"`
+	Keys        int    `json:"keys"`
+	MemoryBytes int64  `json:"memory_bytes"`
+}
+
+// AnalyzeResult redis_analyze_keyspace 结果
+type AnalyzeResult struct {
+	Type           string                  `json:"type"`
+	Pattern        string                  `json:"pattern"`
+	DBSize         int64                   `json:"dbsize"`
+	Scanned        int                     `json:"scanned"`
+	Complete       bool                    `json:"complete"`
+	Cursor         string                  `json:"cursor"` // 未完成时用于继续分析
+	StopReason     string                  `json:"stop_reason,omitempty"`
+	DurationMs     int64                   `json:"duration_ms"`
+	Commands       int64                   `json:"commands"`
+	EvictionPolicy string                  `json:"maxmemory_policy,omitempty"`
+	TotalMemory    int64                   `json:"memory_bytes"`
+	Types          map[string]*TypeSummary `json:"types"`
+	HotKeys        []HotKey                `json:"hot_keys,omitempty"`
+	TTL            []TTLBucket             `json:"ttl_distribution"`
+	Prefixes       []PrefixStat            `json:"prefixes"`
+	OtherPrefixes  int                     `json:"other_prefixes,omitempty"`
+	Notes          []string                `json:"notes,omitempty"`
+}
+
+// ttlBuckets TTL 区间上限（毫秒），0 表示不过期
+var ttlBuckets = []struct {
+	name  string
+	limit int64
+}{
+	{"no_expiry", 0},
+	{"<1m", int64(time.Minute / time.Millisecond)},
+	{"1m-1h", int64(time.Hour / time.Millisecond)},
+	{"1h-1d", int64(24 * time.Hour / time.Millisecond)},
+	{"1d-7d", int64(7 * 24 * time.Hour / time.Millisecond)},
+	{">7d", -1},
+}
+
+// opsLimiter 按累计命令数限制速率
+type opsLimiter struct {
+	perSec int
+	start  time.Time
+	ops    int64
+}
+
+// wait 记录 n 个命令，必要时睡眠使平均速率不超过上限
+func (l *opsLimiter) wait(ctx context.Context, n int) error {
+	l.ops += int64(n)
+	if l.perSec <= 0 {
+		return nil
+	}
+	due := l.start.Add(time.Duration(float64(l.ops) / float64(l.perSec) * float64(time.Second)))
+	if d := time.Until(due); d > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(d):
+		}
+	}
+	return nil
+}
+
+// AnalyzeKeyspace 以限速的 SCAN 遍历数据库，统计大键、热键、TTL 分布和各前缀的内存。
+// 达到时间预算或键数量上限时停止，并返回可继续的游标
+func (r *RedisClient) AnalyzeKeyspace(ctx context.Context, opts AnalyzeOptions) (*AnalyzeResult, error) {
+	start := time.Now()
+	if opts.Pattern == "" {
+		opts.Pattern = "*"
+	}
+	if opts.Count <= 0 {
+		opts.Count = 100
+	}
+	if opts.TopN <= 0 {
+		opts.TopN = DefaultAnalyzeTopN
+	}
+	if opts.MaxDuration <= 0 {
+		opts.MaxDuration = DefaultAnalyzeDuration
+	}
+	if opts.Separator == "" {
+		opts.Separator = ":"
+	}
+	if opts.PrefixDepth <= 0 {
+		opts.PrefixDepth = 1
+	}
+	deadline := start.Add(opts.MaxDuration)
+	limiter := &opsLimiter{perSec: opts.MaxOpsPerSec, start: start}
+
+	result := &AnalyzeResult{Type: "redis_analyze_keyspace", Pattern: opts.Pattern, Types: map[string]*TypeSummary{}}
+	result.DBSize, _ = r.client.DBSize(ctx).Result()
+
+	// 只有 LFU 淘汰策略下 OBJECT FREQ 才可用
+	lfu := false
+	if policy, err := r.client.ConfigGet(ctx, "maxmemory-policy").Result(); err == nil {
+		result.EvictionPolicy = policy["maxmemory-policy"]
+		lfu = strings.Contains(result.EvictionPolicy, "lfu")
+		if !lfu {
+			result.Notes = append(result.Notes, "hot keys need an LFU maxmemory-policy (OBJECT FREQ); current policy is "+result.EvictionPolicy)
+		}
+	} else {
+		result.Notes = append(result.Notes, "CONFIG GET maxmemory-policy failed, hot keys are not reported: "+err.Error())
+	}
+	limiter.wait(ctx, 2)
+
+	ttl := make([]TTLBucket, len(ttlBuckets))
+	for i, b := range ttlBuckets {
+		ttl[i].Bucket = b.name
+	}
+	prefixes := map[string]*PrefixStat{}
+	withMemory := true
+
+	scanner, err := r.newKeyScanner(ctx, opts.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if time.Now().After(deadline) {
+			result.StopReason = fmt.Sprintf("time budget of %s exhausted", opts.MaxDuration)
+			break
+		}
+		if opts.MaxKeys > 0 && result.Scanned >= opts.MaxKeys {
+			result.StopReason = fmt.Sprintf("max_keys %d reached", opts.MaxKeys)
+			break
+		}
+		keys, err := scanner.Next(ctx, opts.Pattern, opts.Count, "")
+		if err != nil {
+			return nil, fmt.Errorf("SCAN failed: %w", err)
+		}
+		if err := limiter.wait(ctx, 1); err != nil {
+			return nil, err
+		}
+
+		stats, ops, err := r.analyzeBatch(ctx, keys, lfu, &withMemory, opts.MemorySamples)
+		if err != nil {
+			return nil, err
+		}
+		if err := limiter.wait(ctx, ops); err != nil {
+			return nil, err
+		}
+		for _, s := range stats {
+			result.Scanned++
+			result.TotalMemory += s.MemoryBytes
+			summary := result.Types[s.typ]
+			if summary == nil {
+				summary = &TypeSummary{TopByElements: []BigKey{}, TopByMemory: []BigKey{}}
+				result.Types[s.typ] = summary
+			}
+			summary.Keys++
+			summary.Elements += s.Elements
+			summary.MemoryBytes += s.MemoryBytes
+			summary.TopByElements = pushTop(summary.TopByElements, s.BigKey, opts.TopN, func(a, b BigKey) bool { return a.Elements > b.Elements })
+			if withMemory {
+				summary.TopByMemory = pushTop(summary.TopByMemory, s.BigKey, opts.TopN, func(a, b BigKey) bool { return a.MemoryBytes > b.MemoryBytes })
+			}
+			if s.freq != nil {
+				result.HotKeys = pushTop(result.HotKeys, HotKey{Key: s.Key, Type: s.typ, Frequency: *s.freq}, opts.TopN, func(a, b HotKey) bool { return a.Frequency > b.Frequency })
+			}
+
+			b := ttlBucket(s.TTLMs)
+			ttl[b].Keys++
+			ttl[b].MemoryBytes += s.MemoryBytes
+
+			prefix := keyPrefix(s.Key, opts.Separator, opts.PrefixDepth)
+			p := prefixes[prefix]
+			if p == nil {
+				p = &PrefixStat{Prefix: prefix}
+				prefixes[prefix] = p
+			}
+			p.Keys++
+			p.MemoryBytes += s.MemoryBytes
+		}
+		if opts.Progress != nil {
+			opts.Progress(result.Scanned, result.DBSize)
+		}
+		if scanner.Done() {
+			result.Complete = true
+			break
+		}
+	}
+	if !withMemory {
+		result.Notes = append(result.Notes, "MEMORY USAGE is unavailable, memory figures are zero")
+	}
+
+	result.Cursor = scanner.Cursor()
+	result.TTL = ttl
+	result.Prefixes = make([]PrefixStat, 0, len(prefixes))
+	for _, p := range prefixes {
+		result.Prefixes = append(result.Prefixes, *p)
+	}
+	sort.Slice(result.Prefixes, func(i, j int) bool {
+		a, b := result.Prefixes[i], result.Prefixes[j]
+		if a.MemoryBytes != b.MemoryBytes {
+			return a.MemoryBytes > b.MemoryBytes
+		}
+		if a.Keys != b.Keys {
+			return a.Keys > b.Keys
+		}
+		return a.Prefix < b.Prefix
+	})
+	if len(result.Prefixes) > maxPrefixStats {
+		result.OtherPrefixes = len(result.Prefixes) - maxPrefixStats
+		result.Prefixes = result.Prefixes[:maxPrefixStats]
+	}
+	result.Commands = limiter.ops
+	result.DurationMs = time.Since(start).Milliseconds()
+	return result, nil
+}
+
+// keyStat 单个键的分析数据
+type keyStat struct {
+	BigKey
+	typ  string
+	freq *int64
+}
+
+// analyzeBatch 用两次管道读取一批键的类型、TTL、内存、访问频率和元素数量，返回发送的命令数
+func (r *RedisClient) analyzeBatch(ctx context.Context, keys []string, lfu bool, withMemory *bool, samples int) ([]keyStat, int, error) {
+	if len(keys) == 0 {
+		return nil, 0, nil
+	}
+	ops := 0
+	pipe := r.client.Pipeline()
+	typeCmds := make([]*redis.StatusCmd, len(keys))
+	ttlCmds := make([]*redis.DurationCmd, len(keys))
+	memCmds := make([]*redis.IntCmd, len(keys))
+	freqCmds := make([]*redis.IntCmd, len(keys))
+	for i, key := range keys {
+		typeCmds[i] = pipe.Type(ctx, key)
+		ttlCmds[i] = pipe.PTTL(ctx, key)
+		ops += 2
+		if *withMemory {
+			if samples > 0 {
+				memCmds[i] = pipe.MemoryUsage(ctx, key, samples)
+			} else {
+				memCmds[i] = pipe.MemoryUsage(ctx, key)
+			}
+			ops++
+		}
+		if lfu {
+			freqCmds[i] = pipe.ObjectFreq(ctx, key)
+			ops++
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !isReplyError(err) {
+		return nil, ops, fmt.Errorf("failed to read key metadata: %w", err)
+	}
+
+	pipe = r.client.Pipeline()
+	lenCmds := make([]*redis.IntCmd, len(keys))
+	for i, key := range keys {
+		switch typeCmds[i].Val() {
+		case "string":
+			lenCmds[i] = pipe.StrLen(ctx, key)
+		case "hash":
+			lenCmds[i] = pipe.HLen(ctx, key)
+		case "list":
+			lenCmds[i] = pipe.LLen(ctx, key)
+		case "set":
+			lenCmds[i] = pipe.SCard(ctx, key)
+		case "zset":
+			lenCmds[i] = pipe.ZCard(ctx, key)
+		case "stream":
+			lenCmds[i] = pipe.XLen(ctx, key)
+		default:
+			continue
+		}
+		ops++
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !isReplyError(err) {
+		return nil, ops, fmt.Errorf("failed to read key lengths: %w", err)
+	}
+
+	stats := make([]keyStat, 0, len(keys))
+	for i, key := range keys {
+		typ := typeCmds[i].Val()
+		if typ == "none" || typ == "" {
+			continue
+		}
+		s := keyStat{BigKey: BigKey{Key: key, TTLMs: ttlMillis(ttlCmds[i].Val())}, typ: typ}
+		if lenCmds[i] != nil {
+			s.Elements = lenCmds[i].Val()
+		}
+		if memCmds[i] != nil {
+			if err := memCmds[i].Err(); err != nil && err != redis.Nil {
+				*withMemory = false
+			} else {
+				s.MemoryBytes = memCmds[i].Val()
+			}
+		}
+		if freqCmds[i] != nil && freqCmds[i].Err() == nil {
+			freq := freqCmds[i].Val()
+			s.freq = &freq
+		}
+		stats = append(stats, s)
+	}
+	return stats, ops, nil
+}
+
+// pushTop 把元素插入按 less 排序、最多 n 个元素的排行
+func pushTop[T any](list []T, item T, n int, less func(a, b T) bool) []T {
+	i := sort.Search(len(list), func(i int) bool { return less(item, list[i]) })
+	if i >= n {
+		return list
+	}
+	list = append(list, item)
+	copy(list[i+1:], list[i:])
+	list[i] = item
+	if len(list) > n {
+		list = list[:n]
+	}
+	return list
+}
+
+// ttlBucket 返回 TTL 所在区间的下标
+func ttlBucket(ttlMs int64) int {
+	if ttlMs < 0 {
+		return 0
+	}
+	for i := 1; i < len(ttlBuckets)-1; i++ {
+		if ttlMs < ttlBuckets[i].limit {
+			return i
+		}
+	}
+	return len(ttlBuckets) - 1
+}
+
+// keyPrefix 取键的前 depth 段作为前缀，没有分隔符的键归入 "(none)"
+func keyPrefix(key, separator string, depth int) string {
+	parts := strings.SplitN(key, separator, depth+1)
+	if len(parts) == 1 {
+		return "(none)"
+	}
+	// 最后一段是键本身，不作为前缀
+	n := depth
+	if len(parts) <= depth {
+		n = len(parts) - 1
+	}
+	return strings.Join(parts[:n], separator) + separator
+}
@@ -0,0 +1,75 @@
+package redis_db
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAnalyzeKeyspace(t *testing.T) {
+	ks := newFakeKeyspace(map[string]*fakeKey{
+		"user:1:profile": {typ: "hash", ttlMs: -1, memory: 100, freq: 3, value: map[string]string{"a": "1", "b": "2"}},
+		"user:2:profile": {typ: "hash", ttlMs: 30000, memory: 300, freq: 50, value: map[string]string{"a": "1"}},
+		"user:2:tags":    {typ: "set", ttlMs: 2 * 3600 * 1000, memory: 80, freq: 1, value: []string{"x", "y", "z"}},
+		"session:abc":    {typ: "string", ttlMs: 10 * 24 * 3600 * 1000, memory: 60, freq: 200, value: "abcdef"},
+		"counter":        {typ: "string", ttlMs: -1, memory: 50, freq: 0, value: "1"},
+	})
+	ks.config = map[string]string{"maxmemory-policy": "allkeys-lfu"}
+	_, client := newFakeRedis(t, ks.handle)
+	ctx := context.Background()
+
+	result, err := client.AnalyzeKeyspace(ctx, AnalyzeOptions{Count: 2, TopN: 1, MaxOpsPerSec: 100000})
+	if err != nil {
+		t.Fatalf("AnalyzeKeyspace: %v", err)
+	}
+	if !result.Complete || result.Scanned != 5 || result.DBSize != 5 || result.TotalMemory != 590 {
+		t.Fatalf("result = %+v", result)
+	}
+	hash := result.Types["hash"]
+	if hash.Keys != 2 || hash.TopByMemory[0].Key != "user:2:profile" || hash.TopByElements[0].Key != "user:1:profile" {
+		t.Errorf("hash summary = %+v", hash)
+	}
+	if s := result.Types["string"]; len(s.TopByElements) != 1 || s.TopByElements[0].Elements != 6 {
+		t.Errorf("string summary = %+v", s)
+	}
+	if len(result.HotKeys) != 1 || result.HotKeys[0].Key != "session:abc" {
+		t.Errorf("hot keys = %+v", result.HotKeys)
+	}
+	want := map[string]int{"no_expiry": 2, "<1m": 1, "1h-1d": 1, ">7d": 1}
+	for _, b := range result.TTL {
+		if b.Keys != want[b.Bucket] {
+			t.Errorf("ttl bucket %s = %d, want %d", b.Bucket, b.Keys, want[b.Bucket])
+		}
+	}
+	if len(result.Prefixes) != 3 || result.Prefixes[0].Prefix != "user:" || result.Prefixes[0].MemoryBytes != 480 || result.Prefixes[2].Prefix != "(none)" {
+		t.Errorf("prefixes = %+v", result.Prefixes)
+	}
+
+	// 时间预算用尽时返回可继续的游标
+	ks.config = nil
+	partial, err := client.AnalyzeKeyspace(ctx, AnalyzeOptions{Count: 2, MaxDuration: time.Nanosecond})
+	if err != nil {
+		t.Fatalf("AnalyzeKeyspace: %v", err)
+	}
+	if partial.Complete || partial.StopReason == "" || partial.HotKeys != nil || len(partial.Notes) == 0 {
+		t.Errorf("partial = %+v", partial)
+	}
+}
+
+func TestKeyPrefix(t *testing.T) {
+	tests := []struct {
+		key   string
+		depth int
+		want  string
+	}{
+		{"user:1:profile", 1, "user:"},
+		{"user:1:profile", 2, "user:1:"},
+		{"user:1", 2, "user:"},
+		{"counter", 1, "(none)"},
+	}
+	for _, tt := range tests {
+		if got := keyPrefix(tt.key, ":", tt.depth); got != tt.want {
+			t.Errorf("keyPrefix(%q, %d) = %q, want %q", tt.key, tt.depth, got, tt.want)
+		}
+	}
+}
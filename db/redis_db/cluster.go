@@ -0,0 +1,505 @@
+package redis_db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 连接模式
+const (
+	ModeStandalone = "standalone"
+	ModeCluster    = "cluster"
+	ModeSentinel   = "sentinel"
+)
+
+// clusterSlots Redis Cluster 的哈希槽总数
+const clusterSlots = 16384
+
+// Validate 检查连接模式及其必需参数
+func (c RedisConfig) Validate() error {
+	switch c.Mode {
+	case "", ModeStandalone:
+		if c.Addr == "" && c.URL == "" {
+			return fmt.Errorf("addr or url is required")
+		}
+	case ModeCluster:
+		if len(c.seedAddrs()) == 0 {
+			return fmt.Errorf("cluster mode needs at least one seed address")
+		}
+		if c.DB != 0 {
+			return fmt.Errorf("redis cluster only supports db 0")
+		}
+	case ModeSentinel:
+		if len(c.seedAddrs()) == 0 {
+			return fmt.Errorf("sentinel mode needs at least one sentinel address")
+		}
+		if c.MasterName == "" {
+			return fmt.Errorf("sentinel mode needs master_name")
+		}
+	default:
+		return fmt.Errorf("unsupported mode %q: expected standalone, cluster or sentinel", c.Mode)
+	}
+	return nil
+}
+
+// seedAddrs cluster 的种子节点或 sentinel 地址；Addrs 为空时使用 Addr
+func (c RedisConfig) seedAddrs() []string {
+	var addrs []string
+	for _, addr := range c.Addrs {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+	if len(addrs) == 0 && c.Addr != "" {
+		addrs = []string{c.Addr}
+	}
+	return addrs
+}
+
+// Mode 返回当前连接模式
+func (r *RedisClient) Mode() string {
+	if r.config.Mode == "" {
+		return ModeStandalone
+	}
+	return r.config.Mode
+}
+
+// scanNodes 返回需要 SCAN 的节点：集群模式下为按地址排序的全部主节点，其他模式下为当前连接
+func (r *RedisClient) scanNodes(ctx context.Context) ([]redis.Cmdable, error) {
+	cluster, ok := r.client.(*redis.ClusterClient)
+	if !ok {
+		return []redis.Cmdable{r.client}, nil
+	}
+	var mu sync.Mutex
+	var masters []*redis.Client
+	err := cluster.ForEachMaster(ctx, func(ctx context.Context, master *redis.Client) error {
+		mu.Lock()
+		masters = append(masters, master)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list cluster masters: %w", err)
+	}
+	sort.Slice(masters, func(i, j int) bool { return masters[i].Options().Addr < masters[j].Options().Addr })
+	nodes := make([]redis.Cmdable, len(masters))
+	for i, m := range masters {
+		nodes[i] = m
+	}
+	return nodes, nil
+}
+
+// keyScanner 依次在每个节点上执行 SCAN。单节点时游标就是 SCAN 游标；
+// 多个主节点时游标为 "<节点序号>:<节点游标>"，"0" 始终表示从头开始或已遍历完
+type keyScanner struct {
+	nodes  []redis.Cmdable
+	node   int
+	cursor uint64
+	done   bool
+}
+
+// newKeyScanner 从游标位置创建遍历器
+func (r *RedisClient) newKeyScanner(ctx context.Context, cursor string) (*keyScanner, error) {
+	nodes, err := r.scanNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s := &keyScanner{nodes: nodes}
+	if cursor == "" || cursor == "0" {
+		return s, nil
+	}
+	nodeCursor := cursor
+	if node, rest, ok := strings.Cut(cursor, ":"); ok {
+		if s.node, err = strconv.Atoi(node); err != nil || s.node < 0 || s.node >= len(nodes) {
+			return nil, fmt.Errorf("invalid cursor %q: node index out of range", cursor)
+		}
+		nodeCursor = rest
+	} else if len(nodes) > 1 {
+		return nil, fmt.Errorf("invalid cursor %q: expected <node>:<cursor> in cluster mode", cursor)
+	}
+	if s.cursor, err = strconv.ParseUint(nodeCursor, 10, 64); err != nil {
+		return nil, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	return s, nil
+}
+
+// Next 在当前节点执行一次 SCAN；当前节点遍历完后切换到下一个节点
+func (s *keyScanner) Next(ctx context.Context, pattern string, count int64, typ string) ([]string, error) {
+	node := s.nodes[s.node]
+	var keys []string
+	var next uint64
+	var err error
+	if typ != "" {
+		keys, next, err = node.ScanType(ctx, s.cursor, pattern, count, typ).Result()
+	} else {
+		keys, next, err = node.Scan(ctx, s.cursor, pattern, count).Result()
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.cursor = next
+	if next == 0 {
+		s.node++
+		s.done = s.node >= len(s.nodes)
+	}
+	return keys, nil
+}
+
+// Done 所有节点是否都已遍历完
+func (s *keyScanner) Done() bool {
+	return s.done
+}
+
+// Cursor 返回可以继续遍历的游标
+func (s *keyScanner) Cursor() string {
+	if s.done {
+		return "0"
+	}
+	if len(s.nodes) == 1 {
+		return strconv.FormatUint(s.cursor, 10)
+	}
+	return fmt.Sprintf("%d:%d", s.node, s.cursor)
+}
+
+// ClusterNodeInfo 集群或哨兵拓扑中的一个节点
+type ClusterNodeInfo struct {
+	ID          string   `json:"id,omitempty"`
+	Addr        string   `json:"addr"`
+	Role        string   `json:"role"` // master / replica
+	Flags       []string `json:"flags"`
+	LinkState   string   `json:"link_state,omitempty"` // cluster: connected/disconnected；sentinel: master-link-status
+	ConfigEpoch int64    `json:"config_epoch,omitempty"`
+	Slots       []string `json:"slots,omitempty"`
+	SlotCount   int      `json:"slot_count,omitempty"`
+	ReplState   string   `json:"replication_state,omitempty"` // 主节点 INFO replication 中副本的 state
+	Offset      *int64   `json:"offset,omitempty"`
+	LagSeconds  *int64   `json:"lag_seconds,omitempty"`
+	Healthy     bool     `json:"healthy"`
+
+	masterID string
+}
+
+// ClusterShard 一个主节点及其副本
+type ClusterShard struct {
+	Master   ClusterNodeInfo   `json:"master"`
+	Replicas []ClusterNodeInfo `json:"replicas"`
+}
+
+// ClusterInfo redis_cluster_info 结果
+type ClusterInfo struct {
+	Type           string            `json:"type"`
+	Mode           string            `json:"mode"`
+	State          string            `json:"state"`
+	Info           map[string]string `json:"info,omitempty"`
+	Shards         []ClusterShard    `json:"shards"`
+	UncoveredSlots []string          `json:"uncovered_slots,omitempty"`
+	Problems       []string          `json:"problems,omitempty"`
+	Notes          []string          `json:"notes,omitempty"`
+}
+
+// ClusterInfo 返回集群的槽位分布、节点和副本健康状况；哨兵模式下返回主节点和副本的状态
+func (r *RedisClient) ClusterInfo(ctx context.Context) (*ClusterInfo, error) {
+	switch r.Mode() {
+	case ModeCluster:
+		return r.clusterInfo(ctx)
+	case ModeSentinel:
+		return r.sentinelInfo(ctx)
+	}
+	return nil, fmt.Errorf("not connected in cluster or sentinel mode (current mode: %s)", r.Mode())
+}
+
+func (r *RedisClient) clusterInfo(ctx context.Context) (*ClusterInfo, error) {
+	text, err := r.client.ClusterInfo(ctx).Result()
+	if err != nil {
+		return nil, fmt.Errorf("CLUSTER INFO failed: %w", err)
+	}
+	result := &ClusterInfo{Type: "redis_cluster_info", Mode: ModeCluster, Info: parseInfoFields(text)}
+	result.State = result.Info["cluster_state"]
+
+	text, err = r.client.ClusterNodes(ctx).Result()
+	if err != nil {
+		return nil, fmt.Errorf("CLUSTER NODES failed: %w", err)
+	}
+	nodes := parseClusterNodes(text)
+
+	// 副本的复制状态和延迟来自各主节点的 INFO replication
+	replication := map[string]map[string]string{}
+	var mu sync.Mutex
+	if cluster, ok := r.client.(*redis.ClusterClient); ok {
+		err = cluster.ForEachMaster(ctx, func(ctx context.Context, master *redis.Client) error {
+			info, err := master.Info(ctx, "replication").Result()
+			if err != nil {
+				return fmt.Errorf("%s: %w", master.Options().Addr, err)
+			}
+			mu.Lock()
+			for addr, fields := range parseReplicas(info) {
+				replication[addr] = fields
+			}
+			mu.Unlock()
+			return nil
+		})
+		if err != nil {
+			result.Notes = append(result.Notes, "INFO replication failed, replica lag is not reported: "+err.Error())
+		}
+	}
+
+	var covered [clusterSlots]bool
+	shards := map[string]*ClusterShard{}
+	var order []string
+	for _, n := range nodes {
+		if n.Role == "master" {
+			shards[n.ID] = &ClusterShard{Master: n, Replicas: []ClusterNodeInfo{}}
+			order = append(order, n.ID)
+			for _, slots := range n.Slots {
+				start, end := slotRange(slots)
+				for s := start; s >= 0 && s <= end && s < clusterSlots; s++ {
+					covered[s] = true
+				}
+			}
+		}
+	}
+	for _, n := range nodes {
+		if n.Role != "replica" {
+			continue
+		}
+		if fields, ok := replication[n.Addr]; ok {
+			n.ReplState = fields["state"]
+			if v, err := strconv.ParseInt(fields["offset"], 10, 64); err == nil {
+				n.Offset = &v
+			}
+			if v, err := strconv.ParseInt(fields["lag"], 10, 64); err == nil {
+				n.LagSeconds = &v
+			}
+			n.Healthy = n.Healthy && n.ReplState == "online"
+		}
+		if shard, ok := shards[n.masterID]; ok {
+			shard.Replicas = append(shard.Replicas, n)
+		} else {
+			result.Problems = append(result.Problems, fmt.Sprintf("replica %s follows unknown master %s", n.Addr, n.masterID))
+		}
+	}
+
+	sort.Slice(order, func(i, j int) bool { return shards[order[i]].Master.Addr < shards[order[j]].Master.Addr })
+	result.Shards = make([]ClusterShard, 0, len(order))
+	for _, id := range order {
+		shard := shards[id]
+		result.Shards = append(result.Shards, *shard)
+		if !shard.Master.Healthy {
+			result.Problems = append(result.Problems, fmt.Sprintf("master %s is unhealthy (flags %s, link %s)", shard.Master.Addr, strings.Join(shard.Master.Flags, ","), shard.Master.LinkState))
+		}
+		if shard.Master.SlotCount > 0 && len(shard.Replicas) == 0 {
+			result.Problems = append(result.Problems, fmt.Sprintf("master %s has no replicas", shard.Master.Addr))
+		}
+		for _, rep := range shard.Replicas {
+			if !rep.Healthy {
+				result.Problems = append(result.Problems, fmt.Sprintf("replica %s of %s is unhealthy (flags %s, link %s, replication %s)",
+					rep.Addr, shard.Master.Addr, strings.Join(rep.Flags, ","), rep.LinkState, rep.ReplState))
+			}
+		}
+	}
+	for start := 0; start < clusterSlots; start++ {
+		if covered[start] {
+			continue
+		}
+		end := start
+		for end+1 < clusterSlots && !covered[end+1] {
+			end++
+		}
+		if start == end {
+			result.UncoveredSlots = append(result.UncoveredSlots, strconv.Itoa(start))
+		} else {
+			result.UncoveredSlots = append(result.UncoveredSlots, fmt.Sprintf("%d-%d", start, end))
+		}
+		start = end
+	}
+	if len(result.UncoveredSlots) > 0 {
+		result.Problems = append(result.Problems, fmt.Sprintf("slots not covered by any master: %s", strings.Join(result.UncoveredSlots, ",")))
+	}
+	if result.State != "ok" {
+		result.Problems = append(result.Problems, "cluster_state is "+result.State)
+	}
+	return result, nil
+}
+
+// parseClusterNodes 解析 CLUSTER NODES：
+// <id> <ip:port@cport[,hostname]> <flags> <master> <ping-sent> <pong-recv> <config-epoch> <link-state> <slot> ...
+func parseClusterNodes(text string) []ClusterNodeInfo {
+	var nodes []ClusterNodeInfo
+	for _, line := range strings.Split(text, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 8 {
+			continue
+		}
+		addr := fields[1]
+		if i := strings.IndexAny(addr, "@,"); i >= 0 {
+			addr = addr[:i]
+		}
+		n := ClusterNodeInfo{
+			ID:        fields[0],
+			Addr:      addr,
+			Role:      "master",
+			Flags:     strings.Split(fields[2], ","),
+			LinkState: fields[7],
+			Healthy:   fields[7] == "connected",
+		}
+		if fields[3] != "-" {
+			n.masterID = fields[3]
+		}
+		n.ConfigEpoch, _ = strconv.ParseInt(fields[6], 10, 64)
+		for _, flag := range n.Flags {
+			switch flag {
+			case "slave", "replica":
+				n.Role = "replica"
+			case "fail", "fail?", "handshake", "noaddr":
+				n.Healthy = false
+			}
+		}
+		for _, slots := range fields[8:] {
+			// [slot->-id] / [slot-<-id] 表示正在迁移的槽
+			if strings.HasPrefix(slots, "[") {
+				continue
+			}
+			start, end := slotRange(slots)
+			if start < 0 {
+				continue
+			}
+			n.Slots = append(n.Slots, slots)
+			n.SlotCount += end - start + 1
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes
+}
+
+// slotRange 解析 "start-end" 或单个槽号，无法解析时返回 -1
+func slotRange(s string) (int, int) {
+	startText, endText, isRange := strings.Cut(s, "-")
+	start, err := strconv.Atoi(startText)
+	if err != nil {
+		return -1, -1
+	}
+	if !isRange {
+		return start, start
+	}
+	end, err := strconv.Atoi(endText)
+	if err != nil {
+		return -1, -1
+	}
+	return start, end
+}
+
+// parseInfoFields 解析 INFO / CLUSTER INFO 的 key:value 行
+func parseInfoFields(text string) map[string]string {
+	fields := map[string]string{}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if k, v, ok := strings.Cut(line, ":"); ok {
+			fields[k] = v
+		}
+	}
+	return fields
+}
+
+// parseReplicas 从 INFO replication 的 slaveN:ip=...,port=...,state=...,offset=...,lag=... 行中按地址取副本状态
+func parseReplicas(text string) map[string]map[string]string {
+	replicas := map[string]map[string]string{}
+	for k, v := range parseInfoFields(text) {
+		if !strings.HasPrefix(k, "slave") || !strings.Contains(v, "=") {
+			continue
+		}
+		fields := map[string]string{}
+		for _, part := range strings.Split(v, ",") {
+			if name, value, ok := strings.Cut(part, "="); ok {
+				fields[name] = value
+			}
+		}
+		replicas[fields["ip"]+":"+fields["port"]] = fields
+	}
+	return replicas
+}
+
+// sentinelInfo 依次询问各哨兵，返回第一个可用哨兵看到的主节点和副本状态
+func (r *RedisClient) sentinelInfo(ctx context.Context) (*ClusterInfo, error) {
+	var lastErr error
+	for _, addr := range r.config.seedAddrs() {
+		sentinel := redis.NewSentinelClient(&redis.Options{
+			Addr:      addr,
+			Password:  r.config.SentinelPassword,
+			TLSConfig: r.tls,
+		})
+		master, err := sentinel.Master(ctx, r.config.MasterName).Result()
+		if err != nil {
+			sentinel.Close()
+			lastErr = fmt.Errorf("%s: %w", addr, err)
+			continue
+		}
+		replicas, err := sentinel.Replicas(ctx, r.config.MasterName).Result()
+		sentinel.Close()
+		if err != nil {
+			lastErr = fmt.Errorf("%s: %w", addr, err)
+			continue
+		}
+
+		result := &ClusterInfo{Type: "redis_cluster_info", Mode: ModeSentinel, State: "ok", Info: map[string]string{"sentinel": addr}}
+		for _, k := range []string{"name", "quorum", "num-slaves", "num-other-sentinels", "failover-timeout"} {
+			if v, ok := master[k]; ok {
+				result.Info[k] = v
+			}
+		}
+		shard := ClusterShard{Master: sentinelNode(master, "master"), Replicas: []ClusterNodeInfo{}}
+		for _, rep := range replicas {
+			shard.Replicas = append(shard.Replicas, sentinelNode(rep, "replica"))
+		}
+		result.Shards = []ClusterShard{shard}
+		if !shard.Master.Healthy {
+			result.State = "fail"
+			result.Problems = append(result.Problems, fmt.Sprintf("master %s is unhealthy (flags %s)", shard.Master.Addr, strings.Join(shard.Master.Flags, ",")))
+		}
+		if len(shard.Replicas) == 0 {
+			result.Problems = append(result.Problems, fmt.Sprintf("master %s has no replicas", shard.Master.Addr))
+		}
+		for _, rep := range shard.Replicas {
+			if !rep.Healthy {
+				result.Problems = append(result.Problems, fmt.Sprintf("replica %s is unhealthy (flags %s, link %s)", rep.Addr, strings.Join(rep.Flags, ","), rep.LinkState))
+			}
+		}
+		return result, nil
+	}
+	return nil, fmt.Errorf("no sentinel answered for master %q: %w", r.config.MasterName, lastErr)
+}
+
+// sentinelNode 把 SENTINEL MASTER / REPLICAS 的字段转换为节点状态
+func sentinelNode(fields map[string]string, role string) ClusterNodeInfo {
+	n := ClusterNodeInfo{
+		ID:        fields["runid"],
+		Addr:      fields["ip"] + ":" + fields["port"],
+		Role:      role,
+		Flags:     strings.Split(fields["flags"], ","),
+		LinkState: fields["master-link-status"],
+		Healthy:   true,
+	}
+	for _, flag := range n.Flags {
+		switch flag {
+		case "s_down", "o_down", "disconnected":
+			n.Healthy = false
+		}
+	}
+	if role == "replica" {
+		if n.LinkState != "" && n.LinkState != "ok" {
+			n.Healthy = false
+		}
+		if v, err := strconv.ParseInt(fields["slave-repl-offset"], 10, 64); err == nil {
+			n.Offset = &v
+		}
+	}
+	return n
+}
@@ -0,0 +1,242 @@
+package redis_db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeCluster 两个主节点的假集群：每个节点 SCAN 和 DBSIZE 只看自己的键，
+// 其他键命令查共享键空间（测试不关心键落在哪个槽）
+type fakeCluster struct {
+	mu    sync.Mutex
+	ports [2]int
+	all   *fakeKeyspace
+}
+
+func (c *fakeCluster) node(own *fakeKeyspace, replication string) func(args []string) interface{} {
+	return func(args []string) interface{} {
+		switch strings.ToUpper(args[0]) {
+		case "SCAN", "DBSIZE":
+			return own.handle(args)
+		case "INFO":
+			return replication
+		case "CLUSTER":
+			c.mu.Lock()
+			a, b := c.ports[0], c.ports[1]
+			c.mu.Unlock()
+			switch strings.ToUpper(args[1]) {
+			case "SLOTS":
+				return []interface{}{
+					[]interface{}{0, 8191, []interface{}{"127.0.0.1", a, "node-a"}},
+					[]interface{}{8192, 16383, []interface{}{"127.0.0.1", b, "node-b"}},
+				}
+			case "INFO":
+				return "cluster_state:ok\r\ncluster_slots_assigned:16384\r\ncluster_known_nodes:4\r\ncluster_size:2\r\n"
+			case "NODES":
+				return fmt.Sprintf("node-a 127.0.0.1:%d@1%d myself,master - 0 0 1 connected 0-8191\n"+
+					"node-b 127.0.0.1:%d@1%d master - 0 0 2 connected 8192-16000 [16001->-node-a]\n"+
+					"node-c 127.0.0.1:7003@17003 slave node-a 0 0 1 connected\n"+
+					"node-d 127.0.0.1:7004@17004 slave,fail node-b 0 0 2 disconnected\n", a, a, b, b)
+			}
+		}
+		return c.all.handle(args)
+	}
+}
+
+func newFakeCluster(t *testing.T) (*fakeCluster, *RedisClient) {
+	t.Helper()
+	str := func() *fakeKey { return &fakeKey{typ: "string", encoding: "embstr", ttlMs: -1, memory: 10, value: "v"} }
+	ownA := newFakeKeyspace(map[string]*fakeKey{"a:1": str(), "a:2": str(), "a:3": str()})
+	ownB := newFakeKeyspace(map[string]*fakeKey{"b:1": str(), "b:2": str()})
+	all := map[string]*fakeKey{}
+	for _, ks := range []*fakeKeyspace{ownA, ownB} {
+		for k, v := range ks.keys {
+			all[k] = v
+		}
+	}
+	c := &fakeCluster{all: newFakeKeyspace(all)}
+	a := startFakeRedis(t, c.node(ownA, "# Replication\r\nrole:master\r\nconnected_slaves:1\r\nslave0:ip=127.0.0.1,port=7003,state=online,offset=120,lag=1\r\n"))
+	b := startFakeRedis(t, c.node(ownB, "# Replication\r\nrole:master\r\nconnected_slaves:0\r\n"))
+	c.mu.Lock()
+	c.ports = [2]int{a.port(), b.port()}
+	c.mu.Unlock()
+
+	client, err := NewRedisClient(RedisConfig{Mode: ModeCluster, Addrs: []string{a.addr()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return c, client
+}
+
+func TestClusterScanFansOut(t *testing.T) {
+	_, client := newFakeCluster(t)
+	ctx := context.Background()
+
+	var keys []string
+	cursor := "0"
+	pages := 0
+	for {
+		res, err := client.Scan(ctx, ScanOptions{Cursor: cursor, Count: 2, MaxKeys: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, k := range res.Keys {
+			keys = append(keys, k.Key)
+		}
+		pages++
+		if res.Complete {
+			if res.Cursor != "0" {
+				t.Errorf("complete scan should return cursor 0, got %q", res.Cursor)
+			}
+			break
+		}
+		if !strings.Contains(res.Cursor, ":") {
+			t.Fatalf("cluster cursor should name the node, got %q", res.Cursor)
+		}
+		cursor = res.Cursor
+		if pages > 10 {
+			t.Fatal("scan did not finish")
+		}
+	}
+	sort.Strings(keys)
+	if strings.Join(keys, ",") != "a:1,a:2,a:3,b:1,b:2" {
+		t.Fatalf("expected keys from both masters, got %v", keys)
+	}
+
+	if _, err := client.Scan(ctx, ScanOptions{Cursor: "5:0"}); err == nil {
+		t.Error("expected out of range node index to be rejected")
+	}
+	if _, err := client.Scan(ctx, ScanOptions{Cursor: "17"}); err == nil {
+		t.Error("expected plain cursor to be rejected in cluster mode")
+	}
+
+	analyzed, err := client.AnalyzeKeyspace(ctx, AnalyzeOptions{Count: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !analyzed.Complete || analyzed.Scanned != 5 || analyzed.DBSize != 5 {
+		t.Errorf("analyze should cover every master: scanned=%d dbsize=%d complete=%v", analyzed.Scanned, analyzed.DBSize, analyzed.Complete)
+	}
+}
+
+func TestClusterInfo(t *testing.T) {
+	c, client := newFakeCluster(t)
+	info, err := client.ClusterInfo(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode != ModeCluster || info.State != "ok" || info.Info["cluster_size"] != "2" || len(info.Shards) != 2 {
+		t.Fatalf("unexpected cluster info: %+v", info)
+	}
+	a := info.Shards[0]
+	if c.ports[0] > c.ports[1] {
+		a = info.Shards[1]
+	}
+	if a.Master.ID != "node-a" || a.Master.SlotCount != 8192 || !a.Master.Healthy || len(a.Replicas) != 1 {
+		t.Fatalf("unexpected shard: %+v", a)
+	}
+	rep := a.Replicas[0]
+	if !rep.Healthy || rep.ReplState != "online" || rep.LagSeconds == nil || *rep.LagSeconds != 1 || *rep.Offset != 120 {
+		t.Errorf("unexpected replica: %+v", rep)
+	}
+	if strings.Join(info.UncoveredSlots, ",") != "16001-16383" {
+		t.Errorf("unexpected uncovered slots: %v", info.UncoveredSlots)
+	}
+	problems := strings.Join(info.Problems, "\n")
+	if !strings.Contains(problems, "127.0.0.1:7004") || !strings.Contains(problems, "16001-16383") {
+		t.Errorf("expected failed replica and uncovered slots in problems: %v", info.Problems)
+	}
+
+	_, standalone := newFakeRedis(t, func(args []string) interface{} { return nil })
+	if _, err := standalone.ClusterInfo(context.Background()); err == nil {
+		t.Error("expected error in standalone mode")
+	}
+}
+
+func TestSentinelMode(t *testing.T) {
+	master := startFakeRedis(t, func(args []string) interface{} {
+		if strings.ToUpper(args[0]) == "GET" {
+			return "from-master"
+		}
+		return fakeError("ERR unknown command '" + args[0] + "'")
+	})
+	sentinel := startFakeRedis(t, func(args []string) interface{} {
+		if strings.ToUpper(args[0]) != "SENTINEL" {
+			return fakeError("ERR unknown command '" + args[0] + "'")
+		}
+		if args[2] != "mymaster" {
+			return fakeError("ERR No such master with that name")
+		}
+		switch strings.ToLower(args[1]) {
+		case "get-master-addr-by-name":
+			return []string{"127.0.0.1", fmt.Sprint(master.port())}
+		case "sentinels":
+			return []interface{}{}
+		case "master":
+			return []string{"name", "mymaster", "ip", "127.0.0.1", "port", fmt.Sprint(master.port()), "runid", "m1", "flags", "master", "quorum", "2", "num-other-sentinels", "2"}
+		case "replicas", "slaves":
+			return []interface{}{
+				[]string{"ip", "10.0.0.2", "port", "6379", "flags", "slave", "master-link-status", "ok", "slave-repl-offset", "500"},
+				[]string{"ip", "10.0.0.3", "port", "6379", "flags", "slave,s_down,disconnected", "master-link-status", "err"},
+			}
+		}
+		return fakeError("ERR unknown sentinel subcommand")
+	})
+
+	config := RedisConfig{Mode: ModeSentinel, Addrs: []string{sentinel.addr()}}
+	if err := config.Validate(); err == nil {
+		t.Fatal("expected master_name to be required")
+	}
+	config.MasterName = "mymaster"
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewRedisClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+
+	got, err := client.ExecuteCommand(ctx, []interface{}{"GET", "k"})
+	if err != nil || got != "from-master" {
+		t.Fatalf("command should reach the master: %v, %v", got, err)
+	}
+
+	info, err := client.ClusterInfo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shard := info.Shards[0]
+	if info.Mode != ModeSentinel || info.Info["quorum"] != "2" || shard.Master.Addr != master.addr() || !shard.Master.Healthy {
+		t.Fatalf("unexpected sentinel info: %+v", info)
+	}
+	if len(shard.Replicas) != 2 || !shard.Replicas[0].Healthy || shard.Replicas[1].Healthy || *shard.Replicas[0].Offset != 500 {
+		t.Errorf("unexpected replicas: %+v", shard.Replicas)
+	}
+	if len(info.Problems) != 1 || !strings.Contains(info.Problems[0], "10.0.0.3:6379") {
+		t.Errorf("unexpected problems: %v", info.Problems)
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	for _, tc := range []struct {
+		config RedisConfig
+		ok     bool
+	}{
+		{RedisConfig{Addr: "127.0.0.1:6379"}, true},
+		{RedisConfig{}, false},
+		{RedisConfig{Mode: ModeCluster, Addrs: []string{"a:1", "b:2"}}, true},
+		{RedisConfig{Mode: ModeCluster, Addr: "a:1", DB: 1}, false},
+		{RedisConfig{Mode: "ring", Addr: "a:1"}, false},
+	} {
+		if err := tc.config.Validate(); (err == nil) != tc.ok {
+			t.Errorf("Validate(%+v) = %v", tc.config, err)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package redis_db
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"unicode/utf8"
+)
+
+// maxDecompressed gzip 解压后的最大字节数，防止压缩炸弹
+const maxDecompressed = 16 << 20
+
+// DecodedValue 自动识别出的值格式及解码结果
+type DecodedValue struct {
+	Format string      `json:"format"` // json / msgpack / gzip+json / gzip+msgpack / gzip+text / gzip+binary
+	Value  interface{} `json:"value"`
+	Bytes  int         `json:"decoded_bytes,omitempty"` // gzip 解压后的大小
+}
+
+// DecodePayload 尝试把字符串值识别为 JSON、msgpack 或 gzip（解压后再识别），无法识别时返回 nil
+func DecodePayload(data []byte) *DecodedValue {
+	if len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil
+		}
+		plain, err := io.ReadAll(io.LimitReader(zr, maxDecompressed+1))
+		if err != nil || len(plain) > maxDecompressed {
+			return nil
+		}
+		if inner := DecodePayload(plain); inner != nil {
+			inner.Format = "gzip+" + inner.Format
+			inner.Bytes = len(plain)
+			return inner
+		}
+		if utf8.Valid(plain) {
+			return &DecodedValue{Format: "gzip+text", Value: string(plain), Bytes: len(plain)}
+		}
+		return &DecodedValue{Format: "gzip+binary", Value: plain, Bytes: len(plain)}
+	}
+	if v, ok := decodeJSON(data); ok {
+		return &DecodedValue{Format: "json", Value: v}
+	}
+	if v, ok := decodeMsgpack(data); ok {
+		return &DecodedValue{Format: "msgpack", Value: v}
+	}
+	return nil
+}
+
+// decodeJSON 只识别对象和数组，避免把普通数字或字符串当作 JSON
+func decodeJSON(data []byte) (interface{}, bool) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) < 2 || (trimmed[0] != '{' && trimmed[0] != '[') || !json.Valid(trimmed) {
+		return nil, false
+	}
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(trimmed))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+// decodeMsgpack 只识别以 map 或 array 开头且恰好消费全部字节的 msgpack 数据
+func decodeMsgpack(data []byte) (interface{}, bool) {
+	if len(data) == 0 {
+		return nil, false
+	}
+	switch b := data[0]; {
+	case b >= 0x80 && b <= 0x9f, b == 0xdc, b == 0xdd, b == 0xde, b == 0xdf:
+	default:
+		return nil, false
+	}
+	d := &msgpackDecoder{data: data}
+	v, err := d.value(0)
+	if err != nil || d.pos != len(data) {
+		return nil, false
+	}
+	return v, true
+}
+
+// msgpackDecoder 最小的 msgpack 解码器，map 的键统一转为字符串以便输出 JSON
+type msgpackDecoder struct {
+	data []byte
+	pos  int
+}
+
+var errMsgpack = fmt.Errorf("invalid msgpack")
+
+func (d *msgpackDecoder) take(n int) ([]byte, error) {
+	if n < 0 || d.pos+n > len(d.data) {
+		return nil, errMsgpack
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+func (d *msgpackDecoder) uint(n int) (uint64, error) {
+	b, err := d.take(n)
+	if err != nil {
+		return 0, err
+	}
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v, nil
+}
+
+func (d *msgpackDecoder) value(depth int) (interface{}, error) {
+	if depth > 64 {
+		return nil, errMsgpack
+	}
+	tag, err := d.take(1)
+	if err != nil {
+		return nil, err
+	}
+	b := tag[0]
+	switch {
+	case b <= 0x7f:
+		return int64(b), nil
+	case b >= 0xe0:
+		return int64(int8(b)), nil
+	case b >= 0x80 && b <= 0x8f:
+		return d.mapOf(int(b&0x0f), depth)
+	case b >= 0x90 && b <= 0x9f:
+		return d.arrayOf(int(b&0x0f), depth)
+	case b >= 0xa0 && b <= 0xbf:
+		return d.str(int(b & 0x1f))
+	}
+	switch b {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6: // bin 8/16/32
+		n, err := d.uint(1 << (b - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		raw, err := d.take(int(n))
+		return append([]byte(nil), raw...), err
+	case 0xc7, 0xc8, 0xc9: // ext 8/16/32
+		n, err := d.uint(1 << (b - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return d.ext(int(n))
+	case 0xca:
+		n, err := d.uint(4)
+		return float64(math.Float32frombits(uint32(n))), err
+	case 0xcb:
+		n, err := d.uint(8)
+		return math.Float64frombits(n), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n, err := d.uint(1 << (b - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		if n > math.MaxInt64 {
+			return n, nil
+		}
+		return int64(n), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (b - 0xd0)
+		raw, err := d.take(size)
+		if err != nil {
+			return nil, err
+		}
+		switch size {
+		case 1:
+			return int64(int8(raw[0])), nil
+		case 2:
+			return int64(int16(binary.BigEndian.Uint16(raw))), nil
+		case 4:
+			return int64(int32(binary.BigEndian.Uint32(raw))), nil
+		}
+		return int64(binary.BigEndian.Uint64(raw)), nil
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8: // fixext 1/2/4/8/16
+		return d.ext(1 << (b - 0xd4))
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.uint(1 << (b - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.str(int(n))
+	case 0xdc, 0xdd:
+		n, err := d.uint(2 << (b - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.arrayOf(int(n), depth)
+	case 0xde, 0xdf:
+		n, err := d.uint(2 << (b - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.mapOf(int(n), depth)
+	}
+	return nil, errMsgpack
+}
+
+func (d *msgpackDecoder) str(n int) (interface{}, error) {
+	raw, err := d.take(n)
+	if err != nil {
+		return nil, err
+	}
+	return string(raw), nil
+}
+
+// ext 扩展类型以 {ext_type, data} 表示
+func (d *msgpackDecoder) ext(n int) (interface{}, error) {
+	typ, err := d.take(1)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := d.take(n)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"ext_type": int8(typ[0]), "data": append([]byte(nil), raw...)}, nil
+}
+
+func (d *msgpackDecoder) arrayOf(n, depth int) (interface{}, error) {
+	if n > len(d.data)-d.pos {
+		return nil, errMsgpack
+	}
+	items := make([]interface{}, n)
+	for i := range items {
+		v, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		items[i] = v
+	}
+	return items, nil
+}
+
+func (d *msgpackDecoder) mapOf(n, depth int) (interface{}, error) {
+	if n > len(d.data)-d.pos {
+		return nil, errMsgpack
+	}
+	m := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		k, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		v, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		m[fmt.Sprint(k)] = v
+	}
+	return m, nil
+}
@@ -0,0 +1,464 @@
+package redis_db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 诊断的默认值和上限
+const (
+	DefaultDiagnosticsSample   = time.Second
+	maxDiagnosticsSample       = 10 * time.Second
+	DefaultSlowlogCount        = 20
+	DefaultDiagnosticsClients  = 100
+	maxDiagnosticsSlowlogCount = 1000
+)
+
+// DiagnosticsOptions redis_diagnostics 参数
+type DiagnosticsOptions struct {
+	SampleInterval time.Duration // 两次 INFO 采样的间隔，用于计算速率；0 表示只采样一次
+	SlowlogCount   int           // SLOWLOG GET 的条数
+	MaxClients     int           // 最多返回的客户端数量，按占用内存从大到小
+}
+
+// Diagnostics redis_diagnostics 结果
+type Diagnostics struct {
+	Type    string                            `json:"type"`
+	Info    map[string]map[string]interface{} `json:"info"`
+	Derived DerivedMetrics                    `json:"derived"`
+	Slowlog []SlowlogEntry                    `json:"slowlog"`
+	Clients ClientsReport                     `json:"clients"`
+	Latency []LatencyEvent                    `json:"latency"`
+	Notes   []string                          `json:"notes,omitempty"`
+}
+
+// DerivedMetrics 由 INFO 计算出的指标
+type DerivedMetrics struct {
+	HitRatio           *float64           `json:"keyspace_hit_ratio,omitempty"` // 启动以来 keyspace_hits / (hits + misses)
+	FragmentationRatio *float64           `json:"mem_fragmentation_ratio,omitempty"`
+	MemoryUsedPercent  *float64           `json:"memory_used_percent,omitempty"` // used_memory / maxmemory，未设置 maxmemory 时为空
+	SampleSeconds      float64            `json:"sample_seconds,omitempty"`
+	Rates              map[string]float64 `json:"rates_per_sec,omitempty"`    // 两次采样之间的每秒增量
+	SampleHitRatio     *float64           `json:"sample_hit_ratio,omitempty"` // 采样期间的命中率
+	Replication        ReplicationStatus  `json:"replication"`
+}
+
+// ReplicationStatus 复制状态
+type ReplicationStatus struct {
+	Role              string       `json:"role"`
+	ConnectedReplicas int64        `json:"connected_replicas,omitempty"`
+	Replicas          []ReplicaLag `json:"replicas,omitempty"`
+	MasterHost        string       `json:"master_host,omitempty"`
+	MasterLinkStatus  string       `json:"master_link_status,omitempty"`
+	MasterLastIOSec   *int64       `json:"master_last_io_seconds_ago,omitempty"`
+	SyncInProgress    bool         `json:"sync_in_progress,omitempty"`
+}
+
+// ReplicaLag 主节点看到的一个副本
+type ReplicaLag struct {
+	Addr         string `json:"addr"`
+	State        string `json:"state"`
+	Offset       int64  `json:"offset"`
+	OffsetBehind int64  `json:"offset_behind"` // master_repl_offset - offset
+	LagSeconds   int64  `json:"lag_seconds"`
+}
+
+// SlowlogEntry SLOWLOG GET 的一条记录
+type SlowlogEntry struct {
+	ID         int64    `json:"id"`
+	Time       string   `json:"time"`
+	DurationUs int64    `json:"duration_us"`
+	Command    string   `json:"command"`
+	Args       []string `json:"args"` // 不可打印的参数以 Go 带引号的转义形式给出
+	ClientAddr string   `json:"client_addr,omitempty"`
+	ClientName string   `json:"client_name,omitempty"`
+}
+
+// ClientInfo CLIENT LIST 中的一个客户端
+type ClientInfo struct {
+	ID            int64  `json:"id"`
+	Addr          string `json:"addr"`
+	Name          string `json:"name,omitempty"`
+	User          string `json:"user,omitempty"`
+	DB            int64  `json:"db"`
+	AgeSeconds    int64  `json:"age_seconds"`
+	IdleSeconds   int64  `json:"idle_seconds"`
+	Flags         string `json:"flags"`
+	Cmd           string `json:"last_command"`
+	Subscriptions int64  `json:"subscriptions,omitempty"`
+	QueryBuffer   int64  `json:"query_buffer_bytes"`
+	OutputMemory  int64  `json:"output_memory_bytes"`
+	TotalMemory   int64  `json:"total_memory_bytes"`
+}
+
+// ClientsReport CLIENT LIST 汇总
+type ClientsReport struct {
+	Total    int            `json:"total"`
+	Returned int            `json:"returned"`
+	ByFlag   map[string]int `json:"by_flag"` // 按 flags 中的角色计数：normal/replica/master/pubsub/blocked/multi
+	ByUser   map[string]int `json:"by_user,omitempty"`
+	Clients  []ClientInfo   `json:"clients"`
+}
+
+// LatencyEvent LATENCY LATEST 的一项
+type LatencyEvent struct {
+	Event    string `json:"event"`
+	Time     string `json:"time"`
+	LatestMs int64  `json:"latest_ms"`
+	MaxMs    int64  `json:"max_ms"`
+}
+
+// rateCounters 计算每秒增量的 INFO stats 字段
+var rateCounters = []string{
+	"total_commands_processed", "keyspace_hits", "keyspace_misses", "evicted_keys", "expired_keys",
+	"total_connections_received", "rejected_connections", "total_net_input_bytes", "total_net_output_bytes",
+}
+
+// Diagnostics 采集 INFO all（两次采样计算速率）、SLOWLOG、CLIENT LIST 和 LATENCY LATEST
+func (r *RedisClient) Diagnostics(ctx context.Context, opts DiagnosticsOptions) (*Diagnostics, error) {
+	if opts.SampleInterval < 0 {
+		opts.SampleInterval = 0
+	}
+	if opts.SampleInterval > maxDiagnosticsSample {
+		opts.SampleInterval = maxDiagnosticsSample
+	}
+	if opts.SlowlogCount <= 0 {
+		opts.SlowlogCount = DefaultSlowlogCount
+	}
+	if opts.SlowlogCount > maxDiagnosticsSlowlogCount {
+		opts.SlowlogCount = maxDiagnosticsSlowlogCount
+	}
+	if opts.MaxClients <= 0 {
+		opts.MaxClients = DefaultDiagnosticsClients
+	}
+	// 集群客户端会把无键命令发给任意节点，这里固定诊断地址最小的主节点
+	node := r.client
+	result := &Diagnostics{Type: "redis_diagnostics"}
+	if r.Mode() == ModeCluster {
+		nodes, err := r.scanNodes(ctx)
+		if err != nil {
+			return nil, err
+		}
+		master := nodes[0].(*redis.Client)
+		node = master
+		result.Notes = append(result.Notes, "cluster mode: figures are from master "+master.Options().Addr)
+	}
+
+	text, err := node.Info(ctx, "all").Result()
+	if err != nil {
+		return nil, fmt.Errorf("INFO failed: %w", err)
+	}
+	first := parseInfoSections(text)
+	start := time.Now()
+	second := first
+	if opts.SampleInterval > 0 {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(opts.SampleInterval):
+		}
+		if text, err = node.Info(ctx, "all").Result(); err != nil {
+			return nil, fmt.Errorf("INFO failed: %w", err)
+		}
+		second = parseInfoSections(text)
+	}
+	elapsed := time.Since(start).Seconds()
+
+	result.Info = typedInfo(second)
+	result.Derived = deriveMetrics(first, second, elapsed, opts.SampleInterval > 0)
+
+	if logs, err := node.SlowLogGet(ctx, int64(opts.SlowlogCount)).Result(); err == nil {
+		result.Slowlog = make([]SlowlogEntry, 0, len(logs))
+		for _, l := range logs {
+			entry := SlowlogEntry{
+				ID:         l.ID,
+				Time:       l.Time.UTC().Format(time.RFC3339),
+				DurationUs: l.Duration.Microseconds(),
+				ClientAddr: l.ClientAddr,
+				ClientName: l.ClientName,
+				Args:       make([]string, len(l.Args)),
+			}
+			for i, arg := range l.Args {
+				entry.Args[i] = printableArg(arg)
+			}
+			if len(l.Args) > 0 {
+				entry.Command = strings.ToUpper(l.Args[0])
+			}
+			result.Slowlog = append(result.Slowlog, entry)
+		}
+	} else {
+		result.Notes = append(result.Notes, "SLOWLOG GET failed: "+err.Error())
+	}
+
+	if list, err := node.ClientList(ctx).Result(); err == nil {
+		result.Clients = clientsReport(list, opts.MaxClients)
+	} else {
+		result.Notes = append(result.Notes, "CLIENT LIST failed: "+err.Error())
+	}
+
+	if latest, err := node.Do(ctx, "LATENCY", "LATEST").Slice(); err == nil {
+		result.Latency = parseLatencyLatest(latest)
+		if len(result.Latency) == 0 {
+			if cfg, err := node.ConfigGet(ctx, "latency-monitor-threshold").Result(); err == nil && cfg["latency-monitor-threshold"] == "0" {
+				result.Notes = append(result.Notes, "latency monitor is disabled (latency-monitor-threshold 0)")
+			}
+		}
+	} else {
+		result.Notes = append(result.Notes, "LATENCY LATEST failed: "+err.Error())
+	}
+	if result.Latency == nil {
+		result.Latency = []LatencyEvent{}
+	}
+	return result, nil
+}
+
+// parseInfoSections 按 "# Section" 分组解析 INFO，节名转为小写
+func parseInfoSections(text string) map[string]map[string]string {
+	sections := map[string]map[string]string{}
+	current := "default"
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			current = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(line, "#")))
+			continue
+		}
+		k, v, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		if sections[current] == nil {
+			sections[current] = map[string]string{}
+		}
+		sections[current][k] = v
+	}
+	return sections
+}
+
+// typedInfo 把 INFO 值转换为整数、浮点数、字符串，或 key=value,... 形式的嵌套对象（如 db0、slave0、cmdstat_*）
+func typedInfo(sections map[string]map[string]string) map[string]map[string]interface{} {
+	out := make(map[string]map[string]interface{}, len(sections))
+	for name, fields := range sections {
+		typed := make(map[string]interface{}, len(fields))
+		for k, v := range fields {
+			typed[k] = typedInfoValue(v, true)
+		}
+		out[name] = typed
+	}
+	return out
+}
+
+func typedInfoValue(v string, nested bool) interface{} {
+	if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return n
+	}
+	if f, err := strconv.ParseFloat(v, 64); err == nil && !strings.ContainsAny(v, "xXnN") {
+		return f
+	}
+	if nested && strings.Contains(v, "=") {
+		obj := map[string]interface{}{}
+		for _, part := range strings.Split(v, ",") {
+			k, val, ok := strings.Cut(part, "=")
+			if !ok {
+				return v
+			}
+			obj[k] = typedInfoValue(val, false)
+		}
+		return obj
+	}
+	return v
+}
+
+// infoInt 读取整数字段，不存在时 ok 为 false
+func infoInt(sections map[string]map[string]string, section, key string) (int64, bool) {
+	v, ok := sections[section][key]
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	return n, err == nil
+}
+
+func deriveMetrics(first, second map[string]map[string]string, elapsed float64, sampled bool) DerivedMetrics {
+	var d DerivedMetrics
+	hits, okHits := infoInt(second, "stats", "keyspace_hits")
+	misses, okMisses := infoInt(second, "stats", "keyspace_misses")
+	if okHits && okMisses && hits+misses > 0 {
+		ratio := float64(hits) / float64(hits+misses)
+		d.HitRatio = &ratio
+	}
+	if v, err := strconv.ParseFloat(second["memory"]["mem_fragmentation_ratio"], 64); err == nil {
+		d.FragmentationRatio = &v
+	}
+	used, okUsed := infoInt(second, "memory", "used_memory")
+	max, okMax := infoInt(second, "memory", "maxmemory")
+	if okUsed && okMax && max > 0 {
+		pct := float64(used) * 100 / float64(max)
+		d.MemoryUsedPercent = &pct
+	}
+
+	if sampled && elapsed > 0 {
+		d.SampleSeconds = elapsed
+		d.Rates = map[string]float64{}
+		for _, k := range rateCounters {
+			before, ok1 := infoInt(first, "stats", k)
+			after, ok2 := infoInt(second, "stats", k)
+			if ok1 && ok2 {
+				d.Rates[k] = float64(after-before) / elapsed
+			}
+		}
+		hitsBefore, _ := infoInt(first, "stats", "keyspace_hits")
+		missesBefore, _ := infoInt(first, "stats", "keyspace_misses")
+		if total := (hits - hitsBefore) + (misses - missesBefore); total > 0 {
+			ratio := float64(hits-hitsBefore) / float64(total)
+			d.SampleHitRatio = &ratio
+		}
+	}
+
+	repl := second["replication"]
+	d.Replication.Role = repl["role"]
+	d.Replication.ConnectedReplicas, _ = infoInt(second, "replication", "connected_slaves")
+	if d.Replication.Role == "master" {
+		masterOffset, _ := infoInt(second, "replication", "master_repl_offset")
+		var names []string
+		for k := range repl {
+			if strings.HasPrefix(k, "slave") && strings.Contains(repl[k], "=") {
+				names = append(names, k)
+			}
+		}
+		sort.Strings(names)
+		for _, k := range names {
+			fields := map[string]string{}
+			for _, part := range strings.Split(repl[k], ",") {
+				if name, value, ok := strings.Cut(part, "="); ok {
+					fields[name] = value
+				}
+			}
+			lag := ReplicaLag{Addr: fields["ip"] + ":" + fields["port"], State: fields["state"]}
+			lag.Offset, _ = strconv.ParseInt(fields["offset"], 10, 64)
+			lag.LagSeconds, _ = strconv.ParseInt(fields["lag"], 10, 64)
+			lag.OffsetBehind = masterOffset - lag.Offset
+			d.Replication.Replicas = append(d.Replication.Replicas, lag)
+		}
+	} else {
+		if repl["master_host"] != "" {
+			d.Replication.MasterHost = repl["master_host"] + ":" + repl["master_port"]
+		}
+		d.Replication.MasterLinkStatus = repl["master_link_status"]
+		if v, ok := infoInt(second, "replication", "master_last_io_seconds_ago"); ok {
+			d.Replication.MasterLastIOSec = &v
+		}
+		d.Replication.SyncInProgress = repl["master_sync_in_progress"] == "1"
+	}
+	return d
+}
+
+// printableArg 可打印的 UTF-8 参数原样返回，否则返回带引号的转义形式
+func printableArg(arg string) string {
+	if !utf8.ValidString(arg) {
+		return strconv.Quote(arg)
+	}
+	for _, r := range arg {
+		if !unicode.IsPrint(r) {
+			return strconv.Quote(arg)
+		}
+	}
+	return arg
+}
+
+// clientsReport 解析 CLIENT LIST（每行 key=value 以空格分隔），按占用内存从大到小保留 max 个
+func clientsReport(text string, max int) ClientsReport {
+	report := ClientsReport{ByFlag: map[string]int{}, ByUser: map[string]int{}, Clients: []ClientInfo{}}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		fields := map[string]string{}
+		for _, part := range strings.Fields(line) {
+			if k, v, ok := strings.Cut(part, "="); ok {
+				fields[k] = v
+			}
+		}
+		num := func(k string) int64 {
+			n, _ := strconv.ParseInt(fields[k], 10, 64)
+			return n
+		}
+		c := ClientInfo{
+			ID:            num("id"),
+			Addr:          fields["addr"],
+			Name:          fields["name"],
+			User:          fields["user"],
+			DB:            num("db"),
+			AgeSeconds:    num("age"),
+			IdleSeconds:   num("idle"),
+			Flags:         fields["flags"],
+			Cmd:           fields["cmd"],
+			Subscriptions: num("sub") + num("psub") + num("ssub"),
+			QueryBuffer:   num("qbuf"),
+			OutputMemory:  num("omem"),
+			TotalMemory:   num("tot-mem"),
+		}
+		report.Total++
+		for _, role := range clientRoles(c.Flags) {
+			report.ByFlag[role]++
+		}
+		if c.User != "" {
+			report.ByUser[c.User]++
+		}
+		report.Clients = append(report.Clients, c)
+	}
+	sort.SliceStable(report.Clients, func(i, j int) bool { return report.Clients[i].TotalMemory > report.Clients[j].TotalMemory })
+	if len(report.Clients) > max {
+		report.Clients = report.Clients[:max]
+	}
+	report.Returned = len(report.Clients)
+	return report
+}
+
+// clientRoles 把 CLIENT LIST 的 flags 字母转换为可读的角色
+func clientRoles(flags string) []string {
+	names := map[rune]string{'S': "replica", 'M': "master", 'P': "pubsub", 'b': "blocked", 'x': "multi"}
+	var roles []string
+	for _, f := range flags {
+		if name, ok := names[f]; ok {
+			roles = append(roles, name)
+		}
+	}
+	if len(roles) == 0 {
+		roles = []string{"normal"}
+	}
+	return roles
+}
+
+// parseLatencyLatest 解析 LATENCY LATEST：每项为 [事件, 时间戳, 最近延迟ms, 最大延迟ms]
+func parseLatencyLatest(items []interface{}) []LatencyEvent {
+	events := make([]LatencyEvent, 0, len(items))
+	for _, item := range items {
+		fields, ok := item.([]interface{})
+		if !ok || len(fields) < 4 {
+			continue
+		}
+		name, _ := fields[0].(string)
+		ts, _ := fields[1].(int64)
+		latest, _ := fields[2].(int64)
+		max, _ := fields[3].(int64)
+		events = append(events, LatencyEvent{
+			Event:    name,
+			Time:     time.Unix(ts, 0).UTC().Format(time.RFC3339),
+			LatestMs: latest,
+			MaxMs:    max,
+		})
+	}
+	return events
+}
@@ -0,0 +1,124 @@
+package redis_db
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// diagnosticsInfo 两次 INFO 采样：第二次命令数、命中、淘汰和过期计数增加
+var diagnosticsInfo = []string{
+	"# Server\r\nredis_version:7.2.4\r\nuptime_in_seconds:1000\r\n\r\n" +
+		"# Memory\r\nused_memory:52428800\r\nmaxmemory:104857600\r\nmem_fragmentation_ratio:1.35\r\nmaxmemory_policy:allkeys-lfu\r\n\r\n" +
+		"# Stats\r\ntotal_commands_processed:1000\r\nkeyspace_hits:900\r\nkeyspace_misses:100\r\nevicted_keys:10\r\nexpired_keys:50\r\n\r\n" +
+		"# Replication\r\nrole:master\r\nconnected_slaves:2\r\nmaster_repl_offset:5000\r\n" +
+		"slave0:ip=10.0.0.2,port=6379,state=online,offset=5000,lag=0\r\nslave1:ip=10.0.0.3,port=6379,state=wait_bgsave,offset=4200,lag=3\r\n\r\n" +
+		"# Commandstats\r\ncmdstat_get:calls=900,usec=1800,usec_per_call=2.00,rejected_calls=0,failed_calls=0\r\n\r\n" +
+		"# Keyspace\r\ndb0:keys=120,expires=30,avg_ttl=0\r\n",
+	"# Server\r\nredis_version:7.2.4\r\nuptime_in_seconds:1001\r\n\r\n" +
+		"# Memory\r\nused_memory:52428800\r\nmaxmemory:104857600\r\nmem_fragmentation_ratio:1.35\r\nmaxmemory_policy:allkeys-lfu\r\n\r\n" +
+		"# Stats\r\ntotal_commands_processed:1100\r\nkeyspace_hits:940\r\nkeyspace_misses:110\r\nevicted_keys:12\r\nexpired_keys:60\r\n\r\n" +
+		"# Replication\r\nrole:master\r\nconnected_slaves:2\r\nmaster_repl_offset:5000\r\n" +
+		"slave0:ip=10.0.0.2,port=6379,state=online,offset=5000,lag=0\r\nslave1:ip=10.0.0.3,port=6379,state=wait_bgsave,offset=4200,lag=3\r\n\r\n" +
+		"# Commandstats\r\ncmdstat_get:calls=940,usec=1880,usec_per_call=2.00,rejected_calls=0,failed_calls=0\r\n\r\n" +
+		"# Keyspace\r\ndb0:keys=118,expires=30,avg_ttl=0\r\n",
+}
+
+func diagnosticsHandler() func(args []string) interface{} {
+	infoCalls := 0
+	return func(args []string) interface{} {
+		switch strings.ToUpper(args[0]) {
+		case "INFO":
+			reply := diagnosticsInfo[infoCalls%2]
+			infoCalls++
+			return reply
+		case "SLOWLOG":
+			// id, 时间戳, 微秒, 参数, 客户端地址, 客户端名
+			return []interface{}{
+				[]interface{}{14, 1700000000, 25000, []string{"KEYS", "*"}, "10.0.0.9:51000", "worker"},
+				[]interface{}{13, 1699999990, 12000, []string{"SET", "bin", "\x00\xffpayload"}, "10.0.0.9:51001", ""},
+			}
+		case "CLIENT":
+			return "id=3 addr=10.0.0.9:51000 laddr=10.0.0.1:6379 fd=8 name=worker age=100 idle=0 flags=N db=0 sub=0 psub=0 ssub=0 multi=-1 qbuf=26 qbuf-free=20448 argv-mem=10 multi-mem=0 rbs=1024 rbp=0 obl=0 oll=0 omem=0 tot-mem=22298 events=r cmd=client|list user=default\n" +
+				"id=4 addr=10.0.0.2:40000 laddr=10.0.0.1:6379 fd=9 name= age=5000 idle=1 flags=S db=0 sub=0 psub=0 ssub=0 multi=-1 qbuf=0 qbuf-free=0 argv-mem=0 multi-mem=0 rbs=0 rbp=0 obl=0 oll=0 omem=1048576 tot-mem=1070874 events=r cmd=replconf user=default\n" +
+				"id=5 addr=10.0.0.9:51002 laddr=10.0.0.1:6379 fd=10 name= age=30 idle=30 flags=P db=0 sub=2 psub=1 ssub=0 multi=-1 qbuf=0 qbuf-free=0 argv-mem=0 multi-mem=0 rbs=0 rbp=0 obl=0 oll=0 omem=0 tot-mem=1800 events=r cmd=psubscribe user=reader\n"
+		case "LATENCY":
+			return []interface{}{[]interface{}{"command", 1700000000, 120, 350}}
+		}
+		return fakeError("ERR unknown command '" + args[0] + "'")
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	_, client := newFakeRedis(t, diagnosticsHandler())
+	d, err := client.Diagnostics(context.Background(), DiagnosticsOptions{SampleInterval: 50 * time.Millisecond, MaxClients: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Notes) != 0 {
+		t.Errorf("unexpected notes: %v", d.Notes)
+	}
+
+	// INFO 字段按类型转换，key=value 列表转换为对象
+	if d.Info["server"]["redis_version"] != "7.2.4" || d.Info["stats"]["keyspace_hits"] != int64(940) || d.Info["memory"]["mem_fragmentation_ratio"] != 1.35 {
+		t.Errorf("unexpected typed info: %v", d.Info)
+	}
+	db0, ok := d.Info["keyspace"]["db0"].(map[string]interface{})
+	if !ok || db0["keys"] != int64(118) {
+		t.Errorf("db0 should be an object: %#v", d.Info["keyspace"]["db0"])
+	}
+	if get, ok := d.Info["commandstats"]["cmdstat_get"].(map[string]interface{}); !ok || get["usec_per_call"] != 2.0 {
+		t.Errorf("cmdstat_get should be an object: %#v", d.Info["commandstats"]["cmdstat_get"])
+	}
+
+	m := d.Derived
+	if m.HitRatio == nil || *m.HitRatio < 0.895 || *m.HitRatio > 0.896 {
+		t.Errorf("hit ratio = %v", m.HitRatio)
+	}
+	if m.FragmentationRatio == nil || *m.FragmentationRatio != 1.35 || m.MemoryUsedPercent == nil || *m.MemoryUsedPercent != 50 {
+		t.Errorf("memory metrics = %+v", m)
+	}
+	// 采样期间：命中 40、未命中 10，淘汰 2、过期 10
+	if m.SampleHitRatio == nil || *m.SampleHitRatio != 0.8 {
+		t.Errorf("sample hit ratio = %v", m.SampleHitRatio)
+	}
+	if m.SampleSeconds <= 0 || m.Rates["evicted_keys"] <= 0 || math.Abs(m.Rates["expired_keys"]-m.Rates["evicted_keys"]*5) > 1e-6 {
+		t.Errorf("rates = %v over %v s", m.Rates, m.SampleSeconds)
+	}
+	repl := m.Replication
+	if repl.Role != "master" || len(repl.Replicas) != 2 || repl.Replicas[1].OffsetBehind != 800 || repl.Replicas[1].LagSeconds != 3 || repl.Replicas[1].State != "wait_bgsave" {
+		t.Errorf("replication = %+v", repl)
+	}
+
+	if len(d.Slowlog) != 2 || d.Slowlog[0].Command != "KEYS" || d.Slowlog[0].DurationUs != 25000 || d.Slowlog[0].ClientName != "worker" {
+		t.Fatalf("slowlog = %+v", d.Slowlog)
+	}
+	if d.Slowlog[1].Args[2] != `"\x00\xffpayload"` {
+		t.Errorf("binary slowlog arg should be escaped: %q", d.Slowlog[1].Args[2])
+	}
+
+	c := d.Clients
+	if c.Total != 3 || c.Returned != 2 || c.Clients[0].Addr != "10.0.0.2:40000" || c.Clients[0].OutputMemory != 1048576 {
+		t.Errorf("clients should be sorted by memory and capped: %+v", c)
+	}
+	if c.ByFlag["replica"] != 1 || c.ByFlag["pubsub"] != 1 || c.ByFlag["normal"] != 1 || c.ByUser["default"] != 2 {
+		t.Errorf("client summary = %+v", c)
+	}
+
+	if len(d.Latency) != 1 || d.Latency[0].Event != "command" || d.Latency[0].MaxMs != 350 || d.Latency[0].LatestMs != 120 {
+		t.Errorf("latency = %+v", d.Latency)
+	}
+}
+
+func TestDiagnosticsSingleSample(t *testing.T) {
+	_, client := newFakeRedis(t, diagnosticsHandler())
+	d, err := client.Diagnostics(context.Background(), DiagnosticsOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Derived.Rates != nil || d.Derived.SampleHitRatio != nil {
+		t.Errorf("rates need two samples: %+v", d.Derived)
+	}
+}
@@ -0,0 +1,352 @@
+package redis_db
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DumpFormat 键导出文件首行的格式标识
+const DumpFormat = "redis-dump-jsonl"
+
+const dumpVersion = 1
+
+// 导入时的 TTL 处理方式
+const (
+	TTLKeep  = "keep"  // 使用导出时的剩余 TTL（默认）
+	TTLNone  = "none"  // 不设置过期时间
+	TTLFixed = "fixed" // 统一使用 RestoreOptions.TTL
+)
+
+// maxRestoreErrors 结果中最多列出的失败键数量
+const maxRestoreErrors = 20
+
+// DumpHeader 导出文件首行，记录来源服务器版本，DUMP 数据不能恢复到 RDB 版本更低的服务器
+type DumpHeader struct {
+	Format       string `json:"format"`
+	Version      int    `json:"version"`
+	RedisVersion string `json:"redis_version,omitempty"`
+	Pattern      string `json:"pattern"`
+	CreatedAt    string `json:"created_at"`
+}
+
+// DumpRecord 文件中的一个键：DUMP 序列化值（base64）及导出时的剩余 TTL
+type DumpRecord struct {
+	Key     string `json:"key"`
+	Type    string `json:"type"`
+	TTLMs   int64  `json:"ttl_ms"` // 0 表示不过期
+	Payload string `json:"payload"`
+}
+
+// DumpExportResult redis_export_keys 结果
+type DumpExportResult struct {
+	Type         string         `json:"type"`
+	Path         string         `json:"path"`
+	RedisVersion string         `json:"redis_version,omitempty"`
+	Keys         int            `json:"keys"`
+	Skipped      int            `json:"skipped,omitempty"` // SCAN 之后被删除或过期的键
+	Bytes        int64          `json:"bytes"`
+	Types        map[string]int `json:"types"`
+}
+
+// RestoreOptions RESTORE 参数
+type RestoreOptions struct {
+	Replace bool          // 目标键已存在时覆盖（RESTORE ... REPLACE），否则跳过
+	TTLMode string        // keep / none / fixed
+	TTL     time.Duration // TTLMode 为 fixed 时使用
+}
+
+// KeyError 单个键的失败原因
+type KeyError struct {
+	Key   string `json:"key"`
+	Error string `json:"error"`
+}
+
+// RestoreResult redis_import_keys / redis_copy_keys 结果
+type RestoreResult struct {
+	Type     string     `json:"type"`
+	Source   string     `json:"source,omitempty"`
+	Target   string     `json:"target,omitempty"`
+	Keys     int        `json:"keys"`
+	Restored int        `json:"restored"`
+	Existing int        `json:"existing"` // 目标键已存在且未指定 replace 而跳过
+	Skipped  int        `json:"skipped,omitempty"`
+	Failed   int        `json:"failed"`
+	Errors   []KeyError `json:"errors,omitempty"`
+}
+
+func (o RestoreOptions) validate() error {
+	switch o.TTLMode {
+	case "", TTLKeep, TTLNone:
+	case TTLFixed:
+		if o.TTL <= 0 {
+			return fmt.Errorf("ttl mode fixed needs a positive ttl")
+		}
+	default:
+		return fmt.Errorf("unsupported ttl mode %q: expected keep, none or fixed", o.TTLMode)
+	}
+	return nil
+}
+
+// ExportKeys 以 SCAN 遍历匹配的键，把 DUMP 序列化值和剩余 TTL 逐行写入 JSONL 文件
+func (r *RedisClient) ExportKeys(ctx context.Context, scan ScanExportOptions, path string, overwrite bool) (*DumpExportResult, error) {
+	if path == "" {
+		return nil, fmt.Errorf("export path is required")
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid export path: %v", err)
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !overwrite {
+		flags |= os.O_EXCL
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create export directory: %v", err)
+	}
+	file, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			return nil, fmt.Errorf("file %s already exists (set overwrite to replace it)", path)
+		}
+		return nil, fmt.Errorf("failed to create export file: %v", err)
+	}
+
+	result := &DumpExportResult{Type: "redis_export_keys", Path: path, RedisVersion: r.serverVersion(ctx), Types: map[string]int{}}
+	w := bufio.NewWriter(file)
+	enc := json.NewEncoder(w)
+	pattern := scan.Pattern
+	if pattern == "" {
+		pattern = "*"
+	}
+	err = enc.Encode(DumpHeader{Format: DumpFormat, Version: dumpVersion, RedisVersion: result.RedisVersion, Pattern: pattern, CreatedAt: time.Now().Format(time.RFC3339)})
+	if err == nil {
+		err = r.scanKeys(ctx, scan, func(keys []string) error {
+			records, skipped, err := r.dumpKeys(ctx, keys)
+			if err != nil {
+				return err
+			}
+			result.Skipped += skipped
+			for _, rec := range records {
+				if err := enc.Encode(rec); err != nil {
+					return err
+				}
+				result.Keys++
+				result.Types[rec.Type]++
+			}
+			return nil
+		})
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+	if info, err := os.Stat(path); err == nil {
+		result.Bytes = info.Size()
+	}
+	return result, nil
+}
+
+// ImportKeys 读取 ExportKeys 写出的文件，按批以 RESTORE 写入
+func (r *RedisClient) ImportKeys(ctx context.Context, path string, opts RestoreOptions, batchSize int) (*RestoreResult, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open import file: %v", err)
+	}
+	defer file.Close()
+
+	result := &RestoreResult{Type: "redis_import_keys", Source: path}
+	scanner := bufio.NewScanner(file)
+	// DUMP 值可能很大，单行上限 512MB（Redis 单个值的上限）
+	scanner.Buffer(make([]byte, 64*1024), 512*1024*1024)
+	var batch []DumpRecord
+	line := 0
+	headerSeen := false
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		if !headerSeen {
+			headerSeen = true
+			var header DumpHeader
+			if err := json.Unmarshal([]byte(text), &header); err != nil || header.Format != DumpFormat {
+				return nil, fmt.Errorf("%s is not a %s file", path, DumpFormat)
+			}
+			if header.Version > dumpVersion {
+				return nil, fmt.Errorf("unsupported %s version %d", DumpFormat, header.Version)
+			}
+			continue
+		}
+		var rec DumpRecord
+		if err := json.Unmarshal([]byte(text), &rec); err != nil || rec.Key == "" {
+			return nil, fmt.Errorf("line %d: invalid record", line)
+		}
+		batch = append(batch, rec)
+		if len(batch) >= batchSize {
+			if err := r.restoreKeys(ctx, batch, opts, result); err != nil {
+				return nil, err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read import file: %v", err)
+	}
+	if !headerSeen {
+		return nil, fmt.Errorf("%s is empty", path)
+	}
+	if err := r.restoreKeys(ctx, batch, opts, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CopyKeys 以 SCAN 遍历源实例中匹配的键，逐批 DUMP 后在目标实例上 RESTORE
+func (r *RedisClient) CopyKeys(ctx context.Context, target *RedisClient, scan ScanExportOptions, opts RestoreOptions) (*RestoreResult, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	result := &RestoreResult{Type: "redis_copy_keys", Source: r.config.Addr, Target: target.config.Addr}
+	err := r.scanKeys(ctx, scan, func(keys []string) error {
+		records, skipped, err := r.dumpKeys(ctx, keys)
+		if err != nil {
+			return err
+		}
+		result.Skipped += skipped
+		return target.restoreKeys(ctx, records, opts, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// dumpKeys 用一次管道读取一批键的 TYPE、PTTL 和 DUMP，已删除的键计入 skipped
+func (r *RedisClient) dumpKeys(ctx context.Context, keys []string) ([]DumpRecord, int, error) {
+	if len(keys) == 0 {
+		return nil, 0, nil
+	}
+	pipe := r.client.Pipeline()
+	typeCmds := make([]*redis.StatusCmd, len(keys))
+	ttlCmds := make([]*redis.DurationCmd, len(keys))
+	dumpCmds := make([]*redis.StringCmd, len(keys))
+	for i, key := range keys {
+		typeCmds[i] = pipe.Type(ctx, key)
+		ttlCmds[i] = pipe.PTTL(ctx, key)
+		dumpCmds[i] = pipe.Dump(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, 0, fmt.Errorf("DUMP failed: %w", err)
+	}
+
+	records := make([]DumpRecord, 0, len(keys))
+	skipped := 0
+	for i, key := range keys {
+		payload, err := dumpCmds[i].Result()
+		if err == redis.Nil || typeCmds[i].Val() == "none" {
+			skipped++
+			continue
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("DUMP %s failed: %w", key, err)
+		}
+		rec := DumpRecord{Key: key, Type: typeCmds[i].Val(), Payload: base64.StdEncoding.EncodeToString([]byte(payload))}
+		if d := ttlCmds[i].Val(); d > 0 {
+			rec.TTLMs = d.Milliseconds()
+			if rec.TTLMs == 0 {
+				rec.TTLMs = 1
+			}
+		}
+		records = append(records, rec)
+	}
+	return records, skipped, nil
+}
+
+// restoreKeys 用一次管道 RESTORE 一批键并累计到 result；目标键已存在且未指定 Replace 时计入 Existing
+func (r *RedisClient) restoreKeys(ctx context.Context, records []DumpRecord, opts RestoreOptions, result *RestoreResult) error {
+	if len(records) == 0 {
+		return nil
+	}
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.StatusCmd, len(records))
+	for i, rec := range records {
+		payload, err := base64.StdEncoding.DecodeString(rec.Payload)
+		if err != nil {
+			return fmt.Errorf("key %s: invalid payload: %v", rec.Key, err)
+		}
+		var ttl time.Duration
+		switch opts.TTLMode {
+		case TTLNone:
+		case TTLFixed:
+			ttl = opts.TTL
+		default:
+			ttl = time.Duration(rec.TTLMs) * time.Millisecond
+		}
+		if opts.Replace {
+			cmds[i] = pipe.RestoreReplace(ctx, rec.Key, ttl, string(payload))
+		} else {
+			cmds[i] = pipe.Restore(ctx, rec.Key, ttl, string(payload))
+		}
+	}
+	// 单个键失败不影响其他键，错误逐个记录
+	if _, err := pipe.Exec(ctx); err != nil && !isKeyError(cmds, err) {
+		return fmt.Errorf("RESTORE failed: %w", err)
+	}
+	for i, cmd := range cmds {
+		result.Keys++
+		err := cmd.Err()
+		switch {
+		case err == nil:
+			result.Restored++
+		case redis.HasErrorPrefix(err, "BUSYKEY"):
+			result.Existing++
+		default:
+			result.Failed++
+			if len(result.Errors) < maxRestoreErrors {
+				result.Errors = append(result.Errors, KeyError{Key: records[i].Key, Error: err.Error()})
+			}
+		}
+	}
+	return nil
+}
+
+// isKeyError 管道返回的错误是否来自某个命令的服务器回复（而不是连接错误）
+func isKeyError(cmds []*redis.StatusCmd, err error) bool {
+	for _, cmd := range cmds {
+		if cmd.Err() == err {
+			return true
+		}
+	}
+	return false
+}
+
+// serverVersion 读取 INFO server 中的 redis_version，失败时返回空字符串
+func (r *RedisClient) serverVersion(ctx context.Context) string {
+	info, err := r.client.Info(ctx, "server").Result()
+	if err != nil {
+		return ""
+	}
+	return parseInfoFields(info)["redis_version"]
+}
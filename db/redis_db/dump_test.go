@@ -0,0 +1,176 @@
+package redis_db
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeDumpStore 支持 SCAN、TYPE、PTTL、DUMP、RESTORE 的键空间，DUMP 值原样保存
+type fakeDumpStore struct {
+	mu   sync.Mutex
+	keys map[string]*fakeDumped
+}
+
+type fakeDumped struct {
+	typ     string
+	payload string
+	ttlMs   int64 // -1 表示不过期
+}
+
+func (s *fakeDumpStore) handle(args []string) interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch strings.ToUpper(args[0]) {
+	case "INFO":
+		return "# Server\r\nredis_version:7.2.4\r\n"
+	case "SCAN":
+		pattern := "*"
+		for i := 2; i+1 < len(args); i += 2 {
+			if strings.ToUpper(args[i]) == "MATCH" {
+				pattern = args[i+1]
+			}
+		}
+		keys := []string{}
+		for k := range s.keys {
+			if ok, _ := path.Match(pattern, k); ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		return []interface{}{"0", keys}
+	case "TYPE":
+		if k, ok := s.keys[args[1]]; ok {
+			return fakeStatus(k.typ)
+		}
+		return fakeStatus("none")
+	case "PTTL":
+		if k, ok := s.keys[args[1]]; ok {
+			return k.ttlMs
+		}
+		return -2
+	case "DUMP":
+		if k, ok := s.keys[args[1]]; ok {
+			return k.payload
+		}
+		return nil
+	case "RESTORE":
+		key, payload := args[1], args[3]
+		if _, ok := s.keys[key]; ok && (len(args) < 5 || strings.ToUpper(args[4]) != "REPLACE") {
+			return fakeError("BUSYKEY Target key name already exists.")
+		}
+		if !strings.HasPrefix(payload, "dump:") {
+			return fakeError("ERR DUMP payload version or checksum are wrong")
+		}
+		ttl, _ := strconv.ParseInt(args[2], 10, 64)
+		if ttl == 0 {
+			ttl = -1
+		}
+		s.keys[key] = &fakeDumped{typ: strings.Split(payload, ":")[1], payload: payload, ttlMs: ttl}
+		return fakeStatus("OK")
+	}
+	return fakeError("ERR unknown command '" + args[0] + "'")
+}
+
+func (s *fakeDumpStore) get(key string) *fakeDumped {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.keys[key]
+}
+
+func newDumpFixture(t *testing.T) (*fakeDumpStore, *RedisClient, *fakeDumpStore, *RedisClient) {
+	source := &fakeDumpStore{keys: map[string]*fakeDumped{
+		"order:1":  {typ: "hash", payload: "dump:hash:\x00\xff\x10", ttlMs: 60000},
+		"order:2":  {typ: "string", payload: "dump:string:v2", ttlMs: -1},
+		"order:3":  {typ: "zset", payload: "dump:zset:z", ttlMs: -1},
+		"session:": {typ: "string", payload: "dump:string:s", ttlMs: -1},
+	}}
+	target := &fakeDumpStore{keys: map[string]*fakeDumped{
+		"order:2": {typ: "string", payload: "dump:string:old", ttlMs: -1},
+	}}
+	_, src := newFakeRedis(t, source.handle)
+	_, dst := newFakeRedis(t, target.handle)
+	return source, src, target, dst
+}
+
+func TestExportImportKeys(t *testing.T) {
+	_, src, target, dst := newDumpFixture(t)
+	ctx := context.Background()
+	file := filepath.Join(t.TempDir(), "orders.jsonl")
+
+	exported, err := src.ExportKeys(ctx, ScanExportOptions{Pattern: "order:*"}, file, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exported.Keys != 3 || exported.RedisVersion != "7.2.4" || exported.Types["hash"] != 1 || exported.Bytes == 0 {
+		t.Fatalf("unexpected export result: %+v", exported)
+	}
+	if _, err := src.ExportKeys(ctx, ScanExportOptions{Pattern: "order:*"}, file, false); err == nil {
+		t.Error("expected existing file to be kept without overwrite")
+	}
+	content, _ := os.ReadFile(file)
+	if lines := strings.Split(strings.TrimSpace(string(content)), "\n"); len(lines) != 4 || !strings.Contains(lines[0], DumpFormat) {
+		t.Fatalf("unexpected file content:\n%s", content)
+	}
+
+	// 不覆盖：已存在的 order:2 跳过
+	imported, err := dst.ImportKeys(ctx, file, RestoreOptions{}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.Keys != 3 || imported.Restored != 2 || imported.Existing != 1 || imported.Failed != 0 {
+		t.Fatalf("unexpected import result: %+v", imported)
+	}
+	if k := target.get("order:1"); k == nil || k.payload != "dump:hash:\x00\xff\x10" || k.ttlMs != 60000 {
+		t.Errorf("binary payload or ttl not restored: %+v", k)
+	}
+	if target.get("order:2").payload != "dump:string:old" {
+		t.Error("existing key must not be replaced")
+	}
+
+	// 覆盖并统一设置 TTL
+	imported, err = dst.ImportKeys(ctx, file, RestoreOptions{Replace: true, TTLMode: TTLFixed, TTL: time.Minute}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.Restored != 3 || target.get("order:2").payload != "dump:string:v2" || target.get("order:3").ttlMs != 60000 {
+		t.Errorf("replace with fixed ttl failed: %+v", imported)
+	}
+
+	if _, err := dst.ImportKeys(ctx, file, RestoreOptions{TTLMode: TTLFixed}, 0); err == nil {
+		t.Error("expected fixed ttl without ttl to be rejected")
+	}
+	bogus := filepath.Join(t.TempDir(), "bogus.jsonl")
+	os.WriteFile(bogus, []byte("{\"key\":\"k\",\"payload\":\"\"}\n"), 0644)
+	if _, err := dst.ImportKeys(ctx, bogus, RestoreOptions{}, 0); err == nil {
+		t.Error("expected file without header to be rejected")
+	}
+}
+
+func TestCopyKeys(t *testing.T) {
+	source, src, target, dst := newDumpFixture(t)
+	ctx := context.Background()
+	// 损坏的 payload 单独记录错误，不影响其他键
+	source.keys["order:4"] = &fakeDumped{typ: "string", payload: "corrupt", ttlMs: -1}
+
+	copied, err := src.CopyKeys(ctx, dst, ScanExportOptions{Pattern: "order:*"}, RestoreOptions{Replace: true, TTLMode: TTLNone})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if copied.Keys != 4 || copied.Restored != 3 || copied.Failed != 1 || copied.Errors[0].Key != "order:4" {
+		t.Fatalf("unexpected copy result: %+v", copied)
+	}
+	if k := target.get("order:1"); k == nil || k.ttlMs != -1 {
+		t.Errorf("ttl should be dropped with ttl mode none: %+v", k)
+	}
+	if target.get("session:") != nil {
+		t.Error("keys outside the pattern must not be copied")
+	}
+}
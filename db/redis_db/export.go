@@ -0,0 +1,263 @@
+package redis_db
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+
+	"xz_mcp/db/dataio"
+)
+
+// DefaultExportPageSize 导出时集合键每页读取的元素数量
+const DefaultExportPageSize = 1000
+
+// ScanExportOptions Redis 导出时的 SCAN 参数
+type ScanExportOptions struct {
+	Pattern  string // 匹配模式，默认 *
+	Type     string // 只导出指定类型（string/hash/list/set/zset/stream）
+	Count    int64  // 每次 SCAN 的 COUNT 提示
+	MaxKeys  int    // 最多导出的键数量，0 表示不限制
+	PageSize int64  // 集合键每页读取的元素数量，默认 DefaultExportPageSize
+}
+
+// exportColumns Redis 导出文件的列；超过一页的集合键拆成多行，part 为页序号
+var exportColumns = []dataio.Column{
+	{Name: "key", Type: dataio.TypeString},
+	{Name: "type", Type: dataio.TypeString},
+	{Name: "ttl_ms", Type: dataio.TypeInt},
+	{Name: "part", Type: dataio.TypeInt},
+	{Name: "value", Type: dataio.TypeString},
+}
+
+// Export 以 SCAN 方式遍历键并分页读取值，每页读到后立即写入文件，大集合不会整个载入内存
+func (r *RedisClient) Export(ctx context.Context, scan ScanExportOptions, opts dataio.ExportOptions) (*dataio.ExportResult, error) {
+	exporter, err := dataio.NewExporter(opts, exportColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.scanKeys(ctx, scan, func(keys []string) error {
+		return r.readKeys(ctx, keys, scan.PageSize, func(page keyPage) error {
+			return exporter.Write([]interface{}{page.key, page.keyType, page.ttl, page.part, page.value})
+		})
+	})
+	if err != nil {
+		exporter.Abort()
+		return nil, err
+	}
+	return exporter.Close()
+}
+
+// scanKeys 按 SCAN 参数分批遍历键（集群模式下遍历所有主节点），达到 MaxKeys 后停止
+func (r *RedisClient) scanKeys(ctx context.Context, scan ScanExportOptions, fn func(keys []string) error) error {
+	if scan.Pattern == "" {
+		scan.Pattern = "*"
+	}
+	if scan.Count <= 0 {
+		scan.Count = 100
+	}
+	scanner, err := r.newKeyScanner(ctx, "")
+	if err != nil {
+		return err
+	}
+	seen := 0
+	for {
+		keys, err := scanner.Next(ctx, scan.Pattern, scan.Count, scan.Type)
+		if err != nil {
+			return fmt.Errorf("SCAN failed: %w", err)
+		}
+		if scan.MaxKeys > 0 && seen+len(keys) > scan.MaxKeys {
+			keys = keys[:scan.MaxKeys-seen]
+		}
+		if len(keys) > 0 {
+			if err := fn(keys); err != nil {
+				return err
+			}
+		}
+		seen += len(keys)
+		if scanner.Done() || (scan.MaxKeys > 0 && seen >= scan.MaxKeys) {
+			return nil
+		}
+	}
+}
+
+// keyPage 一个键的一页值，part 从 0 开始
+type keyPage struct {
+	key     string
+	keyType string
+	ttl     interface{}
+	part    int
+	value   interface{}
+}
+
+// readKeys 以管道批量读取键的类型、TTL 和第一页值，超过一页的集合键再逐页读取，每页交给 fn；
+// 已过期的键会被跳过
+func (r *RedisClient) readKeys(ctx context.Context, keys []string, pageSize int64, fn func(page keyPage) error) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if pageSize <= 0 {
+		pageSize = DefaultExportPageSize
+	}
+	pipe := r.client.Pipeline()
+	typeCmds := make([]*redis.StatusCmd, len(keys))
+	ttlCmds := make([]*redis.DurationCmd, len(keys))
+	for i, key := range keys {
+		typeCmds[i] = pipe.Type(ctx, key)
+		ttlCmds[i] = pipe.PTTL(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to read key metadata: %w", err)
+	}
+
+	pipe = r.client.Pipeline()
+	readers := make([]*pageReader, len(keys))
+	for i, key := range keys {
+		readers[i] = &pageReader{key: key, keyType: typeCmds[i].Val(), size: pageSize, next: "-"}
+		readers[i].queue(ctx, pipe)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return fmt.Errorf("failed to read key values: %w", err)
+	}
+
+	for i, reader := range readers {
+		if reader.keyType == "none" {
+			continue
+		}
+		var ttl interface{}
+		if d := ttlCmds[i].Val(); d > 0 {
+			ttl = d.Milliseconds()
+		}
+		for part := 0; ; part++ {
+			value, more, err := reader.page()
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", reader.key, err)
+			}
+			if err := fn(keyPage{key: reader.key, keyType: reader.keyType, ttl: ttl, part: part, value: value}); err != nil {
+				return err
+			}
+			if !more {
+				break
+			}
+			reader.queue(ctx, r.client)
+		}
+	}
+	return nil
+}
+
+// pageReader 分页读取一个键：hash/set/zset 用 HSCAN/SSCAN/ZSCAN，list 用 LRANGE 窗口，
+// stream 用带 COUNT 的 XRANGE，string 一次读完
+type pageReader struct {
+	key     string
+	keyType string
+	size    int64
+	cursor  uint64 // HSCAN/SSCAN/ZSCAN 游标
+	offset  int64  // LRANGE 起始下标
+	next    string // XRANGE 起始 ID
+	cmd     redis.Cmder
+}
+
+// queue 在 c（客户端或管道）上发出读取下一页的命令
+func (p *pageReader) queue(ctx context.Context, c redis.Cmdable) {
+	switch p.keyType {
+	case "string":
+		p.cmd = c.Get(ctx, p.key)
+	case "hash":
+		p.cmd = c.HScan(ctx, p.key, p.cursor, "", p.size)
+	case "set":
+		p.cmd = c.SScan(ctx, p.key, p.cursor, "", p.size)
+	case "zset":
+		p.cmd = c.ZScan(ctx, p.key, p.cursor, "", p.size)
+	case "list":
+		p.cmd = c.LRange(ctx, p.key, p.offset, p.offset+p.size-1)
+	case "stream":
+		p.cmd = c.XRangeN(ctx, p.key, p.next, "+", p.size)
+	default:
+		p.cmd = nil
+	}
+}
+
+// page 返回 queue 读到的一页，more 表示还有下一页
+func (p *pageReader) page() (interface{}, bool, error) {
+	switch c := p.cmd.(type) {
+	case nil:
+		return nil, false, nil
+	case *redis.StringCmd:
+		value, err := c.Result()
+		if err == redis.Nil {
+			return nil, false, nil
+		}
+		return value, false, err
+	case *redis.ScanCmd:
+		items, cursor, err := c.Result()
+		if err != nil {
+			return nil, false, err
+		}
+		p.cursor = cursor
+		more := cursor != 0
+		switch p.keyType {
+		case "hash":
+			fields := make(map[string]string, len(items)/2)
+			for i := 0; i+1 < len(items); i += 2 {
+				fields[items[i]] = items[i+1]
+			}
+			return fields, more, nil
+		case "zset":
+			members := make([]map[string]interface{}, 0, len(items)/2)
+			for i := 0; i+1 < len(items); i += 2 {
+				score, _ := strconv.ParseFloat(items[i+1], 64)
+				members = append(members, map[string]interface{}{"member": items[i], "score": score})
+			}
+			return members, more, nil
+		}
+		return items, more, nil
+	case *redis.StringSliceCmd:
+		items, err := c.Result()
+		if err != nil {
+			return nil, false, err
+		}
+		p.offset += int64(len(items))
+		return items, int64(len(items)) == p.size, nil
+	case *redis.XMessageSliceCmd:
+		messages, err := c.Result()
+		if err != nil {
+			return nil, false, err
+		}
+		entries := make([]map[string]interface{}, 0, len(messages))
+		for _, m := range messages {
+			entries = append(entries, map[string]interface{}{"id": m.ID, "values": m.Values})
+		}
+		more := int64(len(messages)) == p.size
+		if more {
+			if p.next, err = nextStreamID(messages[len(messages)-1].ID); err != nil {
+				return nil, false, err
+			}
+		}
+		return entries, more, nil
+	}
+	return nil, false, fmt.Errorf("unexpected reply %T", p.cmd)
+}
+
+// nextStreamID 返回紧随 id 之后的消息 ID，用作下一页 XRANGE 的起点（不依赖 Redis 6.2 的排他区间语法）
+func nextStreamID(id string) (string, error) {
+	ms, seq, ok := strings.Cut(id, "-")
+	if !ok {
+		return "", fmt.Errorf("invalid stream ID %q", id)
+	}
+	n, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid stream ID %q", id)
+	}
+	if n == math.MaxUint64 {
+		t, err := strconv.ParseUint(ms, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid stream ID %q", id)
+		}
+		return fmt.Sprintf("%d-0", t+1), nil
+	}
+	return fmt.Sprintf("%s-%d", ms, n+1), nil
+}
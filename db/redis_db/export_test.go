@@ -0,0 +1,111 @@
+package redis_db
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"xz_mcp/db/dataio"
+)
+
+func TestExport(t *testing.T) {
+	client, err := NewRedisClient(testConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	if err := client.Ping(ctx); err != nil {
+		t.Skipf("Redis server not available: %v", err)
+	}
+
+	client.client.Set(ctx, "test:export:str", "v1", 0)
+	client.client.HSet(ctx, "test:export:hash", "f1", "a", "f2", "b")
+	defer client.client.Del(ctx, "test:export:str", "test:export:hash")
+
+	path := filepath.Join(t.TempDir(), "keys.jsonl")
+	result, err := client.Export(ctx, ScanExportOptions{Pattern: "test:export:*"}, dataio.ExportOptions{Path: path})
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if result.Rows != 2 {
+		t.Errorf("Expected 2 rows, got %d", result.Rows)
+	}
+}
+
+func TestExportPages(t *testing.T) {
+	ks := newFakeKeyspace(map[string]*fakeKey{
+		"h": {typ: "hash", ttlMs: 5000, value: map[string]string{"a": "1", "b": "2", "c": "3"}},
+		"l": {typ: "list", ttlMs: -1, value: []string{"x1", "x2", "x3", "x4"}},
+		"s": {typ: "set", ttlMs: -1, value: []string{"m1", "m2", "m3"}},
+		"x": {typ: "stream", ttlMs: -1, value: []string{"1-1", "1-2", "2-0"}},
+		"z": {typ: "zset", ttlMs: -1, value: []fakeMember{{"p", "1"}, {"q", "2.5"}}},
+		"v": {typ: "string", ttlMs: -1, value: "plain"},
+	})
+	f, client := newFakeRedis(t, ks.handle)
+	path := filepath.Join(t.TempDir(), "keys.jsonl")
+	result, err := client.Export(context.Background(), ScanExportOptions{PageSize: 2}, dataio.ExportOptions{Path: path})
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	// h、s、x 各两页，l 两页满页后还有一次空页，z、v 各一页
+	if result.Rows != 11 {
+		t.Errorf("expected 11 rows, got %d", result.Rows)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	parts := map[string][]interface{}{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var row struct {
+			Key   string      `json:"key"`
+			TTL   interface{} `json:"ttl_ms"`
+			Part  int         `json:"part"`
+			Value interface{} `json:"value"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+			t.Fatal(err)
+		}
+		if row.Part != len(parts[row.Key]) {
+			t.Errorf("%s: unexpected part %d", row.Key, row.Part)
+		}
+		if row.Key == "h" && row.TTL != 5000.0 {
+			t.Errorf("ttl should be repeated on every page: %v", row.TTL)
+		}
+		parts[row.Key] = append(parts[row.Key], row.Value)
+	}
+	if len(parts["h"]) != 2 || len(parts["h"][0].(map[string]interface{})) != 2 || parts["h"][1].(map[string]interface{})["c"] != "3" {
+		t.Errorf("unexpected hash pages: %v", parts["h"])
+	}
+	if len(parts["l"]) != 3 || parts["l"][1].([]interface{})[1] != "x4" || len(parts["l"][2].([]interface{})) != 0 {
+		t.Errorf("unexpected list pages: %v", parts["l"])
+	}
+	if len(parts["x"]) != 2 || parts["x"][1].([]interface{})[0].(map[string]interface{})["id"] != "2-0" {
+		t.Errorf("unexpected stream pages: %v", parts["x"])
+	}
+	if len(parts["z"]) != 1 || parts["z"][0].([]interface{})[1].(map[string]interface{})["score"] != 2.5 || parts["v"][0] != "plain" {
+		t.Errorf("unexpected zset/string pages: %v %v", parts["z"], parts["v"])
+	}
+	for _, cmd := range f.commands() {
+		if cmd == "HGETALL" || cmd == "SMEMBERS" {
+			t.Errorf("collections should be paged, got %s", cmd)
+		}
+	}
+
+	columns, rows, err := client.ScanRows(context.Background(), ScanExportOptions{Pattern: "h", PageSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || strings.Join(columns, ",") != "_key,a,b,c" {
+		t.Errorf("hash pages should be merged into one row: %v %v", columns, rows)
+	}
+}
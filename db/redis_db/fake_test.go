@@ -0,0 +1,559 @@
+package redis_db
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeStatus 以简单字符串（+OK）回复
+type fakeStatus string
+
+// fakeError 以错误（-ERR ...）回复
+type fakeError string
+
+// fakeRedis 测试用的进程内 RESP2 服务器，命令交给 handler 处理；
+// SUBSCRIBE/PSUBSCRIBE/UNSUBSCRIBE/PUNSUBSCRIBE/PUBLISH 由服务器自己实现
+type fakeRedis struct {
+	mu      sync.Mutex
+	ln      net.Listener
+	handler func(args []string) interface{}
+	calls   []string
+	auth    [][]string // 收到的 AUTH 参数
+	conns   map[*fakeConn]bool
+}
+
+// fakeConn 一个客户端连接及其订阅；写入需持有 mu，PUBLISH 会从其他连接的 goroutine 推送消息
+type fakeConn struct {
+	mu       sync.Mutex
+	w        *bufio.Writer
+	channels map[string]bool
+	patterns map[string]bool
+}
+
+// fakeRaw 原样写出的 RESP 数据，用于录制的服务器回复
+type fakeRaw string
+
+// fakeReplies 依次写出多个回复（如 SUBSCRIBE 多个频道时每个频道一个确认）
+type fakeReplies []interface{}
+
+// newFakeRedis 启动服务器并返回连接它的客户端；handler 返回 nil 回复空值，
+// 未处理的连接握手命令（HELLO、CLIENT、SELECT、PING）有默认回复
+func newFakeRedis(t *testing.T, handler func(args []string) interface{}) (*fakeRedis, *RedisClient) {
+	t.Helper()
+	f := startFakeRedis(t, handler)
+	client, err := NewRedisClient(RedisConfig{Addr: f.addr()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return f, client
+}
+
+// startFakeRedis 只启动服务器，用于集群、哨兵等需要多个节点的测试
+func startFakeRedis(t *testing.T, handler func(args []string) interface{}) *fakeRedis {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return serveFakeRedis(t, ln, handler)
+}
+
+// startFakeTLSRedis 启动以 TLS 监听的服务器
+func startFakeTLSRedis(t *testing.T, config *tls.Config, handler func(args []string) interface{}) *fakeRedis {
+	t.Helper()
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return serveFakeRedis(t, ln, handler)
+}
+
+func serveFakeRedis(t *testing.T, ln net.Listener, handler func(args []string) interface{}) *fakeRedis {
+	f := &fakeRedis{ln: ln, handler: handler, conns: make(map[*fakeConn]bool)}
+	go f.serve()
+	t.Cleanup(func() { ln.Close() })
+	return f
+}
+
+// addr 服务器监听地址
+func (f *fakeRedis) addr() string {
+	return f.ln.Addr().String()
+}
+
+// port 服务器监听端口
+func (f *fakeRedis) port() int {
+	return f.ln.Addr().(*net.TCPAddr).Port
+}
+
+// commands 返回收到的命令名（大写），不含连接握手命令
+func (f *fakeRedis) commands() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.calls...)
+}
+
+func (f *fakeRedis) serve() {
+	for {
+		conn, err := f.ln.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	c := &fakeConn{w: bufio.NewWriter(conn), channels: map[string]bool{}, patterns: map[string]bool{}}
+	f.mu.Lock()
+	f.conns[c] = true
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		delete(f.conns, c)
+		f.mu.Unlock()
+	}()
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		var reply interface{}
+		switch cmd := strings.ToUpper(args[0]); cmd {
+		case "HELLO":
+			reply = fakeError("ERR unknown command 'HELLO'")
+		case "SELECT":
+			reply = fakeStatus("OK")
+		case "CLIENT":
+			// 连接握手的 CLIENT SETINFO/SETNAME 直接回复，其他子命令交给 handler
+			if len(args) > 1 && strings.HasPrefix(strings.ToUpper(args[1]), "SET") {
+				reply = fakeStatus("OK")
+			} else {
+				f.record(cmd)
+				reply = f.handler(args)
+			}
+		case "AUTH":
+			f.mu.Lock()
+			f.auth = append(f.auth, args[1:])
+			f.mu.Unlock()
+			reply = fakeStatus("OK")
+		case "PING":
+			reply = fakeStatus("PONG")
+		case "SUBSCRIBE", "PSUBSCRIBE", "UNSUBSCRIBE", "PUNSUBSCRIBE":
+			f.record(cmd)
+			reply = f.subscribe(c, cmd, args[1:])
+		case "PUBLISH":
+			f.record(cmd)
+			reply = f.publish(args[1], args[2])
+		default:
+			f.record(cmd)
+			reply = f.handler(args)
+		}
+		c.mu.Lock()
+		if replies, ok := reply.(fakeReplies); ok {
+			for _, item := range replies {
+				writeReply(c.w, item)
+			}
+		} else {
+			writeReply(c.w, reply)
+		}
+		// 管道中的命令全部读完后再刷新
+		if r.Buffered() == 0 {
+			err = c.w.Flush()
+		}
+		c.mu.Unlock()
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeRedis) record(cmd string) {
+	f.mu.Lock()
+	f.calls = append(f.calls, cmd)
+	f.mu.Unlock()
+}
+
+// subscribe 更新连接的订阅，每个频道或模式回复一条确认
+func (f *fakeRedis) subscribe(c *fakeConn, cmd string, names []string) fakeReplies {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	set := c.channels
+	if strings.HasPrefix(cmd, "P") {
+		set = c.patterns
+	}
+	if len(names) == 0 && strings.Contains(cmd, "UNSUBSCRIBE") {
+		for name := range set {
+			names = append(names, name)
+		}
+	}
+	var replies fakeReplies
+	for _, name := range names {
+		if strings.Contains(cmd, "UNSUBSCRIBE") {
+			delete(set, name)
+		} else {
+			set[name] = true
+		}
+		replies = append(replies, []interface{}{strings.ToLower(cmd), name, len(c.channels) + len(c.patterns)})
+	}
+	if replies == nil {
+		replies = fakeReplies{[]interface{}{strings.ToLower(cmd), nil, 0}}
+	}
+	return replies
+}
+
+// publish 把消息推送给订阅了该频道或匹配模式的连接，返回接收者数量
+func (f *fakeRedis) publish(channel, payload string) int {
+	f.mu.Lock()
+	var targets []*fakeConn
+	var messages [][]interface{}
+	for c := range f.conns {
+		if c.channels[channel] {
+			targets = append(targets, c)
+			messages = append(messages, []interface{}{"message", channel, payload})
+		}
+		for pattern := range c.patterns {
+			if matched, _ := path.Match(pattern, channel); matched {
+				targets = append(targets, c)
+				messages = append(messages, []interface{}{"pmessage", pattern, channel, payload})
+			}
+		}
+	}
+	f.mu.Unlock()
+	for i, c := range targets {
+		c.mu.Lock()
+		writeReply(c.w, messages[i])
+		c.w.Flush()
+		c.mu.Unlock()
+	}
+	return len(targets)
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if !strings.HasPrefix(line, "*") {
+		return strings.Fields(line), nil
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		header, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimRight(header, "\r\n")[1:])
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func writeReply(w *bufio.Writer, reply interface{}) {
+	switch v := reply.(type) {
+	case nil:
+		w.WriteString("$-1\r\n")
+	case fakeStatus:
+		fmt.Fprintf(w, "+%s\r\n", v)
+	case fakeError:
+		fmt.Fprintf(w, "-%s\r\n", v)
+	case fakeRaw:
+		w.WriteString(string(v))
+	case int:
+		fmt.Fprintf(w, ":%d\r\n", v)
+	case int64:
+		fmt.Fprintf(w, ":%d\r\n", v)
+	case string:
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(v), v)
+	case []byte:
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(v), v)
+	case []string:
+		fmt.Fprintf(w, "*%d\r\n", len(v))
+		for _, s := range v {
+			fmt.Fprintf(w, "$%d\r\n%s\r\n", len(s), s)
+		}
+	case []interface{}:
+		fmt.Fprintf(w, "*%d\r\n", len(v))
+		for _, item := range v {
+			writeReply(w, item)
+		}
+	default:
+		fmt.Fprintf(w, "-ERR unsupported fake reply %T\r\n", v)
+	}
+}
+
+// fakeKey 内存键空间中的键
+type fakeKey struct {
+	typ      string
+	encoding string
+	ttlMs    int64 // -1 表示不过期
+	memory   int64
+	freq     int64
+	idle     int64
+	value    interface{} // string / []string（list、set）/ map[string]string / []fakeMember
+}
+
+// fakeMember 有序集合成员
+type fakeMember struct {
+	member string
+	score  string
+}
+
+// fakeKeyspace 支持 SCAN 和常用读命令的简单键空间
+type fakeKeyspace struct {
+	keys   map[string]*fakeKey
+	order  []string          // SCAN 顺序
+	config map[string]string // CONFIG GET 的返回值
+}
+
+func newFakeKeyspace(keys map[string]*fakeKey) *fakeKeyspace {
+	ks := &fakeKeyspace{keys: keys}
+	for k := range keys {
+		ks.order = append(ks.order, k)
+	}
+	sort.Strings(ks.order)
+	return ks
+}
+
+// handle 实现 SCAN、TYPE、PTTL、OBJECT、MEMORY USAGE 以及按类型读取值的命令
+func (ks *fakeKeyspace) handle(args []string) interface{} {
+	cmd := strings.ToUpper(args[0])
+	if cmd == "SCAN" {
+		cursor, _ := strconv.Atoi(args[1])
+		pattern, typ, count := "*", "", 10
+		for i := 2; i+1 < len(args); i += 2 {
+			switch strings.ToUpper(args[i]) {
+			case "MATCH":
+				pattern = args[i+1]
+			case "COUNT":
+				count, _ = strconv.Atoi(args[i+1])
+			case "TYPE":
+				typ = args[i+1]
+			}
+		}
+		var batch []string
+		next := cursor
+		for ; next < len(ks.order) && next < cursor+count; next++ {
+			key := ks.order[next]
+			if matched, _ := path.Match(pattern, key); matched && (typ == "" || ks.keys[key].typ == typ) {
+				batch = append(batch, key)
+			}
+		}
+		if next >= len(ks.order) {
+			next = 0
+		}
+		if batch == nil {
+			batch = []string{}
+		}
+		return []interface{}{strconv.Itoa(next), batch}
+	}
+
+	switch cmd {
+	case "DBSIZE":
+		return len(ks.keys)
+	case "CONFIG":
+		if v, ok := ks.config[args[2]]; ok {
+			return []string{args[2], v}
+		}
+		return []string{}
+	}
+
+	if len(args) < 2 {
+		return fakeError("ERR wrong number of arguments")
+	}
+	key := ks.keys[args[1]]
+	if cmd == "OBJECT" || cmd == "MEMORY" {
+		if len(args) < 3 {
+			return fakeError("ERR wrong number of arguments")
+		}
+		key = ks.keys[args[2]]
+	}
+	switch cmd {
+	case "TYPE":
+		if key == nil {
+			return fakeStatus("none")
+		}
+		return fakeStatus(key.typ)
+	case "PTTL", "TTL":
+		if key == nil {
+			return -2
+		}
+		if cmd == "TTL" && key.ttlMs > 0 {
+			return key.ttlMs / 1000
+		}
+		return key.ttlMs
+	case "EXISTS":
+		if key == nil {
+			return 0
+		}
+		return 1
+	}
+	if key == nil {
+		return nil
+	}
+	switch cmd {
+	case "OBJECT":
+		switch strings.ToUpper(args[1]) {
+		case "ENCODING":
+			return key.encoding
+		case "FREQ":
+			return key.freq
+		case "IDLETIME":
+			return key.idle
+		}
+	case "MEMORY":
+		return key.memory
+	case "GET":
+		return key.value
+	case "STRLEN":
+		return len(key.value.(string))
+	case "GETRANGE":
+		start, _ := strconv.Atoi(args[2])
+		end, _ := strconv.Atoi(args[3])
+		v := key.value.(string)
+		if end >= len(v) {
+			end = len(v) - 1
+		}
+		return v[start : end+1]
+	case "HLEN":
+		return len(key.value.(map[string]string))
+	case "LLEN", "SCARD", "XLEN":
+		return len(key.value.([]string))
+	case "ZCARD":
+		return len(key.value.([]fakeMember))
+	case "HGETALL":
+		var out []string
+		for _, f := range sortedFields(key.value.(map[string]string)) {
+			out = append(out, f, key.value.(map[string]string)[f])
+		}
+		return out
+	case "LRANGE":
+		start, _ := strconv.Atoi(args[2])
+		stop, _ := strconv.Atoi(args[3])
+		items := key.value.([]string)
+		if stop < 0 || stop >= len(items) {
+			stop = len(items) - 1
+		}
+		if start > stop {
+			return []string{}
+		}
+		return items[start : stop+1]
+	case "SMEMBERS":
+		return key.value.([]string)
+	case "HSCAN", "SSCAN", "ZSCAN":
+		cursor, _ := strconv.Atoi(args[2])
+		count := 10
+		for i := 3; i+1 < len(args); i += 2 {
+			if strings.ToUpper(args[i]) == "COUNT" {
+				count, _ = strconv.Atoi(args[i+1])
+			}
+		}
+		var flat []string
+		switch v := key.value.(type) {
+		case map[string]string:
+			for _, f := range sortedFields(v) {
+				flat = append(flat, f, v[f])
+			}
+		case []string:
+			flat = v
+		case []fakeMember:
+			for _, m := range v {
+				flat = append(flat, m.member, m.score)
+			}
+		}
+		step := 1
+		if cmd != "SSCAN" {
+			step = 2
+		}
+		end := cursor + count*step
+		next := end
+		if end >= len(flat) {
+			end, next = len(flat), 0
+		}
+		return []interface{}{strconv.Itoa(next), flat[cursor:end]}
+	case "XRANGE":
+		// stream 的值为按顺序排列的消息 ID，每条消息只有一个字段 n
+		count := -1
+		if len(args) > 5 && strings.ToUpper(args[4]) == "COUNT" {
+			count, _ = strconv.Atoi(args[5])
+		}
+		var out []interface{}
+		for _, id := range key.value.([]string) {
+			if args[2] != "-" && compareStreamIDs(id, args[2]) < 0 {
+				continue
+			}
+			if len(out) == count {
+				break
+			}
+			out = append(out, []interface{}{id, []string{"n", id}})
+		}
+		if out == nil {
+			out = []interface{}{}
+		}
+		return out
+	case "ZRANGE":
+		start, _ := strconv.Atoi(args[2])
+		stop, _ := strconv.Atoi(args[3])
+		members := key.value.([]fakeMember)
+		if stop < 0 || stop >= len(members) {
+			stop = len(members) - 1
+		}
+		var out []string
+		for _, m := range members[start : stop+1] {
+			out = append(out, m.member, m.score)
+		}
+		return out
+	}
+	return fakeError("ERR unknown command '" + args[0] + "'")
+}
+
+func sortedFields(m map[string]string) []string {
+	fields := make([]string, 0, len(m))
+	for f := range m {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// compareStreamIDs 比较 ms-seq 形式的消息 ID
+func compareStreamIDs(a, b string) int {
+	parse := func(id string) (uint64, uint64) {
+		ms, seq, _ := strings.Cut(id, "-")
+		m, _ := strconv.ParseUint(ms, 10, 64)
+		n, _ := strconv.ParseUint(seq, 10, 64)
+		return m, n
+	}
+	am, as := parse(a)
+	bm, bs := parse(b)
+	switch {
+	case am < bm || (am == bm && as < bs):
+		return -1
+	case am == bm && as == bs:
+		return 0
+	}
+	return 1
+}
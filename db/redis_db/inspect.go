@@ -0,0 +1,300 @@
+package redis_db
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 默认值
+const (
+	DefaultInspectLimit    = 100
+	DefaultMaxStringBytes  = 64 << 10
+	smallCollectionMaxSize = 1000 // 元素数不超过 limit 时一次读完，否则分页
+)
+
+// InspectOptions redis_inspect_key 参数
+type InspectOptions struct {
+	Key            string
+	Cursor         string // 分页游标：hash/set/zset 为 SCAN 游标，list 为偏移量，stream 为上一页最后一条消息 ID
+	Limit          int    // 每页元素数量
+	Match          string // hash/set/zset 的元素匹配模式
+	MaxStringBytes int64  // string 最多读取的字节数
+	Decode         bool   // 识别 JSON/msgpack/gzip 并返回解码结果
+}
+
+// ZMember 有序集合成员
+type ZMember struct {
+	Member string  `json:"member"`
+	Score  float64 `json:"score"`
+}
+
+// StreamEntry stream 消息
+type StreamEntry struct {
+	ID     string                 `json:"id"`
+	Fields map[string]interface{} `json:"fields"`
+}
+
+// InspectResult redis_inspect_key 结果
+type InspectResult struct {
+	Type          string        `json:"type"`
+	Key           string        `json:"key"`
+	KeyType       string        `json:"key_type"`
+	Length        int64         `json:"length"` // string 为字节数，其余为元素数量
+	TTLMs         int64         `json:"ttl_ms"`
+	Encoding      string        `json:"encoding,omitempty"`
+	MemoryBytes   int64         `json:"memory_bytes,omitempty"`
+	IdleSeconds   *int64        `json:"idle_seconds,omitempty"`
+	Frequency     *int64        `json:"lfu_frequency,omitempty"` // LFU 策略下 OBJECT IDLETIME 不可用，改为返回访问频率
+	Value         interface{}   `json:"value"`
+	ValueEncoding string        `json:"value_encoding,omitempty"` // 非 UTF-8 的 string 值以 base64 返回
+	Decoded       *DecodedValue `json:"decoded,omitempty"`
+	Returned      int           `json:"returned,omitempty"`
+	Cursor        string        `json:"cursor,omitempty"` // 下一页的游标，为空表示已读完
+	Complete      bool          `json:"complete"`
+	Notes         []string      `json:"notes,omitempty"`
+}
+
+// InspectKey 检测键的类型并返回有界的分页视图及元数据
+func (r *RedisClient) InspectKey(ctx context.Context, opts InspectOptions) (*InspectResult, error) {
+	if opts.Key == "" {
+		return nil, fmt.Errorf("key is required")
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultInspectLimit
+	}
+	if opts.MaxStringBytes <= 0 {
+		opts.MaxStringBytes = DefaultMaxStringBytes
+	}
+	key := opts.Key
+
+	pipe := r.client.Pipeline()
+	typeCmd := pipe.Type(ctx, key)
+	ttlCmd := pipe.PTTL(ctx, key)
+	encCmd := pipe.ObjectEncoding(ctx, key)
+	memCmd := pipe.MemoryUsage(ctx, key)
+	idleCmd := pipe.ObjectIdleTime(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil && !isReplyError(err) {
+		return nil, fmt.Errorf("failed to read key metadata: %w", err)
+	}
+	if err := typeCmd.Err(); err != nil {
+		return nil, fmt.Errorf("TYPE failed: %w", err)
+	}
+	result := &InspectResult{
+		Type:     "redis_inspect_key",
+		Key:      key,
+		KeyType:  typeCmd.Val(),
+		TTLMs:    ttlMillis(ttlCmd.Val()),
+		Encoding: encCmd.Val(),
+	}
+	if result.KeyType == "none" {
+		return nil, fmt.Errorf("key %q does not exist", key)
+	}
+	if err := memCmd.Err(); err == nil {
+		result.MemoryBytes = memCmd.Val()
+	} else {
+		result.Notes = append(result.Notes, "MEMORY USAGE unavailable: "+err.Error())
+	}
+	if err := idleCmd.Err(); err == nil {
+		idle := int64(idleCmd.Val().Seconds())
+		result.IdleSeconds = &idle
+	} else if freq, err := r.client.ObjectFreq(ctx, key).Result(); err == nil {
+		result.Frequency = &freq
+	}
+
+	var err error
+	switch result.KeyType {
+	case "string":
+		err = r.inspectString(ctx, opts, result)
+	case "hash", "set", "zset":
+		err = r.inspectScannable(ctx, opts, result)
+	case "list":
+		err = r.inspectList(ctx, opts, result)
+	case "stream":
+		err = r.inspectStream(ctx, opts, result)
+	default:
+		result.Notes = append(result.Notes, fmt.Sprintf("values of type %s are not read", result.KeyType))
+		result.Complete = true
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// inspectString 读取最多 MaxStringBytes 字节；完整读取时尝试解码
+func (r *RedisClient) inspectString(ctx context.Context, opts InspectOptions, result *InspectResult) error {
+	length, err := r.client.StrLen(ctx, opts.Key).Result()
+	if err != nil {
+		return fmt.Errorf("STRLEN failed: %w", err)
+	}
+	result.Length = length
+	var value string
+	if length > opts.MaxStringBytes {
+		value, err = r.client.GetRange(ctx, opts.Key, 0, opts.MaxStringBytes-1).Result()
+		result.Notes = append(result.Notes, fmt.Sprintf("value truncated to the first %d of %d bytes", opts.MaxStringBytes, length))
+	} else {
+		value, err = r.client.Get(ctx, opts.Key).Result()
+		result.Complete = true
+	}
+	if err != nil && err != redis.Nil {
+		return fmt.Errorf("failed to read value: %w", err)
+	}
+	if utf8.ValidString(value) {
+		result.Value = value
+	} else {
+		result.Value = base64.StdEncoding.EncodeToString([]byte(value))
+		result.ValueEncoding = "base64"
+	}
+	if opts.Decode && result.Complete {
+		result.Decoded = DecodePayload([]byte(value))
+	}
+	return nil
+}
+
+// inspectScannable 小集合一次读完，大集合使用 HSCAN/SSCAN/ZSCAN 分页
+func (r *RedisClient) inspectScannable(ctx context.Context, opts InspectOptions, result *InspectResult) error {
+	var err error
+	switch result.KeyType {
+	case "hash":
+		result.Length, err = r.client.HLen(ctx, opts.Key).Result()
+	case "set":
+		result.Length, err = r.client.SCard(ctx, opts.Key).Result()
+	case "zset":
+		result.Length, err = r.client.ZCard(ctx, opts.Key).Result()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read length: %w", err)
+	}
+
+	if opts.Cursor == "" && opts.Match == "" && result.Length <= int64(opts.Limit) && result.Length <= smallCollectionMaxSize {
+		result.Complete = true
+		switch result.KeyType {
+		case "hash":
+			fields, err := r.client.HGetAll(ctx, opts.Key).Result()
+			if err != nil {
+				return fmt.Errorf("HGETALL failed: %w", err)
+			}
+			result.Value, result.Returned = fields, len(fields)
+		case "set":
+			members, err := r.client.SMembers(ctx, opts.Key).Result()
+			if err != nil {
+				return fmt.Errorf("SMEMBERS failed: %w", err)
+			}
+			result.Value, result.Returned = members, len(members)
+		case "zset":
+			members, err := r.client.ZRangeWithScores(ctx, opts.Key, 0, -1).Result()
+			if err != nil {
+				return fmt.Errorf("ZRANGE failed: %w", err)
+			}
+			result.Value, result.Returned = zMembers(members), len(members)
+		}
+		return nil
+	}
+
+	cursor := uint64(0)
+	if opts.Cursor != "" {
+		if cursor, err = strconv.ParseUint(opts.Cursor, 10, 64); err != nil {
+			return fmt.Errorf("invalid cursor %q for %s", opts.Cursor, result.KeyType)
+		}
+	}
+	var items []string
+	switch result.KeyType {
+	case "hash":
+		items, cursor, err = r.client.HScan(ctx, opts.Key, cursor, opts.Match, int64(opts.Limit)).Result()
+	case "set":
+		items, cursor, err = r.client.SScan(ctx, opts.Key, cursor, opts.Match, int64(opts.Limit)).Result()
+	case "zset":
+		items, cursor, err = r.client.ZScan(ctx, opts.Key, cursor, opts.Match, int64(opts.Limit)).Result()
+	}
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", map[string]string{"hash": "HSCAN", "set": "SSCAN", "zset": "ZSCAN"}[result.KeyType], err)
+	}
+	switch result.KeyType {
+	case "hash":
+		fields := make(map[string]string, len(items)/2)
+		for i := 0; i+1 < len(items); i += 2 {
+			fields[items[i]] = items[i+1]
+		}
+		result.Value, result.Returned = fields, len(fields)
+	case "set":
+		result.Value, result.Returned = items, len(items)
+	case "zset":
+		members := make([]ZMember, 0, len(items)/2)
+		for i := 0; i+1 < len(items); i += 2 {
+			score, _ := strconv.ParseFloat(items[i+1], 64)
+			members = append(members, ZMember{Member: items[i], Score: score})
+		}
+		result.Value, result.Returned = members, len(members)
+	}
+	if cursor == 0 {
+		result.Complete = true
+	} else {
+		result.Cursor = strconv.FormatUint(cursor, 10)
+	}
+	return nil
+}
+
+// inspectList 按偏移量分页读取 list
+func (r *RedisClient) inspectList(ctx context.Context, opts InspectOptions, result *InspectResult) error {
+	var err error
+	if result.Length, err = r.client.LLen(ctx, opts.Key).Result(); err != nil {
+		return fmt.Errorf("LLEN failed: %w", err)
+	}
+	offset := int64(0)
+	if opts.Cursor != "" {
+		if offset, err = strconv.ParseInt(opts.Cursor, 10, 64); err != nil || offset < 0 {
+			return fmt.Errorf("invalid cursor %q for list: expected an offset", opts.Cursor)
+		}
+	}
+	items, err := r.client.LRange(ctx, opts.Key, offset, offset+int64(opts.Limit)-1).Result()
+	if err != nil {
+		return fmt.Errorf("LRANGE failed: %w", err)
+	}
+	result.Value, result.Returned = items, len(items)
+	if next := offset + int64(len(items)); next < result.Length {
+		result.Cursor = strconv.FormatInt(next, 10)
+	} else {
+		result.Complete = true
+	}
+	return nil
+}
+
+// inspectStream 从上一页最后一条消息之后读取
+func (r *RedisClient) inspectStream(ctx context.Context, opts InspectOptions, result *InspectResult) error {
+	var err error
+	if result.Length, err = r.client.XLen(ctx, opts.Key).Result(); err != nil {
+		return fmt.Errorf("XLEN failed: %w", err)
+	}
+	start := "-"
+	if opts.Cursor != "" {
+		start = "(" + opts.Cursor
+	}
+	messages, err := r.client.XRangeN(ctx, opts.Key, start, "+", int64(opts.Limit)).Result()
+	if err != nil {
+		return fmt.Errorf("XRANGE failed: %w", err)
+	}
+	entries := make([]StreamEntry, len(messages))
+	for i, m := range messages {
+		entries[i] = StreamEntry{ID: m.ID, Fields: m.Values}
+	}
+	result.Value, result.Returned = entries, len(entries)
+	if len(messages) == opts.Limit {
+		result.Cursor = messages[len(messages)-1].ID
+	} else {
+		result.Complete = true
+	}
+	return nil
+}
+
+func zMembers(zs []redis.Z) []ZMember {
+	members := make([]ZMember, len(zs))
+	for i, z := range zs {
+		members[i] = ZMember{Member: fmt.Sprint(z.Member), Score: z.Score}
+	}
+	return members
+}
@@ -0,0 +1,92 @@
+package redis_db
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func gzipped(s string) string {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(s))
+	zw.Close()
+	return buf.String()
+}
+
+func TestInspectKey(t *testing.T) {
+	bigHash := map[string]string{}
+	for i := 0; i < 25; i++ {
+		bigHash[fmt.Sprintf("f%02d", i)] = "v"
+	}
+	ks := newFakeKeyspace(map[string]*fakeKey{
+		"json":   {typ: "string", encoding: "raw", ttlMs: -1, memory: 64, idle: 7, value: `{"id": 1, "tags": ["a"]}`},
+		"gz":     {typ: "string", encoding: "raw", ttlMs: 3000, value: gzipped(`[1, 2]`)},
+		"mp":     {typ: "string", encoding: "raw", ttlMs: -1, value: "\x82\xa2id\x01\xa4tags\x91\xa1a"},
+		"bin":    {typ: "string", encoding: "raw", ttlMs: -1, value: "\xff\xfe\x00"},
+		"long":   {typ: "string", encoding: "raw", ttlMs: -1, value: strings.Repeat("x", 100)},
+		"big":    {typ: "hash", encoding: "hashtable", ttlMs: -1, value: bigHash},
+		"queue":  {typ: "list", encoding: "quicklist", ttlMs: -1, value: []string{"a", "b", "c", "d", "e"}},
+		"scores": {typ: "zset", encoding: "listpack", ttlMs: -1, value: []fakeMember{{"x", "1"}, {"y", "2.5"}}},
+	})
+	_, client := newFakeRedis(t, ks.handle)
+	ctx := context.Background()
+
+	r, err := client.InspectKey(ctx, InspectOptions{Key: "json", Decode: true})
+	if err != nil {
+		t.Fatalf("InspectKey: %v", err)
+	}
+	if r.KeyType != "string" || !r.Complete || r.MemoryBytes != 64 || r.IdleSeconds == nil || *r.IdleSeconds != 7 || r.Decoded == nil || r.Decoded.Format != "json" {
+		t.Fatalf("json = %+v", r)
+	}
+	if r, _ = client.InspectKey(ctx, InspectOptions{Key: "gz", Decode: true}); r.Decoded == nil || r.Decoded.Format != "gzip+json" || r.ValueEncoding != "base64" || r.TTLMs != 3000 {
+		t.Fatalf("gzip = %+v", r)
+	}
+	r, _ = client.InspectKey(ctx, InspectOptions{Key: "mp", Decode: true})
+	if r.Decoded == nil || r.Decoded.Format != "msgpack" {
+		t.Fatalf("msgpack = %+v", r)
+	}
+	if m := r.Decoded.Value.(map[string]interface{}); m["id"] != int64(1) || m["tags"].([]interface{})[0] != "a" {
+		t.Errorf("msgpack value = %#v", r.Decoded.Value)
+	}
+	if r, _ = client.InspectKey(ctx, InspectOptions{Key: "bin", Decode: true}); r.Decoded != nil || r.Value != "//4A" {
+		t.Errorf("binary = %+v", r)
+	}
+	if r, _ = client.InspectKey(ctx, InspectOptions{Key: "long", MaxStringBytes: 10}); r.Complete || r.Length != 100 || r.Value != "xxxxxxxxxx" {
+		t.Errorf("truncated = %+v", r)
+	}
+
+	// 大 hash 使用 HSCAN 分页
+	seen := 0
+	cursor := ""
+	for page := 0; page < 10; page++ {
+		r, err = client.InspectKey(ctx, InspectOptions{Key: "big", Limit: 10, Cursor: cursor})
+		if err != nil {
+			t.Fatalf("InspectKey: %v", err)
+		}
+		seen += r.Returned
+		if r.Complete {
+			break
+		}
+		cursor = r.Cursor
+	}
+	if seen != 25 || r.Length != 25 {
+		t.Errorf("hash pages returned %d of %d", seen, r.Length)
+	}
+
+	r, _ = client.InspectKey(ctx, InspectOptions{Key: "queue", Limit: 2, Cursor: "2"})
+	if r.Cursor != "4" || strings.Join(r.Value.([]string), ",") != "c,d" {
+		t.Errorf("list page = %+v", r)
+	}
+	r, _ = client.InspectKey(ctx, InspectOptions{Key: "scores"})
+	if members := r.Value.([]ZMember); !r.Complete || len(members) != 2 || members[1].Score != 2.5 {
+		t.Errorf("zset = %+v", r)
+	}
+
+	if _, err := client.InspectKey(ctx, InspectOptions{Key: "missing"}); err == nil {
+		t.Error("expected error for missing key")
+	}
+}
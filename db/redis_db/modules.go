@@ -0,0 +1,688 @@
+package redis_db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 模块在 MODULE LIST 中的名称（不区分大小写）
+var (
+	moduleJSON       = []string{"ReJSON", "rejson"}
+	moduleSearch     = []string{"search", "ft", "searchlight"}
+	moduleTimeSeries = []string{"timeseries"}
+)
+
+// 默认值
+const (
+	DefaultSearchLimit = 10
+	MaxSearchLimit     = 1000
+	DefaultTSCount     = 1000
+	MaxTSCount         = 100000
+)
+
+// ModuleInfo MODULE LIST 中的一个模块
+type ModuleInfo struct {
+	Name    string `json:"name"`
+	Version int64  `json:"version"`
+	Path    string `json:"path,omitempty"`
+}
+
+// Modules 以 MODULE LIST 返回服务器加载的模块
+func (r *RedisClient) Modules(ctx context.Context) ([]ModuleInfo, error) {
+	reply, err := r.client.Do(ctx, "MODULE", "LIST").Slice()
+	if err != nil {
+		return nil, fmt.Errorf("MODULE LIST failed: %w", err)
+	}
+	var modules []ModuleInfo
+	for _, item := range reply {
+		fields := replyMap(item)
+		if fields == nil {
+			return nil, fmt.Errorf("unexpected MODULE LIST entry %v", item)
+		}
+		version, _ := replyInt(fields["ver"])
+		path, _ := fields["path"].(string)
+		modules = append(modules, ModuleInfo{Name: fmt.Sprint(fields["name"]), Version: version, Path: path})
+	}
+	return modules, nil
+}
+
+// requireModule 确认服务器加载了模块，未加载时错误信息列出已加载的模块
+func (r *RedisClient) requireModule(ctx context.Context, label string, names []string) error {
+	modules, err := r.Modules(ctx)
+	if err != nil {
+		return err
+	}
+	var loaded []string
+	for _, m := range modules {
+		for _, name := range names {
+			if strings.EqualFold(m.Name, name) {
+				return nil
+			}
+		}
+		loaded = append(loaded, m.Name)
+	}
+	if len(loaded) == 0 {
+		return fmt.Errorf("%s module is not loaded (no modules loaded)", label)
+	}
+	sort.Strings(loaded)
+	return fmt.Errorf("%s module is not loaded (loaded: %s)", label, strings.Join(loaded, ", "))
+}
+
+// JSONGetResult redis_json_get 结果
+type JSONGetResult struct {
+	Type   string      `json:"type"`
+	Key    string      `json:"key"`
+	Paths  []string    `json:"paths"`
+	Exists bool        `json:"exists"`
+	Value  interface{} `json:"value"` // 单个 JSONPath 时为匹配值数组，多个路径时为 路径→匹配值数组 的对象
+}
+
+// JSONGet 以 JSON.GET 读取文档，返回解析后的 JSON 值而不是转义字符串
+func (r *RedisClient) JSONGet(ctx context.Context, key string, paths []string) (*JSONGetResult, error) {
+	if key == "" {
+		return nil, fmt.Errorf("key is required")
+	}
+	if len(paths) == 0 {
+		paths = []string{"$"}
+	}
+	if err := r.requireModule(ctx, "RedisJSON", moduleJSON); err != nil {
+		return nil, err
+	}
+	args := []interface{}{"JSON.GET", key}
+	for _, p := range paths {
+		args = append(args, p)
+	}
+	result := &JSONGetResult{Type: "redis_json_get", Key: key, Paths: paths}
+	text, err := r.client.Do(ctx, args...).Text()
+	if err == redis.Nil {
+		return result, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("JSON.GET failed: %w", err)
+	}
+	result.Exists = true
+	if err := json.Unmarshal([]byte(text), &result.Value); err != nil {
+		return nil, fmt.Errorf("JSON.GET returned invalid JSON: %w", err)
+	}
+	return result, nil
+}
+
+// JSONSetOptions redis_json_set 参数
+type JSONSetOptions struct {
+	Key       string
+	Path      string // 默认 $
+	Value     string // JSON 文本
+	Condition string // NX 只在路径不存在时设置，XX 只在路径存在时设置
+}
+
+// JSONSetResult redis_json_set 结果
+type JSONSetResult struct {
+	Type string `json:"type"`
+	Key  string `json:"key"`
+	Path string `json:"path"`
+	Set  bool   `json:"set"` // NX/XX 条件不满足时为 false
+}
+
+// JSONSet 以 JSON.SET 写入文档或路径，写入前检查值是合法 JSON
+func (r *RedisClient) JSONSet(ctx context.Context, opts JSONSetOptions) (*JSONSetResult, error) {
+	if opts.Key == "" {
+		return nil, fmt.Errorf("key is required")
+	}
+	if opts.Path == "" {
+		opts.Path = "$"
+	}
+	if !json.Valid([]byte(opts.Value)) {
+		return nil, fmt.Errorf("value is not valid JSON")
+	}
+	args := []interface{}{"JSON.SET", opts.Key, opts.Path, opts.Value}
+	switch cond := strings.ToUpper(opts.Condition); cond {
+	case "":
+	case "NX", "XX":
+		args = append(args, cond)
+	default:
+		return nil, fmt.Errorf("unknown condition %q (use NX or XX)", opts.Condition)
+	}
+	if err := r.requireModule(ctx, "RedisJSON", moduleJSON); err != nil {
+		return nil, err
+	}
+	result := &JSONSetResult{Type: "redis_json_set", Key: opts.Key, Path: opts.Path}
+	err := r.client.Do(ctx, args...).Err()
+	if err == redis.Nil {
+		return result, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("JSON.SET failed: %w", err)
+	}
+	result.Set = true
+	return result, nil
+}
+
+// SearchOptions redis_ft_search 参数
+type SearchOptions struct {
+	Index        string
+	Query        string
+	ReturnFields []string
+	Offset       int
+	Limit        int
+	SortBy       string
+	SortDesc     bool
+	WithScores   bool
+	NoContent    bool
+	Params       map[string]string // 查询中以 $name 引用的参数，需要 DIALECT 2 及以上
+	Dialect      int
+}
+
+// SearchDocument FT.SEARCH 返回的一个文档
+type SearchDocument struct {
+	ID       string                 `json:"id"`
+	Score    *float64               `json:"score,omitempty"`
+	Fields   map[string]interface{} `json:"fields,omitempty"`
+	Document interface{}            `json:"document,omitempty"` // JSON 索引返回的整个文档（$ 字段）
+}
+
+// SearchResult redis_ft_search 结果
+type SearchResult struct {
+	Type      string           `json:"type"`
+	Index     string           `json:"index"`
+	Query     string           `json:"query"`
+	Total     int64            `json:"total"`
+	Offset    int              `json:"offset"`
+	Documents []SearchDocument `json:"documents"`
+	HasMore   bool             `json:"has_more"`
+}
+
+// Search 以 FT.SEARCH 查询索引，文档以对象返回；JSON 索引的文档和 $ 开头的字段会解析为 JSON
+func (r *RedisClient) Search(ctx context.Context, opts SearchOptions) (*SearchResult, error) {
+	if opts.Index == "" {
+		return nil, fmt.Errorf("index is required")
+	}
+	if opts.Query == "" {
+		opts.Query = "*"
+	}
+	if opts.Offset < 0 {
+		opts.Offset = 0
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultSearchLimit
+	}
+	if opts.Limit > MaxSearchLimit {
+		opts.Limit = MaxSearchLimit
+	}
+	args := []interface{}{"FT.SEARCH", opts.Index, opts.Query}
+	if opts.NoContent {
+		args = append(args, "NOCONTENT")
+	}
+	if opts.WithScores {
+		args = append(args, "WITHSCORES")
+	}
+	if len(opts.ReturnFields) > 0 && !opts.NoContent {
+		args = append(args, "RETURN", len(opts.ReturnFields))
+		for _, f := range opts.ReturnFields {
+			args = append(args, f)
+		}
+	}
+	if opts.SortBy != "" {
+		order := "ASC"
+		if opts.SortDesc {
+			order = "DESC"
+		}
+		args = append(args, "SORTBY", opts.SortBy, order)
+	}
+	args = append(args, "LIMIT", opts.Offset, opts.Limit)
+	if len(opts.Params) > 0 {
+		names := make([]string, 0, len(opts.Params))
+		for name := range opts.Params {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		args = append(args, "PARAMS", len(names)*2)
+		for _, name := range names {
+			args = append(args, name, opts.Params[name])
+		}
+		if opts.Dialect < 2 {
+			opts.Dialect = 2
+		}
+	}
+	if opts.Dialect > 0 {
+		args = append(args, "DIALECT", opts.Dialect)
+	}
+	if err := r.requireModule(ctx, "RediSearch", moduleSearch); err != nil {
+		return nil, err
+	}
+	reply, err := r.client.Do(ctx, args...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("FT.SEARCH failed: %w", err)
+	}
+	result := &SearchResult{Type: "redis_ft_search", Index: opts.Index, Query: opts.Query, Offset: opts.Offset, Documents: []SearchDocument{}}
+	if m, ok := reply.(map[interface{}]interface{}); ok {
+		err = parseSearchResp3(result, m)
+	} else {
+		err = parseSearchResp2(result, reply, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.HasMore = int64(opts.Offset+len(result.Documents)) < result.Total
+	return result, nil
+}
+
+// parseSearchResp2 解析 [total, id, (score), ([field, value, ...]), ...]
+func parseSearchResp2(result *SearchResult, reply interface{}, opts SearchOptions) error {
+	items, ok := reply.([]interface{})
+	if !ok || len(items) == 0 {
+		return fmt.Errorf("unexpected FT.SEARCH reply %v", reply)
+	}
+	total, ok := replyInt(items[0])
+	if !ok {
+		return fmt.Errorf("unexpected FT.SEARCH total %v", items[0])
+	}
+	result.Total = total
+	for i := 1; i < len(items); {
+		doc := SearchDocument{ID: fmt.Sprint(items[i])}
+		i++
+		if opts.WithScores && i < len(items) {
+			if score, ok := replyFloat(items[i]); ok {
+				doc.Score = &score
+			}
+			i++
+		}
+		if !opts.NoContent && i < len(items) {
+			fields, _ := items[i].([]interface{})
+			values := map[string]interface{}{}
+			for j := 0; j+1 < len(fields); j += 2 {
+				values[fmt.Sprint(fields[j])] = fields[j+1]
+			}
+			setDocumentFields(&doc, values)
+			i++
+		}
+		result.Documents = append(result.Documents, doc)
+	}
+	return nil
+}
+
+// parseSearchResp3 解析 RESP3 的 {total_results, results: [{id, score, extra_attributes}]}
+func parseSearchResp3(result *SearchResult, m map[interface{}]interface{}) error {
+	total, ok := replyInt(m["total_results"])
+	if !ok {
+		return fmt.Errorf("unexpected FT.SEARCH reply %v", m)
+	}
+	result.Total = total
+	items, _ := m["results"].([]interface{})
+	for _, item := range items {
+		entry := replyMap(item)
+		if entry == nil {
+			return fmt.Errorf("unexpected FT.SEARCH result %v", item)
+		}
+		doc := SearchDocument{ID: fmt.Sprint(entry["id"])}
+		if score, ok := replyFloat(entry["score"]); ok {
+			doc.Score = &score
+		}
+		if attrs := replyMap(entry["extra_attributes"]); len(attrs) > 0 {
+			setDocumentFields(&doc, attrs)
+		}
+		result.Documents = append(result.Documents, doc)
+	}
+	return nil
+}
+
+// setDocumentFields $ 字段是 JSON 索引的整个文档，其他以 $ 开头的字段是 JSONPath 的返回值
+func setDocumentFields(doc *SearchDocument, values map[string]interface{}) {
+	for name, value := range values {
+		text, isText := value.(string)
+		if !strings.HasPrefix(name, "$") || !isText {
+			continue
+		}
+		var decoded interface{}
+		if json.Unmarshal([]byte(text), &decoded) != nil {
+			continue
+		}
+		if name == "$" {
+			doc.Document = decoded
+			delete(values, name)
+		} else {
+			values[name] = decoded
+		}
+	}
+	if len(values) > 0 {
+		doc.Fields = values
+	}
+}
+
+// SearchAttribute 索引中的一个字段
+type SearchAttribute struct {
+	Identifier string                 `json:"identifier"` // 哈希字段名或 JSONPath
+	Attribute  string                 `json:"attribute"`  // 查询中使用的名称
+	Type       string                 `json:"type"`
+	Options    map[string]interface{} `json:"options,omitempty"` // 如 WEIGHT、SEPARATOR 以及向量字段的参数
+	Flags      []string               `json:"flags,omitempty"`   // 如 SORTABLE、NOSTEM
+}
+
+// SearchIndexInfo redis_ft_info 结果
+type SearchIndexInfo struct {
+	Type             string                 `json:"type"`
+	Index            string                 `json:"index"`
+	Definition       map[string]interface{} `json:"definition"`
+	Attributes       []SearchAttribute      `json:"attributes"`
+	NumDocs          int64                  `json:"num_docs"`
+	Indexing         bool                   `json:"indexing"`
+	PercentIndexed   float64                `json:"percent_indexed"`
+	IndexingFailures int64                  `json:"hash_indexing_failures"`
+	Info             map[string]interface{} `json:"info"` // 其他统计字段，数字字符串已转换为数值
+}
+
+// SearchInfo 以 FT.INFO 返回索引定义、字段和统计信息
+func (r *RedisClient) SearchInfo(ctx context.Context, index string) (*SearchIndexInfo, error) {
+	if index == "" {
+		return nil, fmt.Errorf("index is required")
+	}
+	if err := r.requireModule(ctx, "RediSearch", moduleSearch); err != nil {
+		return nil, err
+	}
+	reply, err := r.client.Do(ctx, "FT.INFO", index).Result()
+	if err != nil {
+		return nil, fmt.Errorf("FT.INFO failed: %w", err)
+	}
+	fields := replyMap(reply)
+	if fields == nil {
+		return nil, fmt.Errorf("unexpected FT.INFO reply %v", reply)
+	}
+	result := &SearchIndexInfo{
+		Type:       "redis_ft_info",
+		Index:      index,
+		Definition: map[string]interface{}{},
+		Attributes: []SearchAttribute{},
+		Info:       map[string]interface{}{},
+	}
+	for name, value := range fields {
+		switch name {
+		case "index_name":
+			result.Index = fmt.Sprint(value)
+		case "index_definition":
+			for k, v := range replyMap(value) {
+				result.Definition[k] = replyValue(v)
+			}
+		case "attributes", "fields":
+			items, _ := value.([]interface{})
+			for _, item := range items {
+				result.Attributes = append(result.Attributes, searchAttribute(item))
+			}
+		case "num_docs":
+			result.NumDocs, _ = replyInt(value)
+		case "indexing":
+			n, _ := replyInt(value)
+			result.Indexing = n != 0
+		case "percent_indexed":
+			result.PercentIndexed, _ = replyFloat(value)
+		case "hash_indexing_failures":
+			result.IndexingFailures, _ = replyInt(value)
+		default:
+			result.Info[name] = replyValue(value)
+		}
+	}
+	return result, nil
+}
+
+// searchAttribute 解析 [identifier, $.name, attribute, name, type, TEXT, WEIGHT, 1, SORTABLE, ...]，
+// 键值对之后单独出现的大写词是标志
+func searchAttribute(item interface{}) SearchAttribute {
+	var attr SearchAttribute
+	var items []interface{}
+	switch v := item.(type) {
+	case []interface{}:
+		items = v
+	case map[interface{}]interface{}:
+		for k, val := range v {
+			items = append(items, k, val)
+		}
+	}
+	for i := 0; i < len(items); i++ {
+		name := fmt.Sprint(items[i])
+		if searchAttributeFlag(name) || i+1 >= len(items) {
+			attr.Flags = append(attr.Flags, name)
+			continue
+		}
+		value := items[i+1]
+		i++
+		switch strings.ToLower(name) {
+		case "identifier":
+			attr.Identifier = fmt.Sprint(value)
+		case "attribute":
+			attr.Attribute = fmt.Sprint(value)
+		case "type":
+			attr.Type = fmt.Sprint(value)
+		case "flags":
+			// RESP3 把标志放在 flags 数组中
+			for _, f := range toSlice(value) {
+				attr.Flags = append(attr.Flags, fmt.Sprint(f))
+			}
+		default:
+			if attr.Options == nil {
+				attr.Options = map[string]interface{}{}
+			}
+			attr.Options[name] = replyValue(value)
+		}
+	}
+	sort.Strings(attr.Flags)
+	return attr
+}
+
+// searchAttributeFlag FT.INFO 字段定义中不带值的选项
+func searchAttributeFlag(name string) bool {
+	switch name {
+	case "SORTABLE", "UNF", "NOSTEM", "NOINDEX", "CASESENSITIVE", "WITHSUFFIXTRIE", "INDEXEMPTY", "INDEXMISSING", "PHONETIC":
+		return true
+	}
+	return false
+}
+
+// TSRangeOptions redis_ts_range 参数
+type TSRangeOptions struct {
+	Key         string
+	From        string // 毫秒时间戳或 -（最早）
+	To          string // 毫秒时间戳或 +（最新）
+	Count       int
+	Aggregation string // 如 avg、sum、max，需要 BucketMs
+	BucketMs    int64
+	Reverse     bool // 使用 TS.REVRANGE，从新到旧
+}
+
+// TSPoint 时间序列中的一个点
+type TSPoint struct {
+	Timestamp int64   `json:"timestamp"`
+	Time      string  `json:"time"`
+	Value     float64 `json:"value"`
+}
+
+// TSRangeResult redis_ts_range 结果
+type TSRangeResult struct {
+	Type        string    `json:"type"`
+	Key         string    `json:"key"`
+	Aggregation string    `json:"aggregation,omitempty"`
+	BucketMs    int64     `json:"bucket_ms,omitempty"`
+	Points      []TSPoint `json:"points"`
+	Truncated   bool      `json:"truncated"` // 达到 count 限制，可能还有更多点
+}
+
+// TSRange 以 TS.RANGE/TS.REVRANGE 读取时间序列，返回带时间的点
+func (r *RedisClient) TSRange(ctx context.Context, opts TSRangeOptions) (*TSRangeResult, error) {
+	if opts.Key == "" {
+		return nil, fmt.Errorf("key is required")
+	}
+	if opts.From == "" {
+		opts.From = "-"
+	}
+	if opts.To == "" {
+		opts.To = "+"
+	}
+	for _, bound := range []string{opts.From, opts.To} {
+		if bound == "-" || bound == "+" {
+			continue
+		}
+		if _, err := strconv.ParseInt(bound, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid timestamp %q (use milliseconds, - or +)", bound)
+		}
+	}
+	if opts.Count <= 0 {
+		opts.Count = DefaultTSCount
+	}
+	if opts.Count > MaxTSCount {
+		opts.Count = MaxTSCount
+	}
+	cmd := "TS.RANGE"
+	if opts.Reverse {
+		cmd = "TS.REVRANGE"
+	}
+	args := []interface{}{cmd, opts.Key, opts.From, opts.To, "COUNT", opts.Count}
+	if opts.Aggregation != "" {
+		if opts.BucketMs <= 0 {
+			return nil, fmt.Errorf("bucket_ms is required with aggregation")
+		}
+		args = append(args, "AGGREGATION", strings.ToLower(opts.Aggregation), opts.BucketMs)
+	}
+	if err := r.requireModule(ctx, "RedisTimeSeries", moduleTimeSeries); err != nil {
+		return nil, err
+	}
+	reply, err := r.client.Do(ctx, args...).Slice()
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w", cmd, err)
+	}
+	result := &TSRangeResult{Type: "redis_ts_range", Key: opts.Key, Points: []TSPoint{}}
+	if opts.Aggregation != "" {
+		result.Aggregation = strings.ToLower(opts.Aggregation)
+		result.BucketMs = opts.BucketMs
+	}
+	for _, item := range reply {
+		pair, ok := item.([]interface{})
+		if !ok || len(pair) != 2 {
+			return nil, fmt.Errorf("unexpected %s sample %v", cmd, item)
+		}
+		ts, ok1 := replyInt(pair[0])
+		value, ok2 := replyFloat(pair[1])
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("unexpected %s sample %v", cmd, item)
+		}
+		result.Points = append(result.Points, TSPoint{
+			Timestamp: ts,
+			Time:      time.UnixMilli(ts).UTC().Format(time.RFC3339Nano),
+			Value:     value,
+		})
+	}
+	result.Truncated = len(result.Points) == opts.Count
+	return result, nil
+}
+
+// replyMap 把 RESP2 的 [k, v, k, v] 或 RESP3 的 map 转换为以字符串为键的对象
+func replyMap(v interface{}) map[string]interface{} {
+	out := map[string]interface{}{}
+	switch m := v.(type) {
+	case map[interface{}]interface{}:
+		for k, val := range m {
+			out[fmt.Sprint(k)] = val
+		}
+	case []interface{}:
+		if len(m)%2 != 0 {
+			return nil
+		}
+		for i := 0; i < len(m); i += 2 {
+			out[fmt.Sprint(m[i])] = m[i+1]
+		}
+	default:
+		return nil
+	}
+	return out
+}
+
+// replyValue 递归转换回复：键值对数组转为对象，数字字符串转为数值
+func replyValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case string:
+		if n, err := strconv.ParseInt(val, 10, 64); err == nil {
+			return n
+		}
+		// FT.INFO 的统计项可能是 -nan，JSON 不能表示，保留原字符串
+		if f, err := strconv.ParseFloat(val, 64); err == nil && !math.IsNaN(f) && !math.IsInf(f, 0) {
+			return f
+		}
+		return val
+	case map[interface{}]interface{}:
+		out := map[string]interface{}{}
+		for k, item := range val {
+			out[fmt.Sprint(k)] = replyValue(item)
+		}
+		return out
+	case []interface{}:
+		if isPairList(val) {
+			out := map[string]interface{}{}
+			for i := 0; i < len(val); i += 2 {
+				out[val[i].(string)] = replyValue(val[i+1])
+			}
+			return out
+		}
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = replyValue(item)
+		}
+		return out
+	}
+	return v
+}
+
+// isPairList 偶数长度、偶数位置都是小写标识符（如 key_type、prefixes）的数组视为键值对
+func isPairList(items []interface{}) bool {
+	if len(items) == 0 || len(items)%2 != 0 {
+		return false
+	}
+	for i := 0; i < len(items); i += 2 {
+		name, ok := items[i].(string)
+		if !ok || name == "" || strings.ToLower(name) != name || strings.ContainsAny(name, " :$.") {
+			return false
+		}
+	}
+	return true
+}
+
+// toSlice 非数组时返回 nil
+func toSlice(v interface{}) []interface{} {
+	items, _ := v.([]interface{})
+	return items
+}
+
+// replyInt 接受整数回复或数字字符串
+func replyInt(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return n, true
+	case float64:
+		return int64(n), true
+	case string:
+		i, err := strconv.ParseInt(n, 10, 64)
+		if err != nil {
+			f, ferr := strconv.ParseFloat(n, 64)
+			return int64(f), ferr == nil
+		}
+		return i, true
+	}
+	return 0, false
+}
+
+// replyFloat 接受浮点（RESP3 double）、整数或数字字符串
+func replyFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int64:
+		return float64(n), true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
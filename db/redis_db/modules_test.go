@@ -0,0 +1,243 @@
+package redis_db
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// respFixture 读取 testdata/modules 下录制的 Redis Stack 7.2 回复
+func respFixture(t *testing.T, name string) fakeRaw {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "modules", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fakeRaw(data)
+}
+
+// moduleHandler MODULE LIST 回复 modules 录制文件，其他命令按命令名查 replies，
+// 并把收到的完整命令写入 got
+func moduleHandler(t *testing.T, modules string, replies map[string]interface{}, got *[][]string) func(args []string) interface{} {
+	list := respFixture(t, modules)
+	return func(args []string) interface{} {
+		cmd := strings.ToUpper(args[0])
+		if cmd == "MODULE" {
+			return list
+		}
+		*got = append(*got, args)
+		if reply, ok := replies[cmd]; ok {
+			return reply
+		}
+		return fakeError("ERR unknown command '" + args[0] + "'")
+	}
+}
+
+func TestModules(t *testing.T) {
+	var got [][]string
+	_, client := newFakeRedis(t, moduleHandler(t, "module_list.resp", nil, &got))
+	modules, err := client.Modules(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(modules) != 4 || modules[0].Name != "ReJSON" || modules[0].Version != 20609 || modules[2].Name != "timeseries" {
+		t.Fatalf("unexpected modules: %+v", modules)
+	}
+
+	_, jsonOnly := newFakeRedis(t, moduleHandler(t, "module_list_json_only.resp", nil, &got))
+	_, err = jsonOnly.Search(context.Background(), SearchOptions{Index: "idx"})
+	if err == nil || !strings.Contains(err.Error(), "RediSearch module is not loaded (loaded: ReJSON)") {
+		t.Errorf("expected missing module error, got %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("no command should be sent without the module: %v", got)
+	}
+}
+
+func TestJSONGetSet(t *testing.T) {
+	var got [][]string
+	replies := map[string]interface{}{"JSON.GET": respFixture(t, "json_get_root.resp"), "JSON.SET": fakeStatus("OK")}
+	_, client := newFakeRedis(t, moduleHandler(t, "module_list.resp", replies, &got))
+	ctx := context.Background()
+
+	doc, err := client.JSONGet(ctx, "user:1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	matches, ok := doc.Value.([]interface{})
+	if !doc.Exists || !ok || len(matches) != 1 {
+		t.Fatalf("unexpected JSON.GET result: %+v", doc)
+	}
+	user := matches[0].(map[string]interface{})
+	if user["name"] != "Alice" || user["age"] != 30.0 || user["address"].(map[string]interface{})["zip"] != "10115" {
+		t.Errorf("document should be decoded: %v", user)
+	}
+	if strings.Join(got[0], " ") != "JSON.GET user:1 $" {
+		t.Errorf("unexpected command: %v", got[0])
+	}
+
+	replies["JSON.GET"] = respFixture(t, "json_get_paths.resp")
+	doc, err = client.JSONGet(ctx, "user:1", []string{"$.name", "$..zip"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	byPath := doc.Value.(map[string]interface{})
+	if byPath["$.name"].([]interface{})[0] != "Alice" || byPath["$..zip"].([]interface{})[0] != "10115" {
+		t.Errorf("unexpected multi-path value: %v", doc.Value)
+	}
+
+	replies["JSON.GET"] = nil
+	doc, err = client.JSONGet(ctx, "user:404", nil)
+	if err != nil || doc.Exists || doc.Value != nil {
+		t.Errorf("missing key should not exist: %+v, %v", doc, err)
+	}
+
+	set, err := client.JSONSet(ctx, JSONSetOptions{Key: "user:1", Path: "$.age", Value: "31", Condition: "xx"})
+	if err != nil || !set.Set {
+		t.Fatalf("JSON.SET failed: %+v, %v", set, err)
+	}
+	if last := got[len(got)-1]; strings.Join(last, " ") != "JSON.SET user:1 $.age 31 XX" {
+		t.Errorf("unexpected command: %v", last)
+	}
+	replies["JSON.SET"] = nil
+	set, err = client.JSONSet(ctx, JSONSetOptions{Key: "user:1", Value: `{"name":"Alice"}`, Condition: "NX"})
+	if err != nil || set.Set {
+		t.Errorf("unmet NX should report set=false: %+v, %v", set, err)
+	}
+	if _, err := client.JSONSet(ctx, JSONSetOptions{Key: "user:1", Value: "{name: Alice}"}); err == nil {
+		t.Error("expected invalid JSON to be rejected")
+	}
+}
+
+func TestSearch(t *testing.T) {
+	var got [][]string
+	replies := map[string]interface{}{"FT.SEARCH": respFixture(t, "ft_search_json.resp")}
+	_, client := newFakeRedis(t, moduleHandler(t, "module_list.resp", replies, &got))
+	ctx := context.Background()
+
+	res, err := client.Search(ctx, SearchOptions{Index: "idx:users", Query: "@age:[$min $max]", Limit: 2, Params: map[string]string{"min": "25", "max": "35"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got[0], " ") != "FT.SEARCH idx:users @age:[$min $max] LIMIT 0 2 PARAMS 4 max 35 min 25 DIALECT 2" {
+		t.Errorf("unexpected command: %v", got[0])
+	}
+	if res.Total != 3 || len(res.Documents) != 2 || !res.HasMore {
+		t.Fatalf("unexpected search result: %+v", res)
+	}
+	alice := res.Documents[0]
+	doc, ok := alice.Document.(map[string]interface{})
+	if alice.ID != "user:1" || !ok || doc["city"] != "Berlin" || alice.Fields != nil {
+		t.Errorf("JSON document should be decoded: %+v", alice)
+	}
+
+	replies["FT.SEARCH"] = respFixture(t, "ft_search_scores.resp")
+	res, err = client.Search(ctx, SearchOptions{Index: "idx:articles", Query: "redis", WithScores: true, ReturnFields: []string{"title", "views", "$.tags"}, SortBy: "views", SortDesc: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := got[len(got)-1]; strings.Join(last, " ") != "FT.SEARCH idx:articles redis WITHSCORES RETURN 3 title views $.tags SORTBY views DESC LIMIT 0 10" {
+		t.Errorf("unexpected command: %v", last)
+	}
+	article := res.Documents[0]
+	if res.HasMore || article.Score == nil || *article.Score != 2.5 || article.Fields["title"] != "Redis streams in practice" || article.Fields["views"] != "1200" {
+		t.Fatalf("unexpected scored document: %+v", article)
+	}
+	if tags, ok := article.Fields["$.tags"].([]interface{}); !ok || len(tags) != 2 {
+		t.Errorf("JSONPath field should be decoded: %#v", article.Fields["$.tags"])
+	}
+
+	replies["FT.SEARCH"] = respFixture(t, "ft_search_nocontent.resp")
+	res, err = client.Search(ctx, SearchOptions{Index: "idx:articles", NoContent: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Documents) != 2 || res.Documents[1].ID != "article:9" || res.Documents[1].Fields != nil {
+		t.Errorf("unexpected NOCONTENT result: %+v", res.Documents)
+	}
+}
+
+func TestSearchResp3(t *testing.T) {
+	result := &SearchResult{Documents: []SearchDocument{}}
+	err := parseSearchResp3(result, map[interface{}]interface{}{
+		"total_results": int64(1),
+		"results": []interface{}{map[interface{}]interface{}{
+			"id":               "user:1",
+			"score":            1.5,
+			"extra_attributes": map[interface{}]interface{}{"$": `{"name":"Alice"}`},
+			"values":           []interface{}{},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := result.Documents[0]
+	if result.Total != 1 || doc.ID != "user:1" || *doc.Score != 1.5 || doc.Document.(map[string]interface{})["name"] != "Alice" {
+		t.Errorf("unexpected RESP3 document: %+v", doc)
+	}
+}
+
+func TestSearchInfo(t *testing.T) {
+	var got [][]string
+	replies := map[string]interface{}{"FT.INFO": respFixture(t, "ft_info.resp")}
+	_, client := newFakeRedis(t, moduleHandler(t, "module_list.resp", replies, &got))
+	info, err := client.SearchInfo(context.Background(), "idx:users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.NumDocs != 2 || info.Indexing || info.PercentIndexed != 1 || info.IndexingFailures != 0 {
+		t.Errorf("unexpected counters: %+v", info)
+	}
+	if info.Definition["key_type"] != "JSON" || info.Definition["prefixes"].([]interface{})[0] != "user:" {
+		t.Errorf("unexpected definition: %v", info.Definition)
+	}
+	if len(info.Attributes) != 3 {
+		t.Fatalf("unexpected attributes: %+v", info.Attributes)
+	}
+	name, age, city := info.Attributes[0], info.Attributes[1], info.Attributes[2]
+	if name.Identifier != "$.name" || name.Attribute != "name" || name.Type != "TEXT" || name.Options["WEIGHT"] != int64(1) || strings.Join(name.Flags, ",") != "SORTABLE" {
+		t.Errorf("unexpected text attribute: %+v", name)
+	}
+	if strings.Join(age.Flags, ",") != "SORTABLE,UNF" || city.Options["SEPARATOR"] != "," {
+		t.Errorf("unexpected attributes: %+v %+v", age, city)
+	}
+	gc := info.Info["gc_stats"].(map[string]interface{})
+	if info.Info["num_terms"] != int64(4) || info.Info["number_of_uses"] != int64(3) || gc["average_cycle_time_ms"] != "-nan" {
+		t.Errorf("unexpected stats: %v", info.Info)
+	}
+	if _, err := json.Marshal(info); err != nil {
+		t.Errorf("result should be JSON encodable: %v", err)
+	}
+}
+
+func TestTSRange(t *testing.T) {
+	var got [][]string
+	replies := map[string]interface{}{"TS.REVRANGE": respFixture(t, "ts_range.resp")}
+	_, client := newFakeRedis(t, moduleHandler(t, "module_list.resp", replies, &got))
+	ctx := context.Background()
+
+	res, err := client.TSRange(ctx, TSRangeOptions{Key: "temp:berlin", From: "1700000000000", Count: 3, Aggregation: "AVG", BucketMs: 60000, Reverse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got[0], " ") != "TS.REVRANGE temp:berlin 1700000000000 + COUNT 3 AGGREGATION avg 60000" {
+		t.Errorf("unexpected command: %v", got[0])
+	}
+	if len(res.Points) != 3 || !res.Truncated || res.Aggregation != "avg" {
+		t.Fatalf("unexpected range: %+v", res)
+	}
+	p := res.Points[1]
+	if p.Timestamp != 1700000060000 || p.Value != 21.75 || p.Time != "2023-11-14T22:14:20Z" {
+		t.Errorf("unexpected point: %+v", p)
+	}
+
+	if _, err := client.TSRange(ctx, TSRangeOptions{Key: "temp:berlin", From: "yesterday"}); err == nil {
+		t.Error("expected invalid timestamp to be rejected")
+	}
+	if _, err := client.TSRange(ctx, TSRangeOptions{Key: "temp:berlin", Aggregation: "avg"}); err == nil {
+		t.Error("expected aggregation without bucket to be rejected")
+	}
+}
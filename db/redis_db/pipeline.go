@@ -0,0 +1,174 @@
+package redis_db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 管道执行模式
+const (
+	ModePipeline = "pipeline" // 普通管道，命令之间不保证原子性
+	ModeMulti    = "multi"    // MULTI/EXEC 事务
+)
+
+// disallowedInPipeline 由工具自己管理或会占用连接的命令
+var disallowedInPipeline = map[string]bool{
+	"MULTI": true, "EXEC": true, "DISCARD": true, "WATCH": true, "UNWATCH": true,
+	"SUBSCRIBE": true, "PSUBSCRIBE": true, "SSUBSCRIBE": true, "MONITOR": true,
+}
+
+// PipelineOptions redis_pipeline 参数
+type PipelineOptions struct {
+	Commands [][]interface{}
+	Mode     string
+	Watch    []string // 仅 multi 模式：EXEC 前这些键被修改时事务不会执行
+}
+
+// CommandResult 单个命令的结果
+type CommandResult struct {
+	Index   int         `json:"index"`
+	Command string      `json:"command"`
+	Result  interface{} `json:"result"`
+	Error   string      `json:"error,omitempty"`
+}
+
+// PipelineResult redis_pipeline 结果
+type PipelineResult struct {
+	Type        string          `json:"type"`
+	Mode        string          `json:"mode"`
+	Watch       []string        `json:"watch,omitempty"`
+	Commands    int             `json:"commands"`
+	Succeeded   int             `json:"succeeded"`
+	Failed      int             `json:"failed"`
+	Aborted     bool            `json:"aborted"`
+	AbortReason string          `json:"abort_reason,omitempty"`
+	Results     []CommandResult `json:"results"`
+	DurationMs  int64           `json:"duration_ms"`
+}
+
+// RunPipeline 按顺序发送一组命令，返回每个命令的结果或错误。
+// multi 模式下命令包在 MULTI/EXEC 中执行；有 WATCH 键且被其他客户端修改时整个事务不执行，结果中 aborted 为 true
+func (r *RedisClient) RunPipeline(ctx context.Context, opts PipelineOptions) (*PipelineResult, error) {
+	start := time.Now()
+	if len(opts.Commands) == 0 {
+		return nil, fmt.Errorf("no commands")
+	}
+	if opts.Mode == "" {
+		opts.Mode = ModePipeline
+	}
+	if opts.Mode != ModePipeline && opts.Mode != ModeMulti {
+		return nil, fmt.Errorf("unsupported mode: %s (supported: pipeline, multi)", opts.Mode)
+	}
+	if len(opts.Watch) > 0 && opts.Mode != ModeMulti {
+		return nil, fmt.Errorf("watch requires mode multi")
+	}
+	for i, args := range opts.Commands {
+		if len(args) == 0 {
+			return nil, fmt.Errorf("command %d is empty", i)
+		}
+		if name := strings.ToUpper(fmt.Sprint(args[0])); disallowedInPipeline[name] {
+			return nil, fmt.Errorf("command %d: %s is not allowed in redis_pipeline", i, name)
+		}
+	}
+
+	result := &PipelineResult{Type: "redis_pipeline", Mode: opts.Mode, Watch: opts.Watch, Commands: len(opts.Commands)}
+	var cmds []*redis.Cmd
+	queue := func(pipe redis.Pipeliner) error {
+		cmds = make([]*redis.Cmd, len(opts.Commands))
+		for i, args := range opts.Commands {
+			cmds[i] = pipe.Do(ctx, args...)
+		}
+		return nil
+	}
+
+	var err error
+	switch {
+	case opts.Mode == ModePipeline:
+		_, err = r.client.Pipelined(ctx, queue)
+	case len(opts.Watch) == 0:
+		_, err = r.client.TxPipelined(ctx, queue)
+	default:
+		err = r.client.Watch(ctx, func(tx *redis.Tx) error {
+			_, err := tx.TxPipelined(ctx, queue)
+			return err
+		}, opts.Watch...)
+	}
+	switch {
+	case errors.Is(err, redis.TxFailedErr):
+		result.Aborted = true
+		result.AbortReason = fmt.Sprintf("transaction aborted: a watched key (%s) was modified before EXEC, no command was executed", strings.Join(opts.Watch, ", "))
+	case opts.Mode == ModeMulti && err != nil && strings.HasPrefix(err.Error(), "EXECABORT"):
+		// 入队阶段的错误（如参数个数错误）会让服务器以 EXECABORT 拒绝整个事务
+		result.Aborted = true
+		result.AbortReason = "transaction discarded by the server because a command failed to queue (EXECABORT), no command was executed"
+	case err != nil && !isReplyError(err):
+		return nil, fmt.Errorf("pipeline failed: %w", err)
+	}
+
+	result.Results = make([]CommandResult, len(opts.Commands))
+	for i, args := range opts.Commands {
+		res := CommandResult{Index: i, Command: commandString(args)}
+		cmdErr := error(nil)
+		if cmds != nil {
+			cmdErr = cmds[i].Err()
+		}
+		switch {
+		case result.Aborted && (cmdErr == nil || cmdErr == err):
+			res.Error = "not executed"
+		case cmdErr == redis.Nil:
+			result.Succeeded++
+		case cmdErr != nil:
+			res.Error = cmdErr.Error()
+			result.Failed++
+		default:
+			res.Result = jsonValue(cmds[i].Val())
+			result.Succeeded++
+		}
+		result.Results[i] = res
+	}
+	result.DurationMs = time.Since(start).Milliseconds()
+	return result, nil
+}
+
+// commandString 命令的可读形式
+func commandString(args []interface{}) string {
+	parts := make([]string, len(args))
+	for i, a := range args {
+		parts[i] = fmt.Sprint(a)
+	}
+	return strings.Join(parts, " ")
+}
+
+// jsonValue 把 RESP3 的 map/set 等结果转换为可以序列化为 JSON 的值
+func jsonValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = jsonValue(item)
+		}
+		return out
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[fmt.Sprint(k)] = jsonValue(item)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[k] = jsonValue(item)
+		}
+		return out
+	case []byte:
+		return string(val)
+	case error:
+		return val.Error()
+	}
+	return v
+}
@@ -0,0 +1,150 @@
+package redis_db
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeTxStore 支持 GET/SET/INCR 以及 MULTI/EXEC/WATCH 的最小实现；
+// abortNext 为 true 时下一次 EXEC 按 WATCH 键被修改处理
+type fakeTxStore struct {
+	values    map[string]string
+	queue     [][]string
+	inMulti   bool
+	queueErr  bool
+	abortNext bool
+}
+
+func (s *fakeTxStore) handle(args []string) interface{} {
+	cmd := strings.ToUpper(args[0])
+	switch cmd {
+	case "WATCH", "UNWATCH":
+		return fakeStatus("OK")
+	case "MULTI":
+		s.inMulti, s.queue, s.queueErr = true, nil, false
+		return fakeStatus("OK")
+	case "EXEC":
+		s.inMulti = false
+		if s.queueErr {
+			return fakeError("EXECABORT Transaction discarded because of previous errors.")
+		}
+		if s.abortNext {
+			s.abortNext = false
+			return nil
+		}
+		replies := make([]interface{}, len(s.queue))
+		for i, queued := range s.queue {
+			replies[i] = s.exec(queued)
+		}
+		return replies
+	}
+	if s.inMulti {
+		if cmd == "SET" && len(args) != 3 {
+			s.queueErr = true
+			return fakeError("ERR wrong number of arguments for 'set' command")
+		}
+		s.queue = append(s.queue, args)
+		return fakeStatus("QUEUED")
+	}
+	return s.exec(args)
+}
+
+func (s *fakeTxStore) exec(args []string) interface{} {
+	switch strings.ToUpper(args[0]) {
+	case "SET":
+		s.values[args[1]] = args[2]
+		return fakeStatus("OK")
+	case "GET":
+		if v, ok := s.values[args[1]]; ok {
+			return v
+		}
+		return nil
+	case "INCR":
+		n := 0
+		if v, ok := s.values[args[1]]; ok {
+			var err error
+			if n, err = strconv.Atoi(v); err != nil {
+				return fakeError("ERR value is not an integer or out of range")
+			}
+		}
+		n++
+		s.values[args[1]] = strconv.Itoa(n)
+		return n
+	}
+	return fakeError("ERR unknown command '" + args[0] + "'")
+}
+
+func TestRunPipeline(t *testing.T) {
+	store := &fakeTxStore{values: map[string]string{"name": "redis"}}
+	_, client := newFakeRedis(t, store.handle)
+	ctx := context.Background()
+
+	// 管道模式：单个命令失败不影响其余命令
+	res, err := client.RunPipeline(ctx, PipelineOptions{Commands: [][]interface{}{
+		{"SET", "counter", 1},
+		{"INCR", "counter"},
+		{"INCR", "name"},
+		{"GET", "missing"},
+		{"GET", "counter"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Mode != ModePipeline || res.Succeeded != 4 || res.Failed != 1 || res.Aborted {
+		t.Fatalf("unexpected summary: %+v", res)
+	}
+	if res.Results[1].Result != int64(2) || res.Results[4].Result != "2" || res.Results[3].Result != nil {
+		t.Errorf("unexpected results: %+v", res.Results)
+	}
+	if !strings.Contains(res.Results[2].Error, "not an integer") {
+		t.Errorf("expected INCR error, got %+v", res.Results[2])
+	}
+
+	// 事务模式
+	res, err = client.RunPipeline(ctx, PipelineOptions{Mode: ModeMulti, Watch: []string{"counter"}, Commands: [][]interface{}{
+		{"INCR", "counter"},
+		{"GET", "counter"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Aborted || res.Succeeded != 2 || res.Results[0].Result != int64(3) || res.Results[1].Result != "3" {
+		t.Fatalf("unexpected multi result: %+v", res)
+	}
+
+	// WATCH 键被修改：事务不执行
+	store.abortNext = true
+	res, err = client.RunPipeline(ctx, PipelineOptions{Mode: ModeMulti, Watch: []string{"counter"}, Commands: [][]interface{}{
+		{"INCR", "counter"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Aborted || !strings.Contains(res.AbortReason, "counter") || res.Results[0].Error != "not executed" || res.Succeeded != 0 {
+		t.Fatalf("expected WATCH abort, got %+v", res)
+	}
+	if store.values["counter"] != "3" {
+		t.Errorf("aborted transaction must not change data, counter=%s", store.values["counter"])
+	}
+
+	// 入队错误：服务器以 EXECABORT 丢弃整个事务
+	res, err = client.RunPipeline(ctx, PipelineOptions{Mode: ModeMulti, Commands: [][]interface{}{
+		{"INCR", "counter"},
+		{"SET", "counter"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Aborted || res.Failed != 1 || res.Results[0].Error != "not executed" || !strings.Contains(res.Results[1].Error, "wrong number") {
+		t.Fatalf("expected EXECABORT, got %+v", res)
+	}
+
+	if _, err := client.RunPipeline(ctx, PipelineOptions{Commands: [][]interface{}{{"MULTI"}}}); err == nil {
+		t.Error("expected MULTI to be rejected")
+	}
+	if _, err := client.RunPipeline(ctx, PipelineOptions{Watch: []string{"k"}, Commands: [][]interface{}{{"GET", "k"}}}); err == nil {
+		t.Error("expected watch without multi to be rejected")
+	}
+}
@@ -0,0 +1,444 @@
+package redis_db
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 默认值
+const (
+	DefaultSubscribeDuration    = 10 * time.Second
+	DefaultSubscribeMaxMessages = 100
+	DefaultSubscribeBuffer      = 1000
+	subscribeConfirmTimeout     = 5 * time.Second
+	subscribeReadTimeout        = 200 * time.Millisecond // 每次读取的超时，用于及时响应停止和截止时间
+	FinishedSubscriptionTTL     = 10 * time.Minute       // 已结束但消息未读完的订阅在注册表中保留的时间
+)
+
+// 订阅停止原因
+const (
+	StopMaxMessages = "max_messages"
+	StopDuration    = "duration"
+	StopStopped     = "stopped"
+	StopError       = "error"
+)
+
+// SubscribeOptions redis_subscribe 参数
+type SubscribeOptions struct {
+	Channels    []string
+	Patterns    []string // PSUBSCRIBE 模式，如 __keyspace@0__:user:*
+	Duration    time.Duration
+	MaxMessages int // 收到这么多条消息后停止，<=0 不限制（仍受 Duration 限制）
+	BufferSize  int // 后台模式下缓存的未读消息数上限，超出时丢弃最早的消息
+}
+
+// PubSubMessage 收到的一条消息
+type PubSubMessage struct {
+	Seq             int64     `json:"seq"`
+	Time            time.Time `json:"time"`
+	Channel         string    `json:"channel"`
+	Pattern         string    `json:"pattern,omitempty"`
+	Payload         string    `json:"payload"`
+	PayloadEncoding string    `json:"payload_encoding,omitempty"` // 非 UTF-8 的消息以 base64 返回
+}
+
+// SubscriptionStatus 订阅状态
+type SubscriptionStatus struct {
+	ID         string    `json:"id,omitempty"`
+	Channels   []string  `json:"channels,omitempty"`
+	Patterns   []string  `json:"patterns,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	Deadline   time.Time `json:"deadline"`
+	Active     bool      `json:"active"`
+	StopReason string    `json:"stop_reason,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Received   int64     `json:"received"`
+	Buffered   int       `json:"buffered"`
+	Dropped    int64     `json:"dropped,omitempty"` // 缓存已满被丢弃的消息数
+	Notes      []string  `json:"notes,omitempty"`
+}
+
+// SubscriptionMessages 一次读取的消息及订阅状态
+type SubscriptionMessages struct {
+	Type string `json:"type"`
+	SubscriptionStatus
+	Messages []PubSubMessage `json:"messages"`
+}
+
+// Subscription 在后台接收消息的订阅，消息缓存在内存中直到被 Poll 读取
+type Subscription struct {
+	ID string
+
+	opts   SubscribeOptions
+	ps     *redis.PubSub
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu       sync.Mutex
+	notify   chan struct{} // 有新消息或订阅结束时关闭并替换，用于 Poll 等待
+	buf      []PubSubMessage
+	status   SubscriptionStatus
+	received int64
+	finished time.Time
+}
+
+// Subscribe 订阅频道和模式并在后台接收消息，确认订阅成功后返回；
+// 订阅在到达 Duration、收到 MaxMessages 条消息或调用 Stop 后结束
+func (r *RedisClient) Subscribe(ctx context.Context, opts SubscribeOptions) (*Subscription, error) {
+	if len(opts.Channels) == 0 && len(opts.Patterns) == 0 {
+		return nil, fmt.Errorf("channels or patterns are required")
+	}
+	if opts.Duration <= 0 {
+		opts.Duration = DefaultSubscribeDuration
+	}
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = DefaultSubscribeBuffer
+	}
+	if opts.MaxMessages > 0 && opts.BufferSize < opts.MaxMessages {
+		opts.BufferSize = opts.MaxMessages
+	}
+
+	ps := r.client.Subscribe(ctx)
+	if len(opts.Channels) > 0 {
+		if err := ps.Subscribe(ctx, opts.Channels...); err != nil {
+			ps.Close()
+			return nil, fmt.Errorf("SUBSCRIBE failed: %w", err)
+		}
+	}
+	if len(opts.Patterns) > 0 {
+		if err := ps.PSubscribe(ctx, opts.Patterns...); err != nil {
+			ps.Close()
+			return nil, fmt.Errorf("PSUBSCRIBE failed: %w", err)
+		}
+	}
+
+	now := time.Now()
+	s := &Subscription{
+		opts:   opts,
+		ps:     ps,
+		done:   make(chan struct{}),
+		notify: make(chan struct{}),
+		status: SubscriptionStatus{
+			Channels:  opts.Channels,
+			Patterns:  opts.Patterns,
+			StartedAt: now,
+			Deadline:  now.Add(opts.Duration),
+			Active:    true,
+		},
+	}
+	s.status.Notes = r.keyspaceNotes(ctx, opts)
+
+	// 等待每个频道和模式的订阅确认；确认前收到的消息同样保留，也计入 MaxMessages
+	full := false
+	pending := len(opts.Channels) + len(opts.Patterns)
+	confirmDeadline := time.Now().Add(subscribeConfirmTimeout)
+	for pending > 0 {
+		msg, err := ps.ReceiveTimeout(ctx, time.Until(confirmDeadline))
+		if err != nil {
+			ps.Close()
+			return nil, fmt.Errorf("subscription was not confirmed: %w", err)
+		}
+		switch m := msg.(type) {
+		case *redis.Subscription:
+			if m.Kind == "subscribe" || m.Kind == "psubscribe" {
+				pending--
+			}
+		case *redis.Message:
+			if s.add(m) {
+				full = true
+			}
+		}
+	}
+
+	runCtx, cancel := context.WithDeadline(context.Background(), s.status.Deadline)
+	s.cancel = cancel
+	if full {
+		cancel()
+		ps.Close()
+		s.finish(StopMaxMessages, "")
+		close(s.done)
+		return s, nil
+	}
+	go s.run(runCtx)
+	return s, nil
+}
+
+// keyspaceNotes 订阅键空间通知但服务器未开启 notify-keyspace-events 时给出提示
+func (r *RedisClient) keyspaceNotes(ctx context.Context, opts SubscribeOptions) []string {
+	keyspace := false
+	for _, name := range append(append([]string(nil), opts.Channels...), opts.Patterns...) {
+		if strings.HasPrefix(name, "__keyspace@") || strings.HasPrefix(name, "__keyevent@") {
+			keyspace = true
+		}
+	}
+	if !keyspace {
+		return nil
+	}
+	cfg, err := r.client.ConfigGet(ctx, "notify-keyspace-events").Result()
+	if err != nil {
+		return []string{"could not read notify-keyspace-events: " + err.Error()}
+	}
+	if cfg["notify-keyspace-events"] == "" {
+		return []string{"notify-keyspace-events is empty, the server publishes no keyspace notifications (enable with CONFIG SET notify-keyspace-events KEA)"}
+	}
+	return nil
+}
+
+func (s *Subscription) run(ctx context.Context) {
+	defer close(s.done)
+	defer s.ps.Close()
+	reason, errText := StopDuration, ""
+	for {
+		select {
+		case <-ctx.Done():
+			if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				reason = StopStopped
+			}
+			s.finish(reason, errText)
+			return
+		default:
+		}
+		msg, err := s.ps.ReceiveTimeout(ctx, subscribeReadTimeout)
+		if err != nil {
+			var netErr net.Error
+			if (errors.As(err, &netErr) && netErr.Timeout()) || ctx.Err() != nil {
+				continue
+			}
+			s.finish(StopError, err.Error())
+			return
+		}
+		if m, ok := msg.(*redis.Message); ok {
+			if s.add(m) {
+				s.finish(StopMaxMessages, "")
+				return
+			}
+		}
+	}
+}
+
+// add 缓存一条消息，返回是否已达到 MaxMessages；达到后到达的消息被忽略
+func (s *Subscription) add(m *redis.Message) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.opts.MaxMessages > 0 && s.received >= int64(s.opts.MaxMessages) {
+		return true
+	}
+	s.received++
+	msg := PubSubMessage{Seq: s.received, Time: time.Now(), Channel: m.Channel, Pattern: m.Pattern, Payload: m.Payload}
+	if !utf8.ValidString(m.Payload) {
+		msg.Payload = base64.StdEncoding.EncodeToString([]byte(m.Payload))
+		msg.PayloadEncoding = "base64"
+	}
+	if len(s.buf) >= s.opts.BufferSize {
+		s.buf = s.buf[1:]
+		s.status.Dropped++
+	}
+	s.buf = append(s.buf, msg)
+	s.wake()
+	return s.opts.MaxMessages > 0 && s.received >= int64(s.opts.MaxMessages)
+}
+
+func (s *Subscription) finish(reason, errText string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.status.Active {
+		return
+	}
+	s.status.Active = false
+	s.status.StopReason = reason
+	s.status.Error = errText
+	s.finished = time.Now()
+	s.wake()
+}
+
+// finishedBefore 订阅是否在 t 之前已经结束
+func (s *Subscription) finishedBefore(t time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.status.Active && s.finished.Before(t)
+}
+
+// wake 唤醒等待中的 Poll，调用时需持有 mu
+func (s *Subscription) wake() {
+	close(s.notify)
+	s.notify = make(chan struct{})
+}
+
+// Poll 取出最多 max 条未读消息（<=0 取出全部）；没有未读消息且订阅仍在进行时最多等待 wait
+func (s *Subscription) Poll(ctx context.Context, max int, wait time.Duration) *SubscriptionMessages {
+	s.mu.Lock()
+	if len(s.buf) == 0 && s.status.Active && wait > 0 {
+		notify := s.notify
+		s.mu.Unlock()
+		timer := time.NewTimer(wait)
+		select {
+		case <-notify:
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+		timer.Stop()
+		s.mu.Lock()
+	}
+	defer s.mu.Unlock()
+	n := len(s.buf)
+	if max > 0 && max < n {
+		n = max
+	}
+	messages := append([]PubSubMessage{}, s.buf[:n]...)
+	s.buf = s.buf[n:]
+	return &SubscriptionMessages{Type: "redis_subscribe", SubscriptionStatus: s.statusLocked(), Messages: messages}
+}
+
+// Status 返回订阅状态
+func (s *Subscription) Status() SubscriptionStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.statusLocked()
+}
+
+func (s *Subscription) statusLocked() SubscriptionStatus {
+	status := s.status
+	status.ID = s.ID
+	status.Received = s.received
+	status.Buffered = len(s.buf)
+	return status
+}
+
+// Wait 等待订阅结束；ctx 取消时停止订阅
+func (s *Subscription) Wait(ctx context.Context) {
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+		s.Stop()
+	}
+}
+
+// Stop 取消订阅并等待后台接收结束，未读消息仍可通过 Poll 读取
+func (s *Subscription) Stop() {
+	s.cancel()
+	<-s.done
+}
+
+// Capture 订阅并阻塞到结束条件满足，返回期间收到的所有消息
+func (r *RedisClient) Capture(ctx context.Context, opts SubscribeOptions) (*SubscriptionMessages, error) {
+	if opts.MaxMessages <= 0 {
+		opts.MaxMessages = DefaultSubscribeMaxMessages
+	}
+	s, err := r.Subscribe(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	s.Wait(ctx)
+	return s.Poll(ctx, 0, 0), nil
+}
+
+// SubscriptionRegistry 后台订阅，按 ID 查找。已结束的订阅在消息被读完后移除，
+// 未读完的在结束 ttl 之后移除
+type SubscriptionRegistry struct {
+	mu   sync.Mutex
+	next int
+	subs map[string]*Subscription
+	ttl  time.Duration
+}
+
+// NewSubscriptionRegistry 创建空的订阅注册表
+func NewSubscriptionRegistry() *SubscriptionRegistry {
+	return &SubscriptionRegistry{subs: make(map[string]*Subscription), ttl: FinishedSubscriptionTTL}
+}
+
+// pruneLocked 移除结束超过 ttl 的订阅，调用时需持有 mu
+func (g *SubscriptionRegistry) pruneLocked() {
+	cutoff := time.Now().Add(-g.ttl)
+	for id, s := range g.subs {
+		if s.finishedBefore(cutoff) {
+			delete(g.subs, id)
+		}
+	}
+}
+
+// Add 登记订阅并分配 ID
+func (g *SubscriptionRegistry) Add(s *Subscription) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.pruneLocked()
+	g.next++
+	s.ID = fmt.Sprintf("sub-%d", g.next)
+	g.subs[s.ID] = s
+	return s.ID
+}
+
+// Get 按 ID 查找订阅
+func (g *SubscriptionRegistry) Get(id string) (*Subscription, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.pruneLocked()
+	s, ok := g.subs[id]
+	return s, ok
+}
+
+// Poll 读取订阅的未读消息（参数同 Subscription.Poll）；订阅已结束且消息全部读完后从注册表中移除
+func (g *SubscriptionRegistry) Poll(ctx context.Context, id string, max int, wait time.Duration) (*SubscriptionMessages, bool) {
+	s, ok := g.Get(id)
+	if !ok {
+		return nil, false
+	}
+	result := s.Poll(ctx, max, wait)
+	if !result.Active && result.Buffered == 0 {
+		g.mu.Lock()
+		if g.subs[id] == s {
+			delete(g.subs, id)
+		}
+		g.mu.Unlock()
+	}
+	return result, true
+}
+
+// Remove 停止并移除订阅
+func (g *SubscriptionRegistry) Remove(id string) (*Subscription, bool) {
+	g.mu.Lock()
+	s, ok := g.subs[id]
+	delete(g.subs, id)
+	g.mu.Unlock()
+	if ok {
+		s.Stop()
+	}
+	return s, ok
+}
+
+// List 按 ID 顺序返回所有订阅的状态
+func (g *SubscriptionRegistry) List() []SubscriptionStatus {
+	g.mu.Lock()
+	g.pruneLocked()
+	subs := make([]*Subscription, 0, len(g.subs))
+	for _, s := range g.subs {
+		subs = append(subs, s)
+	}
+	g.mu.Unlock()
+	sort.Slice(subs, func(i, j int) bool { return subs[i].status.StartedAt.Before(subs[j].status.StartedAt) })
+	list := make([]SubscriptionStatus, len(subs))
+	for i, s := range subs {
+		list[i] = s.Status()
+	}
+	return list
+}
+
+// CloseAll 停止并移除所有订阅
+func (g *SubscriptionRegistry) CloseAll() {
+	g.mu.Lock()
+	subs := g.subs
+	g.subs = make(map[string]*Subscription)
+	g.mu.Unlock()
+	for _, s := range subs {
+		s.Stop()
+	}
+}
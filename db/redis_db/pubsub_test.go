@@ -0,0 +1,177 @@
+package redis_db
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// waitReceived 等待订阅收到 n 条消息
+func waitReceived(t *testing.T, s *Subscription, n int64) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for s.Status().Received < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d messages, status %+v", n, s.Status())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	ks := newFakeKeyspace(map[string]*fakeKey{})
+	ks.config = map[string]string{"notify-keyspace-events": "KA"}
+	_, client := newFakeRedis(t, ks.handle)
+	ctx := context.Background()
+
+	sub, err := client.Subscribe(ctx, SubscribeOptions{
+		Channels:    []string{"orders"},
+		Patterns:    []string{"__keyspace@0__:user:*"},
+		Duration:    5 * time.Second,
+		MaxMessages: 3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Stop()
+	if len(sub.Status().Notes) != 0 {
+		t.Errorf("keyspace notifications are enabled, unexpected notes: %v", sub.Status().Notes)
+	}
+
+	publish := func(channel, payload string) {
+		if _, err := client.ExecuteCommand(ctx, []interface{}{"PUBLISH", channel, payload}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	publish("orders", "order-1")
+	publish("__keyspace@0__:user:1", "set")
+	publish("other", "ignored")
+	waitReceived(t, sub, 2)
+
+	got := sub.Poll(ctx, 1, 0)
+	if len(got.Messages) != 1 || got.Messages[0].Channel != "orders" || got.Messages[0].Payload != "order-1" || got.Messages[0].Seq != 1 {
+		t.Fatalf("unexpected first poll: %+v", got.Messages)
+	}
+	if got.Messages[0].Time.IsZero() || !got.Active || got.Buffered != 1 {
+		t.Errorf("unexpected status: %+v", got.SubscriptionStatus)
+	}
+	got = sub.Poll(ctx, 0, 0)
+	if len(got.Messages) != 1 || got.Messages[0].Pattern != "__keyspace@0__:user:*" || got.Messages[0].Channel != "__keyspace@0__:user:1" {
+		t.Fatalf("unexpected pattern message: %+v", got.Messages)
+	}
+
+	// Poll 等待新消息；第三条消息达到 MaxMessages 后订阅结束
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		publish("orders", "\xff\xfe")
+	}()
+	got = sub.Poll(ctx, 0, 2*time.Second)
+	if len(got.Messages) != 1 || got.Messages[0].PayloadEncoding != "base64" {
+		t.Fatalf("expected a base64 message, got %+v", got.Messages)
+	}
+	sub.Wait(ctx)
+	if status := sub.Status(); status.Active || status.StopReason != StopMaxMessages || status.Received != 3 {
+		t.Errorf("expected max_messages stop, got %+v", status)
+	}
+}
+
+func TestSubscribeBufferAndDuration(t *testing.T) {
+	ks := newFakeKeyspace(map[string]*fakeKey{})
+	ks.config = map[string]string{"notify-keyspace-events": ""}
+	_, client := newFakeRedis(t, ks.handle)
+	ctx := context.Background()
+
+	registry := NewSubscriptionRegistry()
+	defer registry.CloseAll()
+	sub, err := client.Subscribe(ctx, SubscribeOptions{Channels: []string{"events"}, Duration: 5 * time.Second, BufferSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := registry.Add(sub)
+	for _, payload := range []string{"a", "b", "c"} {
+		if _, err := client.ExecuteCommand(ctx, []interface{}{"PUBLISH", "events", payload}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitReceived(t, sub, 3)
+	got := sub.Poll(ctx, 0, 0)
+	if len(got.Messages) != 2 || got.Messages[0].Payload != "b" || got.Dropped != 1 {
+		t.Fatalf("expected oldest message dropped, got %+v", got)
+	}
+	if list := registry.List(); len(list) != 1 || list[0].ID != id || !list[0].Active {
+		t.Fatalf("unexpected registry list: %+v", list)
+	}
+	if _, ok := registry.Remove(id); !ok {
+		t.Fatal("subscription not found")
+	}
+	if status := sub.Status(); status.Active || status.StopReason != StopStopped {
+		t.Errorf("expected stopped subscription, got %+v", status)
+	}
+
+	// 阻塞模式：到达时长后返回，键空间通知未开启时给出提示
+	start := time.Now()
+	res, err := client.Capture(ctx, SubscribeOptions{Patterns: []string{"__keyevent@0__:*"}, Duration: 300 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) < 300*time.Millisecond || res.StopReason != StopDuration || len(res.Messages) != 0 {
+		t.Errorf("unexpected capture result: %+v", res)
+	}
+	if len(res.Notes) != 1 || !strings.Contains(res.Notes[0], "notify-keyspace-events") {
+		t.Errorf("expected keyspace notification note, got %v", res.Notes)
+	}
+}
+
+func TestSubscriptionMaxMessagesAndEviction(t *testing.T) {
+	// 确认订阅期间和接收循环中到达的消息都受 MaxMessages 限制
+	s := &Subscription{opts: SubscribeOptions{MaxMessages: 2, BufferSize: 10}, notify: make(chan struct{}), status: SubscriptionStatus{Active: true}}
+	full := false
+	for _, payload := range []string{"a", "b", "c"} {
+		full = s.add(&redis.Message{Channel: "events", Payload: payload})
+	}
+	if status := s.Status(); !full || status.Received != 2 || status.Buffered != 2 {
+		t.Fatalf("expected messages beyond the limit to be ignored, got %+v", status)
+	}
+
+	ks := newFakeKeyspace(map[string]*fakeKey{})
+	_, client := newFakeRedis(t, ks.handle)
+	ctx := context.Background()
+	registry := NewSubscriptionRegistry()
+	defer registry.CloseAll()
+
+	// 结束后消息读完即从注册表移除
+	sub, err := client.Subscribe(ctx, SubscribeOptions{Channels: []string{"events"}, Duration: 5 * time.Second, MaxMessages: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := registry.Add(sub)
+	if _, err := client.ExecuteCommand(ctx, []interface{}{"PUBLISH", "events", "x"}); err != nil {
+		t.Fatal(err)
+	}
+	sub.Wait(ctx)
+	if got, ok := registry.Poll(ctx, id, 0, 0); !ok || len(got.Messages) != 1 || got.Active {
+		t.Fatalf("unexpected poll: %+v", got)
+	}
+	if _, ok := registry.Get(id); ok {
+		t.Fatal("drained subscription should be removed")
+	}
+
+	// 未读完的订阅在结束 ttl 之后移除
+	registry.ttl = 10 * time.Millisecond
+	sub, err = client.Subscribe(ctx, SubscribeOptions{Channels: []string{"events"}, Duration: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id = registry.Add(sub)
+	sub.Stop()
+	time.Sleep(20 * time.Millisecond)
+	if list := registry.List(); len(list) != 0 {
+		t.Fatalf("expired subscription still listed: %+v", list)
+	}
+	if _, ok := registry.Get(id); ok {
+		t.Fatal("expired subscription should be removed")
+	}
+}
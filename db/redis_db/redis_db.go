@@ -3,49 +3,230 @@ package redis_db
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
+	"net"
+	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
 // RedisConfig Redis配置结构
 type RedisConfig struct {
-	Addr                  string `json:"addr"`
-	Password              string `json:"password"`
-	DB                    int    `json:"db"`
-	SSLInsecureSkipVerify *bool  `json:"ssl_insecure_skip_verify,omitempty"`
+	Addr                  string        `json:"addr"`
+	Password              string        `json:"password"`
+	DB                    int           `json:"db"`
+	SSLInsecureSkipVerify *bool         `json:"ssl_insecure_skip_verify,omitempty"`
+	Mode                  string        `json:"mode,omitempty"`              // standalone（默认）/ cluster / sentinel
+	Addrs                 []string      `json:"addrs,omitempty"`             // cluster 的种子节点或 sentinel 地址，为空时使用 Addr
+	MasterName            string        `json:"master_name,omitempty"`       // sentinel 监控的主节点名称
+	SentinelPassword      string        `json:"sentinel_password,omitempty"` // 连接 sentinel 的密码
+	URL                   string        `json:"url,omitempty"`               // redis:// 或 rediss:// 连接串，其余字段非零时覆盖连接串中的值
+	Username              string        `json:"username,omitempty"`          // Redis 6 ACL 用户名
+	TLS                   bool          `json:"tls,omitempty"`               // 启用 TLS 并校验服务器证书
+	CAFile                string        `json:"tls_ca_file,omitempty"`       // 校验服务器证书的 CA（PEM），为空时使用系统根证书
+	CertFile              string        `json:"tls_cert_file,omitempty"`     // 客户端证书（PEM）
+	KeyFile               string        `json:"tls_key_file,omitempty"`      // 客户端私钥（PEM）
+	ServerName            string        `json:"tls_server_name,omitempty"`   // 校验证书时使用的服务器名，默认取地址中的主机名，集群和哨兵默认取各节点的地址
+	MinTLSVersion         string        `json:"tls_min_version,omitempty"`   // 1.0 / 1.1 / 1.2 / 1.3，默认 1.2
+	Protocol              int           `json:"protocol,omitempty"`          // RESP 协议版本 2 或 3，0 使用 go-redis 默认值
+	DialTimeout           time.Duration `json:"dial_timeout,omitempty"`
+	ReadTimeout           time.Duration `json:"read_timeout,omitempty"`
+	WriteTimeout          time.Duration `json:"write_timeout,omitempty"`
+	PoolSize              int           `json:"pool_size,omitempty"` // 每个节点的连接池大小，0 使用 go-redis 默认值
 }
 
 // RedisClient Redis客户端包装器
 type RedisClient struct {
-	client *redis.Client
+	client redis.UniversalClient
 	config RedisConfig
+	tls    *tls.Config
 }
 
-// NewRedisClient 创建新的Redis客户端
-func NewRedisClient(config RedisConfig) *RedisClient {
-	options := &redis.Options{
-		Addr:     config.Addr,
-		Password: config.Password,
-		DB:       config.DB,
+// NewRedisClient 创建新的Redis客户端，按 Mode 创建单节点、集群或哨兵客户端
+func NewRedisClient(config RedisConfig) (*RedisClient, error) {
+	opt, err := config.options()
+	if err != nil {
+		return nil, err
+	}
+	// 记录合并连接串之后的实际地址、数据库和用户名
+	config.Addr, config.DB, config.Username = opt.Addr, opt.DB, opt.Username
+	if err := config.Validate(); err != nil {
+		return nil, err
 	}
+	r := &RedisClient{config: config, tls: opt.TLSConfig}
 
-	// 配置 TLS，如果指定了 ssl_insecure_skip_verify 为 true 时跳过SSL验证
-	if config.SSLInsecureSkipVerify != nil && *config.SSLInsecureSkipVerify == true {
-		options.TLSConfig = &tls.Config{
-			InsecureSkipVerify: true,
+	switch config.Mode {
+	case ModeCluster:
+		if opt.DB != 0 {
+			return nil, fmt.Errorf("redis cluster only supports db 0")
 		}
+		r.client = redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:        config.seedAddrs(),
+			Username:     opt.Username,
+			Password:     opt.Password,
+			Protocol:     opt.Protocol,
+			DialTimeout:  opt.DialTimeout,
+			ReadTimeout:  opt.ReadTimeout,
+			WriteTimeout: opt.WriteTimeout,
+			PoolSize:     opt.PoolSize,
+			TLSConfig:    opt.TLSConfig,
+		})
+	case ModeSentinel:
+		r.client = redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       config.MasterName,
+			SentinelAddrs:    config.seedAddrs(),
+			SentinelPassword: config.SentinelPassword,
+			Username:         opt.Username,
+			Password:         opt.Password,
+			DB:               opt.DB,
+			Protocol:         opt.Protocol,
+			DialTimeout:      opt.DialTimeout,
+			ReadTimeout:      opt.ReadTimeout,
+			WriteTimeout:     opt.WriteTimeout,
+			PoolSize:         opt.PoolSize,
+			TLSConfig:        opt.TLSConfig,
+		})
+	default:
+		r.client = redis.NewClient(opt)
+	}
+	return r, nil
+}
+
+// options 解析连接串并用非零字段覆盖，得到单节点连接参数；集群和哨兵模式复用其中的认证、超时和 TLS 设置
+func (c RedisConfig) options() (*redis.Options, error) {
+	opt := &redis.Options{}
+	if c.URL != "" {
+		var err error
+		if opt, err = redis.ParseURL(c.URL); err != nil {
+			return nil, fmt.Errorf("invalid redis url: %w", err)
+		}
+	}
+	if c.Addr != "" {
+		opt.Addr = c.Addr
+	}
+	if c.Username != "" {
+		opt.Username = c.Username
+	}
+	if c.Password != "" {
+		opt.Password = c.Password
+	}
+	if c.DB != 0 {
+		opt.DB = c.DB
+	}
+	switch c.Protocol {
+	case 0:
+	case 2, 3:
+		opt.Protocol = c.Protocol
+	default:
+		return nil, fmt.Errorf("unsupported RESP protocol %d: expected 2 or 3", c.Protocol)
+	}
+	if c.DialTimeout < 0 || c.ReadTimeout < 0 || c.WriteTimeout < 0 || c.PoolSize < 0 {
+		return nil, fmt.Errorf("timeouts and pool size must not be negative")
+	}
+	if c.DialTimeout > 0 {
+		opt.DialTimeout = c.DialTimeout
+	}
+	if c.ReadTimeout > 0 {
+		opt.ReadTimeout = c.ReadTimeout
+	}
+	if c.WriteTimeout > 0 {
+		opt.WriteTimeout = c.WriteTimeout
+	}
+	if c.PoolSize > 0 {
+		opt.PoolSize = c.PoolSize
 	}
 
-	rdb := redis.NewClient(options)
+	tlsConfig, err := c.tlsConfig(opt.TLSConfig, opt.Addr)
+	if err != nil {
+		return nil, err
+	}
+	opt.TLSConfig = tlsConfig
+	return opt, nil
+}
 
-	return &RedisClient{
-		client: rdb,
-		config: config,
+// tlsConfig 在 rediss:// 连接串、tls 为 true、指定了证书文件，或 ssl_insecure_skip_verify 为 true 时启用 TLS
+func (c RedisConfig) tlsConfig(base *tls.Config, addr string) (*tls.Config, error) {
+	skipVerify := c.SSLInsecureSkipVerify != nil && *c.SSLInsecureSkipVerify
+	if base == nil && !c.TLS && !skipVerify && c.CAFile == "" && c.CertFile == "" && c.KeyFile == "" {
+		return nil, nil
+	}
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if base != nil {
+		cfg = base.Clone()
 	}
+	cfg.InsecureSkipVerify = skipVerify
+
+	switch {
+	case c.ServerName != "":
+		cfg.ServerName = c.ServerName
+	case c.Mode == ModeCluster || c.Mode == ModeSentinel:
+		// 集群和哨兵会连接多个节点，清空 rediss:// 连接串中的主机名，交给 crypto/tls 按各自的拨号地址校验
+		cfg.ServerName = ""
+	case cfg.ServerName == "":
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			cfg.ServerName = host
+		}
+	}
+	if c.MinTLSVersion != "" {
+		version, err := tlsVersion(c.MinTLSVersion)
+		if err != nil {
+			return nil, err
+		}
+		cfg.MinVersion = version
+	}
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in CA file %s", c.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if c.CertFile != "" || c.KeyFile != "" {
+		if c.CertFile == "" || c.KeyFile == "" {
+			return nil, fmt.Errorf("tls_cert_file and tls_key_file must be given together")
+		}
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// tlsVersion 解析 "1.2"、"TLS1.2"、"tls12" 等写法
+func tlsVersion(s string) (uint16, error) {
+	v := strings.NewReplacer("tls", "", "TLS", "", "v", "", " ", "", "_", ".").Replace(s)
+	switch v {
+	case "1.0", "10":
+		return tls.VersionTLS10, nil
+	case "1.1", "11":
+		return tls.VersionTLS11, nil
+	case "1.2", "12":
+		return tls.VersionTLS12, nil
+	case "1.3", "13":
+		return tls.VersionTLS13, nil
+	}
+	return 0, fmt.Errorf("unsupported TLS version %q: expected 1.0, 1.1, 1.2 or 1.3", s)
+}
+
+// Config 返回合并连接串之后的连接配置
+func (r *RedisClient) Config() RedisConfig {
+	return r.config
+}
+
+// TLSEnabled 连接是否使用 TLS
+func (r *RedisClient) TLSEnabled() bool {
+	return r.tls != nil
 }
 
 // Close 关闭Redis连接
@@ -73,9 +254,9 @@ func (r *RedisClient) ExecuteCommand(ctx context.Context, cmdArgs []interface{})
 	return result, nil
 }
 
-// ExecuteLuaScript 执行Lua脚本
+// ExecuteLuaScript 执行Lua脚本，先以 EVALSHA 发送 SHA，服务器缓存中没有时再用 EVAL 发送脚本内容
 func (r *RedisClient) ExecuteLuaScript(ctx context.Context, script string, keys []string, args []interface{}) (interface{}, error) {
-	cmd := r.client.Eval(ctx, script, keys, args...)
+	cmd := redis.NewScript(script).Run(ctx, r.client, keys, args...)
 	result, err := cmd.Result()
 	if err != nil {
 		return nil, fmt.Errorf("lua script execution failed: %w", err)
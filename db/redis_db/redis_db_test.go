@@ -2,7 +2,22 @@ package redis_db
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
 )
 
 // 测试用的Redis配置
@@ -13,7 +28,10 @@ var testConfig = RedisConfig{
 }
 
 func TestNewRedisClient(t *testing.T) {
-	client := NewRedisClient(testConfig)
+	client, err := NewRedisClient(testConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
 	defer client.Close()
 
 	ctx := context.Background()
@@ -25,7 +43,10 @@ func TestNewRedisClient(t *testing.T) {
 }
 
 func TestExecuteCommand(t *testing.T) {
-	client := NewRedisClient(testConfig)
+	client, err := NewRedisClient(testConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
 	defer client.Close()
 
 	ctx := context.Background()
@@ -69,7 +90,10 @@ func TestExecuteCommand(t *testing.T) {
 }
 
 func TestExecuteLuaScript(t *testing.T) {
-	client := NewRedisClient(testConfig)
+	client, err := NewRedisClient(testConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
 	defer client.Close()
 
 	ctx := context.Background()
@@ -204,7 +228,10 @@ func TestSSLInsecureSkipVerifyConfig(t *testing.T) {
 			SSLInsecureSkipVerify: nil, // 默认不设置
 		}
 
-		client := NewRedisClient(config)
+		client, err := NewRedisClient(config)
+		if err != nil {
+			t.Fatal(err)
+		}
 		defer client.Close()
 
 		// 验证客户端创建成功（TLS 配置为 nil）
@@ -223,7 +250,10 @@ func TestSSLInsecureSkipVerifyConfig(t *testing.T) {
 			SSLInsecureSkipVerify: &skipVerify, // 设置为 true 时启用跳过验证
 		}
 
-		client := NewRedisClient(config)
+		client, err := NewRedisClient(config)
+		if err != nil {
+			t.Fatal(err)
+		}
 		defer client.Close()
 
 		// 验证客户端创建成功（TLS 配置应该存在且 InsecureSkipVerify=true）
@@ -242,7 +272,10 @@ func TestSSLInsecureSkipVerifyConfig(t *testing.T) {
 			SSLInsecureSkipVerify: &skipVerify, // 设置为 false 时不启用跳过验证
 		}
 
-		client := NewRedisClient(config)
+		client, err := NewRedisClient(config)
+		if err != nil {
+			t.Fatal(err)
+		}
 		defer client.Close()
 
 		// 验证客户端创建成功（TLS 配置应该为 nil）
@@ -252,3 +285,167 @@ func TestSSLInsecureSkipVerifyConfig(t *testing.T) {
 		t.Log("SSL skip verify disabled test passed")
 	})
 }
+
+// writeTestCert 生成同时可用作服务器证书和客户端证书的自签名证书（127.0.0.1 / redis.test），写入 PEM 文件
+func writeTestCert(t *testing.T) (certFile, keyFile string, pair tls.Certificate, pool *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "redis.test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		DNSNames:              []string{"redis.test"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, certPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if pair, err = tls.X509KeyPair(certPEM, keyPEM); err != nil {
+		t.Fatal(err)
+	}
+	pool = x509.NewCertPool()
+	pool.AppendCertsFromPEM(certPEM)
+	return certFile, keyFile, pair, pool
+}
+
+func TestTLSConnection(t *testing.T) {
+	certFile, keyFile, pair, pool := writeTestCert(t)
+	srv := startFakeTLSRedis(t, &tls.Config{
+		Certificates: []tls.Certificate{pair},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+		MaxVersion:   tls.VersionTLS12,
+	}, func(args []string) interface{} { return nil })
+	ctx := context.Background()
+
+	connect := func(config RedisConfig) error {
+		client, err := NewRedisClient(config)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+		return client.Ping(ctx)
+	}
+	base := RedisConfig{Addr: srv.addr(), TLS: true, CAFile: certFile, CertFile: certFile, KeyFile: keyFile, ServerName: "redis.test", DialTimeout: time.Second}
+
+	// 校验服务器证书并出示客户端证书
+	if err := connect(base); err != nil {
+		t.Fatalf("verified TLS connection failed: %v", err)
+	}
+	// 默认服务器名取地址中的主机名，证书包含 127.0.0.1
+	noName := base
+	noName.ServerName = ""
+	if err := connect(noName); err != nil {
+		t.Fatalf("TLS with address host name failed: %v", err)
+	}
+
+	failures := map[string]func(c *RedisConfig){
+		"unknown CA":          func(c *RedisConfig) { c.CAFile = "" },
+		"wrong server name":   func(c *RedisConfig) { c.ServerName = "other.test" },
+		"no client cert":      func(c *RedisConfig) { c.CertFile, c.KeyFile = "", "" },
+		"min version too new": func(c *RedisConfig) { c.MinTLSVersion = "1.3" },
+	}
+	for name, mutate := range failures {
+		config := base
+		mutate(&config)
+		if err := connect(config); err == nil {
+			t.Errorf("%s: expected connection to fail", name)
+		}
+	}
+
+	for name, config := range map[string]RedisConfig{
+		"cert without key": {Addr: srv.addr(), CertFile: certFile},
+		"bad min version":  {Addr: srv.addr(), TLS: true, MinTLSVersion: "2.0"},
+		"missing CA file":  {Addr: srv.addr(), TLS: true, CAFile: filepath.Join(t.TempDir(), "missing.pem")},
+		"bad protocol":     {Addr: srv.addr(), Protocol: 4},
+	} {
+		if _, err := NewRedisClient(config); err == nil {
+			t.Errorf("%s: expected configuration error", name)
+		}
+	}
+}
+
+func TestConnectionURL(t *testing.T) {
+	srv := startFakeRedis(t, func(args []string) interface{} { return nil })
+	url := "redis://app:secret@" + srv.addr() + "/2?protocol=2&dial_timeout=2s&read_timeout=4s&pool_size=3"
+
+	client, err := NewRedisClient(RedisConfig{URL: url, WriteTimeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	opt := client.client.(*redis.Client).Options()
+	if opt.Addr != srv.addr() || opt.Username != "app" || opt.Password != "secret" || opt.DB != 2 || opt.Protocol != 2 ||
+		opt.DialTimeout != 2*time.Second || opt.ReadTimeout != 4*time.Second || opt.WriteTimeout != 5*time.Second || opt.PoolSize != 3 {
+		t.Fatalf("URL not applied: %+v", opt)
+	}
+	if client.TLSEnabled() || client.Config().Addr != srv.addr() || client.Config().DB != 2 {
+		t.Errorf("unexpected resolved config: %+v", client.Config())
+	}
+	if err := client.Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// RESP2 下以 AUTH <username> <password> 认证 ACL 用户
+	srv.mu.Lock()
+	auth := srv.auth
+	srv.mu.Unlock()
+	if len(auth) == 0 || strings.Join(auth[0], " ") != "app secret" {
+		t.Errorf("expected ACL AUTH, got %v", auth)
+	}
+
+	// 显式参数覆盖连接串；rediss:// 启用 TLS 并以主机名作为服务器名
+	client, err = NewRedisClient(RedisConfig{URL: "rediss://cache.internal:6380/1", Username: "ops", DB: 3, MinTLSVersion: "TLS1.3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	opt = client.client.(*redis.Client).Options()
+	if opt.Username != "ops" || opt.DB != 3 || opt.TLSConfig == nil || opt.TLSConfig.ServerName != "cache.internal" || opt.TLSConfig.MinVersion != tls.VersionTLS13 {
+		t.Fatalf("overrides not applied: %+v", opt)
+	}
+
+	// 集群连接多个节点，不能沿用连接串中的主机名校验所有节点的证书
+	cluster, err := NewRedisClient(RedisConfig{URL: "rediss://seed.internal:6380", Mode: ModeCluster})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cluster.Close()
+	if tlsCfg := cluster.client.(*redis.ClusterClient).Options().TLSConfig; tlsCfg == nil || tlsCfg.ServerName != "" {
+		t.Fatalf("cluster TLS config should leave ServerName empty: %+v", tlsCfg)
+	}
+	cluster, err = NewRedisClient(RedisConfig{URL: "rediss://seed.internal:6380", Mode: ModeCluster, ServerName: "redis.internal"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cluster.Close()
+	if tlsCfg := cluster.client.(*redis.ClusterClient).Options().TLSConfig; tlsCfg == nil || tlsCfg.ServerName != "redis.internal" {
+		t.Fatalf("explicit tls_server_name not applied: %+v", tlsCfg)
+	}
+
+	if _, err := NewRedisClient(RedisConfig{URL: "http://example.com"}); err == nil {
+		t.Error("expected unsupported scheme error")
+	}
+}
@@ -0,0 +1,93 @@
+package redis_db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// KeyColumn 展开为行时存放键名的列
+const KeyColumn = "_key"
+
+// ScanRows 以 SCAN 方式读取键并展开为表格行：hash 每个键一行、每个字段一列，
+// string 的值放在 value 列，list/set 每个元素一行（value），zset 每个成员一行（member、score），
+// stream 每条消息一行（id 加消息字段）。列按首次出现的顺序排列，第一列为键名
+func (r *RedisClient) ScanRows(ctx context.Context, scan ScanExportOptions) ([]string, [][]interface{}, error) {
+	columns := []string{KeyColumn}
+	index := map[string]int{KeyColumn: 0}
+	var records []map[string]interface{}
+	addColumns := func(order []string) {
+		for _, name := range order {
+			if _, ok := index[name]; !ok {
+				index[name] = len(columns)
+				columns = append(columns, name)
+			}
+		}
+	}
+	add := func(record map[string]interface{}, order []string) {
+		addColumns(order)
+		records = append(records, record)
+	}
+
+	err := r.scanKeys(ctx, scan, func(keys []string) error {
+		return r.readKeys(ctx, keys, scan.PageSize, func(page keyPage) error {
+			key := page.key
+			switch v := page.value.(type) {
+			case string:
+				add(map[string]interface{}{KeyColumn: key, "value": v}, []string{"value"})
+			case map[string]string:
+				order := make([]string, 0, len(v))
+				for field := range v {
+					order = append(order, field)
+				}
+				sort.Strings(order)
+				// hash 的后续页合并到同一行
+				record := map[string]interface{}{KeyColumn: key}
+				if page.part > 0 && len(records) > 0 && records[len(records)-1][KeyColumn] == key {
+					record = records[len(records)-1]
+					addColumns(order)
+				} else {
+					add(record, order)
+				}
+				for field, value := range v {
+					record[field] = value
+				}
+			case []string:
+				for _, value := range v {
+					add(map[string]interface{}{KeyColumn: key, "value": value}, []string{"value"})
+				}
+			case []map[string]interface{}:
+				for _, entry := range v {
+					if values, ok := entry["values"].(map[string]interface{}); ok {
+						// stream 消息
+						record := map[string]interface{}{KeyColumn: key, "id": entry["id"]}
+						order := []string{"id"}
+						fields := make([]string, 0, len(values))
+						for field, value := range values {
+							record[field] = fmt.Sprint(value)
+							fields = append(fields, field)
+						}
+						sort.Strings(fields)
+						add(record, append(order, fields...))
+					} else {
+						add(map[string]interface{}{KeyColumn: key, "member": fmt.Sprint(entry["member"]), "score": entry["score"]}, []string{"member", "score"})
+					}
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows := make([][]interface{}, len(records))
+	for i, record := range records {
+		row := make([]interface{}, len(columns))
+		for name, value := range record {
+			row[index[name]] = value
+		}
+		rows[i] = row
+	}
+	return columns, rows, nil
+}
@@ -0,0 +1,258 @@
+package redis_db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ScanOptions redis_scan 参数
+type ScanOptions struct {
+	Pattern       string // 匹配模式，默认 *
+	Type          string // 只返回指定类型的键（SCAN ... TYPE）
+	Cursor        string // 起始游标，空或 "0" 表示从头开始；集群模式下为 "<节点序号>:<节点游标>"
+	Count         int64  // 每次 SCAN 的 COUNT 提示
+	MaxKeys       int    // 本次最多返回的键数量
+	WithMemory    bool   // 读取 MEMORY USAGE
+	MemorySamples int    // MEMORY USAGE 的 SAMPLES 参数，0 使用服务器默认值
+	GroupByPrefix bool   // 按前缀聚合而不是逐个返回键
+	Separator     string // 前缀分隔符，默认 :
+	MaxDepth      int    // 前缀树最大深度
+	MaxChildren   int    // 每个节点最多保留的子前缀数量，其余合并计数
+}
+
+// KeyInfo 单个键的元数据
+type KeyInfo struct {
+	Key         string `json:"key"`
+	Type        string `json:"type"`
+	TTLMs       int64  `json:"ttl_ms"` // -1 表示不过期
+	Encoding    string `json:"encoding,omitempty"`
+	MemoryBytes int64  `json:"memory_bytes,omitempty"`
+}
+
+// PrefixNode 前缀树节点
+type PrefixNode struct {
+	Prefix          string         `json:"prefix"`
+	Keys            int            `json:"keys"`
+	MemoryBytes     int64          `json:"memory_bytes,omitempty"`
+	Types           map[string]int `json:"types"`
+	Children        []*PrefixNode  `json:"children,omitempty"`
+	OmittedChildren int            `json:"omitted_children,omitempty"`
+
+	children map[string]*PrefixNode
+}
+
+// ScanResult redis_scan 结果
+type ScanResult struct {
+	Type     string        `json:"type"`
+	Pattern  string        `json:"pattern"`
+	Cursor   string        `json:"cursor"` // 继续遍历时传入的游标，"0" 表示已遍历完
+	Complete bool          `json:"complete"`
+	Returned int           `json:"returned"`
+	Calls    int           `json:"scan_calls"`
+	Keys     []KeyInfo     `json:"keys,omitempty"`
+	Groups   []*PrefixNode `json:"groups,omitempty"`
+	Other    *PrefixNode   `json:"no_prefix,omitempty"` // 不含分隔符的键
+	Notes    []string      `json:"notes,omitempty"`
+}
+
+// Scan 从游标开始以 SCAN 遍历键并批量读取元数据，达到 MaxKeys 后返回可继续的游标；集群模式下依次遍历所有主节点。
+// 为了保证游标可以准确续传，最后一次 SCAN 的 COUNT 会降到剩余额度，但不会截断 SCAN 返回的批次，
+// 因此返回的键数可能略多于 MaxKeys
+func (r *RedisClient) Scan(ctx context.Context, opts ScanOptions) (*ScanResult, error) {
+	if opts.Pattern == "" {
+		opts.Pattern = "*"
+	}
+	if opts.Count <= 0 {
+		opts.Count = 100
+	}
+	if opts.MaxKeys <= 0 {
+		opts.MaxKeys = 1000
+	}
+	result := &ScanResult{Type: "redis_scan", Pattern: opts.Pattern}
+
+	scanner, err := r.newKeyScanner(ctx, opts.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	var keys []KeyInfo
+	memoryErr := ""
+	for {
+		count := opts.Count
+		if remaining := int64(opts.MaxKeys - len(keys)); remaining < count {
+			count = remaining
+		}
+		batch, err := scanner.Next(ctx, opts.Pattern, count, opts.Type)
+		if err != nil {
+			return nil, fmt.Errorf("SCAN failed: %w", err)
+		}
+		result.Calls++
+		infos, err := r.keyInfos(ctx, batch, opts, &memoryErr)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, infos...)
+		if scanner.Done() || len(keys) >= opts.MaxKeys {
+			break
+		}
+	}
+	if memoryErr != "" {
+		result.Notes = append(result.Notes, "MEMORY USAGE unavailable: "+memoryErr)
+	}
+
+	result.Cursor = scanner.Cursor()
+	result.Complete = scanner.Done()
+	result.Returned = len(keys)
+	if opts.GroupByPrefix {
+		result.Groups, result.Other = PrefixTree(keys, opts.Separator, opts.MaxDepth, opts.MaxChildren)
+	} else {
+		result.Keys = keys
+	}
+	return result, nil
+}
+
+// keyInfos 用一次管道读取一批键的类型、TTL、编码和内存占用；已删除的键会被跳过
+func (r *RedisClient) keyInfos(ctx context.Context, keys []string, opts ScanOptions, memoryErr *string) ([]KeyInfo, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	pipe := r.client.Pipeline()
+	typeCmds := make([]*redis.StatusCmd, len(keys))
+	ttlCmds := make([]*redis.DurationCmd, len(keys))
+	encCmds := make([]*redis.StringCmd, len(keys))
+	memCmds := make([]*redis.IntCmd, len(keys))
+	for i, key := range keys {
+		if opts.Type == "" {
+			typeCmds[i] = pipe.Type(ctx, key)
+		}
+		ttlCmds[i] = pipe.PTTL(ctx, key)
+		encCmds[i] = pipe.ObjectEncoding(ctx, key)
+		if opts.WithMemory && *memoryErr == "" {
+			if opts.MemorySamples > 0 {
+				memCmds[i] = pipe.MemoryUsage(ctx, key, opts.MemorySamples)
+			} else {
+				memCmds[i] = pipe.MemoryUsage(ctx, key)
+			}
+		}
+	}
+	// 单个命令的错误（键已过期、命令被禁用）逐个处理，只有连接级错误才中止
+	if _, err := pipe.Exec(ctx); err != nil && !isReplyError(err) {
+		return nil, fmt.Errorf("failed to read key metadata: %w", err)
+	}
+
+	infos := make([]KeyInfo, 0, len(keys))
+	for i, key := range keys {
+		info := KeyInfo{Key: key, Type: opts.Type}
+		if typeCmds[i] != nil {
+			info.Type = typeCmds[i].Val()
+		}
+		if info.Type == "none" || ttlCmds[i].Val() == -2 {
+			continue
+		}
+		info.TTLMs = ttlMillis(ttlCmds[i].Val())
+		info.Encoding = encCmds[i].Val()
+		if memCmds[i] != nil {
+			if err := memCmds[i].Err(); err != nil && err != redis.Nil {
+				*memoryErr = err.Error()
+			} else {
+				info.MemoryBytes = memCmds[i].Val()
+			}
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// isReplyError 判断是否是 Redis 返回的命令错误（而不是网络错误）
+func isReplyError(err error) bool {
+	if err == redis.Nil {
+		return true
+	}
+	var redisErr redis.Error
+	return errors.As(err, &redisErr)
+}
+
+// ttlMillis PTTL 结果转为毫秒，不过期返回 -1
+func ttlMillis(d time.Duration) int64 {
+	if d < 0 {
+		return int64(d)
+	}
+	return d.Milliseconds()
+}
+
+// PrefixTree 按分隔符把键聚合成前缀树。每一层只保留键数/内存最多的 maxChildren 个子前缀，
+// 其余的只计入父节点的 omitted_children；没有分隔符的键单独汇总
+func PrefixTree(keys []KeyInfo, separator string, maxDepth, maxChildren int) ([]*PrefixNode, *PrefixNode) {
+	if separator == "" {
+		separator = ":"
+	}
+	if maxDepth <= 0 {
+		maxDepth = 3
+	}
+	if maxChildren <= 0 {
+		maxChildren = 20
+	}
+	root := &PrefixNode{children: map[string]*PrefixNode{}}
+	var other *PrefixNode
+	for _, k := range keys {
+		parts := strings.Split(k.Key, separator)
+		if len(parts) == 1 {
+			if other == nil {
+				other = &PrefixNode{Prefix: "", Types: map[string]int{}}
+			}
+			other.add(k)
+			continue
+		}
+		node := root
+		prefix := ""
+		// 最后一段是键本身，不作为前缀
+		for depth := 0; depth < len(parts)-1 && depth < maxDepth; depth++ {
+			prefix += parts[depth] + separator
+			child := node.children[prefix]
+			if child == nil {
+				child = &PrefixNode{Prefix: prefix, Types: map[string]int{}, children: map[string]*PrefixNode{}}
+				node.children[prefix] = child
+			}
+			child.add(k)
+			node = child
+		}
+	}
+	root.finish(maxChildren)
+	return root.Children, other
+}
+
+func (n *PrefixNode) add(k KeyInfo) {
+	n.Keys++
+	n.MemoryBytes += k.MemoryBytes
+	n.Types[k.Type]++
+}
+
+// finish 把子节点 map 转为按内存、键数排序并截断的切片
+func (n *PrefixNode) finish(maxChildren int) {
+	for _, child := range n.children {
+		n.Children = append(n.Children, child)
+	}
+	sort.Slice(n.Children, func(i, j int) bool {
+		a, b := n.Children[i], n.Children[j]
+		if a.MemoryBytes != b.MemoryBytes {
+			return a.MemoryBytes > b.MemoryBytes
+		}
+		if a.Keys != b.Keys {
+			return a.Keys > b.Keys
+		}
+		return a.Prefix < b.Prefix
+	})
+	if len(n.Children) > maxChildren {
+		n.OmittedChildren = len(n.Children) - maxChildren
+		n.Children = n.Children[:maxChildren]
+	}
+	for _, child := range n.Children {
+		child.finish(maxChildren)
+	}
+	n.children = nil
+}
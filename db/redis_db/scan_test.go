@@ -0,0 +1,73 @@
+package redis_db
+
+import (
+	"context"
+	"testing"
+)
+
+func scanTestKeyspace() *fakeKeyspace {
+	return newFakeKeyspace(map[string]*fakeKey{
+		"user:1:profile": {typ: "hash", encoding: "listpack", ttlMs: -1, memory: 100, value: map[string]string{"name": "a"}},
+		"user:2:profile": {typ: "hash", encoding: "listpack", ttlMs: 5000, memory: 120, value: map[string]string{"name": "b"}},
+		"user:2:tags":    {typ: "set", encoding: "listpack", ttlMs: -1, memory: 80, value: []string{"x"}},
+		"session:abc":    {typ: "string", encoding: "embstr", ttlMs: 1000, memory: 60, value: "s"},
+		"counter":        {typ: "string", encoding: "int", ttlMs: -1, memory: 50, value: "1"},
+	})
+}
+
+func TestScanResumable(t *testing.T) {
+	ks := scanTestKeyspace()
+	_, client := newFakeRedis(t, ks.handle)
+	ctx := context.Background()
+
+	first, err := client.Scan(ctx, ScanOptions{Count: 2, MaxKeys: 3, WithMemory: true})
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if first.Complete || first.Returned < 3 || first.Cursor == "0" {
+		t.Fatalf("first page = %+v", first)
+	}
+	if k := first.Keys[0]; k.Key != "counter" || k.Type != "string" || k.TTLMs != -1 || k.Encoding != "int" || k.MemoryBytes != 50 {
+		t.Errorf("key info = %+v", k)
+	}
+
+	rest, err := client.Scan(ctx, ScanOptions{Cursor: first.Cursor, Count: 2, MaxKeys: 10})
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if !rest.Complete || first.Returned+rest.Returned != 5 {
+		t.Fatalf("second page = %+v", rest)
+	}
+	if rest.Keys[0].MemoryBytes != 0 {
+		t.Errorf("memory should not be read without WithMemory: %+v", rest.Keys[0])
+	}
+
+	typed, err := client.Scan(ctx, ScanOptions{Type: "hash", Pattern: "user:*"})
+	if err != nil || typed.Returned != 2 || typed.Keys[1].TTLMs != 5000 {
+		t.Fatalf("typed scan = %+v, %v", typed, err)
+	}
+}
+
+func TestScanGroupByPrefix(t *testing.T) {
+	ks := scanTestKeyspace()
+	_, client := newFakeRedis(t, ks.handle)
+
+	result, err := client.Scan(context.Background(), ScanOptions{WithMemory: true, GroupByPrefix: true, MaxChildren: 1})
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if len(result.Groups) != 1 || result.Groups[0].Prefix != "user:" {
+		t.Fatalf("groups = %+v", result.Groups)
+	}
+	user := result.Groups[0]
+	if user.Keys != 3 || user.MemoryBytes != 300 || user.Types["hash"] != 2 || user.OmittedChildren != 1 {
+		t.Errorf("user: = %+v", user)
+	}
+	// user:2: 的内存更多，排在前面
+	if len(user.Children) != 1 || user.Children[0].Prefix != "user:2:" || user.Children[0].Keys != 2 {
+		t.Errorf("children = %+v", user.Children)
+	}
+	if result.Other == nil || result.Other.Keys != 1 {
+		t.Errorf("no prefix = %+v", result.Other)
+	}
+}
@@ -0,0 +1,333 @@
+package redis_db
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ScriptRunResult EVALSHA 执行结果
+type ScriptRunResult struct {
+	Type     string      `json:"type"`
+	Name     string      `json:"name,omitempty"`
+	SHA      string      `json:"sha"`
+	Fallback bool        `json:"fallback_to_eval"` // 服务器缓存中没有该脚本(NOSCRIPT)，改用 EVAL 发送了脚本内容
+	Result   interface{} `json:"result"`
+}
+
+// ScriptSHA 计算脚本的 SHA1，与 SCRIPT LOAD 返回值一致
+func ScriptSHA(script string) string {
+	sum := sha1.Sum([]byte(script))
+	return hex.EncodeToString(sum[:])
+}
+
+// ScriptLoad 把脚本加载到服务器的脚本缓存并返回 SHA
+func (r *RedisClient) ScriptLoad(ctx context.Context, script string) (string, error) {
+	if strings.TrimSpace(script) == "" {
+		return "", fmt.Errorf("script is empty")
+	}
+	sha, err := r.client.ScriptLoad(ctx, script).Result()
+	if err != nil {
+		return "", fmt.Errorf("SCRIPT LOAD failed: %w", err)
+	}
+	return sha, nil
+}
+
+// EvalSHA 以 EVALSHA 执行已缓存的脚本；服务器返回 NOSCRIPT 且提供了脚本内容时改用 EVAL，
+// EVAL 同时会把脚本放入缓存，之后的调用又可以只发送 SHA
+func (r *RedisClient) EvalSHA(ctx context.Context, sha, script string, keys []string, args []interface{}) (*ScriptRunResult, error) {
+	if sha == "" {
+		if script == "" {
+			return nil, fmt.Errorf("sha or script is required")
+		}
+		sha = ScriptSHA(script)
+	}
+	result := &ScriptRunResult{Type: "redis_evalsha", SHA: sha}
+	val, err := r.client.EvalSha(ctx, sha, keys, args...).Result()
+	if redis.HasErrorPrefix(err, "NOSCRIPT") && script != "" {
+		result.Fallback = true
+		val, err = r.client.Eval(ctx, script, keys, args...).Result()
+	}
+	if err != nil && err != redis.Nil {
+		if redis.HasErrorPrefix(err, "NOSCRIPT") {
+			return nil, fmt.Errorf("script %s is not cached on the server, provide the script body to fall back to EVAL: %w", sha, err)
+		}
+		return nil, fmt.Errorf("script execution failed: %w", err)
+	}
+	result.Result = jsonValue(val)
+	return result, nil
+}
+
+// scriptNamePattern 脚本库中的脚本名（文件名去掉 .lua）
+var scriptNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// LibraryScript 脚本库中的一个脚本
+type LibraryScript struct {
+	Name        string `json:"name"`
+	Path        string `json:"path"`
+	Description string `json:"description,omitempty"` // 文件开头的 -- 注释
+	SHA         string `json:"sha"`
+	Cached      *bool  `json:"cached,omitempty"` // 是否已在服务器的脚本缓存中
+	Source      string `json:"-"`
+}
+
+// ScriptLibrary 从目录加载的具名 Lua 脚本，按名称以 EVALSHA 调用
+type ScriptLibrary struct {
+	mu      sync.RWMutex
+	dir     string
+	scripts map[string]*LibraryScript
+}
+
+// LoadScriptLibrary 读取目录下（不递归）所有 .lua 文件，脚本名为去掉扩展名的文件名
+func LoadScriptLibrary(dir string) (*ScriptLibrary, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid script directory: %w", err)
+	}
+	entries, err := os.ReadDir(abs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read script directory: %w", err)
+	}
+	lib := &ScriptLibrary{dir: abs, scripts: make(map[string]*LibraryScript)}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".lua") {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		if !scriptNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid script name %q: only letters, digits, '_', '-' and '.' are allowed", name)
+		}
+		path := filepath.Join(abs, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		source := string(data)
+		if strings.TrimSpace(source) == "" {
+			return nil, fmt.Errorf("script %s is empty", path)
+		}
+		lib.scripts[name] = &LibraryScript{
+			Name:        name,
+			Path:        path,
+			Description: scriptDescription(source),
+			SHA:         ScriptSHA(source),
+			Source:      source,
+		}
+	}
+	return lib, nil
+}
+
+// scriptDescription 取文件开头连续的 -- 注释作为说明
+func scriptDescription(source string) string {
+	var lines []string
+	scanner := bufio.NewScanner(strings.NewReader(source))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "--") {
+			break
+		}
+		if text := strings.TrimSpace(strings.TrimLeft(line, "-")); text != "" {
+			lines = append(lines, text)
+		}
+	}
+	return strings.Join(lines, " ")
+}
+
+// Dir 脚本目录
+func (l *ScriptLibrary) Dir() string {
+	return l.dir
+}
+
+// Get 按名称查找脚本
+func (l *ScriptLibrary) Get(name string) (*LibraryScript, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	s, ok := l.scripts[name]
+	return s, ok
+}
+
+// List 按名称排序返回所有脚本的副本
+func (l *ScriptLibrary) List() []LibraryScript {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	list := make([]LibraryScript, 0, len(l.scripts))
+	for _, s := range l.scripts {
+		list = append(list, *s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// LoadLibrary 把脚本库中的所有脚本 SCRIPT LOAD 到服务器
+func (r *RedisClient) LoadLibrary(ctx context.Context, lib *ScriptLibrary) error {
+	for _, s := range lib.List() {
+		sha, err := r.ScriptLoad(ctx, s.Source)
+		if err != nil {
+			return fmt.Errorf("script %s: %w", s.Name, err)
+		}
+		if sha != s.SHA {
+			return fmt.Errorf("script %s: server returned sha %s, expected %s", s.Name, sha, s.SHA)
+		}
+	}
+	return nil
+}
+
+// LibraryStatus 返回脚本列表，并用 SCRIPT EXISTS 标记哪些已经在服务器缓存中
+func (r *RedisClient) LibraryStatus(ctx context.Context, lib *ScriptLibrary) ([]LibraryScript, error) {
+	list := lib.List()
+	if len(list) == 0 {
+		return list, nil
+	}
+	shas := make([]string, len(list))
+	for i, s := range list {
+		shas[i] = s.SHA
+	}
+	exists, err := r.client.ScriptExists(ctx, shas...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("SCRIPT EXISTS failed: %w", err)
+	}
+	for i := range list {
+		if i < len(exists) {
+			cached := exists[i]
+			list[i].Cached = &cached
+		}
+	}
+	return list, nil
+}
+
+// RunLibraryScript 按名称执行脚本库中的脚本，缓存缺失时自动回退到 EVAL
+func (r *RedisClient) RunLibraryScript(ctx context.Context, lib *ScriptLibrary, name string, keys []string, args []interface{}) (*ScriptRunResult, error) {
+	s, ok := lib.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("script %q not found in %s", name, lib.Dir())
+	}
+	result, err := r.EvalSHA(ctx, s.SHA, s.Source, keys, args)
+	if err != nil {
+		return nil, err
+	}
+	result.Name = name
+	return result, nil
+}
+
+// FunctionLibrary FUNCTION LIST 中的函数库
+type FunctionLibrary struct {
+	Name      string         `json:"library_name"`
+	Engine    string         `json:"engine"`
+	Functions []FunctionInfo `json:"functions"`
+	Code      string         `json:"library_code,omitempty"`
+}
+
+// FunctionInfo 函数库中的函数
+type FunctionInfo struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Flags       []string `json:"flags"`
+}
+
+// FunctionLoad 以 FUNCTION LOAD 加载函数库代码（需以 #!lua name=<库名> 开头），返回库名
+func (r *RedisClient) FunctionLoad(ctx context.Context, code string, replace bool) (string, error) {
+	if strings.TrimSpace(code) == "" {
+		return "", fmt.Errorf("library code is empty")
+	}
+	var cmd *redis.StringCmd
+	if replace {
+		cmd = r.client.FunctionLoadReplace(ctx, code)
+	} else {
+		cmd = r.client.FunctionLoad(ctx, code)
+	}
+	name, err := cmd.Result()
+	if err != nil {
+		return "", fmt.Errorf("FUNCTION LOAD failed: %w", err)
+	}
+	return name, nil
+}
+
+// FunctionList 列出函数库，pattern 为库名匹配模式
+func (r *RedisClient) FunctionList(ctx context.Context, pattern string, withCode bool) ([]FunctionLibrary, error) {
+	libs, err := r.client.FunctionList(ctx, redis.FunctionListQuery{LibraryNamePattern: pattern, WithCode: withCode}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("FUNCTION LIST failed: %w", err)
+	}
+	result := make([]FunctionLibrary, len(libs))
+	for i, lib := range libs {
+		fns := make([]FunctionInfo, len(lib.Functions))
+		for j, fn := range lib.Functions {
+			fns[j] = FunctionInfo{Name: fn.Name, Description: fn.Description, Flags: fn.Flags}
+			if fns[j].Flags == nil {
+				fns[j].Flags = []string{}
+			}
+		}
+		result[i] = FunctionLibrary{Name: lib.Name, Engine: lib.Engine, Functions: fns, Code: lib.Code}
+	}
+	return result, nil
+}
+
+// FunctionDelete 删除函数库
+func (r *RedisClient) FunctionDelete(ctx context.Context, library string) error {
+	if library == "" {
+		return fmt.Errorf("library name is required")
+	}
+	if err := r.client.FunctionDelete(ctx, library).Err(); err != nil {
+		return fmt.Errorf("FUNCTION DELETE failed: %w", err)
+	}
+	return nil
+}
+
+// FunctionDump 以 FUNCTION DUMP 导出所有函数库，返回 base64 编码的序列化数据
+func (r *RedisClient) FunctionDump(ctx context.Context) (string, error) {
+	payload, err := r.client.FunctionDump(ctx).Result()
+	if err != nil {
+		return "", fmt.Errorf("FUNCTION DUMP failed: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString([]byte(payload)), nil
+}
+
+// FunctionRestore 以 FUNCTION RESTORE 恢复 FunctionDump 导出的数据，
+// policy 为 APPEND（默认，库名冲突时报错）、REPLACE 或 FLUSH（先删除所有现有库）
+func (r *RedisClient) FunctionRestore(ctx context.Context, payload, policy string) error {
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return fmt.Errorf("payload is not valid base64: %w", err)
+	}
+	args := []interface{}{"FUNCTION", "RESTORE", string(data)}
+	switch policy = strings.ToUpper(policy); policy {
+	case "":
+	case "APPEND", "REPLACE", "FLUSH":
+		args = append(args, policy)
+	default:
+		return fmt.Errorf("unsupported restore policy: %s (supported: APPEND, REPLACE, FLUSH)", policy)
+	}
+	if err := r.client.Do(ctx, args...).Err(); err != nil {
+		return fmt.Errorf("FUNCTION RESTORE failed: %w", err)
+	}
+	return nil
+}
+
+// FCall 调用函数库中的函数；readOnly 为 true 时使用 FCALL_RO，可以在只读副本上执行
+func (r *RedisClient) FCall(ctx context.Context, function string, keys []string, args []interface{}, readOnly bool) (interface{}, error) {
+	if function == "" {
+		return nil, fmt.Errorf("function name is required")
+	}
+	var cmd *redis.Cmd
+	if readOnly {
+		cmd = r.client.FCallRO(ctx, function, keys, args...)
+	} else {
+		cmd = r.client.FCall(ctx, function, keys, args...)
+	}
+	val, err := cmd.Result()
+	if err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("function call failed: %w", err)
+	}
+	return jsonValue(val), nil
+}
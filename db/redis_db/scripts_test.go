@@ -0,0 +1,237 @@
+package redis_db
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeScripting 模拟脚本缓存和函数库：EVAL/EVALSHA 返回 "<sha>:<numkeys>"，FCALL 返回 "<命令>:<函数名>"
+type fakeScripting struct {
+	scripts   map[string]string
+	libraries map[string]string
+	restored  []string
+}
+
+const fakeDump = "\xf5\xc3dump\x00"
+
+func (s *fakeScripting) handle(args []string) interface{} {
+	switch cmd := strings.ToUpper(args[0]); cmd {
+	case "SCRIPT":
+		switch strings.ToUpper(args[1]) {
+		case "LOAD":
+			sha := ScriptSHA(args[2])
+			s.scripts[sha] = args[2]
+			return sha
+		case "EXISTS":
+			out := make([]interface{}, 0, len(args)-2)
+			for _, sha := range args[2:] {
+				if _, ok := s.scripts[sha]; ok {
+					out = append(out, 1)
+				} else {
+					out = append(out, 0)
+				}
+			}
+			return out
+		}
+	case "EVAL":
+		sha := ScriptSHA(args[1])
+		s.scripts[sha] = args[1]
+		return sha + ":" + args[2]
+	case "EVALSHA":
+		if _, ok := s.scripts[args[1]]; !ok {
+			return fakeError("NOSCRIPT No matching script. Please use EVAL.")
+		}
+		return args[1] + ":" + args[2]
+	case "FUNCTION":
+		switch strings.ToUpper(args[1]) {
+		case "LOAD":
+			code := args[len(args)-1]
+			name := strings.TrimPrefix(strings.Fields(code)[1], "name=")
+			if _, ok := s.libraries[name]; ok && strings.ToUpper(args[2]) != "REPLACE" {
+				return fakeError("ERR Library '" + name + "' already exists")
+			}
+			s.libraries[name] = code
+			return name
+		case "LIST":
+			out := []interface{}{}
+			for _, name := range sortedFields(s.libraries) {
+				out = append(out, []interface{}{
+					"library_name", name,
+					"engine", "LUA",
+					"functions", []interface{}{
+						[]interface{}{"name", name + "_get", "description", nil, "flags", []string{"no-writes"}},
+					},
+				})
+			}
+			return out
+		case "DELETE":
+			if _, ok := s.libraries[args[2]]; !ok {
+				return fakeError("ERR Library not found")
+			}
+			delete(s.libraries, args[2])
+			return fakeStatus("OK")
+		case "DUMP":
+			return fakeDump
+		case "RESTORE":
+			s.restored = append(s.restored, strings.Join(args[2:], "|"))
+			return fakeStatus("OK")
+		}
+	case "FCALL", "FCALL_RO":
+		return strings.ToLower(cmd) + ":" + args[1]
+	}
+	return fakeError("ERR unknown command '" + args[0] + "'")
+}
+
+func TestEvalSHAFallback(t *testing.T) {
+	fake := &fakeScripting{scripts: map[string]string{}, libraries: map[string]string{}}
+	srv, client := newFakeRedis(t, fake.handle)
+	ctx := context.Background()
+	script := "return redis.call('GET', KEYS[1])"
+	sha := ScriptSHA(script)
+
+	// 缓存中没有：EVALSHA 返回 NOSCRIPT 后回退到 EVAL
+	res, err := client.EvalSHA(ctx, "", script, []string{"k"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Fallback || res.SHA != sha || res.Result != sha+":1" {
+		t.Fatalf("unexpected fallback result: %+v", res)
+	}
+	// EVAL 之后已缓存：只发送 EVALSHA
+	res, err = client.EvalSHA(ctx, sha, script, []string{"k"}, nil)
+	if err != nil || res.Fallback {
+		t.Fatalf("expected cached EVALSHA, got %+v, %v", res, err)
+	}
+	// 未缓存且没有脚本内容时报错
+	if _, err := client.EvalSHA(ctx, ScriptSHA("return 1"), "", nil, nil); err == nil || !strings.Contains(err.Error(), "NOSCRIPT") {
+		t.Fatalf("expected NOSCRIPT error, got %v", err)
+	}
+
+	loaded, err := client.ScriptLoad(ctx, "return 1")
+	if err != nil || loaded != ScriptSHA("return 1") {
+		t.Fatalf("SCRIPT LOAD: %s, %v", loaded, err)
+	}
+
+	// ExecuteLuaScript 同样先尝试 EVALSHA
+	before := len(srv.commands())
+	if _, err := client.ExecuteLuaScript(ctx, "return 1", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := srv.commands()[before:]; !reflect.DeepEqual(got, []string{"EVALSHA"}) {
+		t.Errorf("expected a single EVALSHA, got %v", got)
+	}
+}
+
+func TestScriptLibrary(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"incr_capped.lua": "-- 自增但不超过上限\n-- KEYS[1] 计数器, ARGV[1] 上限\nreturn 1",
+		"get_or_set.lua":  "return 2",
+		"notes.txt":       "ignored",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lib, err := LoadScriptLibrary(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := lib.List()
+	if len(list) != 2 || list[0].Name != "get_or_set" || list[1].Name != "incr_capped" {
+		t.Fatalf("unexpected library: %+v", list)
+	}
+	if list[1].Description != "自增但不超过上限 KEYS[1] 计数器, ARGV[1] 上限" {
+		t.Errorf("unexpected description: %q", list[1].Description)
+	}
+
+	fake := &fakeScripting{scripts: map[string]string{}, libraries: map[string]string{}}
+	_, client := newFakeRedis(t, fake.handle)
+	ctx := context.Background()
+
+	status, err := client.LibraryStatus(ctx, lib)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *status[0].Cached || *status[1].Cached {
+		t.Fatalf("nothing should be cached yet: %+v", status)
+	}
+	if err := client.LoadLibrary(ctx, lib); err != nil {
+		t.Fatal(err)
+	}
+	status, _ = client.LibraryStatus(ctx, lib)
+	if !*status[0].Cached || !*status[1].Cached {
+		t.Fatalf("all scripts should be cached: %+v", status)
+	}
+
+	res, err := client.RunLibraryScript(ctx, lib, "incr_capped", []string{"counter"}, []interface{}{10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Name != "incr_capped" || res.Fallback || res.Result != list[1].SHA+":1" {
+		t.Errorf("unexpected run result: %+v", res)
+	}
+	if _, err := client.RunLibraryScript(ctx, lib, "missing", nil, nil); err == nil {
+		t.Error("expected unknown script error")
+	}
+}
+
+func TestFunctions(t *testing.T) {
+	fake := &fakeScripting{scripts: map[string]string{}, libraries: map[string]string{}}
+	_, client := newFakeRedis(t, fake.handle)
+	ctx := context.Background()
+	code := "#!lua name=mylib\nredis.register_function('mylib_get', function(keys) return 1 end)"
+
+	name, err := client.FunctionLoad(ctx, code, false)
+	if err != nil || name != "mylib" {
+		t.Fatalf("FUNCTION LOAD: %s, %v", name, err)
+	}
+	if _, err := client.FunctionLoad(ctx, code, false); err == nil {
+		t.Error("expected error loading an existing library without replace")
+	}
+	if _, err := client.FunctionLoad(ctx, code, true); err != nil {
+		t.Fatal(err)
+	}
+
+	libs, err := client.FunctionList(ctx, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(libs) != 1 || libs[0].Name != "mylib" || libs[0].Functions[0].Name != "mylib_get" ||
+		!reflect.DeepEqual(libs[0].Functions[0].Flags, []string{"no-writes"}) {
+		t.Fatalf("unexpected FUNCTION LIST: %+v", libs)
+	}
+
+	for readOnly, want := range map[bool]string{false: "fcall:mylib_get", true: "fcall_ro:mylib_get"} {
+		got, err := client.FCall(ctx, "mylib_get", []string{"k"}, nil, readOnly)
+		if err != nil || got != want {
+			t.Errorf("FCall(readOnly=%v) = %v, %v", readOnly, got, err)
+		}
+	}
+
+	payload, err := client.FunctionDump(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.FunctionRestore(ctx, payload, "replace"); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.restored) != 1 || fake.restored[0] != fakeDump+"|REPLACE" {
+		t.Errorf("dump payload not restored verbatim: %q", fake.restored)
+	}
+	if err := client.FunctionRestore(ctx, payload, "merge"); err == nil {
+		t.Error("expected unsupported policy error")
+	}
+
+	if err := client.FunctionDelete(ctx, "mylib"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.FunctionDelete(ctx, "mylib"); err == nil {
+		t.Error("expected error deleting a missing library")
+	}
+}
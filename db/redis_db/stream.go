@@ -0,0 +1,457 @@
+package redis_db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 默认值
+const (
+	DefaultStreamCount = 100
+	MaxStreamCount     = 10000
+	MaxStreamBlock     = 60 * time.Second
+)
+
+// StreamInfo redis_stream_info 结果
+type StreamInfo struct {
+	Type                 string            `json:"type"`
+	Key                  string            `json:"key"`
+	Length               int64             `json:"length"`
+	RadixTreeKeys        int64             `json:"radix_tree_keys"`
+	RadixTreeNodes       int64             `json:"radix_tree_nodes"`
+	LastGeneratedID      string            `json:"last_generated_id"`
+	MaxDeletedEntryID    string            `json:"max_deleted_entry_id,omitempty"`
+	EntriesAdded         int64             `json:"entries_added"`
+	RecordedFirstEntryID string            `json:"recorded_first_entry_id,omitempty"`
+	FirstEntry           *StreamEntry      `json:"first_entry,omitempty"`
+	LastEntry            *StreamEntry      `json:"last_entry,omitempty"`
+	Groups               []StreamGroupInfo `json:"groups"`
+}
+
+// StreamGroupInfo 消费者组
+type StreamGroupInfo struct {
+	Name            string               `json:"name"`
+	Consumers       int64                `json:"consumers"`
+	Pending         int64                `json:"pending"` // 已投递未确认的消息数
+	LastDeliveredID string               `json:"last_delivered_id"`
+	EntriesRead     *int64               `json:"entries_read"` // Redis 7 之前没有该字段，为 null
+	Lag             *int64               `json:"lag"`          // 尚未投递给该组的消息数，服务器无法计算或 Redis 7 之前为 null
+	OldestPendingID string               `json:"oldest_pending_id,omitempty"`
+	NewestPendingID string               `json:"newest_pending_id,omitempty"`
+	ConsumerDetails []StreamConsumerInfo `json:"consumer_details"`
+}
+
+// StreamConsumerInfo 消费者
+type StreamConsumerInfo struct {
+	Name       string `json:"name"`
+	Pending    int64  `json:"pending"`
+	IdleMs     int64  `json:"idle_ms"`               // 距离最近一次尝试读取或认领的时间
+	InactiveMs *int64 `json:"inactive_ms,omitempty"` // 距离最近一次成功读取或认领的时间（Redis 7.2+）
+}
+
+// StreamInfo 返回 stream、消费者组和消费者的结构化信息，包括各组的 lag 和待确认消息数
+func (r *RedisClient) StreamInfo(ctx context.Context, key string) (*StreamInfo, error) {
+	if key == "" {
+		return nil, fmt.Errorf("key is required")
+	}
+	info, err := r.client.XInfoStream(ctx, key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("XINFO STREAM failed: %w", err)
+	}
+	result := &StreamInfo{
+		Type:                 "redis_stream_info",
+		Key:                  key,
+		Length:               info.Length,
+		RadixTreeKeys:        info.RadixTreeKeys,
+		RadixTreeNodes:       info.RadixTreeNodes,
+		LastGeneratedID:      info.LastGeneratedID,
+		MaxDeletedEntryID:    info.MaxDeletedEntryID,
+		EntriesAdded:         info.EntriesAdded,
+		RecordedFirstEntryID: info.RecordedFirstEntryID,
+		FirstEntry:           streamEntry(info.FirstEntry),
+		LastEntry:            streamEntry(info.LastEntry),
+		Groups:               []StreamGroupInfo{},
+	}
+	if info.Groups == 0 {
+		return result, nil
+	}
+
+	groups, err := r.streamGroups(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	pipe := r.client.Pipeline()
+	consumerCmds := make([]*redis.XInfoConsumersCmd, len(groups))
+	pendingCmds := make([]*redis.XPendingCmd, len(groups))
+	for i, g := range groups {
+		consumerCmds[i] = pipe.XInfoConsumers(ctx, key, g.Name)
+		pendingCmds[i] = pipe.XPending(ctx, key, g.Name)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !isReplyError(err) {
+		return nil, fmt.Errorf("failed to read consumer groups: %w", err)
+	}
+	for i, g := range groups {
+		group := g
+		if pending, err := pendingCmds[i].Result(); err == nil {
+			group.OldestPendingID, group.NewestPendingID = pending.Lower, pending.Higher
+		}
+		consumers, err := consumerCmds[i].Result()
+		if err != nil {
+			return nil, fmt.Errorf("XINFO CONSUMERS %s failed: %w", g.Name, err)
+		}
+		for _, c := range consumers {
+			consumer := StreamConsumerInfo{Name: c.Name, Pending: c.Pending, IdleMs: c.Idle.Milliseconds()}
+			if c.Inactive != 0 {
+				inactive := c.Inactive.Milliseconds()
+				consumer.InactiveMs = &inactive
+			}
+			group.ConsumerDetails = append(group.ConsumerDetails, consumer)
+		}
+		result.Groups = append(result.Groups, group)
+	}
+	return result, nil
+}
+
+// streamGroups 读取 XINFO GROUPS。直接解析回复而不用 go-redis 的 XInfoGroups：后者把缺失的 lag 当作 0，
+// 无法区分 Redis 7 之前没有该字段和组已追上
+func (r *RedisClient) streamGroups(ctx context.Context, key string) ([]StreamGroupInfo, error) {
+	reply, err := r.client.Do(ctx, "XINFO", "GROUPS", key).Slice()
+	if err != nil {
+		return nil, fmt.Errorf("XINFO GROUPS failed: %w", err)
+	}
+	groups := make([]StreamGroupInfo, 0, len(reply))
+	for _, item := range reply {
+		fields := replyMap(item)
+		if fields == nil {
+			return nil, fmt.Errorf("unexpected XINFO GROUPS reply: %v", item)
+		}
+		group := StreamGroupInfo{
+			Name:            fmt.Sprint(fields["name"]),
+			LastDeliveredID: fmt.Sprint(fields["last-delivered-id"]),
+			ConsumerDetails: []StreamConsumerInfo{},
+		}
+		group.Consumers, _ = replyInt(fields["consumers"])
+		group.Pending, _ = replyInt(fields["pending"])
+		if n, ok := replyInt(fields["entries-read"]); ok {
+			group.EntriesRead = &n
+		}
+		if n, ok := replyInt(fields["lag"]); ok {
+			group.Lag = &n
+		}
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+// streamEntry 空消息（stream 为空时的 first/last entry）返回 nil
+func streamEntry(m redis.XMessage) *StreamEntry {
+	if m.ID == "" {
+		return nil
+	}
+	return &StreamEntry{ID: m.ID, Fields: m.Values}
+}
+
+func streamEntries(msgs []redis.XMessage) []StreamEntry {
+	entries := make([]StreamEntry, len(msgs))
+	for i, m := range msgs {
+		entries[i] = StreamEntry{ID: m.ID, Fields: m.Values}
+	}
+	return entries
+}
+
+// streamCount 把 count 限制在 1..MaxStreamCount，未设置时使用默认值
+func streamCount(count int) int64 {
+	if count <= 0 {
+		return DefaultStreamCount
+	}
+	if count > MaxStreamCount {
+		return MaxStreamCount
+	}
+	return int64(count)
+}
+
+// PendingOptions redis_stream_pending 参数
+type PendingOptions struct {
+	Key      string
+	Group    string
+	Consumer string        // 只列出该消费者的待确认消息
+	MinIdle  time.Duration // 只列出空闲时间不小于该值的消息（Redis 6.2+）
+	Start    string        // 起始 ID（默认 -），翻页时传入上一页返回的 next_start
+	End      string        // 结束 ID（默认 +）
+	Count    int
+}
+
+// PendingEntry 待确认消息
+type PendingEntry struct {
+	ID         string `json:"id"`
+	Consumer   string `json:"consumer"`
+	IdleMs     int64  `json:"idle_ms"`
+	Deliveries int64  `json:"deliveries"` // 投递次数，持续增长说明消息反复处理失败
+}
+
+// PendingResult redis_stream_pending 结果
+type PendingResult struct {
+	Type      string           `json:"type"`
+	Key       string           `json:"key"`
+	Group     string           `json:"group"`
+	Total     int64            `json:"total"` // 组内全部待确认消息数
+	OldestID  string           `json:"oldest_id,omitempty"`
+	NewestID  string           `json:"newest_id,omitempty"`
+	Consumers map[string]int64 `json:"consumers"` // 每个消费者的待确认消息数
+	Entries   []PendingEntry   `json:"entries"`
+	NextStart string           `json:"next_start,omitempty"` // 可能还有更多条目时用于下一页的起始 ID
+}
+
+// StreamPending 返回消费者组的待确认消息汇总和明细，可按消费者和空闲时间过滤
+func (r *RedisClient) StreamPending(ctx context.Context, opts PendingOptions) (*PendingResult, error) {
+	if opts.Key == "" || opts.Group == "" {
+		return nil, fmt.Errorf("key and group are required")
+	}
+	if opts.Start == "" {
+		opts.Start = "-"
+	}
+	if opts.End == "" {
+		opts.End = "+"
+	}
+	count := streamCount(opts.Count)
+
+	summary, err := r.client.XPending(ctx, opts.Key, opts.Group).Result()
+	if err != nil {
+		return nil, fmt.Errorf("XPENDING failed: %w", err)
+	}
+	pending, err := r.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream:   opts.Key,
+		Group:    opts.Group,
+		Idle:     opts.MinIdle,
+		Start:    opts.Start,
+		End:      opts.End,
+		Count:    count,
+		Consumer: opts.Consumer,
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("XPENDING failed: %w", err)
+	}
+	result := &PendingResult{
+		Type:      "redis_stream_pending",
+		Key:       opts.Key,
+		Group:     opts.Group,
+		Total:     summary.Count,
+		OldestID:  summary.Lower,
+		NewestID:  summary.Higher,
+		Consumers: summary.Consumers,
+		Entries:   make([]PendingEntry, len(pending)),
+	}
+	for i, p := range pending {
+		result.Entries[i] = PendingEntry{ID: p.ID, Consumer: p.Consumer, IdleMs: p.Idle.Milliseconds(), Deliveries: p.RetryCount}
+	}
+	if int64(len(pending)) == count {
+		result.NextStart = "(" + pending[len(pending)-1].ID
+	}
+	return result, nil
+}
+
+// ClaimOptions redis_stream_claim 参数
+type ClaimOptions struct {
+	Key      string
+	Group    string
+	Consumer string        // 认领到的消费者
+	MinIdle  time.Duration // 只认领空闲时间不小于该值的消息
+	Start    string        // 起始 ID（默认 0-0），继续时传入上次返回的 next_start
+	Count    int
+	JustID   bool // 只返回 ID，不增加投递次数
+}
+
+// ClaimResult redis_stream_claim 结果
+type ClaimResult struct {
+	Type       string        `json:"type"`
+	Key        string        `json:"key"`
+	Group      string        `json:"group"`
+	Consumer   string        `json:"consumer"`
+	Claimed    []StreamEntry `json:"claimed"`
+	ClaimedIDs []string      `json:"claimed_ids,omitempty"` // just_id 时只返回 ID
+	DeletedIDs []string      `json:"deleted_ids,omitempty"` // 待确认但消息已被删除的 ID，服务器已将其从待确认列表移除（Redis 7+）
+	NextStart  string        `json:"next_start"`
+	Complete   bool          `json:"complete"` // next_start 为 0-0，表示已扫描完整个待确认列表
+}
+
+// StreamClaim 以 XAUTOCLAIM 把空闲时间超过阈值的待确认消息转给指定消费者
+func (r *RedisClient) StreamClaim(ctx context.Context, opts ClaimOptions) (*ClaimResult, error) {
+	if opts.Key == "" || opts.Group == "" || opts.Consumer == "" {
+		return nil, fmt.Errorf("key, group and consumer are required")
+	}
+	if opts.Start == "" {
+		opts.Start = "0-0"
+	}
+	args := []interface{}{"XAUTOCLAIM", opts.Key, opts.Group, opts.Consumer, opts.MinIdle.Milliseconds(), opts.Start, "COUNT", streamCount(opts.Count)}
+	if opts.JustID {
+		args = append(args, "JUSTID")
+	}
+	reply, err := r.client.Do(ctx, args...).Slice()
+	if err != nil {
+		return nil, fmt.Errorf("XAUTOCLAIM failed: %w", err)
+	}
+	if len(reply) < 2 {
+		return nil, fmt.Errorf("unexpected XAUTOCLAIM reply with %d elements", len(reply))
+	}
+	result := &ClaimResult{
+		Type:       "redis_stream_claim",
+		Key:        opts.Key,
+		Group:      opts.Group,
+		Consumer:   opts.Consumer,
+		Claimed:    []StreamEntry{},
+		NextStart:  fmt.Sprint(reply[0]),
+		DeletedIDs: stringItems(reply, 2),
+	}
+	result.Complete = result.NextStart == "0-0"
+	items, _ := reply[1].([]interface{})
+	for _, item := range items {
+		if item == nil {
+			continue // Redis 7 之前已删除的消息以 nil 返回
+		}
+		if opts.JustID {
+			result.ClaimedIDs = append(result.ClaimedIDs, fmt.Sprint(item))
+			continue
+		}
+		entry, ok := rawStreamEntry(item)
+		if !ok {
+			return nil, fmt.Errorf("unexpected XAUTOCLAIM entry %v", item)
+		}
+		result.Claimed = append(result.Claimed, entry)
+	}
+	return result, nil
+}
+
+// stringItems 把 reply[i] 的数组转换为字符串列表，不存在时返回 nil
+func stringItems(reply []interface{}, i int) []string {
+	if i >= len(reply) {
+		return nil
+	}
+	items, _ := reply[i].([]interface{})
+	var out []string
+	for _, item := range items {
+		out = append(out, fmt.Sprint(item))
+	}
+	return out
+}
+
+// rawStreamEntry 解析 [id, [field, value, ...]] 形式的原始消息
+func rawStreamEntry(item interface{}) (StreamEntry, bool) {
+	pair, ok := item.([]interface{})
+	if !ok || len(pair) != 2 {
+		return StreamEntry{}, false
+	}
+	entry := StreamEntry{ID: fmt.Sprint(pair[0]), Fields: map[string]interface{}{}}
+	fields, _ := pair[1].([]interface{})
+	for i := 0; i+1 < len(fields); i += 2 {
+		entry.Fields[fmt.Sprint(fields[i])] = fields[i+1]
+	}
+	return entry, true
+}
+
+// StreamReadOptions redis_stream_read 参数
+type StreamReadOptions struct {
+	Streams  []string
+	IDs      []string // 与 Streams 一一对应；XREAD 默认 0（从头读），XREADGROUP 默认 >（新消息）
+	Group    string   // 指定时使用 XREADGROUP
+	Consumer string
+	Count    int
+	Block    time.Duration // 没有消息时最多阻塞等待的时间，0 表示不阻塞
+	NoAck    bool
+}
+
+// StreamMessages 一个 stream 中读到的消息
+type StreamMessages struct {
+	Stream   string        `json:"stream"`
+	Messages []StreamEntry `json:"messages"`
+	LastID   string        `json:"last_id,omitempty"` // 继续读取时作为下一次的 ID
+}
+
+// StreamReadResult redis_stream_read 结果
+type StreamReadResult struct {
+	Type     string           `json:"type"`
+	Group    string           `json:"group,omitempty"`
+	Consumer string           `json:"consumer,omitempty"`
+	Streams  []StreamMessages `json:"streams"`
+	Total    int              `json:"total"`
+	TimedOut bool             `json:"timed_out,omitempty"` // 阻塞等待超时仍没有消息
+}
+
+// StreamRead 以有界的 XREAD/XREADGROUP 读取消息，阻塞时间不超过 MaxStreamBlock
+func (r *RedisClient) StreamRead(ctx context.Context, opts StreamReadOptions) (*StreamReadResult, error) {
+	if len(opts.Streams) == 0 {
+		return nil, fmt.Errorf("streams are required")
+	}
+	if len(opts.IDs) != 0 && len(opts.IDs) != len(opts.Streams) {
+		return nil, fmt.Errorf("ids must match streams one to one")
+	}
+	if opts.Group != "" && opts.Consumer == "" {
+		return nil, fmt.Errorf("consumer is required when group is set")
+	}
+	if opts.Block > MaxStreamBlock {
+		opts.Block = MaxStreamBlock
+	}
+	// go-redis 中 Block 为 0 表示 BLOCK 0（永久阻塞），不阻塞需传负数
+	block := opts.Block
+	if block <= 0 {
+		block = -1
+	}
+	args := make([]string, 0, 2*len(opts.Streams))
+	args = append(args, opts.Streams...)
+	for i := range opts.Streams {
+		switch {
+		case len(opts.IDs) > 0:
+			args = append(args, opts.IDs[i])
+		case opts.Group != "":
+			args = append(args, ">")
+		default:
+			args = append(args, "0")
+		}
+	}
+
+	var streams []redis.XStream
+	var err error
+	if opts.Group != "" {
+		streams, err = r.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    opts.Group,
+			Consumer: opts.Consumer,
+			Streams:  args,
+			Count:    streamCount(opts.Count),
+			Block:    block,
+			NoAck:    opts.NoAck,
+		}).Result()
+	} else {
+		streams, err = r.client.XRead(ctx, &redis.XReadArgs{
+			Streams: args,
+			Count:   streamCount(opts.Count),
+			Block:   block,
+		}).Result()
+	}
+	result := &StreamReadResult{Type: "redis_stream_read", Group: opts.Group, Consumer: opts.Consumer, Streams: []StreamMessages{}}
+	if err == redis.Nil {
+		result.TimedOut = opts.Block > 0
+		return result, nil
+	}
+	if err != nil {
+		cmd := "XREAD"
+		if opts.Group != "" {
+			cmd = "XREADGROUP"
+		}
+		if strings.HasPrefix(err.Error(), "NOGROUP") {
+			return nil, fmt.Errorf("%s failed: %w (create the group with XGROUP CREATE first)", cmd, err)
+		}
+		return nil, fmt.Errorf("%s failed: %w", cmd, err)
+	}
+	for _, s := range streams {
+		msgs := StreamMessages{Stream: s.Stream, Messages: streamEntries(s.Messages)}
+		if len(s.Messages) > 0 {
+			msgs.LastID = s.Messages[len(s.Messages)-1].ID
+		}
+		result.Total += len(s.Messages)
+		result.Streams = append(result.Streams, msgs)
+	}
+	return result, nil
+}
@@ -0,0 +1,203 @@
+package redis_db
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// streamFixture 按 Redis 7 的 RESP2 格式回复 stream 相关命令，并记录最后一次收到的参数
+type streamFixture struct {
+	last map[string][]string
+}
+
+func (s *streamFixture) handle(args []string) interface{} {
+	cmd := strings.ToUpper(args[0])
+	if cmd == "XINFO" {
+		cmd += " " + strings.ToUpper(args[1])
+	}
+	s.last[cmd] = args
+	switch cmd {
+	case "XINFO STREAM":
+		if args[2] == "legacy" {
+			// Redis 6 的回复没有 entries-added 等字段
+			return []interface{}{"length", 1, "radix-tree-keys", 1, "radix-tree-nodes", 2, "last-generated-id", "1-0", "groups", 1,
+				"first-entry", []interface{}{"1-0", []string{"sku", "a"}}, "last-entry", []interface{}{"1-0", []string{"sku", "a"}}}
+		}
+		if args[2] != "orders" {
+			return fakeError("ERR no such key")
+		}
+		return []interface{}{
+			"length", 3,
+			"radix-tree-keys", 1,
+			"radix-tree-nodes", 2,
+			"last-generated-id", "3-0",
+			"max-deleted-entry-id", "0-0",
+			"entries-added", 3,
+			"recorded-first-entry-id", "1-0",
+			"groups", 2,
+			"first-entry", []interface{}{"1-0", []string{"sku", "a"}},
+			"last-entry", []interface{}{"3-0", []string{"sku", "c"}},
+		}
+	case "XINFO GROUPS":
+		if args[2] == "legacy" {
+			return []interface{}{
+				[]interface{}{"name", "billing", "consumers", 0, "pending", 0, "last-delivered-id", "0-0"},
+			}
+		}
+		return []interface{}{
+			[]interface{}{"name", "billing", "consumers", 2, "pending", 2, "last-delivered-id", "2-0", "entries-read", 2, "lag", 1},
+			[]interface{}{"name", "audit", "consumers", 0, "pending", 0, "last-delivered-id", "0-0", "entries-read", nil, "lag", nil},
+		}
+	case "XINFO CONSUMERS":
+		if args[3] == "audit" {
+			return []interface{}{}
+		}
+		return []interface{}{
+			[]interface{}{"name", "worker-1", "pending", 2, "idle", 600000, "inactive", 600000},
+			[]interface{}{"name", "worker-2", "pending", 0, "idle", 50, "inactive", 50},
+		}
+	case "XPENDING":
+		if len(args) == 3 {
+			if args[2] == "audit" {
+				return []interface{}{0, nil, nil, nil}
+			}
+			return []interface{}{2, "1-0", "2-0", []interface{}{[]interface{}{"worker-1", "2"}}}
+		}
+		return []interface{}{
+			[]interface{}{"1-0", "worker-1", 600000, 3},
+			[]interface{}{"2-0", "worker-1", 590000, 1},
+		}
+	case "XAUTOCLAIM":
+		return []interface{}{"0-0",
+			[]interface{}{[]interface{}{"1-0", []string{"sku", "a"}}},
+			[]string{"0-5"},
+		}
+	case "XREAD", "XREADGROUP":
+		for _, a := range args {
+			if a == "empty" {
+				return nil
+			}
+		}
+		return []interface{}{
+			[]interface{}{"orders", []interface{}{
+				[]interface{}{"2-0", []string{"sku", "b"}},
+				[]interface{}{"3-0", []string{"sku", "c"}},
+			}},
+		}
+	}
+	return fakeError("ERR unknown command '" + args[0] + "'")
+}
+
+func TestStreamInfo(t *testing.T) {
+	fixture := &streamFixture{last: map[string][]string{}}
+	_, client := newFakeRedis(t, fixture.handle)
+	ctx := context.Background()
+
+	info, err := client.StreamInfo(ctx, "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Length != 3 || info.FirstEntry.ID != "1-0" || info.LastEntry.Fields["sku"] != "c" || len(info.Groups) != 2 {
+		t.Fatalf("unexpected stream info: %+v", info)
+	}
+	billing, audit := info.Groups[0], info.Groups[1]
+	if billing.Lag == nil || *billing.Lag != 1 || billing.Pending != 2 || billing.OldestPendingID != "1-0" || billing.NewestPendingID != "2-0" {
+		t.Errorf("unexpected billing group: %+v", billing)
+	}
+	if len(billing.ConsumerDetails) != 2 || billing.ConsumerDetails[0].IdleMs != 600000 || *billing.ConsumerDetails[0].InactiveMs != 600000 {
+		t.Errorf("unexpected consumers: %+v", billing.ConsumerDetails)
+	}
+	if audit.Lag != nil || audit.EntriesRead != nil || len(audit.ConsumerDetails) != 0 {
+		t.Errorf("lag of audit group should be unknown: %+v", audit)
+	}
+	if billing.EntriesRead == nil || *billing.EntriesRead != 2 {
+		t.Errorf("unexpected entries read: %+v", billing)
+	}
+
+	// Redis 7 之前 XINFO GROUPS 没有 lag，不能报告为 0（已追上）
+	legacy, err := client.StreamInfo(ctx, "legacy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(legacy.Groups) != 1 || legacy.Groups[0].Lag != nil || legacy.Groups[0].EntriesRead != nil {
+		t.Errorf("lag should be null without server support: %+v", legacy.Groups)
+	}
+	if _, err := client.StreamInfo(ctx, "missing"); err == nil {
+		t.Error("expected error for missing stream")
+	}
+}
+
+func TestStreamPendingAndClaim(t *testing.T) {
+	fixture := &streamFixture{last: map[string][]string{}}
+	_, client := newFakeRedis(t, fixture.handle)
+	ctx := context.Background()
+
+	pending, err := client.StreamPending(ctx, PendingOptions{Key: "orders", Group: "billing", Consumer: "worker-1", MinIdle: 5 * time.Minute, Count: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"xpending", "orders", "billing", "idle", "300000", "-", "+", "2", "worker-1"}
+	if got := fixture.last["XPENDING"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("XPENDING args = %v, want %v", got, want)
+	}
+	if pending.Total != 2 || pending.Consumers["worker-1"] != 2 || len(pending.Entries) != 2 ||
+		pending.Entries[0].IdleMs != 600000 || pending.Entries[0].Deliveries != 3 || pending.NextStart != "(2-0" {
+		t.Fatalf("unexpected pending result: %+v", pending)
+	}
+
+	claim, err := client.StreamClaim(ctx, ClaimOptions{Key: "orders", Group: "billing", Consumer: "worker-2", MinIdle: time.Minute, Count: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"XAUTOCLAIM", "orders", "billing", "worker-2", "60000", "0-0", "COUNT", "10"}
+	if got := fixture.last["XAUTOCLAIM"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("XAUTOCLAIM args = %v, want %v", got, want)
+	}
+	if len(claim.Claimed) != 1 || claim.Claimed[0].ID != "1-0" || claim.Claimed[0].Fields["sku"] != "a" ||
+		!reflect.DeepEqual(claim.DeletedIDs, []string{"0-5"}) || !claim.Complete {
+		t.Fatalf("unexpected claim result: %+v", claim)
+	}
+}
+
+func TestStreamRead(t *testing.T) {
+	fixture := &streamFixture{last: map[string][]string{}}
+	_, client := newFakeRedis(t, fixture.handle)
+	ctx := context.Background()
+
+	res, err := client.StreamRead(ctx, StreamReadOptions{Streams: []string{"orders"}, IDs: []string{"1-0"}, Count: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"xread", "count", "5", "streams", "orders", "1-0"}; !reflect.DeepEqual(fixture.last["XREAD"], want) {
+		t.Errorf("XREAD args = %v, want %v (must not block)", fixture.last["XREAD"], want)
+	}
+	if res.Total != 2 || res.Streams[0].LastID != "3-0" || res.Streams[0].Messages[0].Fields["sku"] != "b" {
+		t.Fatalf("unexpected read result: %+v", res)
+	}
+
+	res, err = client.StreamRead(ctx, StreamReadOptions{Streams: []string{"orders"}, Group: "billing", Consumer: "worker-1", Block: 2 * time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"xreadgroup", "group", "billing", "worker-1", "count", "100", "block", "60000", "streams", "orders", ">"}
+	if got := fixture.last["XREADGROUP"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("XREADGROUP args = %v, want %v", got, want)
+	}
+	if res.Total != 2 {
+		t.Errorf("unexpected group read: %+v", res)
+	}
+
+	res, err = client.StreamRead(ctx, StreamReadOptions{Streams: []string{"empty"}, Block: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.TimedOut || res.Total != 0 {
+		t.Errorf("expected timeout, got %+v", res)
+	}
+	if _, err := client.StreamRead(ctx, StreamReadOptions{Streams: []string{"orders"}, Group: "billing"}); err == nil {
+		t.Error("expected error without consumer")
+	}
+}
@@ -0,0 +1,750 @@
+package schema
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"xz_mcp/db/sqlutil"
+)
+
+// 词法单元类型
+const (
+	tkEOF = iota
+	tkWord
+	tkString
+	tkNumber
+	tkPunct
+)
+
+// token DDL 词法单元，start/end 为在语句中的字节位置
+type token struct {
+	kind       int
+	text       string // 标识符已去除引号
+	upper      string
+	start, end int
+}
+
+var dollarOpen = regexp.MustCompile(`^\$[A-Za-z_]*\$`)
+
+// ParseDDLFile 读取并解析 DDL 文件
+func ParseDDLFile(path, dialect string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DDL file: %v", err)
+	}
+	return ParseDDL(string(data), dialect), nil
+}
+
+// ParseDDL 解析 DDL 脚本中的 CREATE TABLE/INDEX/VIEW/FUNCTION/PROCEDURE 和 ALTER TABLE 语句，
+// 其他语句跳过并在 Notes 中计数；无法解析的语句同样记录在 Notes 中
+func ParseDDL(text, dialect string) *Schema {
+	s := &Schema{Dialect: dialect, Tables: []*Table{}, Views: []*View{}, Routines: []*Routine{}}
+	skipped := map[string]int{}
+	for n, stmt := range SplitStatements(text, dialect) {
+		p := &parser{dialect: dialect, src: stmt, toks: lex(stmt, dialect)}
+		if p.eof() {
+			continue
+		}
+		var err error
+		switch first := p.peek().upper; first {
+		case "CREATE":
+			var kind string
+			kind, err = p.create(s)
+			if kind != "" {
+				skipped["CREATE "+kind]++
+			}
+		case "ALTER":
+			if !p.is("ALTER", "TABLE") {
+				skipped[first]++
+				continue
+			}
+			err = p.alterTable(s)
+		default:
+			skipped[first]++
+		}
+		if err != nil {
+			s.Notes = append(s.Notes, fmt.Sprintf("statement %d: %v", n+1, err))
+		}
+	}
+	if len(skipped) > 0 {
+		kinds := make([]string, 0, len(skipped))
+		for k, c := range skipped {
+			kinds = append(kinds, fmt.Sprintf("%s x%d", k, c))
+		}
+		sort.Strings(kinds)
+		s.Notes = append(s.Notes, "skipped statements: "+strings.Join(kinds, ", "))
+	}
+	finalize(s)
+	return s
+}
+
+// SplitStatements 按分隔符拆分脚本，支持 mysql 客户端的 DELIMITER 指令和 PostgreSQL 的 $$ 引用
+func SplitStatements(text, dialect string) []string {
+	var stmts []string
+	delim := ";"
+	start := 0
+	emit := func(end int) {
+		if stmt := strings.TrimSpace(text[start:end]); stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+	}
+	for i := 0; i < len(text); {
+		if strings.TrimSpace(text[start:i]) == "" && len(text)-i > 10 && strings.EqualFold(text[i:i+10], "DELIMITER ") {
+			end := strings.IndexByte(text[i:], '\n')
+			if end < 0 {
+				end = len(text) - i
+			}
+			delim = strings.TrimSpace(text[i+10 : i+end])
+			i += end
+			start = i
+			continue
+		}
+		c := text[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			i = skipQuote(text, i, dialect)
+			continue
+		case c == '-' && strings.HasPrefix(text[i:], "--"), c == '#' && dialect == sqlutil.DialectMySQL:
+			for i < len(text) && text[i] != '\n' {
+				i++
+			}
+			continue
+		case c == '/' && strings.HasPrefix(text[i:], "/*"):
+			if end := strings.Index(text[i+2:], "*/"); end >= 0 {
+				i += end + 4
+			} else {
+				i = len(text)
+			}
+			continue
+		case c == '$' && dialect == sqlutil.DialectPostgres:
+			if tag := dollarOpen.FindString(text[i:]); tag != "" {
+				if end := strings.Index(text[i+len(tag):], tag); end >= 0 {
+					i += len(tag) + end + len(tag)
+				} else {
+					i = len(text)
+				}
+				continue
+			}
+		}
+		if strings.HasPrefix(text[i:], delim) {
+			emit(i)
+			i += len(delim)
+			start = i
+			continue
+		}
+		i++
+	}
+	emit(len(text))
+	return stmts
+}
+
+// skipQuote 跳过引号包围的内容，返回结束引号之后的位置
+func skipQuote(text string, i int, dialect string) int {
+	q := text[i]
+	for j := i + 1; j < len(text); j++ {
+		if text[j] == '\\' && q != '`' && dialect == sqlutil.DialectMySQL {
+			j++
+			continue
+		}
+		if text[j] == q {
+			if j+1 < len(text) && text[j+1] == q {
+				j++
+				continue
+			}
+			return j + 1
+		}
+	}
+	return len(text)
+}
+
+// lex 将单条语句拆分为词法单元，忽略注释
+func lex(stmt, dialect string) []token {
+	var toks []token
+	for i := 0; i < len(stmt); {
+		c := stmt[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '-' && strings.HasPrefix(stmt[i:], "--"), c == '#' && dialect == sqlutil.DialectMySQL:
+			for i < len(stmt) && stmt[i] != '\n' {
+				i++
+			}
+		case c == '/' && strings.HasPrefix(stmt[i:], "/*"):
+			if end := strings.Index(stmt[i+2:], "*/"); end >= 0 {
+				i += end + 4
+			} else {
+				i = len(stmt)
+			}
+		case c == '\'':
+			end := skipQuote(stmt, i, dialect)
+			toks = append(toks, token{kind: tkString, text: stmt[i:end], start: i, end: end})
+			i = end
+		case c == '`' || c == '"':
+			end := skipQuote(stmt, i, dialect)
+			text := sqlutil.UnquoteIdent(stmt[i:end])
+			toks = append(toks, token{kind: tkWord, text: text, upper: "\x00" + text, start: i, end: end})
+			i = end
+		case c == '$' && dollarOpen.MatchString(stmt[i:]):
+			tag := dollarOpen.FindString(stmt[i:])
+			end := len(stmt)
+			if n := strings.Index(stmt[i+len(tag):], tag); n >= 0 {
+				end = i + len(tag) + n + len(tag)
+			}
+			toks = append(toks, token{kind: tkString, text: stmt[i:end], start: i, end: end})
+			i = end
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80:
+			start := i
+			for i < len(stmt) && (stmt[i] == '_' || stmt[i] == '$' || stmt[i] >= 'a' && stmt[i] <= 'z' || stmt[i] >= 'A' && stmt[i] <= 'Z' || stmt[i] >= '0' && stmt[i] <= '9' || stmt[i] >= 0x80) {
+				i++
+			}
+			text := stmt[start:i]
+			toks = append(toks, token{kind: tkWord, text: text, upper: strings.ToUpper(text), start: start, end: i})
+		case c >= '0' && c <= '9':
+			start := i
+			for i < len(stmt) && (stmt[i] == '.' || stmt[i] >= '0' && stmt[i] <= '9') {
+				i++
+			}
+			toks = append(toks, token{kind: tkNumber, text: stmt[start:i], start: start, end: i})
+		default:
+			n := 1
+			if strings.HasPrefix(stmt[i:], "::") {
+				n = 2
+			}
+			toks = append(toks, token{kind: tkPunct, text: stmt[i : i+n], upper: stmt[i : i+n], start: i, end: i + n})
+			i += n
+		}
+	}
+	return toks
+}
+
+// parser 基于词法单元的递归下降解析器
+type parser struct {
+	dialect string
+	src     string
+	toks    []token
+	pos     int
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.toks)
+}
+
+func (p *parser) peek() token {
+	if p.eof() {
+		return token{kind: tkEOF}
+	}
+	return p.toks[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.peek()
+	if !p.eof() {
+		p.pos++
+	}
+	return t
+}
+
+// is 判断当前位置的单词序列（不消费）
+func (p *parser) is(words ...string) bool {
+	for i, w := range words {
+		if p.pos+i >= len(p.toks) || p.toks[p.pos+i].upper != w {
+			return false
+		}
+	}
+	return true
+}
+
+// accept 当前位置匹配单词序列时消费并返回 true
+func (p *parser) accept(words ...string) bool {
+	if !p.is(words...) {
+		return false
+	}
+	p.pos += len(words)
+	return true
+}
+
+// skipGroup 跳过当前位置开始的括号组
+func (p *parser) skipGroup() {
+	depth := 0
+	for !p.eof() {
+		switch p.next().upper {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		}
+		if depth <= 0 {
+			return
+		}
+	}
+}
+
+// name 读取可能带 schema 前缀的对象名，返回最后一段
+func (p *parser) name() string {
+	name := p.next().text
+	for p.is(".") {
+		p.next()
+		name = p.next().text
+	}
+	return name
+}
+
+// text 返回 [from, p.pos) 范围内词法单元对应的原文
+func (p *parser) text(from int) string {
+	if from >= p.pos {
+		return ""
+	}
+	return strings.Join(strings.Fields(p.src[p.toks[from].start:p.toks[p.pos-1].end]), " ")
+}
+
+// items 读取括号内按顶层逗号分隔的各项，消费右括号
+func (p *parser) items() []*parser {
+	var items []*parser
+	p.next() // (
+	depth, from := 0, p.pos
+	for !p.eof() {
+		t := p.peek()
+		switch {
+		case t.upper == "(":
+			depth++
+		case t.upper == ")" && depth > 0:
+			depth--
+		case (t.upper == "," || t.upper == ")") && depth == 0:
+			items = append(items, &parser{dialect: p.dialect, src: p.src, toks: p.toks[from:p.pos]})
+			p.next()
+			if t.upper == ")" {
+				return items
+			}
+			from = p.pos
+			continue
+		}
+		p.next()
+	}
+	return items
+}
+
+// columnList 读取 (a, b(10) DESC, ...) 形式的列列表
+func (p *parser) columnList() []string {
+	for !p.eof() && !p.is("(") {
+		p.next()
+	}
+	var columns []string
+	for _, item := range p.items() {
+		if item.eof() {
+			continue
+		}
+		// 列名后面可以跟前缀长度、排序方向或操作符类，其余情况视为表达式
+		first := item.next()
+		if first.kind == tkWord && (!item.is("(") || item.prefixLength()) {
+			columns = append(columns, first.text)
+			continue
+		}
+		item.pos = len(item.toks)
+		columns = append(columns, item.text(0))
+	}
+	return columns
+}
+
+// prefixLength 判断当前位置是否为 MySQL 索引前缀长度，如 (10)
+func (p *parser) prefixLength() bool {
+	return p.pos+2 < len(p.toks) && p.toks[p.pos+1].kind == tkNumber && p.toks[p.pos+2].upper == ")"
+}
+
+// 列定义中结束类型名的关键字
+var columnStops = map[string]bool{
+	"NOT": true, "NULL": true, "DEFAULT": true, "PRIMARY": true, "UNIQUE": true, "REFERENCES": true,
+	"CHECK": true, "CONSTRAINT": true, "AUTO_INCREMENT": true, "AUTOINCREMENT": true, "COMMENT": true,
+	"COLLATE": true, "GENERATED": true, "ON": true, "AS": true, "CHARSET": true, "KEY": true,
+	"INVISIBLE": true, "VISIBLE": true, "STORED": true, "VIRTUAL": true,
+}
+
+func (p *parser) atColumnStop() bool {
+	t := p.peek()
+	return t.kind == tkEOF || columnStops[t.upper] || p.is("CHARACTER", "SET")
+}
+
+// expression 读取到下一个列约束关键字为止的表达式
+func (p *parser) expression() string {
+	from := p.pos
+	for !p.eof() && (p.pos == from || !p.atColumnStop()) {
+		if p.is("(") {
+			p.skipGroup()
+		} else {
+			p.next()
+		}
+	}
+	return p.text(from)
+}
+
+// create 解析 CREATE 语句，返回被跳过的对象类型
+func (p *parser) create(s *Schema) (string, error) {
+	p.next()
+	unique := false
+	for !p.eof() {
+		t := p.next()
+		switch t.upper {
+		case "TABLE":
+			return "", p.createTable(s)
+		case "UNIQUE":
+			unique = true
+		case "INDEX":
+			return "", p.createIndex(s, unique)
+		case "VIEW":
+			return "", p.createView(s)
+		case "FUNCTION", "PROCEDURE":
+			name := p.name()
+			def := definerClause.ReplaceAllString(p.src[p.toks[0].start:], "")
+			if r := s.routine(t.upper, name); r != nil {
+				r.Definition = def
+			} else {
+				s.Routines = append(s.Routines, &Routine{Name: name, Kind: t.upper, Definition: def})
+			}
+			return "", nil
+		case "TEMPORARY", "TEMP":
+			return "TEMPORARY TABLE", nil
+		case "TRIGGER", "SEQUENCE", "TYPE", "EXTENSION", "SCHEMA", "DATABASE", "DOMAIN", "EVENT", "MATERIALIZED", "RULE", "POLICY", "AGGREGATE":
+			return t.upper, nil
+		}
+	}
+	return "", fmt.Errorf("unrecognized CREATE statement")
+}
+
+func (p *parser) createTable(s *Schema) error {
+	p.accept("IF", "NOT", "EXISTS")
+	name := p.name()
+	if !p.is("(") {
+		return fmt.Errorf("CREATE TABLE %s without a column list is not supported", name)
+	}
+	t := s.addTable(name)
+	*t = Table{Name: name}
+	for _, item := range p.items() {
+		if err := item.tableItem(t); err != nil {
+			return fmt.Errorf("table %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// tableItem 解析表定义中的一项（列或表级约束），ALTER TABLE ADD 也复用这里
+func (p *parser) tableItem(t *Table) error {
+	if p.eof() {
+		return nil
+	}
+	name := ""
+	if p.accept("CONSTRAINT") && !p.is("PRIMARY") && !p.is("UNIQUE") && !p.is("FOREIGN") && !p.is("CHECK") {
+		name = p.next().text
+	}
+	switch {
+	case p.accept("PRIMARY", "KEY"):
+		t.PrimaryKey = p.columnList()
+		t.PrimaryName = name
+	case p.accept("UNIQUE"):
+		if !p.accept("KEY") {
+			p.accept("INDEX")
+		}
+		if !p.is("(") && !p.is("USING") {
+			name = p.next().text
+		}
+		t.Indexes = append(t.Indexes, &Index{Name: name, Columns: p.columnList(), Unique: true})
+	case p.accept("KEY"), p.accept("INDEX"), p.accept("FULLTEXT"), p.accept("SPATIAL"):
+		if !p.accept("KEY") {
+			p.accept("INDEX")
+		}
+		if !p.is("(") && !p.is("USING") {
+			name = p.next().text
+		}
+		t.Indexes = append(t.Indexes, &Index{Name: name, Columns: p.columnList()})
+	case p.accept("FOREIGN", "KEY"):
+		if !p.is("(") {
+			name = p.next().text
+		}
+		fk := &ForeignKey{Name: name, Columns: p.columnList()}
+		if err := p.references(fk); err != nil {
+			return err
+		}
+		t.ForeignKeys = append(t.ForeignKeys, fk)
+	case p.is("CHECK"), p.is("EXCLUDE"), p.is("PERIOD"):
+	default:
+		return p.column(t)
+	}
+	return nil
+}
+
+// references 解析 REFERENCES 子句
+func (p *parser) references(fk *ForeignKey) error {
+	if !p.accept("REFERENCES") {
+		return fmt.Errorf("expected REFERENCES after FOREIGN KEY")
+	}
+	fk.RefTable = p.name()
+	if p.is("(") {
+		fk.RefColumns = p.columnList()
+	}
+	for !p.eof() && !p.atColumnStop() || p.is("ON") {
+		switch {
+		case p.accept("ON", "DELETE"):
+			fk.OnDelete = p.action()
+		case p.accept("ON", "UPDATE"):
+			fk.OnUpdate = p.action()
+		default:
+			p.next()
+		}
+	}
+	return nil
+}
+
+// action 读取外键动作
+func (p *parser) action() string {
+	if p.is("SET") || p.is("NO") {
+		first := p.next().upper
+		return first + " " + p.next().upper
+	}
+	return p.next().upper
+}
+
+// column 解析列定义
+func (p *parser) column(t *Table) error {
+	name := p.next()
+	if name.kind != tkWord {
+		return fmt.Errorf("unexpected %q in column list", name.text)
+	}
+	c := &Column{Name: name.text, Nullable: true}
+	from := p.pos
+	for !p.atColumnStop() {
+		if p.is("(") {
+			p.skipGroup()
+		} else {
+			p.next()
+		}
+	}
+	c.Type = p.text(from)
+	switch strings.ToUpper(c.Type) {
+	case "SERIAL", "SERIAL4", "BIGSERIAL", "SERIAL8", "SMALLSERIAL", "SERIAL2":
+		c.Type = normalizeType(sqlutil.DialectPostgres, c.Type)
+		c.AutoIncrement = true
+		c.Nullable = false
+	}
+
+	for !p.eof() {
+		switch {
+		case p.accept("NOT", "NULL"):
+			c.Nullable = false
+		case p.accept("NULL"):
+		case p.accept("DEFAULT"):
+			if def := p.expression(); !strings.EqualFold(def, "NULL") {
+				c.Default = &def
+			}
+		case p.accept("PRIMARY", "KEY"):
+			t.PrimaryKey = []string{c.Name}
+		case p.accept("UNIQUE"):
+			p.accept("KEY")
+			t.Indexes = append(t.Indexes, &Index{Columns: []string{c.Name}, Unique: true})
+		case p.is("REFERENCES"):
+			fk := &ForeignKey{Columns: []string{c.Name}}
+			if err := p.references(fk); err != nil {
+				return err
+			}
+			t.ForeignKeys = append(t.ForeignKeys, fk)
+		case p.accept("AUTO_INCREMENT"), p.accept("AUTOINCREMENT"):
+			c.AutoIncrement = true
+		case p.accept("GENERATED"):
+			for !p.eof() && !p.is("AS") {
+				p.next()
+			}
+			p.accept("AS")
+			if p.accept("IDENTITY") {
+				c.AutoIncrement = true
+			}
+			if p.is("(") {
+				p.skipGroup()
+			}
+		case p.accept("AS"):
+			if p.is("(") {
+				p.skipGroup()
+			}
+		case p.accept("CONSTRAINT"), p.accept("COMMENT"), p.accept("COLLATE"), p.accept("CHARSET"), p.accept("CHARACTER", "SET"):
+			p.next()
+		case p.accept("CHECK"):
+			p.skipGroup()
+		case p.accept("ON", "UPDATE"):
+			p.expression()
+		default:
+			p.next()
+		}
+	}
+	if existing := t.column(c.Name); existing != nil {
+		*existing = *c
+	} else {
+		t.Columns = append(t.Columns, c)
+	}
+	return nil
+}
+
+func (p *parser) createIndex(s *Schema, unique bool) error {
+	p.accept("CONCURRENTLY")
+	p.accept("IF", "NOT", "EXISTS")
+	name := ""
+	if !p.is("ON") {
+		name = p.name()
+	}
+	for !p.eof() && !p.is("ON") {
+		p.next()
+	}
+	if !p.accept("ON") {
+		return fmt.Errorf("expected ON in CREATE INDEX %s", name)
+	}
+	p.accept("ONLY")
+	table := p.name()
+	t := s.table(table)
+	if t == nil {
+		return fmt.Errorf("index %s references unknown table %s", name, table)
+	}
+	t.Indexes = append(t.Indexes, &Index{Name: name, Columns: p.columnList(), Unique: unique})
+	return nil
+}
+
+func (p *parser) createView(s *Schema) error {
+	p.accept("IF", "NOT", "EXISTS")
+	name := p.name()
+	for !p.eof() && !p.is("AS") {
+		if p.is("(") {
+			p.skipGroup()
+		} else {
+			p.next()
+		}
+	}
+	if !p.accept("AS") || p.eof() {
+		return fmt.Errorf("view %s has no AS clause", name)
+	}
+	def := strings.TrimSpace(p.src[p.peek().start:])
+	if v := s.view(name); v != nil {
+		v.Definition = def
+	} else {
+		s.Views = append(s.Views, &View{Name: name, Definition: def})
+	}
+	return nil
+}
+
+// alterTable 解析 ALTER TABLE 中影响结构的子句（pg_dump 输出依赖这些语句补充约束和默认值）
+func (p *parser) alterTable(s *Schema) error {
+	p.next()
+	p.next()
+	p.accept("IF", "EXISTS")
+	p.accept("ONLY")
+	name := p.name()
+	t := s.table(name)
+	if t == nil {
+		return fmt.Errorf("ALTER TABLE references unknown table %s", name)
+	}
+	// 复用 items 的逗号拆分：把剩余部分视为一个括号组
+	rest := append([]token{{kind: tkPunct, upper: "("}}, p.toks[p.pos:]...)
+	rest = append(rest, token{kind: tkPunct, upper: ")"})
+	actions := (&parser{dialect: p.dialect, src: p.src, toks: rest}).items()
+	for _, a := range actions {
+		var err error
+		switch {
+		case a.accept("ADD"):
+			a.accept("COLUMN")
+			a.accept("IF", "NOT", "EXISTS")
+			err = a.tableItem(t)
+		case a.accept("ALTER"):
+			a.accept("COLUMN")
+			c := t.column(a.next().text)
+			if c == nil {
+				continue
+			}
+			switch {
+			case a.accept("SET", "DEFAULT"):
+				def := a.expression()
+				c.Default = &def
+			case a.accept("DROP", "DEFAULT"):
+				c.Default = nil
+			case a.accept("SET", "NOT", "NULL"):
+				c.Nullable = false
+			case a.accept("DROP", "NOT", "NULL"):
+				c.Nullable = true
+			case a.accept("ADD", "GENERATED"):
+				c.AutoIncrement = true
+			case a.accept("TYPE"), a.accept("SET", "DATA", "TYPE"):
+				from := a.pos
+				for !a.eof() && !a.is("USING") && !a.is("COLLATE") {
+					a.next()
+				}
+				c.Type = a.text(from)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("table %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// finalize 补全默认约束名并统一自增列表示，解析和内省结果都经过这里
+func finalize(s *Schema) {
+	for _, t := range s.Tables {
+		for _, c := range t.Columns {
+			if c.Default != nil && strings.HasPrefix(strings.ToLower(*c.Default), "nextval(") {
+				c.Default = nil
+				c.AutoIncrement = true
+			}
+			if s.Dialect != sqlutil.DialectSQLite && t.isKey(c.Name) {
+				c.Nullable = false
+			}
+		}
+		if len(t.PrimaryKey) > 0 && t.PrimaryName == "" {
+			switch s.Dialect {
+			case sqlutil.DialectPostgres:
+				t.PrimaryName = t.Name + "_pkey"
+			case sqlutil.DialectMySQL:
+				t.PrimaryName = "PRIMARY"
+			}
+		}
+		for i, idx := range t.Indexes {
+			if idx.Name != "" {
+				continue
+			}
+			switch s.Dialect {
+			case sqlutil.DialectPostgres:
+				suffix := "_idx"
+				if idx.Unique {
+					suffix = "_key"
+				}
+				idx.Name = t.Name + "_" + strings.Join(idx.Columns, "_") + suffix
+			case sqlutil.DialectMySQL:
+				idx.Name = idx.Columns[0]
+			default:
+				idx.Name = fmt.Sprintf("sqlite_autoindex_%s_%d", t.Name, i+1)
+			}
+		}
+		for i, fk := range t.ForeignKeys {
+			if fk.Name == "" {
+				switch s.Dialect {
+				case sqlutil.DialectPostgres:
+					fk.Name = t.Name + "_" + strings.Join(fk.Columns, "_") + "_fkey"
+				case sqlutil.DialectMySQL:
+					fk.Name = fmt.Sprintf("%s_ibfk_%d", t.Name, i+1)
+				}
+			}
+			// MySQL 会为没有可用索引的外键自动创建同名索引
+			if s.Dialect == sqlutil.DialectMySQL && !t.hasIndexPrefix(fk.Columns) {
+				t.Indexes = append(t.Indexes, &Index{Name: fk.Name, Columns: fk.Columns})
+			}
+		}
+	}
+}
+
+// hasIndexPrefix 判断主键或某个索引以给定列开头
+func (t *Table) hasIndexPrefix(columns []string) bool {
+	if len(t.PrimaryKey) >= len(columns) && sameColumns(t.PrimaryKey[:len(columns)], columns) {
+		return true
+	}
+	for _, idx := range t.Indexes {
+		if len(idx.Columns) >= len(columns) && sameColumns(idx.Columns[:len(columns)], columns) {
+			return true
+		}
+	}
+	return false
+}
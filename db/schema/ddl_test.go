@@ -0,0 +1,168 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+
+	"xz_mcp/db/sqlutil"
+)
+
+const mysqlDDL = "CREATE TABLE `users` (\n" + `
+  id INT(11) NOT NULL AUTO_INCREMENT,
+  email VARCHAR(255) NOT NULL COMMENT 'login; unique',
+  status TINYINT(1) DEFAULT '1',
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_email (email),
+  KEY idx_name_prefix (email(10), status)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE orders (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  user_id INT NOT NULL,
+  CONSTRAINT fk_orders_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+INSERT INTO users (email) VALUES ('a@example.com');
+
+DELIMITER $$
+CREATE DEFINER=` + "`root`@`%`" + ` FUNCTION order_count(uid INT) RETURNS INT
+BEGIN
+  DECLARE n INT;
+  SELECT COUNT(*) INTO n FROM orders WHERE user_id = uid;
+  RETURN n;
+END $$
+DELIMITER ;
+
+CREATE OR REPLACE VIEW active_users AS SELECT id, email FROM users WHERE status = 1;
+`
+
+func TestParseMySQLDDL(t *testing.T) {
+	s := ParseDDL(mysqlDDL, sqlutil.DialectMySQL)
+
+	users := s.table("users")
+	if users == nil || len(users.Columns) != 4 {
+		t.Fatalf("users not parsed: %+v", users)
+	}
+	id := users.column("id")
+	if id.Type != "INT(11)" || id.Nullable || !id.AutoIncrement {
+		t.Errorf("id column = %+v", id)
+	}
+	if c := users.column("status"); c.Default == nil || *c.Default != "'1'" || !c.Nullable {
+		t.Errorf("status column = %+v", c)
+	}
+	if c := users.column("created_at"); c.Default == nil || *c.Default != "CURRENT_TIMESTAMP" {
+		t.Errorf("created_at column = %+v", c)
+	}
+	if len(users.PrimaryKey) != 1 || users.PrimaryName != "PRIMARY" {
+		t.Errorf("primary key = %v %s", users.PrimaryKey, users.PrimaryName)
+	}
+	if len(users.Indexes) != 2 || !users.Indexes[0].Unique || strings.Join(users.Indexes[1].Columns, ",") != "email,status" {
+		t.Errorf("indexes = %+v %+v", users.Indexes[0], users.Indexes[1])
+	}
+
+	orders := s.table("orders")
+	if orders == nil || len(orders.ForeignKeys) != 1 {
+		t.Fatalf("orders not parsed: %+v", orders)
+	}
+	fk := orders.ForeignKeys[0]
+	if fk.Name != "fk_orders_user" || fk.RefTable != "users" || fk.OnDelete != "CASCADE" {
+		t.Errorf("foreign key = %+v", fk)
+	}
+	// MySQL 为外键自动创建的索引
+	if len(orders.Indexes) != 1 || orders.Indexes[0].Name != "fk_orders_user" {
+		t.Errorf("implicit foreign key index missing: %+v", orders.Indexes)
+	}
+	if orders.column("id").Type != "BIGINT UNSIGNED" {
+		t.Errorf("orders.id type = %s", orders.column("id").Type)
+	}
+
+	if len(s.Routines) != 1 || s.Routines[0].Kind != "FUNCTION" || !strings.HasSuffix(s.Routines[0].Definition, "END") {
+		t.Fatalf("routines = %+v", s.Routines)
+	}
+	if len(s.Views) != 1 || s.Views[0].Definition != "SELECT id, email FROM users WHERE status = 1" {
+		t.Fatalf("views = %+v", s.Views)
+	}
+	if len(s.Notes) != 1 || s.Notes[0] != "skipped statements: INSERT x1" {
+		t.Errorf("notes = %v", s.Notes)
+	}
+}
+
+const pgDumpDDL = `
+SET statement_timeout = 0;
+
+CREATE FUNCTION public.touch() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+  NEW.updated_at := now(); -- keep ; inside body
+  RETURN NEW;
+END;
+$$;
+
+CREATE TABLE public.accounts (
+    id integer NOT NULL,
+    name character varying(64) DEFAULT 'n/a'::character varying NOT NULL,
+    tags text[],
+    balance numeric(12,2) DEFAULT 0,
+    owner_id bigint
+);
+
+CREATE SEQUENCE public.accounts_id_seq AS integer START WITH 1;
+ALTER TABLE ONLY public.accounts ALTER COLUMN id SET DEFAULT nextval('public.accounts_id_seq'::regclass);
+ALTER TABLE ONLY public.accounts
+    ADD CONSTRAINT accounts_pkey PRIMARY KEY (id);
+ALTER TABLE ONLY public.accounts
+    ADD CONSTRAINT accounts_owner_id_fkey FOREIGN KEY (owner_id) REFERENCES public.accounts(id) ON UPDATE SET NULL;
+CREATE UNIQUE INDEX accounts_lower_name ON public.accounts USING btree (lower((name)::text));
+CREATE INDEX accounts_owner ON public.accounts USING btree (owner_id DESC);
+`
+
+func TestParsePostgresDump(t *testing.T) {
+	s := ParseDDL(pgDumpDDL, sqlutil.DialectPostgres)
+	a := s.table("accounts")
+	if a == nil || len(a.Columns) != 5 {
+		t.Fatalf("accounts not parsed: %+v (notes %v)", a, s.Notes)
+	}
+	if c := a.column("id"); !c.AutoIncrement || c.Default != nil || c.Nullable {
+		t.Errorf("id column = %+v", c)
+	}
+	if c := a.column("name"); c.Type != "character varying(64)" || c.Nullable || normalizeDefault(c.Default) != "'n/a'" {
+		t.Errorf("name column = %+v", c)
+	}
+	if c := a.column("tags"); c.Type != "text[]" {
+		t.Errorf("tags type = %s", c.Type)
+	}
+	if a.PrimaryName != "accounts_pkey" || len(a.ForeignKeys) != 1 || a.ForeignKeys[0].OnUpdate != "SET NULL" {
+		t.Errorf("constraints = %s %+v", a.PrimaryName, a.ForeignKeys[0])
+	}
+	if len(a.Indexes) != 2 || a.Indexes[0].Columns[0] != "lower((name)::text)" || a.Indexes[1].Columns[0] != "owner_id" {
+		t.Errorf("indexes = %+v %+v", a.Indexes[0], a.Indexes[1])
+	}
+	if len(s.Routines) != 1 || !strings.Contains(s.Routines[0].Definition, "keep ; inside body") {
+		t.Errorf("routines = %+v", s.Routines)
+	}
+}
+
+func TestNormalizeType(t *testing.T) {
+	tests := []struct {
+		dialect, a, b string
+	}{
+		{sqlutil.DialectMySQL, "INT(11)", "int"},
+		{sqlutil.DialectMySQL, "BOOLEAN", "tinyint(1)"},
+		{sqlutil.DialectMySQL, "numeric", "decimal(10,0)"},
+		{sqlutil.DialectMySQL, "bigint(20) unsigned", "BIGINT UNSIGNED"},
+		{sqlutil.DialectPostgres, "varchar(64)", "character varying(64)"},
+		{sqlutil.DialectPostgres, "timestamptz", "timestamp with time zone"},
+		{sqlutil.DialectPostgres, "TIMESTAMP", "timestamp without time zone"},
+		{sqlutil.DialectPostgres, "serial", "integer"},
+	}
+	for _, tt := range tests {
+		if normalizeType(tt.dialect, tt.a) != normalizeType(tt.dialect, tt.b) {
+			t.Errorf("%s: %q (%s) != %q (%s)", tt.dialect, tt.a, normalizeType(tt.dialect, tt.a), tt.b, normalizeType(tt.dialect, tt.b))
+		}
+	}
+	if normalizeType(sqlutil.DialectMySQL, "tinyint(1)") == normalizeType(sqlutil.DialectMySQL, "tinyint(4)") {
+		t.Error("tinyint(1) should differ from tinyint(4)")
+	}
+}
@@ -0,0 +1,645 @@
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"xz_mcp/db/dataio"
+	"xz_mcp/db/sqlutil"
+)
+
+// Change 单项结构差异
+type Change struct {
+	Object      string `json:"object"` // table/column/primary_key/index/foreign_key/view/routine
+	Action      string `json:"action"` // create/drop/alter
+	Table       string `json:"table,omitempty"`
+	Name        string `json:"name"`
+	Detail      string `json:"detail,omitempty"`
+	Destructive bool   `json:"destructive,omitempty"`
+}
+
+// DiffResult 结构比较结果，迁移脚本把目标库变更为与源一致
+type DiffResult struct {
+	Type                  string   `json:"type"`
+	Source                string   `json:"source"`
+	Target                string   `json:"target"`
+	Dialect               string   `json:"dialect"`
+	Identical             bool     `json:"identical"`
+	Changes               []Change `json:"changes"`
+	Statements            []string `json:"statements"`
+	DestructiveStatements []string `json:"destructive_statements"`
+	Script                string   `json:"script"`
+	Notes                 []string `json:"notes,omitempty"`
+}
+
+// 迁移步骤的执行顺序：先拆除会被重建的依赖对象，再建表加列、修改列，最后重建索引、外键、视图和例程；
+// 列类型变更可能丢失数据，但新的索引和外键依赖变更后的类型，必须在它们之前执行；
+// stepDropRoutine 及之后的步骤会删除对象，单独放在脚本末尾
+const (
+	stepDropChangedFK = iota
+	stepDropChangedView
+	stepDropChangedIndex
+	stepCreateTable
+	stepAddColumn
+	stepAlterColumn
+	stepChangeType
+	stepAlterPrimaryKey
+	stepCreateIndex
+	stepAddFK
+	stepCreateView
+	stepCreateRoutine
+	stepDropRoutine
+	stepDropView
+	stepDropFK
+	stepDropIndex
+	stepDropColumn
+	stepDropTable
+)
+
+// destructiveStep 该步骤是否可能丢失数据或对象
+func destructiveStep(order int) bool {
+	return order == stepChangeType || order >= stepDropRoutine
+}
+
+type step struct {
+	order int
+	sql   string
+}
+
+type differ struct {
+	src, dst *Schema
+	dialect  string
+	result   *DiffResult
+	steps    []step
+	noted    map[string]bool
+}
+
+// Diff 比较源和目标结构，生成把目标变更为源的迁移脚本（目标方言）
+func Diff(source, target *Schema) *DiffResult {
+	d := &differ{
+		src:     source,
+		dst:     target,
+		dialect: target.Dialect,
+		result: &DiffResult{
+			Type:                  "diff_schema",
+			Dialect:               target.Dialect,
+			Changes:               []Change{},
+			Statements:            []string{},
+			DestructiveStatements: []string{},
+		},
+		noted: map[string]bool{},
+	}
+	for _, n := range source.Notes {
+		d.note("source: " + n)
+	}
+	for _, n := range target.Notes {
+		d.note("target: " + n)
+	}
+	if source.Dialect != target.Dialect {
+		d.note(fmt.Sprintf("source dialect %s differs from target %s: column types are mapped and defaults/views copied as-is, review them before running", source.Dialect, target.Dialect))
+	}
+
+	d.diffTables()
+	d.diffViews()
+	d.diffRoutines()
+
+	sort.SliceStable(d.steps, func(i, j int) bool { return d.steps[i].order < d.steps[j].order })
+	var script strings.Builder
+	fmt.Fprintf(&script, "-- Migration generated by diff_schema (%s)\n", d.dialect)
+	header := false
+	for _, s := range d.steps {
+		switch {
+		case s.order >= stepDropRoutine:
+			if !header {
+				script.WriteString("\n-- ============================================================\n")
+				script.WriteString("-- DESTRUCTIVE CHANGES: the statements below drop objects and\n")
+				script.WriteString("-- may lose data. Review and back up first.\n")
+				script.WriteString("-- ============================================================\n")
+				header = true
+			}
+		case destructiveStep(s.order) && !strings.HasPrefix(s.sql, "--"):
+			script.WriteString("-- DESTRUCTIVE: column type change may lose data\n")
+		}
+		if destructiveStep(s.order) {
+			d.result.DestructiveStatements = append(d.result.DestructiveStatements, s.sql)
+		} else {
+			d.result.Statements = append(d.result.Statements, s.sql)
+		}
+		script.WriteString(scriptStatement(d.dialect, s.sql))
+	}
+	d.result.Script = script.String()
+	d.result.Identical = len(d.result.Changes) == 0
+	return d.result
+}
+
+var routineStatement = regexp.MustCompile(`(?i)^CREATE\s+(OR\s+REPLACE\s+)?(DEFINER\s*=\s*\S+\s+)?(FUNCTION|PROCEDURE)\b`)
+
+// scriptStatement 把单条语句写成脚本文本；MySQL 例程需要临时更换分隔符
+func scriptStatement(dialect, stmt string) string {
+	if strings.HasPrefix(stmt, "--") {
+		return stmt + "\n"
+	}
+	if dialect == sqlutil.DialectMySQL && routineStatement.MatchString(stmt) {
+		return "DELIMITER $$\n" + stmt + " $$\nDELIMITER ;\n"
+	}
+	return stmt + ";\n"
+}
+
+func (d *differ) note(msg string) {
+	if !d.noted[msg] {
+		d.noted[msg] = true
+		d.result.Notes = append(d.result.Notes, msg)
+	}
+}
+
+func (d *differ) add(order int, sql string) {
+	d.steps = append(d.steps, step{order: order, sql: sql})
+}
+
+func (d *differ) change(c Change) {
+	d.result.Changes = append(d.result.Changes, c)
+}
+
+func (d *differ) quote(name string) string {
+	return sqlutil.QuoteIdent(d.dialect, name)
+}
+
+func (d *differ) quoteList(names []string) string {
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		if strings.HasPrefix(n, "(") {
+			quoted[i] = n // 表达式索引
+		} else {
+			quoted[i] = d.quote(n)
+		}
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// rebuild SQLite 无法原地修改的结构，以注释形式写入脚本
+func (d *differ) rebuild(order int, table, what string) {
+	d.add(order, fmt.Sprintf("-- SQLite cannot %s on %s: rebuild the table (create new, copy rows, drop, rename)", what, table))
+	d.note("some SQLite changes require a table rebuild and are emitted as comments")
+}
+
+func (d *differ) diffTables() {
+	for _, st := range sortedTables(d.src) {
+		if dt := d.dst.table(st.Name); dt != nil {
+			d.diffTable(st, dt)
+		} else {
+			d.createTable(st)
+		}
+	}
+	for _, dt := range sortedTables(d.dst) {
+		if d.src.table(dt.Name) == nil {
+			d.change(Change{Object: "table", Action: "drop", Name: dt.Name, Destructive: true})
+			d.add(stepDropTable, "DROP TABLE "+d.quote(dt.Name))
+		}
+	}
+}
+
+func sortedTables(s *Schema) []*Table {
+	tables := append([]*Table(nil), s.Tables...)
+	sort.Slice(tables, func(i, j int) bool { return strings.ToLower(tables[i].Name) < strings.ToLower(tables[j].Name) })
+	return tables
+}
+
+// columnType 返回列在目标方言中的类型
+func (d *differ) columnType(t *Table, c *Column) string {
+	if d.src.Dialect == d.dialect {
+		return c.Type
+	}
+	return dataio.RenderType(d.dialect, dataio.ClassifyTypeName(c.Type), t.isKey(c.Name))
+}
+
+// columnDef 生成列定义（CREATE TABLE/ADD COLUMN/MODIFY COLUMN 使用）
+func (d *differ) columnDef(t *Table, c *Column) string {
+	typ := d.columnType(t, c)
+	if c.AutoIncrement && d.dialect == sqlutil.DialectPostgres {
+		switch normalizeType(sqlutil.DialectPostgres, typ) {
+		case "integer":
+			typ = "serial"
+		case "bigint":
+			typ = "bigserial"
+		case "smallint":
+			typ = "smallserial"
+		}
+	}
+	def := strings.TrimSpace(d.quote(c.Name) + " " + typ)
+	if !c.Nullable {
+		def += " NOT NULL"
+	}
+	if c.Default != nil {
+		def += " DEFAULT " + d.defaultExpr(c.Default)
+	}
+	if c.AutoIncrement && d.dialect == sqlutil.DialectMySQL {
+		def += " AUTO_INCREMENT"
+	}
+	return def
+}
+
+// defaultExpr 跨方言时去掉 PostgreSQL 风格的类型转换
+func (d *differ) defaultExpr(def *string) string {
+	if d.src.Dialect != d.dialect {
+		return castSuffix.ReplaceAllString(*def, "")
+	}
+	return *def
+}
+
+func (d *differ) createTable(t *Table) {
+	defs := make([]string, 0, len(t.Columns)+1)
+	for _, c := range t.Columns {
+		defs = append(defs, d.columnDef(t, c))
+	}
+	if len(t.PrimaryKey) > 0 {
+		defs = append(defs, "PRIMARY KEY ("+d.quoteList(t.PrimaryKey)+")")
+	}
+	// SQLite 不能事后添加外键，建表时内联；其他方言等所有表建好后再添加
+	if d.dialect == sqlutil.DialectSQLite {
+		for _, fk := range t.ForeignKeys {
+			defs = append(defs, d.foreignKeyClause(fk))
+		}
+	} else {
+		for _, fk := range t.ForeignKeys {
+			d.add(stepAddFK, d.addForeignKey(t, fk))
+		}
+	}
+	d.change(Change{Object: "table", Action: "create", Name: t.Name, Detail: fmt.Sprintf("%d columns", len(t.Columns))})
+	d.add(stepCreateTable, fmt.Sprintf("CREATE TABLE %s (\n  %s\n)", d.quote(t.Name), strings.Join(defs, ",\n  ")))
+	for _, idx := range t.Indexes {
+		if !d.autoIndex(t, idx) {
+			d.add(stepCreateIndex, d.createIndex(t, idx))
+		}
+	}
+}
+
+// autoIndex 判断索引是否由数据库随约束自动创建（无需单独建立）
+func (d *differ) autoIndex(t *Table, idx *Index) bool {
+	if strings.HasPrefix(idx.Name, "sqlite_autoindex_") {
+		return true
+	}
+	if d.dialect == sqlutil.DialectMySQL {
+		for _, fk := range t.ForeignKeys {
+			if fk.Name == idx.Name && sameColumns(fk.Columns, idx.Columns) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (d *differ) diffTable(st, dt *Table) {
+	for _, sc := range st.Columns {
+		dc := dt.column(sc.Name)
+		if dc == nil {
+			d.change(Change{Object: "column", Action: "create", Table: st.Name, Name: sc.Name, Detail: sc.Type})
+			d.add(stepAddColumn, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", d.quote(dt.Name), d.columnDef(st, sc)))
+			continue
+		}
+		d.diffColumn(st, sc, dt, dc)
+	}
+	for _, dc := range dt.Columns {
+		if st.column(dc.Name) == nil {
+			d.change(Change{Object: "column", Action: "drop", Table: dt.Name, Name: dc.Name, Destructive: true})
+			d.add(stepDropColumn, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", d.quote(dt.Name), d.quote(dc.Name)))
+		}
+	}
+
+	if !sameColumns(st.PrimaryKey, dt.PrimaryKey) {
+		d.change(Change{Object: "primary_key", Action: "alter", Table: st.Name, Name: st.PrimaryName,
+			Detail: fmt.Sprintf("(%s) -> (%s)", strings.Join(dt.PrimaryKey, ", "), strings.Join(st.PrimaryKey, ", "))})
+		d.alterPrimaryKey(st, dt)
+	}
+
+	d.diffIndexes(st, dt)
+	d.diffForeignKeys(st, dt)
+}
+
+func (d *differ) diffColumn(st *Table, sc *Column, dt *Table, dc *Column) {
+	typeChanged := !sameType(d.src.Dialect, sc.Type, d.dialect, dc.Type)
+	nullChanged := sc.Nullable != dc.Nullable
+	defaultChanged := normalizeDefault(sc.Default) != normalizeDefault(dc.Default)
+	autoChanged := sc.AutoIncrement != dc.AutoIncrement && d.dialect != sqlutil.DialectSQLite && d.src.Dialect != sqlutil.DialectSQLite
+	if !typeChanged && !nullChanged && !defaultChanged && !autoChanged {
+		return
+	}
+
+	var details []string
+	if typeChanged {
+		details = append(details, fmt.Sprintf("type %s -> %s", dc.Type, sc.Type))
+	}
+	if nullChanged {
+		details = append(details, fmt.Sprintf("nullable %v -> %v", dc.Nullable, sc.Nullable))
+	}
+	if defaultChanged {
+		details = append(details, fmt.Sprintf("default %s -> %s", describeDefault(dc.Default), describeDefault(sc.Default)))
+	}
+	if autoChanged {
+		details = append(details, fmt.Sprintf("auto_increment %v -> %v", dc.AutoIncrement, sc.AutoIncrement))
+	}
+	d.change(Change{Object: "column", Action: "alter", Table: st.Name, Name: sc.Name, Detail: strings.Join(details, "; "), Destructive: typeChanged})
+
+	table := d.quote(dt.Name)
+	switch d.dialect {
+	case sqlutil.DialectMySQL:
+		order := stepAlterColumn
+		if typeChanged {
+			order = stepChangeType
+		}
+		d.add(order, fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s", table, d.columnDef(st, sc)))
+	case sqlutil.DialectPostgres:
+		col := d.quote(sc.Name)
+		if typeChanged {
+			typ := d.columnType(st, sc)
+			d.add(stepChangeType, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s USING %s::%s", table, col, typ, col, typ))
+		}
+		if nullChanged {
+			if sc.Nullable {
+				d.add(stepAlterColumn, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL", table, col))
+			} else {
+				d.add(stepAlterColumn, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL", table, col))
+			}
+		}
+		if defaultChanged {
+			if sc.Default == nil {
+				d.add(stepAlterColumn, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT", table, col))
+			} else {
+				d.add(stepAlterColumn, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s", table, col, d.defaultExpr(sc.Default)))
+			}
+		}
+		if autoChanged {
+			d.note(fmt.Sprintf("auto-increment of %s.%s differs: add or drop the identity/sequence manually", st.Name, sc.Name))
+		}
+	default:
+		order := stepAlterColumn
+		if typeChanged {
+			order = stepChangeType
+		}
+		d.rebuild(order, dt.Name, "alter column "+sc.Name+" ("+strings.Join(details, "; ")+")")
+	}
+}
+
+func describeDefault(def *string) string {
+	if def == nil {
+		return "none"
+	}
+	return *def
+}
+
+func (d *differ) alterPrimaryKey(st, dt *Table) {
+	table := d.quote(dt.Name)
+	switch d.dialect {
+	case sqlutil.DialectMySQL:
+		var parts []string
+		if len(dt.PrimaryKey) > 0 {
+			parts = append(parts, "DROP PRIMARY KEY")
+		}
+		if len(st.PrimaryKey) > 0 {
+			parts = append(parts, "ADD PRIMARY KEY ("+d.quoteList(st.PrimaryKey)+")")
+		}
+		d.add(stepAlterPrimaryKey, fmt.Sprintf("ALTER TABLE %s %s", table, strings.Join(parts, ", ")))
+	case sqlutil.DialectPostgres:
+		if len(dt.PrimaryKey) > 0 {
+			d.add(stepAlterPrimaryKey, fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s", table, d.quote(dt.PrimaryName)))
+		}
+		if len(st.PrimaryKey) > 0 {
+			name := st.PrimaryName
+			if name == "" || name == "PRIMARY" {
+				name = dt.Name + "_pkey"
+			}
+			d.add(stepAlterPrimaryKey, fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s PRIMARY KEY (%s)", table, d.quote(name), d.quoteList(st.PrimaryKey)))
+		}
+	default:
+		d.rebuild(stepAlterPrimaryKey, dt.Name, "change the primary key")
+	}
+}
+
+// indexSignature 索引的比较键：唯一性加列列表
+func indexSignature(idx *Index) string {
+	return fmt.Sprintf("%v:%s", idx.Unique, strings.ToLower(strings.Join(idx.Columns, ",")))
+}
+
+func (d *differ) diffIndexes(st, dt *Table) {
+	matched := map[*Index]bool{}
+	var created []*Index
+	// 先按名称匹配，名称不同但定义相同的索引视为同一个（只是命名不同）
+	for _, si := range st.Indexes {
+		var found *Index
+		for _, di := range dt.Indexes {
+			if !matched[di] && strings.EqualFold(si.Name, di.Name) {
+				found = di
+				break
+			}
+		}
+		if found == nil {
+			for _, di := range dt.Indexes {
+				if !matched[di] && indexSignature(si) == indexSignature(di) {
+					found = di
+					break
+				}
+			}
+		}
+		if found == nil {
+			created = append(created, si)
+			continue
+		}
+		matched[found] = true
+		if indexSignature(si) != indexSignature(found) {
+			d.change(Change{Object: "index", Action: "alter", Table: st.Name, Name: si.Name,
+				Detail: fmt.Sprintf("(%s) -> (%s)", strings.Join(found.Columns, ", "), strings.Join(si.Columns, ", "))})
+			if d.autoIndex(dt, found) || d.autoIndex(st, si) {
+				d.rebuild(stepCreateIndex, dt.Name, "change the constraint index "+found.Name)
+				continue
+			}
+			d.add(stepDropChangedIndex, sqlutil.DropIndexStatement(d.dialect, dt.Name, found.Name))
+			d.add(stepCreateIndex, d.createIndex(st, si))
+		}
+	}
+	for _, si := range created {
+		d.change(Change{Object: "index", Action: "create", Table: st.Name, Name: si.Name, Detail: "(" + strings.Join(si.Columns, ", ") + ")"})
+		if d.autoIndex(st, si) {
+			if d.dialect == sqlutil.DialectSQLite {
+				d.rebuild(stepCreateIndex, dt.Name, "add UNIQUE constraint on ("+strings.Join(si.Columns, ", ")+")")
+			}
+			continue
+		}
+		d.add(stepCreateIndex, d.createIndex(st, si))
+	}
+	for _, di := range dt.Indexes {
+		if matched[di] {
+			continue
+		}
+		d.change(Change{Object: "index", Action: "drop", Table: dt.Name, Name: di.Name, Destructive: true})
+		if d.autoIndex(dt, di) {
+			if d.dialect == sqlutil.DialectSQLite {
+				d.rebuild(stepDropIndex, dt.Name, "drop UNIQUE constraint "+di.Name)
+			}
+			continue
+		}
+		d.add(stepDropIndex, sqlutil.DropIndexStatement(d.dialect, dt.Name, di.Name))
+	}
+}
+
+func (d *differ) createIndex(t *Table, idx *Index) string {
+	unique := ""
+	if idx.Unique {
+		unique = "UNIQUE "
+	}
+	return fmt.Sprintf("CREATE %sINDEX %s ON %s (%s)", unique, d.quote(idx.Name), d.quote(t.Name), d.quoteList(idx.Columns))
+}
+
+// foreignKeySignature 外键的比较键；未写被引用列时使用被引用表的主键
+func foreignKeySignature(s *Schema, fk *ForeignKey) string {
+	refColumns := fk.RefColumns
+	if len(refColumns) == 0 {
+		if rt := s.table(fk.RefTable); rt != nil {
+			refColumns = rt.PrimaryKey
+		}
+	}
+	return strings.ToLower(fmt.Sprintf("%s>%s(%s)|%s|%s", strings.Join(fk.Columns, ","), fk.RefTable, strings.Join(refColumns, ","),
+		normalizeAction(fk.OnDelete), normalizeAction(fk.OnUpdate)))
+}
+
+func (d *differ) diffForeignKeys(st, dt *Table) {
+	matched := map[*ForeignKey]bool{}
+	for _, sf := range st.ForeignKeys {
+		sig := foreignKeySignature(d.src, sf)
+		var found *ForeignKey
+		for _, df := range dt.ForeignKeys {
+			if !matched[df] && foreignKeySignature(d.dst, df) == sig {
+				found = df
+				break
+			}
+		}
+		if found == nil && sf.Name != "" {
+			for _, df := range dt.ForeignKeys {
+				if !matched[df] && strings.EqualFold(sf.Name, df.Name) {
+					found = df
+					break
+				}
+			}
+		}
+		if found == nil {
+			d.change(Change{Object: "foreign_key", Action: "create", Table: st.Name, Name: sf.Name, Detail: d.foreignKeyClause(sf)})
+			if d.dialect == sqlutil.DialectSQLite {
+				d.rebuild(stepAddFK, dt.Name, "add foreign key ("+strings.Join(sf.Columns, ", ")+")")
+			} else {
+				d.add(stepAddFK, d.addForeignKey(st, sf))
+			}
+			continue
+		}
+		matched[found] = true
+		if foreignKeySignature(d.dst, found) != sig {
+			d.change(Change{Object: "foreign_key", Action: "alter", Table: st.Name, Name: sf.Name, Detail: d.foreignKeyClause(sf)})
+			if d.dialect == sqlutil.DialectSQLite {
+				d.rebuild(stepAddFK, dt.Name, "change foreign key "+sf.Name)
+				continue
+			}
+			d.add(stepDropChangedFK, d.dropForeignKey(dt, found))
+			d.add(stepAddFK, d.addForeignKey(st, sf))
+		}
+	}
+	for _, df := range dt.ForeignKeys {
+		if matched[df] {
+			continue
+		}
+		d.change(Change{Object: "foreign_key", Action: "drop", Table: dt.Name, Name: df.Name, Detail: d.foreignKeyClause(df), Destructive: true})
+		if d.dialect == sqlutil.DialectSQLite {
+			d.rebuild(stepDropFK, dt.Name, "drop foreign key ("+strings.Join(df.Columns, ", ")+")")
+			continue
+		}
+		d.add(stepDropFK, d.dropForeignKey(dt, df))
+	}
+}
+
+// foreignKeyClause 生成 FOREIGN KEY ... REFERENCES ... 子句
+func (d *differ) foreignKeyClause(fk *ForeignKey) string {
+	clause := fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s", d.quoteList(fk.Columns), d.quote(fk.RefTable))
+	if len(fk.RefColumns) > 0 {
+		clause += " (" + d.quoteList(fk.RefColumns) + ")"
+	}
+	if a := normalizeAction(fk.OnDelete); a != "" {
+		clause += " ON DELETE " + a
+	}
+	if a := normalizeAction(fk.OnUpdate); a != "" {
+		clause += " ON UPDATE " + a
+	}
+	return clause
+}
+
+func (d *differ) addForeignKey(t *Table, fk *ForeignKey) string {
+	name := fk.Name
+	if name == "" {
+		name = t.Name + "_" + strings.Join(fk.Columns, "_") + "_fkey"
+	}
+	return fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s", d.quote(t.Name), d.quote(name), d.foreignKeyClause(fk))
+}
+
+func (d *differ) dropForeignKey(t *Table, fk *ForeignKey) string {
+	if d.dialect == sqlutil.DialectMySQL {
+		return fmt.Sprintf("ALTER TABLE %s DROP FOREIGN KEY %s", d.quote(t.Name), d.quote(fk.Name))
+	}
+	return fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s", d.quote(t.Name), d.quote(fk.Name))
+}
+
+func (d *differ) diffViews() {
+	for _, sv := range d.src.Views {
+		dv := d.dst.view(sv.Name)
+		create := fmt.Sprintf("CREATE VIEW %s AS %s", d.quote(sv.Name), strings.TrimRight(strings.TrimSpace(sv.Definition), ";"))
+		if dv == nil {
+			d.change(Change{Object: "view", Action: "create", Name: sv.Name})
+			d.add(stepCreateView, create)
+			continue
+		}
+		if normalizeBody(d.src.Name, sv.Definition) == normalizeBody(d.dst.Name, dv.Definition) {
+			continue
+		}
+		d.change(Change{Object: "view", Action: "alter", Name: sv.Name, Detail: "definition differs"})
+		d.add(stepDropChangedView, "DROP VIEW "+d.quote(dv.Name))
+		d.add(stepCreateView, create)
+	}
+	for _, dv := range d.dst.Views {
+		if d.src.view(dv.Name) == nil {
+			d.change(Change{Object: "view", Action: "drop", Name: dv.Name, Destructive: true})
+			d.add(stepDropView, "DROP VIEW "+d.quote(dv.Name))
+		}
+	}
+}
+
+func (d *differ) diffRoutines() {
+	if len(d.src.Routines) == 0 && len(d.dst.Routines) == 0 {
+		return
+	}
+	if d.src.Dialect != d.dialect {
+		d.note("functions and procedures are not translated across dialects and were not compared")
+		return
+	}
+	for _, sr := range d.src.Routines {
+		dr := d.dst.routine(sr.Kind, sr.Name)
+		def := sr.Definition
+		if d.dialect == sqlutil.DialectPostgres && !orReplace.MatchString(def) {
+			def = "CREATE OR REPLACE " + strings.TrimSpace(def[len("CREATE"):])
+		}
+		if dr == nil {
+			d.change(Change{Object: "routine", Action: "create", Name: sr.Name, Detail: strings.ToLower(sr.Kind)})
+			d.add(stepCreateRoutine, def)
+			continue
+		}
+		if normalizeBody(d.src.Name, sr.Definition) == normalizeBody(d.dst.Name, dr.Definition) {
+			continue
+		}
+		d.change(Change{Object: "routine", Action: "alter", Name: sr.Name, Detail: strings.ToLower(sr.Kind) + " definition differs"})
+		if d.dialect == sqlutil.DialectMySQL {
+			d.add(stepCreateRoutine, fmt.Sprintf("DROP %s IF EXISTS %s", sr.Kind, d.quote(dr.Name)))
+		}
+		d.add(stepCreateRoutine, def)
+	}
+	for _, dr := range d.dst.Routines {
+		if d.src.routine(dr.Kind, dr.Name) == nil {
+			d.change(Change{Object: "routine", Action: "drop", Name: dr.Name, Detail: strings.ToLower(dr.Kind), Destructive: true})
+			d.add(stepDropRoutine, fmt.Sprintf("DROP %s %s", dr.Kind, d.quote(dr.Name)))
+		}
+	}
+}
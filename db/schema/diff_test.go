@@ -0,0 +1,172 @@
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"xz_mcp/db/sqlutil"
+)
+
+func TestDiffSQLiteRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "target.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, stmt := range []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT NOT NULL, legacy TEXT)",
+		"CREATE INDEX idx_users_legacy ON users (legacy)",
+		"CREATE TABLE audit (id INTEGER PRIMARY KEY)",
+		"CREATE VIEW v_users AS SELECT id FROM users",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	source := ParseDDL(`
+		CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT NOT NULL, name TEXT DEFAULT 'anon');
+		CREATE UNIQUE INDEX idx_users_email ON users (email);
+		CREATE TABLE posts (
+			id INTEGER PRIMARY KEY,
+			user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+			title TEXT
+		);
+		CREATE INDEX idx_posts_user ON posts (user_id);
+		CREATE VIEW v_users AS SELECT id, email FROM users;
+	`, sqlutil.DialectSQLite)
+	target, err := Introspect(ctx, db, sqlutil.DialectSQLite, "")
+	if err != nil {
+		t.Fatalf("Introspect: %v", err)
+	}
+
+	result := Diff(source, target)
+	if result.Identical || len(result.Changes) != 7 {
+		t.Fatalf("unexpected changes: %+v", result.Changes)
+	}
+	wantDestructive := []string{
+		`DROP INDEX "idx_users_legacy"`,
+		`ALTER TABLE "users" DROP COLUMN "legacy"`,
+		`DROP TABLE "audit"`,
+	}
+	if strings.Join(result.DestructiveStatements, "\n") != strings.Join(wantDestructive, "\n") {
+		t.Fatalf("destructive statements:\n%s", strings.Join(result.DestructiveStatements, "\n"))
+	}
+	if len(result.Statements) != 6 || result.Statements[0] != `DROP VIEW "v_users"` || !strings.HasPrefix(result.Statements[1], `CREATE TABLE "posts"`) {
+		t.Fatalf("statements:\n%s", strings.Join(result.Statements, "\n"))
+	}
+	if !strings.Contains(result.Statements[1], `FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE`) {
+		t.Errorf("foreign key not inlined: %s", result.Statements[1])
+	}
+	if !strings.Contains(result.Script, "DESTRUCTIVE CHANGES") {
+		t.Errorf("script does not separate destructive steps:\n%s", result.Script)
+	}
+
+	// 执行迁移后再次比较应当一致
+	for _, stmt := range append(result.Statements, result.DestructiveStatements...) {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	target, err = Introspect(ctx, db, sqlutil.DialectSQLite, "")
+	if err != nil {
+		t.Fatalf("Introspect: %v", err)
+	}
+	if again := Diff(source, target); !again.Identical {
+		t.Fatalf("schema still differs after migration: %+v", again.Changes)
+	}
+}
+
+func TestDiffPostgresScript(t *testing.T) {
+	target := ParseDDL(`
+		CREATE TABLE accounts (id serial PRIMARY KEY, name varchar(32), code int, old_flag boolean);
+		CREATE INDEX accounts_name ON accounts (name);
+		CREATE TABLE logs (id int);
+		CREATE FUNCTION f() RETURNS int LANGUAGE sql AS $$ SELECT 1 $$;
+	`, sqlutil.DialectPostgres)
+	source := ParseDDL(`
+		CREATE TABLE accounts (
+			id serial PRIMARY KEY,
+			name character varying(32) NOT NULL DEFAULT 'x',
+			code bigint,
+			parent_id integer REFERENCES accounts(id)
+		);
+		CREATE INDEX accounts_name ON accounts (name, code);
+		CREATE FUNCTION f() RETURNS int LANGUAGE sql AS $$ SELECT 2 $$;
+	`, sqlutil.DialectPostgres)
+
+	result := Diff(source, target)
+	want := []string{
+		`DROP INDEX "accounts_name"`,
+		`ALTER TABLE "accounts" ADD COLUMN "parent_id" integer`,
+		`ALTER TABLE "accounts" ALTER COLUMN "name" SET NOT NULL`,
+		`ALTER TABLE "accounts" ALTER COLUMN "name" SET DEFAULT 'x'`,
+		`CREATE INDEX "accounts_name" ON "accounts" ("name", "code")`,
+		`ALTER TABLE "accounts" ADD CONSTRAINT "accounts_parent_id_fkey" FOREIGN KEY ("parent_id") REFERENCES "accounts" ("id")`,
+		`CREATE OR REPLACE FUNCTION f() RETURNS int LANGUAGE sql AS $$ SELECT 2 $$`,
+	}
+	if strings.Join(result.Statements, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("statements:\n%s", strings.Join(result.Statements, "\n"))
+	}
+	wantDestructive := []string{
+		`ALTER TABLE "accounts" ALTER COLUMN "code" TYPE bigint USING "code"::bigint`,
+		`ALTER TABLE "accounts" DROP COLUMN "old_flag"`,
+		`DROP TABLE "logs"`,
+	}
+	if strings.Join(result.DestructiveStatements, "\n") != strings.Join(wantDestructive, "\n") {
+		t.Fatalf("destructive statements:\n%s", strings.Join(result.DestructiveStatements, "\n"))
+	}
+}
+
+func TestDiffTypeChangeBeforeForeignKey(t *testing.T) {
+	for _, dialect := range []string{sqlutil.DialectPostgres, sqlutil.DialectMySQL} {
+		target := ParseDDL(`
+			CREATE TABLE customers (id bigint PRIMARY KEY);
+			CREATE TABLE orders (id bigint PRIMARY KEY, customer_id int);
+		`, dialect)
+		source := ParseDDL(`
+			CREATE TABLE customers (id bigint PRIMARY KEY);
+			CREATE TABLE orders (id bigint PRIMARY KEY, customer_id bigint, CONSTRAINT fk_orders_customer FOREIGN KEY (customer_id) REFERENCES customers (id));
+			CREATE INDEX idx_orders_customer ON orders (customer_id);
+		`, dialect)
+
+		result := Diff(source, target)
+		if len(result.DestructiveStatements) != 1 {
+			t.Fatalf("%s: type change should stay destructive: %v", dialect, result.DestructiveStatements)
+		}
+		typeChange := strings.Index(result.Script, result.DestructiveStatements[0])
+		index := strings.Index(result.Script, "CREATE INDEX")
+		fk := strings.Index(result.Script, "ADD CONSTRAINT")
+		if typeChange < 0 || index < 0 || fk < 0 || typeChange > index || typeChange > fk {
+			t.Fatalf("%s: type change must come before the new index and foreign key:\n%s", dialect, result.Script)
+		}
+		if !strings.Contains(result.Script, "-- DESTRUCTIVE: column type change may lose data\n"+result.DestructiveStatements[0]) {
+			t.Errorf("%s: type change should be marked in the script:\n%s", dialect, result.Script)
+		}
+	}
+}
+
+func TestDiffMySQLCreateScript(t *testing.T) {
+	result := Diff(ParseDDL(mysqlDDL, sqlutil.DialectMySQL), ParseDDL("", sqlutil.DialectMySQL))
+	if len(result.DestructiveStatements) != 0 {
+		t.Fatalf("unexpected destructive statements: %v", result.DestructiveStatements)
+	}
+	for _, want := range []string{
+		"CREATE TABLE `orders` (\n  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,",
+		"CREATE UNIQUE INDEX `uk_email` ON `users` (`email`);",
+		"ALTER TABLE `orders` ADD CONSTRAINT `fk_orders_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE;",
+		"DELIMITER $$\nCREATE FUNCTION order_count",
+	} {
+		if !strings.Contains(result.Script, want) {
+			t.Errorf("script missing %q:\n%s", want, result.Script)
+		}
+	}
+	// 外键自动创建的索引不单独建立
+	if strings.Contains(result.Script, "INDEX `fk_orders_user`") {
+		t.Errorf("implicit foreign key index should not be created:\n%s", result.Script)
+	}
+}
@@ -0,0 +1,468 @@
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"xz_mcp/db/sqlutil"
+)
+
+// Introspect 读取连接中的表、列、索引、外键、视图和例程；name 为 MySQL 数据库名或 PostgreSQL schema（可为空）
+func Introspect(ctx context.Context, db *sql.DB, dialect, name string) (*Schema, error) {
+	s := &Schema{Dialect: dialect, Name: name, Tables: []*Table{}, Views: []*View{}, Routines: []*Routine{}}
+	var err error
+	switch dialect {
+	case sqlutil.DialectMySQL:
+		err = introspectMySQL(ctx, db, s)
+	case sqlutil.DialectPostgres:
+		err = introspectPostgres(ctx, db, s)
+	case sqlutil.DialectSQLite:
+		err = introspectSQLite(ctx, db, s)
+	default:
+		err = fmt.Errorf("unsupported dialect: %s", dialect)
+	}
+	if err != nil {
+		return nil, err
+	}
+	finalize(s)
+	return s, nil
+}
+
+// queryEach 执行查询并对每一行调用 fn
+func queryEach(ctx context.Context, db *sql.DB, query string, args []interface{}, fn func(scan func(dest ...interface{}) error) error) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := fn(rows.Scan); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func introspectMySQL(ctx context.Context, db *sql.DB, s *Schema) error {
+	if s.Name == "" {
+		if err := db.QueryRowContext(ctx, "SELECT DATABASE()").Scan(&s.Name); err != nil {
+			return fmt.Errorf("failed to get current database: %v", err)
+		}
+	}
+	args := []interface{}{s.Name}
+
+	err := queryEach(ctx, db, "SELECT TABLE_NAME FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_TYPE = 'BASE TABLE' ORDER BY TABLE_NAME", args,
+		func(scan func(...interface{}) error) error {
+			var name string
+			if err := scan(&name); err != nil {
+				return err
+			}
+			s.addTable(name)
+			return nil
+		})
+	if err != nil {
+		return fmt.Errorf("failed to list tables: %v", err)
+	}
+
+	err = queryEach(ctx, db, `SELECT TABLE_NAME, COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE, COLUMN_DEFAULT, EXTRA, DATA_TYPE
+		FROM INFORMATION_SCHEMA.COLUMNS WHERE TABLE_SCHEMA = ? ORDER BY TABLE_NAME, ORDINAL_POSITION`, args,
+		func(scan func(...interface{}) error) error {
+			var table, name, typ, nullable, extra, dataType string
+			var def sql.NullString
+			if err := scan(&table, &name, &typ, &nullable, &def, &extra, &dataType); err != nil {
+				return err
+			}
+			t := s.table(table)
+			if t == nil {
+				return nil // 视图的列
+			}
+			c := &Column{Name: name, Type: typ, Nullable: nullable == "YES", AutoIncrement: strings.Contains(extra, "auto_increment")}
+			if def.Valid && def.String != "NULL" {
+				// COLUMN_DEFAULT 中字面量不带引号，表达式默认值带 DEFAULT_GENERATED 标记
+				d := def.String
+				if !strings.Contains(extra, "DEFAULT_GENERATED") && !strings.HasPrefix(d, "'") && !(numericValue.MatchString(d) && !isTextType(dataType)) && !strings.EqualFold(d, "CURRENT_TIMESTAMP") {
+					d = "'" + strings.ReplaceAll(d, "'", "''") + "'"
+				}
+				c.Default = &d
+			}
+			t.Columns = append(t.Columns, c)
+			return nil
+		})
+	if err != nil {
+		return fmt.Errorf("failed to list columns: %v", err)
+	}
+
+	err = queryEach(ctx, db, `SELECT TABLE_NAME, INDEX_NAME, NON_UNIQUE, COALESCE(COLUMN_NAME, '(expression)')
+		FROM INFORMATION_SCHEMA.STATISTICS WHERE TABLE_SCHEMA = ? ORDER BY TABLE_NAME, INDEX_NAME, SEQ_IN_INDEX`, args,
+		func(scan func(...interface{}) error) error {
+			var table, index, column string
+			var nonUnique int
+			if err := scan(&table, &index, &nonUnique, &column); err != nil {
+				return err
+			}
+			t := s.table(table)
+			if t == nil {
+				return nil
+			}
+			if index == "PRIMARY" {
+				t.PrimaryKey = append(t.PrimaryKey, column)
+				t.PrimaryName = index
+				return nil
+			}
+			if n := len(t.Indexes); n > 0 && t.Indexes[n-1].Name == index {
+				t.Indexes[n-1].Columns = append(t.Indexes[n-1].Columns, column)
+				return nil
+			}
+			t.Indexes = append(t.Indexes, &Index{Name: index, Columns: []string{column}, Unique: nonUnique == 0})
+			return nil
+		})
+	if err != nil {
+		return fmt.Errorf("failed to list indexes: %v", err)
+	}
+
+	err = queryEach(ctx, db, `SELECT k.TABLE_NAME, k.CONSTRAINT_NAME, k.COLUMN_NAME, k.REFERENCED_TABLE_NAME, k.REFERENCED_COLUMN_NAME, r.UPDATE_RULE, r.DELETE_RULE
+		FROM INFORMATION_SCHEMA.KEY_COLUMN_USAGE k
+		JOIN INFORMATION_SCHEMA.REFERENTIAL_CONSTRAINTS r
+			ON r.CONSTRAINT_SCHEMA = k.CONSTRAINT_SCHEMA AND r.CONSTRAINT_NAME = k.CONSTRAINT_NAME AND r.TABLE_NAME = k.TABLE_NAME
+		WHERE k.TABLE_SCHEMA = ? AND k.REFERENCED_TABLE_NAME IS NOT NULL
+		ORDER BY k.TABLE_NAME, k.CONSTRAINT_NAME, k.ORDINAL_POSITION`, args,
+		func(scan func(...interface{}) error) error {
+			var table, name, column, refTable, refColumn, onUpdate, onDelete string
+			if err := scan(&table, &name, &column, &refTable, &refColumn, &onUpdate, &onDelete); err != nil {
+				return err
+			}
+			t := s.table(table)
+			if t == nil {
+				return nil
+			}
+			if n := len(t.ForeignKeys); n > 0 && t.ForeignKeys[n-1].Name == name {
+				fk := t.ForeignKeys[n-1]
+				fk.Columns = append(fk.Columns, column)
+				fk.RefColumns = append(fk.RefColumns, refColumn)
+				return nil
+			}
+			t.ForeignKeys = append(t.ForeignKeys, &ForeignKey{
+				Name: name, Columns: []string{column}, RefTable: refTable, RefColumns: []string{refColumn},
+				OnDelete: onDelete, OnUpdate: onUpdate,
+			})
+			return nil
+		})
+	if err != nil {
+		return fmt.Errorf("failed to list foreign keys: %v", err)
+	}
+
+	// VIEW_DEFINITION 中的对象名带数据库前缀，去掉以便与 DDL 文件比较
+	qualifier := "`" + s.Name + "`."
+	err = queryEach(ctx, db, "SELECT TABLE_NAME, VIEW_DEFINITION FROM INFORMATION_SCHEMA.VIEWS WHERE TABLE_SCHEMA = ? ORDER BY TABLE_NAME", args,
+		func(scan func(...interface{}) error) error {
+			var name, def string
+			if err := scan(&name, &def); err != nil {
+				return err
+			}
+			s.Views = append(s.Views, &View{Name: name, Definition: strings.ReplaceAll(def, qualifier, "")})
+			return nil
+		})
+	if err != nil {
+		return fmt.Errorf("failed to list views: %v", err)
+	}
+
+	var routines []*Routine
+	err = queryEach(ctx, db, "SELECT ROUTINE_NAME, ROUTINE_TYPE FROM INFORMATION_SCHEMA.ROUTINES WHERE ROUTINE_SCHEMA = ? ORDER BY ROUTINE_TYPE, ROUTINE_NAME", args,
+		func(scan func(...interface{}) error) error {
+			r := &Routine{}
+			if err := scan(&r.Name, &r.Kind); err != nil {
+				return err
+			}
+			routines = append(routines, r)
+			return nil
+		})
+	if err != nil {
+		return fmt.Errorf("failed to list routines: %v", err)
+	}
+	for _, r := range routines {
+		def, err := mysqlShowCreate(ctx, db, r.Kind, sqlutil.QuoteIdent(sqlutil.DialectMySQL, s.Name)+"."+sqlutil.QuoteIdent(sqlutil.DialectMySQL, r.Name))
+		if err != nil || def == "" {
+			s.Notes = append(s.Notes, fmt.Sprintf("cannot read definition of %s %s (missing privileges?)", strings.ToLower(r.Kind), r.Name))
+			continue
+		}
+		r.Definition = strings.TrimSpace(definerClause.ReplaceAllString(def, ""))
+		s.Routines = append(s.Routines, r)
+	}
+	return nil
+}
+
+// isTextType 判断 MySQL DATA_TYPE 是否为字符串类型（数字默认值也需要加引号）
+func isTextType(dataType string) bool {
+	switch strings.ToLower(dataType) {
+	case "char", "varchar", "tinytext", "text", "mediumtext", "longtext", "enum", "set", "date", "datetime", "timestamp", "time", "year":
+		return true
+	}
+	return false
+}
+
+// mysqlShowCreate 通过 SHOW CREATE FUNCTION/PROCEDURE 获取完整定义
+func mysqlShowCreate(ctx context.Context, db *sql.DB, kind, name string) (string, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("SHOW CREATE %s %s", kind, name))
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+	if !rows.Next() {
+		return "", rows.Err()
+	}
+	values := make([]sql.NullString, len(columns))
+	ptrs := make([]interface{}, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	if err := rows.Scan(ptrs...); err != nil {
+		return "", err
+	}
+	for i, c := range columns {
+		if strings.HasPrefix(c, "Create ") {
+			return values[i].String, nil
+		}
+	}
+	return "", nil
+}
+
+func introspectPostgres(ctx context.Context, db *sql.DB, s *Schema) error {
+	if s.Name == "" {
+		s.Name = "public"
+	}
+	args := []interface{}{s.Name}
+
+	err := queryEach(ctx, db, `SELECT c.relname, a.attname, format_type(a.atttypid, a.atttypmod), NOT a.attnotnull,
+			pg_get_expr(d.adbin, d.adrelid), a.attidentity <> ''
+		FROM pg_attribute a
+		JOIN pg_class c ON c.oid = a.attrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		WHERE n.nspname = $1 AND c.relkind IN ('r', 'p') AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY c.relname, a.attnum`, args,
+		func(scan func(...interface{}) error) error {
+			var table string
+			var def sql.NullString
+			c := &Column{}
+			if err := scan(&table, &c.Name, &c.Type, &c.Nullable, &def, &c.AutoIncrement); err != nil {
+				return err
+			}
+			if def.Valid {
+				c.Default = &def.String
+			}
+			t := s.addTable(table)
+			t.Columns = append(t.Columns, c)
+			return nil
+		})
+	if err != nil {
+		return fmt.Errorf("查询列信息失败: %w", err)
+	}
+
+	// 主键和外键通过 pg_get_constraintdef 还原为 DDL 后复用 DDL 解析
+	err = queryEach(ctx, db, `SELECT cl.relname, c.conname, pg_get_constraintdef(c.oid)
+		FROM pg_constraint c
+		JOIN pg_class cl ON cl.oid = c.conrelid
+		JOIN pg_namespace n ON n.oid = cl.relnamespace
+		WHERE n.nspname = $1 AND c.contype IN ('p', 'f')
+		ORDER BY cl.relname, c.conname`, args,
+		func(scan func(...interface{}) error) error {
+			var table, name, def string
+			if err := scan(&table, &name, &def); err != nil {
+				return err
+			}
+			t := s.table(table)
+			if t == nil {
+				return nil
+			}
+			stmt := "CONSTRAINT " + sqlutil.QuoteIdent(sqlutil.DialectPostgres, name) + " " + def
+			p := &parser{dialect: s.Dialect, src: stmt, toks: lex(stmt, s.Dialect)}
+			return p.tableItem(t)
+		})
+	if err != nil {
+		return fmt.Errorf("查询约束失败: %w", err)
+	}
+
+	err = queryEach(ctx, db, `SELECT pg_get_indexdef(x.indexrelid)
+		FROM pg_index x
+		JOIN pg_class t ON t.oid = x.indrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		WHERE n.nspname = $1 AND NOT x.indisprimary
+		ORDER BY t.relname`, args,
+		func(scan func(...interface{}) error) error {
+			var def string
+			if err := scan(&def); err != nil {
+				return err
+			}
+			p := &parser{dialect: s.Dialect, src: def, toks: lex(def, s.Dialect)}
+			if _, err := p.create(s); err != nil {
+				s.Notes = append(s.Notes, err.Error())
+			}
+			return nil
+		})
+	if err != nil {
+		return fmt.Errorf("查询索引失败: %w", err)
+	}
+
+	err = queryEach(ctx, db, "SELECT viewname, definition FROM pg_views WHERE schemaname = $1 ORDER BY viewname", args,
+		func(scan func(...interface{}) error) error {
+			v := &View{}
+			if err := scan(&v.Name, &v.Definition); err != nil {
+				return err
+			}
+			v.Definition = strings.TrimSpace(v.Definition)
+			s.Views = append(s.Views, v)
+			return nil
+		})
+	if err != nil {
+		return fmt.Errorf("查询视图失败: %w", err)
+	}
+
+	// 排除扩展创建的函数
+	err = queryEach(ctx, db, `SELECT p.proname, CASE p.prokind WHEN 'p' THEN 'PROCEDURE' ELSE 'FUNCTION' END, pg_get_functiondef(p.oid)
+		FROM pg_proc p
+		JOIN pg_namespace n ON n.oid = p.pronamespace
+		WHERE n.nspname = $1 AND p.prokind IN ('f', 'p')
+			AND NOT EXISTS (SELECT 1 FROM pg_depend d WHERE d.objid = p.oid AND d.deptype = 'e')
+		ORDER BY p.proname`, args,
+		func(scan func(...interface{}) error) error {
+			r := &Routine{}
+			if err := scan(&r.Name, &r.Kind, &r.Definition); err != nil {
+				return err
+			}
+			if s.routine(r.Kind, r.Name) != nil {
+				s.Notes = append(s.Notes, fmt.Sprintf("overloaded %s %s: only the first definition is compared", strings.ToLower(r.Kind), r.Name))
+				return nil
+			}
+			r.Definition = strings.TrimSpace(r.Definition)
+			s.Routines = append(s.Routines, r)
+			return nil
+		})
+	if err != nil {
+		return fmt.Errorf("查询函数失败: %w", err)
+	}
+	return nil
+}
+
+func introspectSQLite(ctx context.Context, db *sql.DB, s *Schema) error {
+	type object struct{ kind, name, sql string }
+	var objects []object
+	err := queryEach(ctx, db, "SELECT type, name, COALESCE(sql, '') FROM sqlite_master WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%' ORDER BY name", nil,
+		func(scan func(...interface{}) error) error {
+			var o object
+			if err := scan(&o.kind, &o.name, &o.sql); err != nil {
+				return err
+			}
+			objects = append(objects, o)
+			return nil
+		})
+	if err != nil {
+		return fmt.Errorf("failed to list tables: %v", err)
+	}
+
+	for _, o := range objects {
+		if o.kind == "view" {
+			// 视图定义只保存在建表语句中
+			p := &parser{dialect: s.Dialect, src: o.sql, toks: lex(o.sql, s.Dialect)}
+			if _, err := p.create(s); err != nil {
+				s.Notes = append(s.Notes, fmt.Sprintf("view %s: %v", o.name, err))
+			}
+			continue
+		}
+		t := s.addTable(o.name)
+		var pk []struct {
+			pos  int
+			name string
+		}
+		err := queryEach(ctx, db, `SELECT name, type, "notnull", dflt_value, pk FROM pragma_table_info(?) ORDER BY cid`, []interface{}{o.name},
+			func(scan func(...interface{}) error) error {
+				var def sql.NullString
+				var notNull, pkPos int
+				c := &Column{}
+				if err := scan(&c.Name, &c.Type, &notNull, &def, &pkPos); err != nil {
+					return err
+				}
+				c.Nullable = notNull == 0
+				if def.Valid {
+					c.Default = &def.String
+				}
+				if pkPos > 0 {
+					pk = append(pk, struct {
+						pos  int
+						name string
+					}{pkPos, c.Name})
+				}
+				t.Columns = append(t.Columns, c)
+				return nil
+			})
+		if err != nil {
+			return fmt.Errorf("failed to read columns of %s: %v", o.name, err)
+		}
+		t.PrimaryKey = make([]string, len(pk))
+		for _, k := range pk {
+			t.PrimaryKey[k.pos-1] = k.name
+		}
+
+		var indexes []*Index
+		err = queryEach(ctx, db, `SELECT name, "unique" FROM pragma_index_list(?) WHERE origin <> 'pk' ORDER BY name`, []interface{}{o.name},
+			func(scan func(...interface{}) error) error {
+				idx := &Index{}
+				if err := scan(&idx.Name, &idx.Unique); err != nil {
+					return err
+				}
+				indexes = append(indexes, idx)
+				return nil
+			})
+		if err != nil {
+			return fmt.Errorf("failed to read indexes of %s: %v", o.name, err)
+		}
+		for _, idx := range indexes {
+			err := queryEach(ctx, db, "SELECT COALESCE(name, '(expression)') FROM pragma_index_info(?) ORDER BY seqno", []interface{}{idx.Name},
+				func(scan func(...interface{}) error) error {
+					var col string
+					if err := scan(&col); err != nil {
+						return err
+					}
+					idx.Columns = append(idx.Columns, col)
+					return nil
+				})
+			if err != nil {
+				return fmt.Errorf("failed to read index %s: %v", idx.Name, err)
+			}
+			t.Indexes = append(t.Indexes, idx)
+		}
+
+		err = queryEach(ctx, db, `SELECT id, "table", "from", "to", on_update, on_delete FROM pragma_foreign_key_list(?) ORDER BY id, seq`, []interface{}{o.name},
+			func(scan func(...interface{}) error) error {
+				var id int
+				var refTable, from, onUpdate, onDelete string
+				var to sql.NullString
+				if err := scan(&id, &refTable, &from, &to, &onUpdate, &onDelete); err != nil {
+					return err
+				}
+				if n := len(t.ForeignKeys); n > 0 && id == n-1 {
+					fk := t.ForeignKeys[n-1]
+					fk.Columns = append(fk.Columns, from)
+					if to.Valid {
+						fk.RefColumns = append(fk.RefColumns, to.String)
+					}
+					return nil
+				}
+				fk := &ForeignKey{Columns: []string{from}, RefTable: refTable, OnDelete: onDelete, OnUpdate: onUpdate}
+				if to.Valid {
+					fk.RefColumns = []string{to.String}
+				}
+				t.ForeignKeys = append(t.ForeignKeys, fk)
+				return nil
+			})
+		if err != nil {
+			return fmt.Errorf("failed to read foreign keys of %s: %v", o.name, err)
+		}
+	}
+	return nil
+}
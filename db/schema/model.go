@@ -0,0 +1,300 @@
+package schema
+
+import (
+	"regexp"
+	"strings"
+
+	"xz_mcp/db/dataio"
+	"xz_mcp/db/sqlutil"
+)
+
+// Schema 一个数据库（或 DDL 文件）中的结构定义
+type Schema struct {
+	Dialect  string     `json:"dialect"`
+	Name     string     `json:"name,omitempty"` // MySQL 数据库名或 PostgreSQL schema
+	Tables   []*Table   `json:"tables"`
+	Views    []*View    `json:"views"`
+	Routines []*Routine `json:"routines"`
+	Notes    []string   `json:"notes,omitempty"`
+}
+
+// Table 表结构
+type Table struct {
+	Name        string        `json:"name"`
+	Columns     []*Column     `json:"columns"`
+	PrimaryKey  []string      `json:"primary_key,omitempty"`
+	PrimaryName string        `json:"primary_key_name,omitempty"`
+	Indexes     []*Index      `json:"indexes,omitempty"`
+	ForeignKeys []*ForeignKey `json:"foreign_keys,omitempty"`
+}
+
+// Column 列定义，Default 为 SQL 表达式（字符串字面量带引号）
+type Column struct {
+	Name          string  `json:"name"`
+	Type          string  `json:"type"`
+	Nullable      bool    `json:"nullable"`
+	Default       *string `json:"default,omitempty"`
+	AutoIncrement bool    `json:"auto_increment,omitempty"`
+}
+
+// Index 二级索引（不含主键）
+type Index struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Unique  bool     `json:"unique"`
+}
+
+// ForeignKey 外键约束
+type ForeignKey struct {
+	Name       string   `json:"name,omitempty"`
+	Columns    []string `json:"columns"`
+	RefTable   string   `json:"ref_table"`
+	RefColumns []string `json:"ref_columns,omitempty"`
+	OnDelete   string   `json:"on_delete,omitempty"`
+	OnUpdate   string   `json:"on_update,omitempty"`
+}
+
+// View 视图，Definition 为 SELECT 部分
+type View struct {
+	Name       string `json:"name"`
+	Definition string `json:"definition"`
+}
+
+// Routine 函数或存储过程，Definition 为完整的 CREATE 语句
+type Routine struct {
+	Name       string `json:"name"`
+	Kind       string `json:"kind"` // FUNCTION/PROCEDURE
+	Definition string `json:"definition"`
+}
+
+// table 按名称（不区分大小写）查找表
+func (s *Schema) table(name string) *Table {
+	for _, t := range s.Tables {
+		if strings.EqualFold(t.Name, name) {
+			return t
+		}
+	}
+	return nil
+}
+
+// addTable 返回同名表，不存在时创建
+func (s *Schema) addTable(name string) *Table {
+	if t := s.table(name); t != nil {
+		return t
+	}
+	t := &Table{Name: name}
+	s.Tables = append(s.Tables, t)
+	return t
+}
+
+func (s *Schema) view(name string) *View {
+	for _, v := range s.Views {
+		if strings.EqualFold(v.Name, name) {
+			return v
+		}
+	}
+	return nil
+}
+
+func (s *Schema) routine(kind, name string) *Routine {
+	for _, r := range s.Routines {
+		if r.Kind == kind && strings.EqualFold(r.Name, name) {
+			return r
+		}
+	}
+	return nil
+}
+
+func (t *Table) column(name string) *Column {
+	for _, c := range t.Columns {
+		if strings.EqualFold(c.Name, name) {
+			return c
+		}
+	}
+	return nil
+}
+
+// isKey 判断列是否属于主键
+func (t *Table) isKey(name string) bool {
+	for _, k := range t.PrimaryKey {
+		if strings.EqualFold(k, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// 各方言的类型别名，归一到 information_schema/pg_catalog 返回的写法
+var typeAliases = map[string]map[string]string{
+	sqlutil.DialectPostgres: {
+		"int": "integer", "int4": "integer", "serial": "integer", "serial4": "integer",
+		"int8": "bigint", "bigserial": "bigint", "serial8": "bigint",
+		"int2": "smallint", "smallserial": "smallint", "serial2": "smallint",
+		"bool": "boolean", "varchar": "character varying", "char": "character", "bpchar": "character",
+		"float8": "double precision", "float4": "real", "decimal": "numeric",
+		"timestamptz": "timestamp with time zone", "timetz": "time with time zone",
+	},
+	sqlutil.DialectMySQL: {
+		"integer": "int", "bool": "tinyint", "boolean": "tinyint", "dec": "decimal", "numeric": "decimal",
+		"double precision": "double", "character varying": "varchar", "character": "char",
+	},
+}
+
+// normalizeType 归一化类型名以便在同一方言内比较
+func normalizeType(dialect, typ string) string {
+	base, args, rest := splitType(strings.Join(strings.Fields(strings.ToLower(typ)), " "))
+	if alias, ok := typeAliases[dialect][base]; ok {
+		if dialect == sqlutil.DialectMySQL && (base == "bool" || base == "boolean") {
+			args = "(1)"
+		}
+		base = alias
+	}
+	switch dialect {
+	case sqlutil.DialectPostgres:
+		if base == "timestamp" || base == "time" {
+			if rest == "" {
+				rest = "without time zone"
+			}
+		}
+	case sqlutil.DialectMySQL:
+		// MySQL 8 不再显示整数宽度，tinyint(1) 除外（布尔）
+		switch base {
+		case "smallint", "mediumint", "int", "bigint":
+			args = ""
+		case "tinyint":
+			if args != "(1)" {
+				args = ""
+			}
+		case "decimal":
+			if args == "" {
+				args = "(10,0)"
+			}
+		}
+	}
+	return strings.TrimSpace(base + args + " " + rest)
+}
+
+// splitType 把类型拆成基础类型名、参数和后缀，如 "bigint(20) unsigned" -> bigint, (20), unsigned
+func splitType(typ string) (base, args, rest string) {
+	if open := strings.IndexByte(typ, '('); open >= 0 {
+		if end := strings.IndexByte(typ[open:], ')'); end >= 0 {
+			return strings.TrimSpace(typ[:open]), strings.ReplaceAll(typ[open:open+end+1], " ", ""), strings.TrimSpace(typ[open+end+1:])
+		}
+	}
+	fields := strings.Fields(typ)
+	if len(fields) == 0 {
+		return "", "", ""
+	}
+	n := 1
+	if len(fields) > 1 {
+		switch fields[0] + " " + fields[1] {
+		case "double precision", "character varying", "bit varying":
+			n = 2
+		}
+	}
+	return strings.Join(fields[:n], " "), "", strings.Join(fields[n:], " ")
+}
+
+// sameType 比较两列类型；跨方言时按与方言无关的类型分类比较
+func sameType(srcDialect, src, dstDialect, dst string) bool {
+	if srcDialect == dstDialect {
+		return normalizeType(srcDialect, src) == normalizeType(dstDialect, dst)
+	}
+	a, b := dataio.ClassifyTypeName(src), dataio.ClassifyTypeName(dst)
+	return a.Kind == b.Kind && a.Length == b.Length && a.Precision == b.Precision && a.Scale == b.Scale
+}
+
+var (
+	castSuffix   = regexp.MustCompile(`(?i)::[a-z_][a-z0-9_ ]*(\(\d+(,\d+)?\))?(\[\])?`)
+	numericValue = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+)
+
+// normalizeDefault 归一化默认值表达式：去掉类型转换、外层括号和数字两侧的引号
+func normalizeDefault(def *string) string {
+	if def == nil {
+		return ""
+	}
+	d := strings.TrimSpace(*def)
+	if !strings.HasPrefix(d, "'") {
+		d = strings.ToLower(d)
+	}
+	d = castSuffix.ReplaceAllString(d, "")
+	for strings.HasPrefix(d, "(") && strings.HasSuffix(d, ")") && balanced(d[1:len(d)-1]) {
+		d = strings.TrimSpace(d[1 : len(d)-1])
+	}
+	if len(d) >= 2 && d[0] == '\'' && d[len(d)-1] == '\'' && numericValue.MatchString(d[1:len(d)-1]) {
+		d = d[1 : len(d)-1]
+	}
+	switch strings.ToLower(d) {
+	case "null":
+		return ""
+	case "true":
+		return "1"
+	case "false":
+		return "0"
+	case "now()", "current_timestamp()", "localtimestamp", "current_timestamp":
+		return "current_timestamp"
+	}
+	return d
+}
+
+// balanced 判断括号是否配对（用于去掉外层括号）
+func balanced(s string) bool {
+	depth := 0
+	for _, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return false
+			}
+		}
+	}
+	return depth == 0
+}
+
+var (
+	identQuotes   = strings.NewReplacer("`", "", `"`, "")
+	definerClause = regexp.MustCompile("(?i)\\s+DEFINER\\s*=\\s*\\S+")
+	orReplace     = regexp.MustCompile(`(?i)^create\s+or\s+replace\s+`)
+	dollarTag     = regexp.MustCompile(`\$[a-z_]*\$`)
+	punctSpace    = regexp.MustCompile(`\s*([(),;=])\s*`)
+)
+
+// normalizeBody 归一化视图/例程定义文本：忽略大小写、引号、空白、DEFINER 和 OR REPLACE
+func normalizeBody(schemaName, text string) string {
+	text = strings.ToLower(identQuotes.Replace(text))
+	text = definerClause.ReplaceAllString(text, "")
+	text = strings.Join(strings.Fields(text), " ")
+	text = orReplace.ReplaceAllString(text, "create ")
+	text = dollarTag.ReplaceAllString(text, "$$$$")
+	if schemaName != "" {
+		text = strings.ReplaceAll(text, strings.ToLower(schemaName)+".", "")
+	}
+	text = punctSpace.ReplaceAllString(text, "$1")
+	return strings.TrimRight(text, "; ")
+}
+
+// sameColumns 不区分大小写比较列列表
+func sameColumns(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !strings.EqualFold(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeAction 归一化外键动作，NO ACTION/RESTRICT 视为默认
+func normalizeAction(action string) string {
+	action = strings.ToUpper(strings.Join(strings.Fields(action), " "))
+	if action == "NO ACTION" || action == "RESTRICT" {
+		return ""
+	}
+	return action
+}
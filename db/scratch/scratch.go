@@ -0,0 +1,380 @@
+package scratch
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"xz_mcp/db/dataio"
+	"xz_mcp/db/sqlite_db"
+	"xz_mcp/db/sqlutil"
+)
+
+// 默认值
+const (
+	DefaultMaxRows = 1000
+	batchSize      = 500
+)
+
+// Workspace 会话级的内存 SQLite 数据库，用来合并不同引擎的查询结果
+type Workspace struct {
+	db *sql.DB
+}
+
+// Open 创建一个空的内存工作区；内存库属于单个连接，因此连接池固定为一个连接且不过期
+func Open() (*Workspace, error) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open scratch database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxLifetime(0)
+	db.SetConnMaxIdleTime(0)
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open scratch database: %v", err)
+	}
+	return &Workspace{db: db}, nil
+}
+
+// Close 关闭工作区，所有临时表随之删除
+func (w *Workspace) Close() error {
+	return w.db.Close()
+}
+
+// Endpoint 以普通 SQLite 连接的形式使用工作区
+func (w *Workspace) Endpoint() dataio.Endpoint {
+	return dataio.Endpoint{DB: w.db, Dialect: sqlutil.DialectSQLite}
+}
+
+// Column 临时表的列
+type Column struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	SourceType string `json:"source_type,omitempty"`
+}
+
+// LoadResult load_into_scratch 结果
+type LoadResult struct {
+	Type            string   `json:"type"`
+	Table           string   `json:"table"`
+	Source          string   `json:"source"`
+	Columns         []Column `json:"columns"`
+	CreateStatement string   `json:"create_statement,omitempty"`
+	Rows            int64    `json:"rows"`
+	TableRows       int64    `json:"table_rows"`
+	Failed          int64    `json:"failed,omitempty"`
+	Mismatches      []string `json:"mismatches,omitempty"`
+	DurationMs      int64    `json:"duration_ms"`
+}
+
+// prepare replace 为 true 时先删除同名表，使新结果可以使用不同的列
+func (w *Workspace) prepare(ctx context.Context, table string, replace bool) error {
+	if table == "" {
+		return fmt.Errorf("scratch table name is required")
+	}
+	if replace {
+		if _, err := w.db.ExecContext(ctx, "DROP TABLE IF EXISTS "+sqlutil.QuoteTableName(sqlutil.DialectSQLite, table)); err != nil {
+			return fmt.Errorf("failed to drop scratch table %s: %v", table, err)
+		}
+	}
+	return nil
+}
+
+// LoadQuery 在源库执行查询，把结果写入临时表；列类型由源列类型映射而来
+func (w *Workspace) LoadQuery(ctx context.Context, src dataio.Endpoint, query, table string, replace bool, progress func(rows int64)) (*LoadResult, error) {
+	if err := w.prepare(ctx, table, replace); err != nil {
+		return nil, err
+	}
+	copied, err := dataio.CopyTable(ctx, src, w.Endpoint(), dataio.CopyOptions{
+		Query:       query,
+		Table:       table,
+		Mode:        dataio.ModeAppend,
+		CreateTable: true,
+		Progress:    progress,
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := &LoadResult{
+		Type:            "load_into_scratch",
+		Table:           table,
+		Source:          src.Dialect,
+		CreateStatement: copied.CreateStatement,
+		Rows:            copied.Written,
+		TableRows:       copied.TargetAfter,
+		Failed:          copied.Failed,
+		Mismatches:      copied.Mismatches,
+		DurationMs:      copied.DurationMs,
+	}
+	for _, c := range copied.Columns {
+		result.Columns = append(result.Columns, Column{Name: c.Name, Type: c.TargetType, SourceType: c.SourceType})
+	}
+	return result, nil
+}
+
+// LoadRows 把内存中的行写入临时表，列类型根据值推断（数字字符串推断为数值）
+func (w *Workspace) LoadRows(ctx context.Context, table, source string, columns []string, rows [][]interface{}, replace bool) (*LoadResult, error) {
+	start := time.Now()
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no columns to load")
+	}
+	if err := w.prepare(ctx, table, replace); err != nil {
+		return nil, err
+	}
+
+	defs := make([]dataio.ImportColumn, len(columns))
+	for i, name := range columns {
+		typ := ""
+		for _, row := range rows {
+			typ = dataio.MergeType(typ, dataio.InferType(row[i]))
+		}
+		if typ == "" {
+			typ = dataio.TypeString
+		}
+		defs[i] = dataio.ImportColumn{Source: name, Target: name, Type: typ}
+	}
+	result := &LoadResult{
+		Type:            "load_into_scratch",
+		Table:           table,
+		Source:          source,
+		CreateStatement: dataio.CreateTableStatement(sqlutil.DialectSQLite, table, defs),
+	}
+	_, err := w.db.ExecContext(ctx, result.CreateStatement)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scratch table: %v", err)
+	}
+	if result.Columns, err = w.columns(ctx, table); err != nil {
+		return nil, err
+	}
+
+	tx, err := w.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+	quoted := make([]string, len(columns))
+	for i, name := range columns {
+		quoted[i] = sqlutil.QuoteIdent(sqlutil.DialectSQLite, name)
+	}
+	placeholders := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+	prefix := fmt.Sprintf("INSERT INTO %s (%s) VALUES ", sqlutil.QuoteTableName(sqlutil.DialectSQLite, table), strings.Join(quoted, ", "))
+	perBatch := batchSize
+	if limit := 32766 / len(columns); perBatch > limit {
+		perBatch = limit
+	}
+	for offset := 0; offset < len(rows); offset += perBatch {
+		end := offset + perBatch
+		if end > len(rows) {
+			end = len(rows)
+		}
+		args := make([]interface{}, 0, (end-offset)*len(columns))
+		values := make([]string, 0, end-offset)
+		for _, row := range rows[offset:end] {
+			args = append(args, row...)
+			values = append(values, placeholders)
+		}
+		if _, err := tx.ExecContext(ctx, prefix+strings.Join(values, ", "), args...); err != nil {
+			return nil, fmt.Errorf("failed to insert into scratch table: %v", err)
+		}
+		result.Rows += int64(end - offset)
+	}
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+sqlutil.QuoteTableName(sqlutil.DialectSQLite, table)).Scan(&result.TableRows); err != nil {
+		return nil, fmt.Errorf("failed to count scratch rows: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit scratch load: %v", err)
+	}
+	result.DurationMs = time.Since(start).Milliseconds()
+	return result, nil
+}
+
+// QueryResult scratch_query 结果
+type QueryResult struct {
+	Type         string                   `json:"type"`
+	Columns      []string                 `json:"columns,omitempty"`
+	Data         []map[string]interface{} `json:"data,omitempty"`
+	Count        int                      `json:"count"`
+	Truncated    bool                     `json:"truncated,omitempty"`
+	RowsAffected int64                    `json:"rowsAffected,omitempty"`
+}
+
+// Query 在工作区执行任意 SQL（不允许 ATTACH 等访问其他文件的语句）；返回结果集的语句最多返回 maxRows 行
+func (w *Workspace) Query(ctx context.Context, query string, maxRows int) (*QueryResult, error) {
+	if maxRows <= 0 {
+		maxRows = DefaultMaxRows
+	}
+	if err := sqlite_db.CheckFileStatements(query); err != nil {
+		return nil, err
+	}
+	if !returnsRows(query) {
+		res, err := w.db.ExecContext(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("statement execution failed: %v", err)
+		}
+		affected, _ := res.RowsAffected()
+		return &QueryResult{Type: "modification", RowsAffected: affected}, nil
+	}
+
+	rows, err := w.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query execution failed: %v", err)
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get columns: %v", err)
+	}
+	result := &QueryResult{Type: "select", Columns: columns, Data: []map[string]interface{}{}}
+	values := make([]interface{}, len(columns))
+	ptrs := make([]interface{}, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if len(result.Data) >= maxRows {
+			result.Truncated = true
+			break
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %v", err)
+		}
+		row := make(map[string]interface{}, len(columns))
+		for i, col := range columns {
+			if b, ok := values[i].([]byte); ok {
+				row[col] = string(b)
+			} else {
+				row[col] = values[i]
+			}
+		}
+		result.Data = append(result.Data, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %v", err)
+	}
+	result.Count = len(result.Data)
+	return result, nil
+}
+
+// returnsRows 判断语句是否返回结果集
+func returnsRows(query string) bool {
+	fields := strings.Fields(strings.ToUpper(query))
+	if len(fields) == 0 {
+		return false
+	}
+	switch fields[0] {
+	case "SELECT", "WITH", "VALUES", "PRAGMA", "EXPLAIN":
+		return true
+	}
+	return false
+}
+
+// TableInfo 临时表概要
+type TableInfo struct {
+	Name    string   `json:"name"`
+	Columns []Column `json:"columns"`
+	Rows    int64    `json:"rows"`
+}
+
+// Tables 列出工作区中的表
+func (w *Workspace) Tables(ctx context.Context) ([]TableInfo, error) {
+	rows, err := w.db.QueryContext(ctx, "SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scratch tables: %v", err)
+	}
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+
+	tables := make([]TableInfo, 0, len(names))
+	for _, name := range names {
+		info := TableInfo{Name: name}
+		if info.Columns, err = w.columns(ctx, name); err != nil {
+			return nil, err
+		}
+		if err := w.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+sqlutil.QuoteIdent(sqlutil.DialectSQLite, name)).Scan(&info.Rows); err != nil {
+			return nil, err
+		}
+		tables = append(tables, info)
+	}
+	return tables, nil
+}
+
+// columns 读取表的列名和声明类型
+func (w *Workspace) columns(ctx context.Context, table string) ([]Column, error) {
+	rows, err := w.db.QueryContext(ctx, "SELECT name, type FROM pragma_table_info(?)", table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns := []Column{}
+	for rows.Next() {
+		var c Column
+		if err := rows.Scan(&c.Name, &c.Type); err != nil {
+			return nil, err
+		}
+		columns = append(columns, c)
+	}
+	return columns, rows.Err()
+}
+
+// Registry 按会话管理工作区，会话结束时关闭对应的内存库
+type Registry struct {
+	mu     sync.Mutex
+	spaces map[string]*Workspace
+}
+
+// NewRegistry 创建工作区注册表
+func NewRegistry() *Registry {
+	return &Registry{spaces: map[string]*Workspace{}}
+}
+
+// Get 返回会话的工作区，不存在时创建
+func (r *Registry) Get(session string) (*Workspace, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if w, ok := r.spaces[session]; ok {
+		return w, nil
+	}
+	w, err := Open()
+	if err != nil {
+		return nil, err
+	}
+	r.spaces[session] = w
+	return w, nil
+}
+
+// Release 关闭并移除会话的工作区
+func (r *Registry) Release(session string) {
+	r.mu.Lock()
+	w, ok := r.spaces[session]
+	delete(r.spaces, session)
+	r.mu.Unlock()
+	if ok {
+		w.Close()
+	}
+}
+
+// CloseAll 关闭所有工作区
+func (r *Registry) CloseAll() {
+	r.mu.Lock()
+	spaces := r.spaces
+	r.spaces = map[string]*Workspace{}
+	r.mu.Unlock()
+	for _, w := range spaces {
+		w.Close()
+	}
+}
@@ -0,0 +1,87 @@
+package scratch
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"xz_mcp/db/dataio"
+	"xz_mcp/db/sqlutil"
+)
+
+func TestWorkspaceJoin(t *testing.T) {
+	ctx := context.Background()
+	src, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "orders.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	for _, stmt := range []string{
+		"CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER, amount REAL)",
+		"INSERT INTO orders VALUES (1, 1, 9.5), (2, 1, 0.5), (3, 2, 4)",
+	} {
+		if _, err := src.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	registry := NewRegistry()
+	defer registry.CloseAll()
+	w, err := registry.Get("s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := w.LoadQuery(ctx, dataio.Endpoint{DB: src, Dialect: sqlutil.DialectSQLite}, "SELECT user_id, amount FROM orders", "orders", true, nil)
+	if err != nil {
+		t.Fatalf("LoadQuery: %v", err)
+	}
+	if loaded.Rows != 3 || len(loaded.Columns) != 2 {
+		t.Fatalf("loaded = %+v", loaded)
+	}
+	// 类似 Redis hash 展开的行：数字字符串推断为整数
+	users, err := w.LoadRows(ctx, "users", "redis", []string{"_key", "id", "name"}, [][]interface{}{
+		{"user:1", "1", "alice"},
+		{"user:2", "2", nil},
+	}, true)
+	if err != nil {
+		t.Fatalf("LoadRows: %v", err)
+	}
+	if users.TableRows != 2 || users.Columns[1].Type != "INTEGER" || users.Columns[2].Type != "TEXT" {
+		t.Fatalf("users = %+v", users)
+	}
+
+	result, err := w.Query(ctx, "SELECT u.name, SUM(o.amount) AS total FROM users u JOIN orders o ON o.user_id = u.id GROUP BY u.id ORDER BY u.id", 1)
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if result.Count != 1 || !result.Truncated || result.Data[0]["name"] != "alice" || result.Data[0]["total"] != 10.0 {
+		t.Fatalf("result = %+v", result)
+	}
+
+	// 工作区不能附加或写出其他文件
+	for _, query := range []string{"ATTACH DATABASE '" + filepath.Join(t.TempDir(), "x.db") + "' AS x", "VACUUM INTO '" + filepath.Join(t.TempDir(), "copy.db") + "'"} {
+		if _, err := w.Query(ctx, query, 0); err == nil {
+			t.Errorf("%s: expected to be rejected", query)
+		}
+	}
+
+	// replace 允许用不同的列重建表
+	if _, err := w.LoadRows(ctx, "users", "redis", []string{"email"}, [][]interface{}{{"a@example.com"}}, true); err != nil {
+		t.Fatalf("LoadRows replace: %v", err)
+	}
+	tables, err := w.Tables(ctx)
+	if err != nil || len(tables) != 2 || tables[1].Name != "users" || len(tables[1].Columns) != 1 || tables[1].Rows != 1 {
+		t.Fatalf("tables = %+v, %v", tables, err)
+	}
+
+	// 会话结束后工作区被关闭，新会话从空库开始
+	registry.Release("s1")
+	if _, err := w.Query(ctx, "SELECT 1", 0); err == nil {
+		t.Fatal("released workspace is still usable")
+	}
+	w, _ = registry.Get("s1")
+	if tables, _ := w.Tables(ctx); len(tables) != 0 {
+		t.Fatalf("new workspace has tables: %+v", tables)
+	}
+}
@@ -0,0 +1,119 @@
+package sqlite_db
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// maxAttachments SQLite 默认最多同时附加 10 个数据库
+const maxAttachments = 10
+
+// attachAlias 附加数据库的别名只允许普通标识符，main 和 temp 是保留名
+var attachAlias = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+var (
+	// leadingKeyword 语句开头的关键字，后面可能紧跟字符串（如 ATTACH'/path'）
+	leadingKeyword = regexp.MustCompile(`^[A-Za-z]+`)
+	// intoKeyword VACUUM 语句中的 INTO
+	intoKeyword = regexp.MustCompile(`(?i)\bINTO\b`)
+)
+
+// Attachment 以别名附加到全局连接上的数据库文件
+type Attachment struct {
+	Alias string `json:"alias"`
+	Path  string `json:"path"`
+}
+
+// Attach 把全局连接池固定为一个连接并在其上执行 ATTACH DATABASE，之后的查询可以用 别名.表名 跨文件访问。
+// 相对路径相对于主数据库所在目录；附加的文件必须已存在，且解析符号链接后位于沙箱的根目录中，
+// 沙箱没有配置根目录时必须位于主数据库所在目录（含子目录）中
+func Attach(ctx context.Context, mainPath string, attachments map[string]string, sandbox *Sandbox) ([]Attachment, error) {
+	if len(attachments) == 0 {
+		return nil, nil
+	}
+	if len(attachments) > maxAttachments {
+		return nil, fmt.Errorf("at most %d databases can be attached", maxAttachments)
+	}
+	dir, err := realDir(filepath.Dir(mainPath))
+	if err != nil {
+		return nil, err
+	}
+	allowed := sandbox.Roots()
+	if len(allowed) == 0 {
+		allowed = []string{dir}
+	}
+
+	var resolved []Attachment
+	for alias, path := range attachments {
+		if !attachAlias.MatchString(alias) || strings.EqualFold(alias, "main") || strings.EqualFold(alias, "temp") {
+			return nil, fmt.Errorf("invalid attach alias %q", alias)
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		if err := requireFile(path); err != nil {
+			return nil, err
+		}
+		real, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %v", path, err)
+		}
+		if !withinAny(allowed, real) {
+			return nil, fmt.Errorf("attached database %s is outside the allowed directories (%s)", path, strings.Join(allowed, ", "))
+		}
+		resolved = append(resolved, Attachment{Alias: alias, Path: real})
+	}
+	sort.Slice(resolved, func(i, j int) bool { return resolved[i].Alias < resolved[j].Alias })
+
+	// ATTACH 只对执行它的连接生效，连接池只保留一个不过期的连接，后续查询和事务都复用它
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxLifetime(0)
+	for _, a := range resolved {
+		if _, err := db.ExecContext(ctx, fmt.Sprintf(`ATTACH DATABASE ? AS "%s"`, a.Alias), a.Path); err != nil {
+			return nil, fmt.Errorf("failed to attach %s as %s: %v", a.Path, a.Alias, err)
+		}
+	}
+	return resolved, nil
+}
+
+// CheckFileStatements 拒绝 SQL 中的 ATTACH、DETACH 和 VACUUM INTO：它们能读写任意路径的文件，绕过沙箱。
+// 附加数据库只能通过经过校验的 attach 参数，复制数据库使用 sqlite_backup
+func CheckFileStatements(query string) error {
+	for _, stmt := range splitStatements(query) {
+		stmt = sqlStatement(stmt)
+		switch keyword := strings.ToUpper(leadingKeyword.FindString(stmt)); keyword {
+		case "ATTACH", "DETACH":
+			return fmt.Errorf("%s is not allowed in SQL; use the attach parameter to attach databases", keyword)
+		case "VACUUM":
+			if intoKeyword.MatchString(stmt) {
+				return fmt.Errorf("VACUUM INTO is not allowed in SQL; use sqlite_backup to copy a database")
+			}
+		}
+	}
+	return nil
+}
+
+// withinAny path 是否位于 dirs 中的任一目录下
+func withinAny(dirs []string, path string) bool {
+	for _, dir := range dirs {
+		if withinDir(dir, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// withinDir path 是否为 dir 本身或位于其下，两者都应是已解析符号链接的绝对路径
+func withinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(os.PathSeparator)))
+}
@@ -0,0 +1,115 @@
+package sqlite_db
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeDB 在 path 创建数据库并执行建表语句
+func writeDB(t *testing.T, path string, stmts ...string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	conn, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	for _, stmt := range stmts {
+		if _, err := conn.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestAttach(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	mainPath := filepath.Join(dir, "weather.db")
+	writeDB(t, mainPath,
+		"CREATE TABLE cities (id INTEGER PRIMARY KEY, name TEXT, country_id INTEGER)",
+		"INSERT INTO cities VALUES (1, 'Berlin', 49), (2, 'Paris', 33)")
+	writeDB(t, filepath.Join(dir, "ref", "countries.db"),
+		"CREATE TABLE countries (id INTEGER PRIMARY KEY, name TEXT)",
+		"INSERT INTO countries VALUES (49, 'Germany'), (33, 'France')")
+	outside := filepath.Join(t.TempDir(), "other.db")
+	writeDB(t, outside, "CREATE TABLE t (x)")
+
+	if err := InitDB(mainPath); err != nil {
+		t.Fatal(err)
+	}
+	defer CloseDB()
+
+	attached, err := Attach(ctx, mainPath, map[string]string{"ref": "ref/countries.db"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attached) != 1 || attached[0].Alias != "ref" || !filepath.IsAbs(attached[0].Path) {
+		t.Fatalf("unexpected attachments: %+v", attached)
+	}
+	// 连接池只有一个连接，多次查询都能看到附加的库
+	for i := 0; i < 3; i++ {
+		var names []string
+		err := Db().Select(&names, "SELECT c.name || '/' || r.name FROM main.cities c JOIN ref.countries r ON r.id = c.country_id ORDER BY c.id")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(names, ",") != "Berlin/Germany,Paris/France" {
+			t.Fatalf("unexpected join result: %v", names)
+		}
+	}
+
+	for name, attachments := range map[string]map[string]string{
+		"outside directory": {"other": outside},
+		"parent traversal":  {"other": "../" + filepath.Base(filepath.Dir(outside)) + "/other.db"},
+		"missing file":      {"typo": "ref/countrie.db"},
+		"reserved alias":    {"main": "ref/countries.db"},
+		"invalid alias":     {"ref; DROP": "ref/countries.db"},
+	} {
+		if _, err := Attach(ctx, mainPath, attachments, nil); err == nil {
+			t.Errorf("%s: expected attach to be rejected", name)
+		}
+	}
+
+	link := filepath.Join(dir, "link.db")
+	if err := os.Symlink(outside, link); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+	if _, err := Attach(ctx, mainPath, map[string]string{"other": "link.db"}, nil); err == nil {
+		t.Error("expected symlink escaping the directory to be rejected")
+	}
+
+	// 沙箱配置了根目录时，附加的库可以位于任一根目录中
+	sandbox, err := NewSandbox([]string{dir, filepath.Dir(outside)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Attach(ctx, mainPath, map[string]string{"other": outside}, sandbox); err != nil {
+		t.Errorf("attach inside another root should be allowed: %v", err)
+	}
+}
+
+func TestCheckFileStatements(t *testing.T) {
+	for query, allowed := range map[string]bool{
+		"SELECT * FROM cities":                           true,
+		"SELECT 'ATTACH DATABASE x AS y; VACUUM INTO z'": true,
+		"INSERT INTO cities VALUES (3, 'Rome', 39)":      true,
+		"VACUUM":                               true,
+		"VACUUM main":                          true,
+		"ATTACH DATABASE '/etc/app.db' AS app": false,
+		"attach'/etc/app.db' as app":           false,
+		"SELECT 1; /* c */ ATTACH '/etc/app.db' AS app": false,
+		"-- note\nDETACH DATABASE app":                  false,
+		"VACUUM INTO '/tmp/copy.db'":                    false,
+		"vacuum main into'/tmp/copy.db'":                false,
+		"SELECT 1;\nVACUUM/**/INTO '/tmp/copy.db'":      false,
+	} {
+		if err := CheckFileStatements(query); (err == nil) != allowed {
+			t.Errorf("CheckFileStatements(%q) = %v", query, err)
+		}
+	}
+}
@@ -0,0 +1,503 @@
+package sqlite_db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"modernc.org/sqlite"
+)
+
+// 备份方式
+const (
+	BackupOnline = "backup"      // SQLite 在线备份 API，逐页复制，不阻塞其他连接的读写
+	BackupVacuum = "vacuum_into" // VACUUM INTO，生成整理过碎片的副本
+)
+
+// 默认值
+const (
+	DefaultCheckErrors = 100
+	backupStepPages    = 256
+)
+
+// BackupResult sqlite_backup 结果
+type BackupResult struct {
+	Type       string `json:"type"`
+	Source     string `json:"source"`
+	Path       string `json:"path"`
+	Method     string `json:"method"`
+	Bytes      int64  `json:"bytes"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// backupConn modernc 驱动连接提供的在线备份方法
+type backupConn interface {
+	NewBackup(dstUri string) (*sqlite.Backup, error)
+	NewRestore(srcUri string) (*sqlite.Backup, error)
+}
+
+// BackupPath 在源文件旁生成带时间戳的备份文件名，如 weather.20240102-150405.bak.db；
+// 同一秒内已有同名文件时追加序号，如 weather.20240102-150405-2.bak.db
+func BackupPath(src string, now time.Time) string {
+	ext := filepath.Ext(src)
+	stem := strings.TrimSuffix(src, ext)
+	if ext == "" {
+		ext = ".db"
+	}
+	stamp := now.Format("20060102-150405")
+	path := fmt.Sprintf("%s.%s.bak%s", stem, stamp, ext)
+	for n := 2; ; n++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return path
+		}
+		path = fmt.Sprintf("%s.%s-%d.bak%s", stem, stamp, n, ext)
+	}
+}
+
+// Backup 把 src 备份到 dest（为空时使用 BackupPath），dest 已存在时报错，失败时删除不完整的文件
+func Backup(ctx context.Context, src, dest, method string) (*BackupResult, error) {
+	start := time.Now()
+	if method == "" {
+		method = BackupOnline
+	}
+	if method != BackupOnline && method != BackupVacuum {
+		return nil, fmt.Errorf("unknown backup method %q (use %s or %s)", method, BackupOnline, BackupVacuum)
+	}
+	if err := requireFile(src); err != nil {
+		return nil, err
+	}
+	if dest == "" {
+		dest = BackupPath(src, start)
+	}
+	if _, err := os.Stat(dest); err == nil {
+		return nil, fmt.Errorf("backup file %s already exists", dest)
+	}
+
+	conn, err := Open(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", src, err)
+	}
+	defer conn.Close()
+	if method == BackupVacuum {
+		_, err = conn.ExecContext(ctx, "VACUUM INTO ?", dest)
+	} else {
+		err = copyDatabase(ctx, conn.DB, func(c backupConn) (*sqlite.Backup, error) { return c.NewBackup(dest) })
+	}
+	if err != nil {
+		os.Remove(dest)
+		return nil, fmt.Errorf("backup failed: %v", err)
+	}
+	info, err := os.Stat(dest)
+	if err != nil {
+		return nil, err
+	}
+	return &BackupResult{
+		Type:       "sqlite_backup",
+		Source:     src,
+		Path:       dest,
+		Method:     method,
+		Bytes:      info.Size(),
+		DurationMs: time.Since(start).Milliseconds(),
+	}, nil
+}
+
+// copyDatabase 在 db 的一个连接上执行在线备份或恢复，每步复制 backupStepPages 页，步与步之间检查 ctx
+func copyDatabase(ctx context.Context, db *sql.DB, start func(c backupConn) (*sqlite.Backup, error)) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return conn.Raw(func(driverConn interface{}) error {
+		c, ok := driverConn.(backupConn)
+		if !ok {
+			return fmt.Errorf("driver does not support online backup")
+		}
+		b, err := start(c)
+		if err != nil {
+			return err
+		}
+		for more := true; more; {
+			if err := ctx.Err(); err != nil {
+				b.Finish()
+				return err
+			}
+			if more, err = b.Step(backupStepPages); err != nil {
+				b.Finish()
+				return err
+			}
+		}
+		return b.Finish()
+	})
+}
+
+// RestoreResult sqlite_restore 结果
+type RestoreResult struct {
+	Type       string        `json:"type"`
+	Backup     string        `json:"backup"`
+	Target     string        `json:"target"`
+	Previous   *BackupResult `json:"previous,omitempty"` // 恢复前对目标库做的备份
+	DurationMs int64         `json:"duration_ms"`
+}
+
+// Restore 先以 quick_check 检查备份文件，再以在线备份 API 用它覆盖 target；
+// backupCurrent 为 true 且 target 已存在时先备份当前内容
+func Restore(ctx context.Context, backup, target string, backupCurrent bool) (*RestoreResult, error) {
+	start := time.Now()
+	if err := requireFile(backup); err != nil {
+		return nil, err
+	}
+	check, err := Check(ctx, backup, []string{CheckQuick}, DefaultCheckErrors)
+	if err != nil {
+		return nil, err
+	}
+	if !check.OK {
+		return nil, fmt.Errorf("backup %s failed quick_check: %s", backup, strings.Join(check.Quick.Messages, "; "))
+	}
+	if same, err := samePath(backup, target); err != nil || same {
+		return nil, fmt.Errorf("backup and target must be different files")
+	}
+	result := &RestoreResult{Type: "sqlite_restore", Backup: backup, Target: target}
+	if _, err := os.Stat(target); err == nil && backupCurrent {
+		if result.Previous, err = Backup(ctx, target, "", BackupOnline); err != nil {
+			return nil, fmt.Errorf("failed to back up current %s: %v", target, err)
+		}
+	}
+
+	conn, err := Open(target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", target, err)
+	}
+	defer conn.Close()
+	err = copyDatabase(ctx, conn.DB, func(c backupConn) (*sqlite.Backup, error) { return c.NewRestore(backup) })
+	if err != nil {
+		return nil, fmt.Errorf("restore failed: %v", err)
+	}
+	result.DurationMs = time.Since(start).Milliseconds()
+	return result, nil
+}
+
+// 检查项
+const (
+	CheckIntegrity   = "integrity"
+	CheckQuick       = "quick"
+	CheckForeignKeys = "foreign_keys"
+)
+
+// CheckStatus integrity_check/quick_check 的结果，通过时 Messages 为空
+type CheckStatus struct {
+	OK       bool     `json:"ok"`
+	Messages []string `json:"messages,omitempty"`
+}
+
+// ForeignKeyViolation foreign_key_check 的一行，补充了外键涉及的列
+type ForeignKeyViolation struct {
+	Table         string   `json:"table"`
+	RowID         *int64   `json:"rowid"` // WITHOUT ROWID 表为 null
+	Parent        string   `json:"parent"`
+	ForeignKeyID  int      `json:"fkid"`
+	Columns       []string `json:"columns,omitempty"`
+	ParentColumns []string `json:"parent_columns,omitempty"`
+}
+
+// CheckResult sqlite_check 结果
+type CheckResult struct {
+	Type        string                `json:"type"`
+	Path        string                `json:"path"`
+	OK          bool                  `json:"ok"`
+	Integrity   *CheckStatus          `json:"integrity_check,omitempty"`
+	Quick       *CheckStatus          `json:"quick_check,omitempty"`
+	ForeignKeys []ForeignKeyViolation `json:"foreign_key_check,omitempty"`
+	Truncated   bool                  `json:"truncated,omitempty"` // 外键违规超过 maxErrors
+	DurationMs  int64                 `json:"duration_ms"`
+}
+
+// Check 依次执行指定的检查（默认全部），maxErrors 限制每项返回的问题数量
+func Check(ctx context.Context, path string, checks []string, maxErrors int) (*CheckResult, error) {
+	start := time.Now()
+	if err := requireFile(path); err != nil {
+		return nil, err
+	}
+	if len(checks) == 0 {
+		checks = []string{CheckIntegrity, CheckQuick, CheckForeignKeys}
+	}
+	if maxErrors <= 0 {
+		maxErrors = DefaultCheckErrors
+	}
+	conn, err := Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	defer conn.Close()
+
+	result := &CheckResult{Type: "sqlite_check", Path: path, OK: true}
+	for _, check := range checks {
+		switch check {
+		case CheckIntegrity, CheckQuick:
+			status, err := pragmaCheck(ctx, conn.DB, check+"_check", maxErrors)
+			if err != nil {
+				return nil, err
+			}
+			if check == CheckIntegrity {
+				result.Integrity = status
+			} else {
+				result.Quick = status
+			}
+			result.OK = result.OK && status.OK
+		case CheckForeignKeys:
+			violations, truncated, err := foreignKeyCheck(ctx, conn.DB, maxErrors)
+			if err != nil {
+				return nil, err
+			}
+			result.ForeignKeys = violations
+			result.Truncated = truncated
+			result.OK = result.OK && len(violations) == 0
+		default:
+			return nil, fmt.Errorf("unknown check %q (use %s, %s or %s)", check, CheckIntegrity, CheckQuick, CheckForeignKeys)
+		}
+	}
+	result.DurationMs = time.Since(start).Milliseconds()
+	return result, nil
+}
+
+// pragmaCheck 执行 PRAGMA integrity_check(N)/quick_check(N)，唯一一行 ok 表示通过
+func pragmaCheck(ctx context.Context, db *sql.DB, pragma string, maxErrors int) (*CheckStatus, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("PRAGMA %s(%d)", pragma, maxErrors))
+	if err != nil {
+		return nil, fmt.Errorf("PRAGMA %s failed: %v", pragma, err)
+	}
+	defer rows.Close()
+	status := &CheckStatus{}
+	for rows.Next() {
+		var msg string
+		if err := rows.Scan(&msg); err != nil {
+			return nil, fmt.Errorf("PRAGMA %s failed: %v", pragma, err)
+		}
+		status.Messages = append(status.Messages, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("PRAGMA %s failed: %v", pragma, err)
+	}
+	if len(status.Messages) == 1 && status.Messages[0] == "ok" {
+		status.OK = true
+		status.Messages = nil
+	}
+	return status, nil
+}
+
+// foreignKeyCheck 执行 PRAGMA foreign_key_check，并从 pragma_foreign_key_list 查出外键的列
+func foreignKeyCheck(ctx context.Context, db *sql.DB, maxErrors int) ([]ForeignKeyViolation, bool, error) {
+	rows, err := db.QueryContext(ctx, "PRAGMA foreign_key_check")
+	if err != nil {
+		return nil, false, fmt.Errorf("PRAGMA foreign_key_check failed: %v", err)
+	}
+	var violations []ForeignKeyViolation
+	truncated := false
+	for rows.Next() {
+		if len(violations) == maxErrors {
+			truncated = true
+			break
+		}
+		var v ForeignKeyViolation
+		var rowid sql.NullInt64
+		if err := rows.Scan(&v.Table, &rowid, &v.Parent, &v.ForeignKeyID); err != nil {
+			rows.Close()
+			return nil, false, fmt.Errorf("PRAGMA foreign_key_check failed: %v", err)
+		}
+		if rowid.Valid {
+			v.RowID = &rowid.Int64
+		}
+		violations = append(violations, v)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, false, fmt.Errorf("PRAGMA foreign_key_check failed: %v", err)
+	}
+
+	for i := range violations {
+		v := &violations[i]
+		cols, err := db.QueryContext(ctx, `SELECT "from", "to" FROM pragma_foreign_key_list(?) WHERE id = ? ORDER BY seq`, v.Table, v.ForeignKeyID)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to read foreign keys of %s: %v", v.Table, err)
+		}
+		for cols.Next() {
+			var from string
+			var to sql.NullString // 引用父表主键时为 NULL
+			if err := cols.Scan(&from, &to); err != nil {
+				cols.Close()
+				return nil, false, err
+			}
+			v.Columns = append(v.Columns, from)
+			if to.Valid {
+				v.ParentColumns = append(v.ParentColumns, to.String)
+			}
+		}
+		cols.Close()
+	}
+	return violations, truncated, nil
+}
+
+// requireFile 确认数据库文件存在，避免驱动为不存在的路径创建空库
+func requireFile(path string) error {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("database file %s not found", path)
+	}
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", path)
+	}
+	return nil
+}
+
+// samePath 比较两个路径的绝对形式
+func samePath(a, b string) (bool, error) {
+	absA, err := filepath.Abs(a)
+	if err != nil {
+		return false, err
+	}
+	absB, err := filepath.Abs(b)
+	if err != nil {
+		return false, err
+	}
+	return absA == absB, nil
+}
+
+// WritesData 判断 SQL 是否可能修改数据库，多条语句时任一条会写入即为 true。
+// SELECT/EXPLAIN/VALUES 和不带赋值的 PRAGMA 是只读的，WITH 开头的语句看其中是否包含 INSERT/UPDATE/DELETE/REPLACE
+func WritesData(query string) bool {
+	for _, stmt := range splitStatements(query) {
+		if statementWrites(stmt) {
+			return true
+		}
+	}
+	return false
+}
+
+// statementWrites 判断单条语句是否可能修改数据库
+func statementWrites(query string) bool {
+	fields := strings.Fields(strings.ToUpper(sqlStatement(query)))
+	if len(fields) == 0 {
+		return false
+	}
+	switch fields[0] {
+	case "SELECT", "EXPLAIN", "VALUES":
+		return false
+	case "PRAGMA":
+		return strings.Contains(query, "=")
+	case "WITH":
+		for _, word := range fields {
+			switch strings.Trim(word, "();,") {
+			case "INSERT", "UPDATE", "DELETE", "REPLACE":
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// splitStatements 按顶层的分号拆分 SQL，忽略引号、方括号标识符和注释中的分号；
+// 触发器体会被拆开，但拆出的片段仍按写入处理
+func splitStatements(query string) []string {
+	var stmts []string
+	start := 0
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; {
+		case c == '\'' || c == '"' || c == '`' || c == '[':
+			end := c
+			if c == '[' {
+				end = ']'
+			}
+			for i++; i < len(query) && query[i] != end; i++ {
+			}
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			if end := strings.Index(query[i+2:], "*/"); end >= 0 {
+				i += end + 3
+			} else {
+				i = len(query)
+			}
+		case c == ';':
+			stmts = append(stmts, query[start:i])
+			start = i + 1
+		}
+	}
+	return append(stmts, query[start:])
+}
+
+// sqlStatement 去掉开头的注释和括号
+func sqlStatement(query string) string {
+	for {
+		query = strings.TrimLeft(strings.TrimSpace(query), "(")
+		switch {
+		case strings.HasPrefix(query, "--"):
+			if i := strings.Index(query, "\n"); i >= 0 {
+				query = query[i+1:]
+				continue
+			}
+			return ""
+		case strings.HasPrefix(query, "/*"):
+			if i := strings.Index(query, "*/"); i >= 0 {
+				query = query[i+2:]
+				continue
+			}
+			return ""
+		}
+		return query
+	}
+}
+
+// Snapshots 记录每个会话已经做过写前快照的数据库，同一会话对同一文件只做一次
+type Snapshots struct {
+	mu    sync.Mutex
+	taken map[string]map[string]string // 会话 ID → 数据库绝对路径 → 快照路径
+}
+
+// NewSnapshots 创建快照记录
+func NewSnapshots() *Snapshots {
+	return &Snapshots{taken: make(map[string]map[string]string)}
+}
+
+// Ensure 会话内第一次写 dbPath 前做一次在线备份；已做过时返回之前的快照路径，created 为 false。
+// 数据库文件还不存在时没有可保存的内容，返回空路径
+func (s *Snapshots) Ensure(ctx context.Context, session, dbPath string) (path string, created bool, err error) {
+	abs, err := filepath.Abs(dbPath)
+	if err != nil {
+		return "", false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if path, ok := s.taken[session][abs]; ok {
+		return path, false, nil
+	}
+	if _, err := os.Stat(abs); os.IsNotExist(err) {
+		return "", false, nil
+	}
+	result, err := Backup(ctx, abs, "", BackupOnline)
+	if err != nil {
+		return "", false, fmt.Errorf("pre-write snapshot failed: %v", err)
+	}
+	if s.taken[session] == nil {
+		s.taken[session] = make(map[string]string)
+	}
+	s.taken[session][abs] = result.Path
+	return result.Path, true, nil
+}
+
+// Release 会话结束时忘记其快照记录，快照文件保留在磁盘上
+func (s *Snapshots) Release(session string) {
+	s.mu.Lock()
+	delete(s.taken, session)
+	s.mu.Unlock()
+}
@@ -0,0 +1,178 @@
+package sqlite_db
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestDB 在临时目录中创建带外键的数据库，orphan 为 true 时插入一条违反外键的记录
+func newTestDB(t *testing.T, orphan bool) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "app.db")
+	conn, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	stmts := []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)",
+		"CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER REFERENCES users(id), total REAL)",
+		"INSERT INTO users VALUES (1, 'alice'), (2, 'bob')",
+		"INSERT INTO orders VALUES (10, 1, 9.5), (11, 2, 3)",
+	}
+	if orphan {
+		stmts = append(stmts, "INSERT INTO orders VALUES (12, 99, 1)")
+	}
+	for _, stmt := range stmts {
+		if _, err := conn.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+func countRows(t *testing.T, path, table string) int {
+	t.Helper()
+	conn, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	var n int
+	if err := conn.Get(&n, "SELECT COUNT(*) FROM "+table); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	ctx := context.Background()
+	src := newTestDB(t, false)
+
+	online, err := Backup(ctx, src, "", BackupOnline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(online.Path) != filepath.Dir(src) || online.Bytes == 0 || countRows(t, online.Path, "orders") != 2 {
+		t.Fatalf("unexpected online backup: %+v", online)
+	}
+	if _, err := Backup(ctx, src, online.Path, BackupOnline); err == nil {
+		t.Error("expected existing backup file to be rejected")
+	}
+
+	vacuum, err := Backup(ctx, src, filepath.Join(t.TempDir(), "copy.db"), BackupVacuum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if countRows(t, vacuum.Path, "users") != 2 {
+		t.Errorf("VACUUM INTO copy is missing rows")
+	}
+	if _, err := Backup(ctx, filepath.Join(t.TempDir(), "typo.db"), "", BackupOnline); err == nil {
+		t.Error("expected missing source to be rejected")
+	}
+
+	conn, err := Open(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Exec("DELETE FROM orders"); err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	restored, err := Restore(ctx, vacuum.Path, src, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if countRows(t, src, "orders") != 2 {
+		t.Error("restore should bring the deleted rows back")
+	}
+	if restored.Previous == nil || countRows(t, restored.Previous.Path, "orders") != 0 {
+		t.Errorf("restore should back up the current content first: %+v", restored.Previous)
+	}
+
+	garbage := filepath.Join(t.TempDir(), "garbage.db")
+	if err := os.WriteFile(garbage, []byte("not a database"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Restore(ctx, garbage, src, false); err == nil {
+		t.Error("expected a corrupt backup to be rejected")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	ctx := context.Background()
+	clean, err := Check(ctx, newTestDB(t, false), nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !clean.OK || !clean.Integrity.OK || !clean.Quick.OK || len(clean.ForeignKeys) != 0 {
+		t.Errorf("clean database should pass: %+v", clean)
+	}
+
+	broken, err := Check(ctx, newTestDB(t, true), []string{CheckForeignKeys}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if broken.OK || broken.Integrity != nil || len(broken.ForeignKeys) != 1 {
+		t.Fatalf("expected one foreign key violation: %+v", broken)
+	}
+	v := broken.ForeignKeys[0]
+	if v.Table != "orders" || v.Parent != "users" || v.RowID == nil || *v.RowID != 12 || v.Columns[0] != "user_id" || v.ParentColumns[0] != "id" {
+		t.Errorf("unexpected violation: %+v", v)
+	}
+	if _, err := Check(ctx, newTestDB(t, false), []string{"vacuum"}, 0); err == nil {
+		t.Error("expected unknown check to be rejected")
+	}
+}
+
+func TestSnapshots(t *testing.T) {
+	ctx := context.Background()
+	path := newTestDB(t, false)
+	snapshots := NewSnapshots()
+
+	first, created, err := snapshots.Ensure(ctx, "s1", path)
+	if err != nil || !created || first == "" {
+		t.Fatalf("first write should take a snapshot: %q %v %v", first, created, err)
+	}
+	again, created, err := snapshots.Ensure(ctx, "s1", path)
+	if err != nil || created || again != first {
+		t.Errorf("second write in the session should reuse the snapshot: %q %v %v", again, created, err)
+	}
+
+	snapshots.Release("s1")
+	next, created, err := snapshots.Ensure(ctx, "s1", path)
+	if err != nil || !created || next == first {
+		t.Errorf("released session should snapshot again: %q %v %v", next, created, err)
+	}
+
+	missing, created, err := snapshots.Ensure(ctx, "s2", filepath.Join(t.TempDir(), "new.db"))
+	if err != nil || created || missing != "" {
+		t.Errorf("nothing to snapshot for a new file: %q %v %v", missing, created, err)
+	}
+}
+
+func TestWritesData(t *testing.T) {
+	for query, want := range map[string]bool{
+		"SELECT * FROM users":                             false,
+		"  -- list\n select 1":                            false,
+		"WITH t AS (SELECT 1) SELECT * FROM t":            false,
+		"WITH t AS (SELECT 1) DELETE FROM users":          true,
+		"PRAGMA table_info(users)":                        false,
+		"PRAGMA foreign_keys = ON":                        true,
+		"DROP TABLE users":                                true,
+		"/* cleanup */ UPDATE users SET name = 'x'":       true,
+		"CREATE TABLE t AS SELECT * FROM users":           true,
+		"EXPLAIN QUERY PLAN SELECT * FROM users WHERE id": false,
+		"SELECT 1; DELETE FROM users":                     true,
+		"SELECT ';'; SELECT 2;":                           false,
+		"SELECT 1 -- ; DROP TABLE users\n; SELECT 2":      false,
+		"SELECT 1; /* ; */ INSERT INTO t VALUES (1)":      true,
+	} {
+		if got := WritesData(query); got != want {
+			t.Errorf("WritesData(%q) = %v", query, got)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package sqlite_db
+
+import (
+	"context"
+	"fmt"
+
+	"xz_mcp/db/sqlutil"
+)
+
+// Explain 执行 EXPLAIN QUERY PLAN 并将结果构建为统一的计划树
+func Explain(ctx context.Context, query string, includeRaw bool) (*sqlutil.PlanSummary, error) {
+	rows, err := db.QueryContext(ctx, "EXPLAIN QUERY PLAN "+sqlutil.TrimStatement(query))
+	if err != nil {
+		return nil, fmt.Errorf("EXPLAIN QUERY PLAN failed: %v", err)
+	}
+	defer rows.Close()
+
+	var planRows []sqlutil.SQLitePlanRow
+	for rows.Next() {
+		var r sqlutil.SQLitePlanRow
+		var notUsed interface{}
+		if err := rows.Scan(&r.ID, &r.Parent, &notUsed, &r.Detail); err != nil {
+			return nil, fmt.Errorf("failed to scan plan row: %v", err)
+		}
+		planRows = append(planRows, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %v", err)
+	}
+
+	summary := sqlutil.Summarize("sqlite", sqlutil.ParseSQLiteQueryPlan(planRows))
+	summary.Notes = append(summary.Notes, "SQLite query plans do not include cost or row estimates")
+	if includeRaw {
+		summary.Raw = planRows
+	}
+	return summary, nil
+}
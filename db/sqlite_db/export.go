@@ -0,0 +1,18 @@
+package sqlite_db
+
+import (
+	"context"
+	"fmt"
+
+	"xz_mcp/db/dataio"
+)
+
+// Export 执行查询并将结果集流式写入文件（CSV/JSONL/Parquet/Markdown）
+func Export(ctx context.Context, query string, opts dataio.ExportOptions) (*dataio.ExportResult, error) {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %v", err)
+	}
+	defer rows.Close()
+	return dataio.ExportRows(rows, opts)
+}
@@ -0,0 +1,13 @@
+package sqlite_db
+
+import (
+	"context"
+
+	"xz_mcp/db/dataio"
+	"xz_mcp/db/sqlutil"
+)
+
+// Import 将 CSV/JSONL/Parquet 文件在单个事务中批量导入到表中
+func Import(ctx context.Context, opts dataio.ImportOptions) (*dataio.ImportResult, error) {
+	return dataio.Import(ctx, db.DB, sqlutil.DialectSQLite, opts)
+}
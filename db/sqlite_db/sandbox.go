@@ -0,0 +1,244 @@
+package sqlite_db
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SandboxEnv 允许访问的 SQLite 根目录，多个目录以系统路径分隔符（Unix 为 :，Windows 为 ;）分隔
+const SandboxEnv = "XZ_MCP_SQLITE_ROOTS"
+
+// 默认值
+const (
+	DefaultListDatabases = 500
+)
+
+// databaseExtensions sqlite_list_databases 识别的文件扩展名
+var databaseExtensions = map[string]bool{".db": true, ".sqlite": true, ".sqlite3": true, ".db3": true}
+
+// uriEscaper 转义 file: URI 中有特殊含义的字符
+var uriEscaper = strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23")
+
+// sqliteHeader SQLite 数据库文件的前 16 个字节
+var sqliteHeader = []byte("SQLite format 3\x00")
+
+// Sandbox 限制 SQLite 工具能访问的目录；没有配置根目录时不限制目录（可以打开进程有权限访问的任何文件），只检查文件是否存在。
+// 沙箱只检查工具参数中的路径，SQL 中的 ATTACH 等语句由 CheckFileStatements 拒绝
+type Sandbox struct {
+	roots []string // 已解析符号链接的绝对路径
+}
+
+// NewSandbox 解析根目录，每个根目录都必须存在
+func NewSandbox(roots []string) (*Sandbox, error) {
+	s := &Sandbox{}
+	for _, root := range roots {
+		if root = strings.TrimSpace(root); root == "" {
+			continue
+		}
+		real, err := realDir(root)
+		if err != nil {
+			return nil, err
+		}
+		s.roots = append(s.roots, real)
+	}
+	return s, nil
+}
+
+// SandboxFromEnv 按 SandboxEnv 环境变量创建沙箱
+func SandboxFromEnv() (*Sandbox, error) {
+	return NewSandbox(filepath.SplitList(os.Getenv(SandboxEnv)))
+}
+
+// Roots 配置的根目录
+func (s *Sandbox) Roots() []string {
+	if s == nil {
+		return nil
+	}
+	return s.roots
+}
+
+// Resolve 解析数据库路径并返回解析符号链接后的真实路径，之后应始终使用该路径打开文件。
+// 相对路径相对于第一个根目录（未配置时相对于当前目录）；文件不存在且 createIfMissing 为 false 时报错，
+// 否则要求所在目录存在且位于根目录中
+func (s *Sandbox) Resolve(path string, createIfMissing bool) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("database path is required")
+	}
+	roots := s.Roots()
+	if !filepath.IsAbs(path) && len(roots) > 0 {
+		path = filepath.Join(roots[0], path)
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	var real string
+	info, err := os.Stat(path)
+	switch {
+	case err == nil:
+		if info.IsDir() {
+			return "", fmt.Errorf("%s is a directory", path)
+		}
+		if real, err = filepath.EvalSymlinks(path); err != nil {
+			return "", fmt.Errorf("failed to resolve %s: %v", path, err)
+		}
+	case os.IsNotExist(err):
+		if _, lerr := os.Lstat(path); lerr == nil {
+			// 指向不存在目标的符号链接，打开时驱动会在链接目标处建库
+			return "", fmt.Errorf("%s is a dangling symlink", path)
+		}
+		if !createIfMissing {
+			return "", fmt.Errorf("database file %s not found (set create_if_missing to create a new database)", path)
+		}
+		dir, err := realDir(filepath.Dir(path))
+		if err != nil {
+			return "", err
+		}
+		real = filepath.Join(dir, filepath.Base(path))
+	default:
+		return "", err
+	}
+	if !s.allowed(real) {
+		return "", fmt.Errorf("%s is outside the allowed SQLite directories (%s)", path, strings.Join(roots, ", "))
+	}
+	return real, nil
+}
+
+// allowed real 是否位于某个根目录中，没有配置根目录时都允许
+func (s *Sandbox) allowed(real string) bool {
+	roots := s.Roots()
+	return len(roots) == 0 || withinAny(roots, real)
+}
+
+// realDir 解析目录的符号链接并返回绝对路径
+func realDir(dir string) (string, error) {
+	real, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("directory %s not found", dir)
+		}
+		return "", fmt.Errorf("failed to resolve %s: %v", dir, err)
+	}
+	real, err = filepath.Abs(real)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(real)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", dir)
+	}
+	return real, nil
+}
+
+// DatabaseFile sqlite_list_databases 中的一个文件
+type DatabaseFile struct {
+	Path     string `json:"path"`
+	Root     string `json:"root"`
+	Bytes    int64  `json:"bytes"`
+	Modified string `json:"modified"`
+	Tables   *int   `json:"tables,omitempty"`
+	Error    string `json:"error,omitempty"` // 不是 SQLite 文件或无法读取
+}
+
+// DatabaseList sqlite_list_databases 结果
+type DatabaseList struct {
+	Type      string         `json:"type"`
+	Roots     []string       `json:"roots"`
+	Databases []DatabaseFile `json:"databases"`
+	Truncated bool           `json:"truncated"` // 超过 maxFiles 时只返回前 maxFiles 个
+}
+
+// ListDatabases 遍历根目录下扩展名为 .db/.sqlite/.sqlite3/.db3 的文件，以只读方式打开并统计表数量；
+// 不进入符号链接
+func (s *Sandbox) ListDatabases(ctx context.Context, maxFiles int) (*DatabaseList, error) {
+	roots := s.Roots()
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("no SQLite root directories configured; set %s", SandboxEnv)
+	}
+	if maxFiles <= 0 {
+		maxFiles = DefaultListDatabases
+	}
+	result := &DatabaseList{Type: "sqlite_databases", Roots: roots, Databases: []DatabaseFile{}}
+	for _, root := range roots {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				// 无权限读取的子目录跳过
+				if path != root && d != nil && d.IsDir() {
+					return fs.SkipDir
+				}
+				return err
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if d.IsDir() || d.Type()&fs.ModeSymlink != 0 || !databaseExtensions[strings.ToLower(filepath.Ext(path))] {
+				return nil
+			}
+			if len(result.Databases) == maxFiles {
+				result.Truncated = true
+				return fs.SkipAll
+			}
+			result.Databases = append(result.Databases, describeDatabase(ctx, root, path))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if result.Truncated {
+			break
+		}
+	}
+	sort.Slice(result.Databases, func(i, j int) bool { return result.Databases[i].Path < result.Databases[j].Path })
+	return result, nil
+}
+
+// describeDatabase 读取文件大小、修改时间，并以只读方式统计表数量
+func describeDatabase(ctx context.Context, root, path string) DatabaseFile {
+	file := DatabaseFile{Path: path, Root: root}
+	info, err := os.Stat(path)
+	if err != nil {
+		file.Error = err.Error()
+		return file
+	}
+	file.Bytes = info.Size()
+	file.Modified = info.ModTime().Format(time.RFC3339)
+	if info.Size() > 0 {
+		header := make([]byte, len(sqliteHeader))
+		f, err := os.Open(path)
+		if err != nil {
+			file.Error = err.Error()
+			return file
+		}
+		_, err = io.ReadFull(f, header)
+		f.Close()
+		if err != nil || !bytes.Equal(header, sqliteHeader) {
+			file.Error = "not a SQLite database"
+			return file
+		}
+	}
+	conn, err := Open("file:" + uriEscaper.Replace(path) + "?mode=ro")
+	if err != nil {
+		file.Error = err.Error()
+		return file
+	}
+	defer conn.Close()
+	var tables int
+	if err := conn.GetContext(ctx, &tables, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'"); err != nil {
+		file.Error = err.Error()
+		return file
+	}
+	file.Tables = &tables
+	return file
+}
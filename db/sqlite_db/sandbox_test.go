@@ -0,0 +1,123 @@
+package sqlite_db
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSandboxResolve(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	writeDB(t, filepath.Join(root, "data", "app.db"), "CREATE TABLE t (x)")
+	writeDB(t, filepath.Join(outside, "secret.db"), "CREATE TABLE t (x)")
+
+	sandbox, err := NewSandbox([]string{root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	realRoot, _ := filepath.EvalSymlinks(root)
+
+	got, err := sandbox.Resolve("data/app.db", false)
+	if err != nil || got != filepath.Join(realRoot, "data", "app.db") {
+		t.Errorf("relative path should resolve inside the first root: %q %v", got, err)
+	}
+	if _, err := sandbox.Resolve("data/ap.db", false); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("typo should report file not found, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "data", "ap.db")); !os.IsNotExist(err) {
+		t.Error("resolving must not create the file")
+	}
+	if got, err := sandbox.Resolve("data/new.db", true); err != nil || got != filepath.Join(realRoot, "data", "new.db") {
+		t.Errorf("create_if_missing should allow a new file: %q %v", got, err)
+	}
+	if _, err := sandbox.Resolve("missing/new.db", true); err == nil {
+		t.Error("expected missing parent directory to be rejected")
+	}
+	if _, err := sandbox.Resolve("data", false); err == nil {
+		t.Error("expected directory to be rejected")
+	}
+
+	for name, path := range map[string]string{
+		"absolute outside": filepath.Join(outside, "secret.db"),
+		"parent traversal": filepath.Join(root, "..", filepath.Base(outside), "secret.db"),
+	} {
+		if _, err := sandbox.Resolve(path, false); err == nil || !strings.Contains(err.Error(), "outside") {
+			t.Errorf("%s: expected path to be rejected, got %v", name, err)
+		}
+	}
+
+	if err := os.Symlink(filepath.Join(outside, "secret.db"), filepath.Join(root, "link.db")); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+	if _, err := sandbox.Resolve("link.db", false); err == nil {
+		t.Error("expected symlink to a file outside the roots to be rejected")
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sandbox.Resolve("escape/new.db", true); err == nil {
+		t.Error("expected new file under a symlinked directory outside the roots to be rejected")
+	}
+	if err := os.Symlink(filepath.Join(outside, "planted.db"), filepath.Join(root, "dangling.db")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sandbox.Resolve("dangling.db", true); err == nil {
+		t.Error("expected dangling symlink to be rejected")
+	}
+
+	var open *Sandbox
+	if got, err := open.Resolve(filepath.Join(outside, "secret.db"), false); err != nil || got == "" {
+		t.Errorf("sandbox without roots should allow any existing file: %q %v", got, err)
+	}
+	if _, err := NewSandbox([]string{filepath.Join(root, "nope")}); err == nil {
+		t.Error("expected missing root to be rejected")
+	}
+}
+
+func TestListDatabases(t *testing.T) {
+	root := t.TempDir()
+	writeDB(t, filepath.Join(root, "weather.db"), "CREATE TABLE cities (id)", "CREATE TABLE states (id)")
+	writeDB(t, filepath.Join(root, "ref", "countries.sqlite"), "CREATE TABLE countries (id)")
+	if err := os.WriteFile(filepath.Join(root, "notes.db"), []byte("plain text, not sqlite"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "readme.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sandbox, err := NewSandbox([]string{root})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := sandbox.ListDatabases(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Databases) != 3 || list.Truncated {
+		t.Fatalf("unexpected databases: %+v", list.Databases)
+	}
+	byName := map[string]DatabaseFile{}
+	for _, db := range list.Databases {
+		byName[filepath.Base(db.Path)] = db
+	}
+	if w := byName["weather.db"]; w.Tables == nil || *w.Tables != 2 || w.Bytes == 0 {
+		t.Errorf("unexpected weather.db: %+v", w)
+	}
+	if c := byName["countries.sqlite"]; c.Tables == nil || *c.Tables != 1 {
+		t.Errorf("unexpected countries.sqlite: %+v", c)
+	}
+	if n := byName["notes.db"]; n.Tables != nil || n.Error == "" {
+		t.Errorf("non-SQLite file should report an error: %+v", n)
+	}
+
+	limited, err := sandbox.ListDatabases(context.Background(), 1)
+	if err != nil || len(limited.Databases) != 1 || !limited.Truncated {
+		t.Errorf("expected truncated listing: %+v %v", limited, err)
+	}
+	if _, err := (&Sandbox{}).ListDatabases(context.Background(), 0); err == nil {
+		t.Error("expected listing without roots to fail")
+	}
+}
@@ -1,12 +1,15 @@
 package sqlite_db
 
 import (
+	"context"
 	"fmt"
 	"github.com/jmoiron/sqlx"
 	"log"
 	//_ "github.com/mutecomm/go-sqlcipher/v4"
 	_ "modernc.org/sqlite"
 	"time"
+
+	"xz_mcp/db/sqlutil"
 )
 
 var db *sqlx.DB
@@ -43,6 +46,31 @@ func CloseDB() {
 	db.Close()
 }
 
+// Open 打开一个独立于全局连接的数据库句柄，调用方负责关闭
+func Open(path string) (*sqlx.DB, error) {
+	return sqlx.Connect("sqlite", path)
+}
+
+// DryRun 在事务中预演DML语句，通过RETURNING采集变更后的行，最终回滚
+func DryRun(ctx context.Context, query string, sampleRows int) (*sqlutil.DryRunResult, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	result, err := sqlutil.DryRun(ctx, tx, sqlutil.DialectSQLite, query, nil, sampleRows)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Rollback(); err != nil {
+		result.Notes = append(result.Notes, fmt.Sprintf("rollback failed: %v", err))
+	} else {
+		result.RolledBack = true
+	}
+	return result, nil
+}
+
 type Scanner interface {
 	Scan(dest ...interface{}) error
 }
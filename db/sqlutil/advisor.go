@@ -0,0 +1,529 @@
+package sqlutil
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// IndexInfo 表上已有的索引
+type IndexInfo struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Unique  bool     `json:"unique"`
+	Primary bool     `json:"primary"`
+}
+
+// AdvisorSource 索引顾问依赖的数据库能力
+type AdvisorSource struct {
+	Dialect      string
+	Explain      func(query string) (*PlanSummary, error)
+	TableIndexes func(table string) ([]IndexInfo, error)
+	// ExplainGeneric 获取含参数占位符的查询的通用计划，为 nil 时这类查询会被跳过
+	ExplainGeneric func(query string) (*PlanSummary, error)
+}
+
+// IndexFinding 单条查询中发现的问题
+type IndexFinding struct {
+	Type    string   `json:"type"` // full_scan/filesort/temp_table/unindexed_filter/unindexed_join
+	Table   string   `json:"table,omitempty"`
+	Columns []string `json:"columns,omitempty"`
+	Detail  string   `json:"detail"`
+}
+
+// QueryAdvice 单条查询的分析结果
+type QueryAdvice struct {
+	SQL        string         `json:"sql"`
+	FullScans  []string       `json:"full_scans"`
+	Filesorts  int            `json:"filesorts"`
+	TempTables int            `json:"temp_tables"`
+	Findings   []IndexFinding `json:"findings"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// IndexSuggestion 建议创建的索引
+type IndexSuggestion struct {
+	Table        string   `json:"table"`
+	Columns      []string `json:"columns"`
+	Statement    string   `json:"statement"`
+	Rationale    []string `json:"rationale"`
+	Queries      []int    `json:"queries"`
+	ExtendsIndex string   `json:"extends_index,omitempty"`
+}
+
+// RedundantIndex 重复或冗余的已有索引
+type RedundantIndex struct {
+	Table         string   `json:"table"`
+	Index         string   `json:"index"`
+	Columns       []string `json:"columns"`
+	CoveredBy     string   `json:"covered_by"`
+	Reason        string   `json:"reason"` // duplicate/redundant_prefix
+	DropStatement string   `json:"drop_statement"`
+}
+
+// IndexReport 索引建议报告
+type IndexReport struct {
+	Engine           string                 `json:"engine"`
+	Queries          []QueryAdvice          `json:"queries"`
+	Suggestions      []IndexSuggestion      `json:"suggestions"`
+	RedundantIndexes []RedundantIndex       `json:"redundant_indexes"`
+	ExistingIndexes  map[string][]IndexInfo `json:"existing_indexes"`
+	Notes            []string               `json:"notes,omitempty"`
+}
+
+// explainForAdvice 获取查询的执行计划。慢日志和 pg_stat_statements 中的查询通常带有 ? 或 $1 占位符，
+// 普通 EXPLAIN 无法执行，改用 ExplainGeneric
+func explainForAdvice(src AdvisorSource, query string) (*PlanSummary, error) {
+	if !HasPlaceholders(src.Dialect, query) {
+		return src.Explain(query)
+	}
+	if src.ExplainGeneric == nil {
+		return nil, fmt.Errorf("query has parameter placeholders and %s cannot EXPLAIN it without values; replace them with representative values", src.Dialect)
+	}
+	return src.ExplainGeneric(query)
+}
+
+// HasPlaceholders SQL 中是否有参数占位符（忽略引号和注释内的内容）：PostgreSQL 为 $1 形式
+// （? 是 jsonb 运算符），其他方言为 ?
+func HasPlaceholders(dialect, sql string) bool {
+	if dialect != DialectPostgres {
+		return CountPlaceholders(sql) > 0
+	}
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			i = skipQuoted(sql, i)
+			continue
+		case c == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+			continue
+		case c == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				return false
+			}
+			i += end + 4
+			continue
+		case c == '$' && i+1 < len(sql) && sql[i+1] >= '0' && sql[i+1] <= '9':
+			return true
+		case isWordChar(c):
+			// 标识符中的 $ 不是占位符
+			for i < len(sql) && isWordChar(sql[i]) {
+				i++
+			}
+			continue
+		}
+		i++
+	}
+	return false
+}
+
+// SamplePlaceholderArgs 为 ? 占位符生成 EXPLAIN 用的样本值：LIMIT/OFFSET 中为整数 1，其他位置为字符串 '1'。
+// MySQL 把字符串常量转换为列类型后比较，整数列和字符串列上的索引都能使用；整数常量与字符串列比较时
+// 会转换列值，计划中会出现本不存在的全表扫描
+func SamplePlaceholderArgs(sql string) []interface{} {
+	var args []interface{}
+	inLimit := false
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			inLimit = false
+			i = skipQuoted(sql, i)
+			continue
+		case c == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+			continue
+		case c == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				return args
+			}
+			i += end + 4
+			continue
+		case isWordStart(c):
+			start := i
+			for i < len(sql) && isWordChar(sql[i]) {
+				i++
+			}
+			word := strings.ToUpper(sql[start:i])
+			inLimit = word == "LIMIT" || word == "OFFSET"
+			continue
+		case c == '?':
+			if inLimit {
+				args = append(args, int64(1))
+			} else {
+				args = append(args, "1")
+			}
+		case c != ',' && c != ' ' && c != '\t' && c != '\n' && c != '\r':
+			inLimit = false
+		}
+		i++
+	}
+	return args
+}
+
+// SuggestIndexes 分析查询的执行计划和已有索引，给出索引建议
+func SuggestIndexes(src AdvisorSource, queries []string) (*IndexReport, error) {
+	if len(queries) == 0 {
+		return nil, fmt.Errorf("no queries to analyze")
+	}
+	report := &IndexReport{
+		Engine:           src.Dialect,
+		Queries:          []QueryAdvice{},
+		Suggestions:      []IndexSuggestion{},
+		RedundantIndexes: []RedundantIndex{},
+		ExistingIndexes:  map[string][]IndexInfo{},
+	}
+	suggestionByKey := map[string]int{}
+
+	loadIndexes := func(table string) []IndexInfo {
+		if indexes, ok := report.ExistingIndexes[table]; ok {
+			return indexes
+		}
+		indexes, err := src.TableIndexes(table)
+		if err != nil {
+			report.Notes = append(report.Notes, fmt.Sprintf("failed to load indexes of %s: %v", table, err))
+			indexes = nil
+		}
+		if indexes == nil {
+			indexes = []IndexInfo{}
+		}
+		report.ExistingIndexes[table] = indexes
+		return indexes
+	}
+
+	for qi, query := range queries {
+		advice := QueryAdvice{SQL: query, FullScans: []string{}, Findings: []IndexFinding{}}
+		plan, err := explainForAdvice(src, query)
+		if err != nil {
+			advice.Error = err.Error()
+			report.Queries = append(report.Queries, advice)
+			report.Notes = append(report.Notes, fmt.Sprintf("query %d skipped: %v", qi, err))
+			continue
+		}
+		advice.FullScans = plan.FullScans
+		advice.Filesorts = plan.Filesorts
+		advice.TempTables = plan.TempTables
+
+		shape := AnalyzeQuery(query)
+		fullScan := map[string]bool{}
+		for _, t := range plan.FullScans {
+			fullScan[strings.ToLower(t)] = true
+			advice.Findings = append(advice.Findings, IndexFinding{Type: "full_scan", Table: t, Detail: "plan reads every row of the table"})
+		}
+		if plan.Filesorts > 0 {
+			advice.Findings = append(advice.Findings, IndexFinding{Type: "filesort", Detail: fmt.Sprintf("plan performs %d extra sort step(s)", plan.Filesorts)})
+		}
+		if plan.TempTables > 0 {
+			advice.Findings = append(advice.Findings, IndexFinding{Type: "temp_table", Detail: fmt.Sprintf("plan uses %d temporary table(s)", plan.TempTables)})
+		}
+
+		for _, table := range shape.Tables {
+			indexes := loadIndexes(table.Name)
+			scanned := fullScan[strings.ToLower(table.Name)] || (table.Alias != "" && fullScan[strings.ToLower(table.Alias)])
+
+			eq := columnsFor(shape.EqualityCols, table.Name)
+			join := columnsFor(shape.JoinCols, table.Name)
+			rng := columnsFor(shape.RangeCols, table.Name)
+			order := columnsFor(shape.OrderByCols, table.Name)
+			if len(order) != len(shape.OrderByCols) {
+				// 排序列来自多个表时，单表索引无法消除排序
+				order = nil
+			}
+
+			var unindexedFilter, unindexedJoin []string
+			for _, col := range append(append([]string{}, eq...), rng...) {
+				if !hasLeadingColumn(indexes, col) {
+					unindexedFilter = append(unindexedFilter, col)
+				}
+			}
+			for _, col := range join {
+				if !hasLeadingColumn(indexes, col) {
+					unindexedJoin = append(unindexedJoin, col)
+				}
+			}
+			if len(unindexedFilter) > 0 {
+				advice.Findings = append(advice.Findings, IndexFinding{Type: "unindexed_filter", Table: table.Name, Columns: unindexedFilter,
+					Detail: "filter columns are not the leading column of any index"})
+			}
+			if len(unindexedJoin) > 0 {
+				advice.Findings = append(advice.Findings, IndexFinding{Type: "unindexed_join", Table: table.Name, Columns: unindexedJoin,
+					Detail: "join columns are not the leading column of any index"})
+			}
+
+			// 组合索引：等值列 + 连接列 + 第一个范围列，没有范围列时追加排序列
+			candidate := uniqueColumns(append(append([]string{}, eq...), join...))
+			var rationale []string
+			if len(eq) > 0 {
+				rationale = append(rationale, fmt.Sprintf("equality filter on %s", strings.Join(eq, ", ")))
+			}
+			if len(join) > 0 {
+				rationale = append(rationale, fmt.Sprintf("join on %s", strings.Join(join, ", ")))
+			}
+			if len(rng) > 0 {
+				candidate = uniqueColumns(append(candidate, rng[0]))
+				rationale = append(rationale, fmt.Sprintf("range filter on %s", rng[0]))
+			} else if len(order) > 0 && plan.Filesorts > 0 {
+				candidate = uniqueColumns(append(candidate, order...))
+				rationale = append(rationale, fmt.Sprintf("ORDER BY %s can use index order instead of a filesort", strings.Join(order, ", ")))
+			}
+			if len(candidate) == 0 {
+				continue
+			}
+			if len(candidate) > 4 {
+				candidate = candidate[:4]
+			}
+			if coveringIndex(indexes, candidate) != "" {
+				continue
+			}
+			if !scanned && len(unindexedFilter) == 0 && len(unindexedJoin) == 0 && plan.Filesorts == 0 {
+				// 计划已经使用了合适的索引
+				continue
+			}
+			if scanned {
+				rationale = append([]string{"full scan in plan"}, rationale...)
+			}
+
+			key := strings.ToLower(table.Name + ":" + strings.Join(candidate, ","))
+			if idx, ok := suggestionByKey[key]; ok {
+				report.Suggestions[idx].Queries = append(report.Suggestions[idx].Queries, qi)
+				continue
+			}
+			suggestion := IndexSuggestion{
+				Table:        table.Name,
+				Columns:      candidate,
+				Statement:    CreateIndexStatement(src.Dialect, table.Name, candidate),
+				Rationale:    rationale,
+				Queries:      []int{qi},
+				ExtendsIndex: prefixIndex(indexes, candidate),
+			}
+			suggestionByKey[key] = len(report.Suggestions)
+			report.Suggestions = append(report.Suggestions, suggestion)
+		}
+		report.Queries = append(report.Queries, advice)
+	}
+
+	tables := make([]string, 0, len(report.ExistingIndexes))
+	for table := range report.ExistingIndexes {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	for _, table := range tables {
+		report.RedundantIndexes = append(report.RedundantIndexes, FindRedundantIndexes(src.Dialect, table, report.ExistingIndexes[table])...)
+	}
+	return report, nil
+}
+
+// FindRedundantIndexes 找出与其他索引完全相同或是其他索引前缀的索引
+func FindRedundantIndexes(dialect, table string, indexes []IndexInfo) []RedundantIndex {
+	var result []RedundantIndex
+	for i, a := range indexes {
+		if a.Primary || a.Unique || len(a.Columns) == 0 {
+			continue
+		}
+		for j, b := range indexes {
+			if i == j || len(b.Columns) < len(a.Columns) {
+				continue
+			}
+			if !equalFoldPrefix(b.Columns, a.Columns) {
+				continue
+			}
+			reason := "redundant_prefix"
+			if len(b.Columns) == len(a.Columns) {
+				// 完全相同的两个普通索引只标记后一个
+				if !b.Primary && !b.Unique && j > i {
+					continue
+				}
+				reason = "duplicate"
+			}
+			result = append(result, RedundantIndex{
+				Table:         table,
+				Index:         a.Name,
+				Columns:       a.Columns,
+				CoveredBy:     b.Name,
+				Reason:        reason,
+				DropStatement: DropIndexStatement(dialect, table, a.Name),
+			})
+			break
+		}
+	}
+	return result
+}
+
+var indexNameInvalid = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// CreateIndexStatement 生成 CREATE INDEX 语句
+func CreateIndexStatement(dialect, table string, columns []string) string {
+	_, short := SplitTableName(table)
+	name := "idx_" + short + "_" + strings.Join(columns, "_")
+	name = strings.ToLower(indexNameInvalid.ReplaceAllString(name, "_"))
+	if len(name) > 60 {
+		name = name[:60]
+	}
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = QuoteIdent(dialect, c)
+	}
+	return fmt.Sprintf("CREATE INDEX %s ON %s (%s)", QuoteIdent(dialect, name), QuoteTableName(dialect, table), strings.Join(quoted, ", "))
+}
+
+// DropIndexStatement 生成 DROP INDEX 语句
+func DropIndexStatement(dialect, table, index string) string {
+	if dialect == DialectMySQL {
+		return fmt.Sprintf("DROP INDEX %s ON %s", QuoteIdent(dialect, index), QuoteTableName(dialect, table))
+	}
+	schema, _ := SplitTableName(table)
+	if schema != "" {
+		return fmt.Sprintf("DROP INDEX %s.%s", QuoteIdent(dialect, schema), QuoteIdent(dialect, index))
+	}
+	return fmt.Sprintf("DROP INDEX %s", QuoteIdent(dialect, index))
+}
+
+// QuoteTableName 为 schema.table 形式的表名加引号
+func QuoteTableName(dialect, table string) string {
+	schema, name := SplitTableName(table)
+	if schema != "" {
+		return QuoteIdent(dialect, schema) + "." + QuoteIdent(dialect, name)
+	}
+	return QuoteIdent(dialect, name)
+}
+
+var pgIndexDef = regexp.MustCompile(`(?i)^CREATE\s+(UNIQUE\s+)?INDEX\s+.*?\s+ON\s+.*?USING\s+\w+\s*\((.*)\)`)
+
+// ParsePostgresIndexDef 解析 pg_indexes.indexdef，提取唯一性和索引列
+func ParsePostgresIndexDef(def string) (unique bool, columns []string) {
+	m := pgIndexDef.FindStringSubmatch(def)
+	if m == nil {
+		return false, nil
+	}
+	unique = m[1] != ""
+	body := m[2]
+	// 去掉 INCLUDE/WHERE 等后续子句
+	depth := 0
+	end := len(body)
+	for i := 0; i < len(body); i++ {
+		if body[i] == '(' {
+			depth++
+		} else if body[i] == ')' {
+			if depth == 0 {
+				end = i
+				break
+			}
+			depth--
+		}
+	}
+	body = body[:end]
+	for _, part := range splitTopLevel(body) {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		columns = append(columns, UnquoteIdent(fields[0]))
+	}
+	return unique, columns
+}
+
+// splitTopLevel 按顶层逗号拆分
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth := 0
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, strings.TrimSpace(s[start:]))
+}
+
+func columnsFor(refs []ColumnRef, table string) []string {
+	var cols []string
+	for _, r := range refs {
+		if r.Table == table {
+			cols = append(cols, r.Column)
+		}
+	}
+	return cols
+}
+
+func uniqueColumns(cols []string) []string {
+	var result []string
+	seen := map[string]bool{}
+	for _, c := range cols {
+		if !seen[strings.ToLower(c)] {
+			seen[strings.ToLower(c)] = true
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+func hasLeadingColumn(indexes []IndexInfo, column string) bool {
+	for _, idx := range indexes {
+		if len(idx.Columns) > 0 && strings.EqualFold(idx.Columns[0], column) {
+			return true
+		}
+	}
+	return false
+}
+
+// coveringIndex 返回以候选列（等值部分顺序无关）为前缀的已有索引
+func coveringIndex(indexes []IndexInfo, candidate []string) string {
+	for _, idx := range indexes {
+		if len(idx.Columns) < len(candidate) {
+			continue
+		}
+		want := map[string]bool{}
+		for _, c := range candidate {
+			want[strings.ToLower(c)] = true
+		}
+		match := true
+		for _, c := range idx.Columns[:len(candidate)] {
+			if !want[strings.ToLower(c)] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return idx.Name
+		}
+	}
+	return ""
+}
+
+// prefixIndex 返回是候选列前缀的已有索引（新索引可以替代它）
+func prefixIndex(indexes []IndexInfo, candidate []string) string {
+	for _, idx := range indexes {
+		if !idx.Primary && !idx.Unique && len(idx.Columns) < len(candidate) && equalFoldPrefix(candidate, idx.Columns) {
+			return idx.Name
+		}
+	}
+	return ""
+}
+
+func equalFoldPrefix(cols, prefix []string) bool {
+	if len(prefix) > len(cols) {
+		return false
+	}
+	for i := range prefix {
+		if !strings.EqualFold(cols[i], prefix[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,148 @@
+package sqlutil
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeQuery(t *testing.T) {
+	shape := AnalyzeQuery(`SELECT o.id, c.name FROM orders AS o
+		JOIN customers c ON c.id = o.customer_id
+		WHERE o.status = ? AND o.created_at >= '2025-01-01' AND c.name LIKE 'Al%'
+		ORDER BY o.created_at DESC LIMIT 10`)
+
+	expectedTables := []TableRef{{Name: "orders", Alias: "o"}, {Name: "customers", Alias: "c"}}
+	if !reflect.DeepEqual(shape.Tables, expectedTables) {
+		t.Errorf("unexpected tables: %+v", shape.Tables)
+	}
+	if !reflect.DeepEqual(shape.EqualityCols, []ColumnRef{{Table: "orders", Column: "status"}}) {
+		t.Errorf("unexpected equality columns: %+v", shape.EqualityCols)
+	}
+	if !reflect.DeepEqual(shape.RangeCols, []ColumnRef{{Table: "orders", Column: "created_at"}, {Table: "customers", Column: "name"}}) {
+		t.Errorf("unexpected range columns: %+v", shape.RangeCols)
+	}
+	if !reflect.DeepEqual(shape.JoinCols, []ColumnRef{{Table: "customers", Column: "id"}, {Table: "orders", Column: "customer_id"}}) {
+		t.Errorf("unexpected join columns: %+v", shape.JoinCols)
+	}
+	if !reflect.DeepEqual(shape.OrderByCols, []ColumnRef{{Table: "orders", Column: "created_at"}}) {
+		t.Errorf("unexpected order by columns: %+v", shape.OrderByCols)
+	}
+}
+
+func TestParsePostgresIndexDef(t *testing.T) {
+	unique, cols := ParsePostgresIndexDef(`CREATE UNIQUE INDEX users_email_key ON public.users USING btree (email, lower((name)::text)) WHERE (deleted_at IS NULL)`)
+	if !unique || !reflect.DeepEqual(cols, []string{"email", "lower((name)::text)"}) {
+		t.Errorf("unexpected parse result: %v %v", unique, cols)
+	}
+	unique, cols = ParsePostgresIndexDef(`CREATE INDEX idx_a ON public.t USING btree ("Status", created_at DESC)`)
+	if unique || !reflect.DeepEqual(cols, []string{"Status", "created_at"}) {
+		t.Errorf("unexpected parse result: %v %v", unique, cols)
+	}
+}
+
+func TestSuggestIndexes(t *testing.T) {
+	scan := &PlanNode{Operation: "table access (ALL)", Table: "orders", FullScan: true}
+	explain := func(query string) (*PlanSummary, error) {
+		return Summarize("mysql", []*PlanNode{scan}), nil
+	}
+	src := AdvisorSource{
+		Dialect:        DialectMySQL,
+		Explain:        explain,
+		ExplainGeneric: explain,
+		TableIndexes: func(table string) ([]IndexInfo, error) {
+			return []IndexInfo{
+				{Name: "PRIMARY", Columns: []string{"id"}, Unique: true, Primary: true},
+				{Name: "idx_status", Columns: []string{"status"}},
+				{Name: "idx_status_dup", Columns: []string{"status"}},
+				{Name: "idx_status_created", Columns: []string{"status", "created_at"}},
+				{Name: "idx_customer", Columns: []string{"customer_id"}},
+			}, nil
+		},
+	}
+	report, err := SuggestIndexes(src, []string{
+		"SELECT * FROM orders WHERE customer_id = ? AND total > 100",
+		"SELECT * FROM orders WHERE customer_id = 7 AND total > ?",
+	})
+	if err != nil {
+		t.Fatalf("SuggestIndexes failed: %v", err)
+	}
+	if len(report.Suggestions) != 1 {
+		t.Fatalf("expected 1 suggestion, got %+v", report.Suggestions)
+	}
+	s := report.Suggestions[0]
+	if s.Statement != "CREATE INDEX `idx_orders_customer_id_total` ON `orders` (`customer_id`, `total`)" {
+		t.Errorf("unexpected statement: %s", s.Statement)
+	}
+	if !reflect.DeepEqual(s.Queries, []int{0, 1}) || s.ExtendsIndex != "idx_customer" {
+		t.Errorf("unexpected suggestion: %+v", s)
+	}
+
+	redundant := map[string]string{}
+	for _, r := range report.RedundantIndexes {
+		redundant[r.Index] = r.Reason
+	}
+	expected := map[string]string{"idx_status": "redundant_prefix", "idx_status_dup": "duplicate"}
+	if !reflect.DeepEqual(redundant, expected) {
+		t.Errorf("unexpected redundant indexes: %+v", report.RedundantIndexes)
+	}
+}
+
+func TestFindRedundantIndexesDuplicate(t *testing.T) {
+	result := FindRedundantIndexes(DialectPostgres, "public.t", []IndexInfo{
+		{Name: "a", Columns: []string{"x", "y"}},
+		{Name: "b", Columns: []string{"x", "y"}},
+	})
+	if len(result) != 1 || result[0].Index != "b" || result[0].Reason != "duplicate" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if result[0].DropStatement != `DROP INDEX "public"."b"` {
+		t.Errorf("unexpected drop statement: %s", result[0].DropStatement)
+	}
+}
+
+func TestSuggestIndexesPlaceholders(t *testing.T) {
+	var plain, generic []string
+	src := AdvisorSource{
+		Dialect: DialectPostgres,
+		Explain: func(query string) (*PlanSummary, error) {
+			plain = append(plain, query)
+			return Summarize("postgres", nil), nil
+		},
+		TableIndexes: func(table string) ([]IndexInfo, error) { return nil, nil },
+	}
+	queries := []string{
+		"SELECT * FROM orders WHERE customer_id = $1",
+		"SELECT * FROM orders WHERE data ? 'vip' AND note = '$1'",
+	}
+	report, err := SuggestIndexes(src, queries)
+	if err != nil {
+		t.Fatalf("SuggestIndexes failed: %v", err)
+	}
+	if report.Queries[0].Error == "" || len(report.Notes) != 1 || !strings.HasPrefix(report.Notes[0], "query 0 skipped: query has parameter placeholders") {
+		t.Errorf("placeholder query should be skipped with a reason: %+v %v", report.Queries[0], report.Notes)
+	}
+	if !reflect.DeepEqual(plain, queries[1:]) {
+		t.Errorf("jsonb ? operator and quoted $1 are not placeholders: %v", plain)
+	}
+
+	src.ExplainGeneric = func(query string) (*PlanSummary, error) {
+		generic = append(generic, query)
+		return Summarize("postgres", nil), nil
+	}
+	if report, err = SuggestIndexes(src, queries[:1]); err != nil || report.Queries[0].Error != "" || len(generic) != 1 {
+		t.Errorf("placeholder query should use the generic plan: %+v %v", report, err)
+	}
+}
+
+func TestSamplePlaceholderArgs(t *testing.T) {
+	args := SamplePlaceholderArgs("SELECT * FROM t WHERE a = ? AND b IN (?, ?) AND c = '?' ORDER BY a LIMIT ?, ?")
+	expected := []interface{}{"1", "1", "1", int64(1), int64(1)}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("unexpected args: %#v", args)
+	}
+	args = SamplePlaceholderArgs("SELECT * FROM t WHERE a > ? LIMIT ? OFFSET ?")
+	if !reflect.DeepEqual(args, []interface{}{"1", int64(1), int64(1)}) {
+		t.Errorf("unexpected args: %#v", args)
+	}
+}
@@ -0,0 +1,411 @@
+package sqlutil
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DMLStatement 解析后的DML语句结构
+type DMLStatement struct {
+	Kind         string   // INSERT/REPLACE/UPDATE/DELETE
+	Table        string   // 目标表名（多表语句时为空）
+	TableClause  string   // 原始表子句（可能包含别名）
+	Where        string   // WHERE 条件（不含关键字）
+	OrderBy      string   // ORDER BY 子句（不含关键字）
+	Limit        string   // LIMIT 子句（不含关键字）
+	HasReturning bool     // 是否已包含 RETURNING 子句
+	MultiTable   bool     // 是否为多表语句（JOIN/USING/FROM）
+	SetColumns   []string // UPDATE 的 SET 子句中被赋值的列（去掉表别名和引号）
+
+	// ArgsBeforeWhere WHERE 之前出现的 ? 占位符数量（SET/VALUES 中的参数）
+	ArgsBeforeWhere int
+	// WhereArgs WHERE 和 ORDER BY 中的 ? 占位符数量
+	WhereArgs int
+	// LimitArgs LIMIT 中的 ? 占位符数量
+	LimitArgs int
+}
+
+// token 顶层单词（不在括号、引号、注释内）
+type token struct {
+	upper string
+	start int
+	end   int
+}
+
+// topLevelWords 提取SQL中位于顶层的单词及其位置
+func topLevelWords(sql string) []token {
+	var words []token
+	depth := 0
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			i = skipQuoted(sql, i)
+			continue
+		case c == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+			continue
+		case c == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				return words
+			}
+			i += end + 4
+			continue
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth > 0 {
+				depth--
+			}
+		case isWordStart(c):
+			start := i
+			for i < len(sql) && isWordChar(sql[i]) {
+				i++
+			}
+			if depth == 0 {
+				words = append(words, token{upper: strings.ToUpper(sql[start:i]), start: start, end: i})
+			}
+			continue
+		}
+		i++
+	}
+	return words
+}
+
+// skipQuoted 跳过引号包裹的内容，返回结束位置之后的下标
+func skipQuoted(sql string, i int) int {
+	quote := sql[i]
+	i++
+	for i < len(sql) {
+		if sql[i] == '\\' && quote == '\'' {
+			i += 2
+			continue
+		}
+		if sql[i] == quote {
+			if i+1 < len(sql) && sql[i+1] == quote {
+				i += 2
+				continue
+			}
+			return i + 1
+		}
+		i++
+	}
+	return i
+}
+
+func isWordStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isWordChar(c byte) bool {
+	return isWordStart(c) || c == '$' || (c >= '0' && c <= '9')
+}
+
+// CountPlaceholders 统计SQL片段中 ? 占位符的数量（忽略引号和注释内的内容）
+func CountPlaceholders(sql string) int {
+	count := 0
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			i = skipQuoted(sql, i)
+			continue
+		case c == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+			continue
+		case c == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				return count
+			}
+			i += end + 4
+			continue
+		case c == '?':
+			count++
+		}
+		i++
+	}
+	return count
+}
+
+// TrimStatement 去除SQL首尾空白和结尾分号
+func TrimStatement(sql string) string {
+	return strings.TrimRight(strings.TrimSpace(sql), "; \t\r\n")
+}
+
+// ParseDML 解析 INSERT/REPLACE/UPDATE/DELETE 语句，提取目标表和条件
+func ParseDML(sql string) (*DMLStatement, error) {
+	sql = TrimStatement(sql)
+	words := topLevelWords(sql)
+	if len(words) == 0 {
+		return nil, fmt.Errorf("empty statement")
+	}
+
+	stmt := &DMLStatement{Kind: words[0].upper}
+	// 找到下一个出现的顶层关键字
+	find := func(from int, keywords ...string) int {
+		for i := from; i < len(words); i++ {
+			for _, kw := range keywords {
+				if words[i].upper == kw {
+					if kw == "ORDER" && (i+1 >= len(words) || words[i+1].upper != "BY") {
+						continue
+					}
+					return i
+				}
+			}
+		}
+		return -1
+	}
+	// 取两个单词之间的原始文本
+	between := func(from, to int) string {
+		start := words[from].end
+		end := len(sql)
+		if to >= 0 {
+			end = words[to].start
+		}
+		return strings.TrimSpace(sql[start:end])
+	}
+
+	for _, w := range words {
+		if w.upper == "RETURNING" {
+			stmt.HasReturning = true
+		}
+	}
+
+	var tableStart, tableEnd int
+	switch stmt.Kind {
+	case "INSERT", "REPLACE":
+		into := find(1, "INTO")
+		if into < 0 || into+1 >= len(words) {
+			return nil, fmt.Errorf("cannot find target table in %s statement", stmt.Kind)
+		}
+		stmt.Table = tableName(sql[words[into].end:])
+		stmt.TableClause = stmt.Table
+		stmt.ArgsBeforeWhere = CountPlaceholders(sql)
+		return stmt, nil
+	case "UPDATE":
+		tableStart = 1
+		for tableStart < len(words) && (words[tableStart].upper == "LOW_PRIORITY" || words[tableStart].upper == "IGNORE" || words[tableStart].upper == "ONLY") {
+			tableStart++
+		}
+		tableEnd = find(tableStart, "SET")
+		if tableEnd < 0 {
+			return nil, fmt.Errorf("UPDATE statement has no SET clause")
+		}
+		if find(tableEnd, "FROM") >= 0 {
+			stmt.MultiTable = true
+		}
+		stmt.SetColumns = assignedColumns(between(tableEnd, find(tableEnd+1, "FROM", "WHERE", "ORDER", "LIMIT", "RETURNING")))
+	case "DELETE":
+		from := find(1, "FROM")
+		if from < 0 {
+			return nil, fmt.Errorf("DELETE statement has no FROM clause")
+		}
+		for i := 1; i < from; i++ {
+			if w := words[i].upper; w != "LOW_PRIORITY" && w != "QUICK" && w != "IGNORE" {
+				stmt.MultiTable = true
+			}
+		}
+		tableStart = from + 1
+		tableEnd = find(tableStart, "WHERE", "ORDER", "LIMIT", "RETURNING", "USING")
+		if tableEnd >= 0 && words[tableEnd].upper == "USING" {
+			stmt.MultiTable = true
+		}
+	default:
+		return nil, fmt.Errorf("unsupported statement type: %s (only INSERT/REPLACE/UPDATE/DELETE are supported)", stmt.Kind)
+	}
+
+	// 表子句从前一个关键字之后开始（表名可能是带引号的标识符）
+	if tableEnd < 0 {
+		stmt.TableClause = strings.TrimSpace(sql[words[tableStart-1].end:])
+	} else {
+		stmt.TableClause = strings.TrimSpace(sql[words[tableStart-1].end:words[tableEnd].start])
+	}
+	if stmt.TableClause == "" {
+		return nil, fmt.Errorf("cannot find target table in %s statement", stmt.Kind)
+	}
+	if join := find(tableStart, "JOIN"); strings.Contains(stmt.TableClause, ",") || (join >= 0 && (tableEnd < 0 || join < tableEnd)) {
+		stmt.MultiTable = true
+	}
+	if !stmt.MultiTable {
+		stmt.Table = tableName(stmt.TableClause)
+	}
+
+	where := find(tableStart, "WHERE")
+	order := find(tableStart, "ORDER")
+	stop := find(tableStart, "LIMIT", "RETURNING")
+	whereEnd := stop
+	if order >= 0 {
+		whereEnd = order
+	}
+	if where >= 0 {
+		stmt.Where = between(where, whereEnd)
+		stmt.ArgsBeforeWhere = CountPlaceholders(sql[:words[where].start])
+	} else {
+		end := len(sql)
+		if whereEnd >= 0 {
+			end = words[whereEnd].start
+		}
+		stmt.ArgsBeforeWhere = CountPlaceholders(sql[:end])
+	}
+	if order >= 0 {
+		// between 返回的文本以 BY 开头
+		stmt.OrderBy = strings.TrimSpace(between(order, stop)[len("BY"):])
+	}
+	if stop >= 0 && words[stop].upper == "LIMIT" {
+		stmt.Limit = between(stop, find(stop+1, "RETURNING"))
+		stmt.LimitArgs = CountPlaceholders(stmt.Limit)
+	}
+	stmt.WhereArgs = CountPlaceholders(stmt.Where) + CountPlaceholders(stmt.OrderBy)
+	return stmt, nil
+}
+
+// tableName 从表子句中提取表名（去掉别名和列清单）
+func tableName(clause string) string {
+	clause = strings.TrimSpace(clause)
+	end := len(clause)
+	for i := 0; i < len(clause); i++ {
+		c := clause[i]
+		if c == '`' || c == '"' {
+			i = skipQuoted(clause, i) - 1
+			continue
+		}
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '(' {
+			end = i
+			break
+		}
+	}
+	return clause[:end]
+}
+
+// assignedColumns 提取 SET 子句中各赋值语句左侧的列名（忽略引号、括号内的逗号和等号）
+func assignedColumns(clause string) []string {
+	var columns []string
+	depth, start, eq, dot := 0, 0, -1, -1
+	flush := func() {
+		if eq < 0 {
+			return
+		}
+		from := start
+		if dot >= start && dot < eq {
+			from = dot + 1
+		}
+		columns = append(columns, UnquoteIdent(clause[from:eq]))
+	}
+	for i := 0; i < len(clause); {
+		c := clause[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			i = skipQuoted(clause, i)
+			continue
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth > 0 {
+				depth--
+			}
+		case depth > 0:
+		case c == '.' && eq < 0:
+			dot = i
+		case c == '=' && eq < 0:
+			eq = i
+		case c == ',':
+			flush()
+			start, eq = i+1, -1
+		}
+		i++
+	}
+	flush()
+	return columns
+}
+
+// SelectForDML 将UPDATE/DELETE改写为等价的SELECT语句，用于预览受影响的行
+func (s *DMLStatement) SelectForDML(limit int) (string, error) {
+	if s.Kind != "UPDATE" && s.Kind != "DELETE" {
+		return "", fmt.Errorf("%s statement cannot be rewritten to SELECT", s.Kind)
+	}
+	if s.MultiTable {
+		return "", fmt.Errorf("multi-table %s cannot be rewritten to SELECT", s.Kind)
+	}
+	var b strings.Builder
+	b.WriteString("SELECT * FROM ")
+	b.WriteString(s.TableClause)
+	if s.Where != "" {
+		b.WriteString(" WHERE ")
+		b.WriteString(s.Where)
+	}
+	if s.OrderBy != "" {
+		b.WriteString(" ORDER BY ")
+		b.WriteString(s.OrderBy)
+	}
+	// 语句自身的 LIMIT 更小时只取实际会被修改的行
+	if n, err := strconv.Atoi(s.Limit); err == nil && (limit <= 0 || n < limit) {
+		limit = n
+	}
+	if limit > 0 {
+		fmt.Fprintf(&b, " LIMIT %d", limit)
+	}
+	return b.String(), nil
+}
+
+// CountForDML 将UPDATE/DELETE改写为COUNT查询，用于统计匹配的行数
+func (s *DMLStatement) CountForDML() (string, error) {
+	if s.Kind != "UPDATE" && s.Kind != "DELETE" {
+		return "", fmt.Errorf("%s statement cannot be rewritten to SELECT", s.Kind)
+	}
+	if s.MultiTable {
+		return "", fmt.Errorf("multi-table %s cannot be rewritten to SELECT", s.Kind)
+	}
+	query := "SELECT COUNT(*) FROM " + s.TableClause
+	if s.Where != "" {
+		query += " WHERE " + s.Where
+	}
+	if s.Limit != "" {
+		// 带 LIMIT 的语句最多修改 LIMIT 行，按原来的排序和条数在子查询中截取
+		inner := "SELECT 1 FROM " + s.TableClause
+		if s.Where != "" {
+			inner += " WHERE " + s.Where
+		}
+		if s.OrderBy != "" {
+			inner += " ORDER BY " + s.OrderBy
+		}
+		query = "SELECT COUNT(*) FROM (" + inner + " LIMIT " + s.Limit + ") AS limited"
+	}
+	return query, nil
+}
+
+// WhereArgsFrom 从完整参数列表中截取WHERE/ORDER BY部分使用的参数
+func (s *DMLStatement) WhereArgsFrom(args []interface{}) []interface{} {
+	start := s.ArgsBeforeWhere
+	end := start + s.WhereArgs
+	if start > len(args) {
+		return nil
+	}
+	if end > len(args) {
+		end = len(args)
+	}
+	return args[start:end]
+}
+
+// CountArgsFrom 从完整参数列表中截取 CountForDML 使用的参数：没有 LIMIT 时只有 WHERE 部分，
+// 有 LIMIT 时还包括 ORDER BY 和 LIMIT 部分
+func (s *DMLStatement) CountArgsFrom(args []interface{}) []interface{} {
+	n := CountPlaceholders(s.Where)
+	if s.Limit != "" {
+		n = s.WhereArgs + s.LimitArgs
+	}
+	start := s.ArgsBeforeWhere
+	if start > len(args) {
+		return nil
+	}
+	if start+n > len(args) {
+		n = len(args) - start
+	}
+	return args[start : start+n]
+}
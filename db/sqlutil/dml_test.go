@@ -0,0 +1,131 @@
+package sqlutil
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDML(t *testing.T) {
+	tests := []struct {
+		sql        string
+		kind       string
+		table      string
+		where      string
+		orderBy    string
+		multiTable bool
+		before     int
+		whereArgs  int
+	}{
+		{
+			sql:   "UPDATE orders SET status = ? WHERE id = ? AND note = 'where ?'",
+			kind:  "UPDATE",
+			table: "orders", where: "id = ? AND note = 'where ?'",
+			before: 1, whereArgs: 1,
+		},
+		{
+			sql:   "update `shop`.`orders` o set o.status='x' where o.id in (select id from t where a = 1) order by o.id limit 10;",
+			kind:  "UPDATE",
+			table: "`shop`.`orders`", where: "o.id in (select id from t where a = 1)", orderBy: "o.id",
+		},
+		{
+			sql:   "DELETE FROM logs WHERE created_at < ? RETURNING *",
+			kind:  "DELETE",
+			table: "logs", where: "created_at < ?",
+			whereArgs: 1,
+		},
+		{
+			sql:  "DELETE t1 FROM t1 JOIN t2 ON t1.id = t2.id WHERE t2.x = 1",
+			kind: "DELETE", where: "t2.x = 1", multiTable: true,
+		},
+		{
+			sql:   "INSERT INTO users(name, email) VALUES (?, ?)",
+			kind:  "INSERT",
+			table: "users", before: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		stmt, err := ParseDML(tt.sql)
+		if err != nil {
+			t.Errorf("ParseDML(%q) failed: %v", tt.sql, err)
+			continue
+		}
+		if stmt.Kind != tt.kind || stmt.Table != tt.table || stmt.Where != tt.where || stmt.OrderBy != tt.orderBy || stmt.MultiTable != tt.multiTable {
+			t.Errorf("ParseDML(%q) = %+v", tt.sql, stmt)
+		}
+		if stmt.ArgsBeforeWhere != tt.before || stmt.WhereArgs != tt.whereArgs {
+			t.Errorf("ParseDML(%q) placeholders = %d/%d, expected %d/%d", tt.sql, stmt.ArgsBeforeWhere, stmt.WhereArgs, tt.before, tt.whereArgs)
+		}
+	}
+
+	if _, err := ParseDML("CREATE TABLE t (id INT)"); err == nil {
+		t.Error("expected DDL to be rejected")
+	}
+}
+
+func TestParseDMLSetColumns(t *testing.T) {
+	tests := map[string][]string{
+		"UPDATE orders SET status = ?, note = 'a, b = c' WHERE id = 1":             {"status", "note"},
+		"update `shop`.`orders` o set o.`id` = o.id + 10, total = IF(a = 1, 2, 3)": {"id", "total"},
+		"DELETE FROM t WHERE a = 1":                                                nil,
+	}
+	for sql, expected := range tests {
+		stmt, err := ParseDML(sql)
+		if err != nil {
+			t.Fatalf("ParseDML(%q) failed: %v", sql, err)
+		}
+		if !reflect.DeepEqual(stmt.SetColumns, expected) {
+			t.Errorf("ParseDML(%q).SetColumns = %q, expected %q", sql, stmt.SetColumns, expected)
+		}
+	}
+}
+
+func TestSelectForDML(t *testing.T) {
+	stmt, err := ParseDML("UPDATE orders o SET status = ? WHERE o.id > ? ORDER BY o.id LIMIT 3")
+	if err != nil {
+		t.Fatalf("ParseDML failed: %v", err)
+	}
+	query, err := stmt.SelectForDML(5)
+	if err != nil {
+		t.Fatalf("SelectForDML failed: %v", err)
+	}
+	if expected := "SELECT * FROM orders o WHERE o.id > ? ORDER BY o.id LIMIT 3"; query != expected {
+		t.Errorf("expected %q, got %q", expected, query)
+	}
+	args := stmt.WhereArgsFrom([]interface{}{"paid", 10})
+	if len(args) != 1 || args[0] != 10 {
+		t.Errorf("unexpected where args: %v", args)
+	}
+}
+
+func TestCountForDMLLimit(t *testing.T) {
+	stmt, err := ParseDML("UPDATE orders SET status = ? WHERE status = ? ORDER BY id LIMIT ?")
+	if err != nil {
+		t.Fatalf("ParseDML failed: %v", err)
+	}
+	query, err := stmt.CountForDML()
+	if err != nil {
+		t.Fatalf("CountForDML failed: %v", err)
+	}
+	if expected := "SELECT COUNT(*) FROM (SELECT 1 FROM orders WHERE status = ? ORDER BY id LIMIT ?) AS limited"; query != expected {
+		t.Errorf("expected %q, got %q", expected, query)
+	}
+	args := stmt.CountArgsFrom([]interface{}{"paid", "new", 2})
+	if len(args) != 2 || args[0] != "new" || args[1] != 2 {
+		t.Errorf("unexpected count args: %v", args)
+	}
+
+	stmt, err = ParseDML("DELETE FROM logs WHERE level = ? LIMIT 100")
+	if err != nil {
+		t.Fatalf("ParseDML failed: %v", err)
+	}
+	if query, _ := stmt.CountForDML(); query != "SELECT COUNT(*) FROM (SELECT 1 FROM logs WHERE level = ? LIMIT 100) AS limited" {
+		t.Errorf("unexpected count query: %q", query)
+	}
+	if query, _ := stmt.SelectForDML(5); query != "SELECT * FROM logs WHERE level = ? LIMIT 5" {
+		t.Errorf("unexpected select query: %q", query)
+	}
+	if args := stmt.CountArgsFrom([]interface{}{"debug"}); len(args) != 1 || args[0] != "debug" {
+		t.Errorf("unexpected count args: %v", args)
+	}
+}
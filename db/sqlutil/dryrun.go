@@ -0,0 +1,284 @@
+package sqlutil
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// 支持的SQL方言
+const (
+	DialectMySQL    = "mysql"
+	DialectPostgres = "postgres"
+	DialectSQLite   = "sqlite"
+)
+
+// DefaultSampleRows 预览时默认返回的样本行数
+const DefaultSampleRows = 5
+
+// DryRunResult DML预演结果
+type DryRunResult struct {
+	Type         string                   `json:"type"`
+	Statement    string                   `json:"statement"`
+	Table        string                   `json:"table,omitempty"`
+	RowsMatched  *int64                   `json:"rows_matched,omitempty"`
+	RowsAffected int64                    `json:"rows_affected"`
+	LastInsertID int64                    `json:"last_insert_id,omitempty"`
+	BeforeSample []map[string]interface{} `json:"before_sample,omitempty"`
+	AfterSample  []map[string]interface{} `json:"after_sample,omitempty"`
+	Executed     bool                     `json:"executed"` // 为 false 时语句未执行，只有匹配行数和执行前样本
+	WouldFail    bool                     `json:"would_fail"`
+	Error        string                   `json:"error,omitempty"`
+	RolledBack   bool                     `json:"rolled_back"`
+	Notes        []string                 `json:"notes,omitempty"`
+}
+
+// DryRun 在给定事务中执行DML并采集影响行数与前后样本，调用方负责回滚事务
+func DryRun(ctx context.Context, tx *sql.Tx, dialect, query string, args []interface{}, sampleRows int) (*DryRunResult, error) {
+	if sampleRows <= 0 {
+		sampleRows = DefaultSampleRows
+	}
+	stmt, err := ParseDML(query)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DryRunResult{
+		Type:      "dry_run",
+		Statement: stmt.Kind,
+		Table:     stmt.Table,
+	}
+
+	// 1. 改写为SELECT，采集执行前的匹配行数和样本
+	if stmt.Kind == "UPDATE" || stmt.Kind == "DELETE" {
+		if stmt.MultiTable {
+			result.Notes = append(result.Notes, "multi-table statement: before sample is not available")
+		} else if err := previewRows(ctx, tx, stmt, args, sampleRows, result); err != nil {
+			return nil, err
+		}
+	}
+
+	// 2. 执行语句：PostgreSQL/SQLite 通过 RETURNING 获取变更后的行
+	if dialect == DialectPostgres || dialect == DialectSQLite {
+		execSQL := TrimStatement(query)
+		if !stmt.HasReturning {
+			execSQL += " RETURNING *"
+		}
+		result.Executed = true
+		rows, err := tx.QueryContext(ctx, execSQL, args...)
+		if err != nil {
+			result.WouldFail = true
+			result.Error = err.Error()
+			return result, nil
+		}
+		_, returned, total, err := ScanRows(rows, sampleRows)
+		rows.Close()
+		if err != nil {
+			result.WouldFail = true
+			result.Error = err.Error()
+			return result, nil
+		}
+		result.RowsAffected = int64(total)
+		if stmt.Kind == "DELETE" {
+			// DELETE 返回的是被删除的行
+			if len(result.BeforeSample) == 0 {
+				result.BeforeSample = returned
+			}
+		} else {
+			result.AfterSample = returned
+		}
+		if dialect == DialectPostgres {
+			// 立即检查延迟约束，否则它们只会在提交时报错
+			if _, err := tx.ExecContext(ctx, "SET CONSTRAINTS ALL IMMEDIATE"); err != nil {
+				result.WouldFail = true
+				result.Error = err.Error()
+			}
+		}
+		return result, nil
+	}
+
+	// 3. MySQL：普通执行，再根据主键回查变更后的行。MyISAM/MEMORY 等非事务引擎上的修改无法回滚，
+	// 无法确认目标表支持事务时不执行语句
+	if reason := nonTransactionalReason(ctx, tx, stmt); reason != "" {
+		result.Notes = append(result.Notes, reason+": statement was not executed")
+		return result, nil
+	}
+	var pkColumns []string
+	if !stmt.MultiTable && (stmt.Kind == "UPDATE" || stmt.Kind == "INSERT" || stmt.Kind == "REPLACE") {
+		pkColumns, err = PrimaryKey(ctx, tx, DialectMySQL, stmt.Table)
+		if err != nil {
+			result.Notes = append(result.Notes, fmt.Sprintf("failed to look up primary key: %v", err))
+		}
+	}
+
+	result.Executed = true
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		result.WouldFail = true
+		result.Error = err.Error()
+		return result, nil
+	}
+	if n, err := res.RowsAffected(); err == nil {
+		result.RowsAffected = n
+	}
+	if stmt.Kind == "INSERT" || stmt.Kind == "REPLACE" {
+		if id, err := res.LastInsertId(); err == nil {
+			result.LastInsertID = id
+		}
+	}
+
+	if len(pkColumns) == 0 {
+		if stmt.Kind != "DELETE" && !stmt.MultiTable {
+			result.Notes = append(result.Notes, "table has no primary key: after sample is not available")
+		}
+		return result, nil
+	}
+
+	var afterSQL string
+	var afterArgs []interface{}
+	changedKey := changedKeyColumn(stmt, pkColumns)
+	switch {
+	case changedKey != "":
+		// 更新主键后按原主键回查不到这些行，会被误认为已删除
+		result.Notes = append(result.Notes, fmt.Sprintf("statement assigns primary key column %s: after sample is not available", changedKey))
+	case stmt.Kind == "UPDATE" && len(result.BeforeSample) > 0:
+		afterSQL, afterArgs = selectByKeys(stmt.TableClause, pkColumns, result.BeforeSample)
+	case stmt.Kind != "UPDATE" && len(pkColumns) == 1 && result.LastInsertID > 0:
+		afterSQL = fmt.Sprintf("SELECT * FROM %s WHERE %s >= ? ORDER BY %s LIMIT %d",
+			stmt.Table, QuoteIdent(DialectMySQL, pkColumns[0]), QuoteIdent(DialectMySQL, pkColumns[0]), sampleRows)
+		afterArgs = []interface{}{result.LastInsertID}
+	}
+	if afterSQL != "" {
+		rows, err := tx.QueryContext(ctx, afterSQL, afterArgs...)
+		if err != nil {
+			result.Notes = append(result.Notes, fmt.Sprintf("failed to sample rows after execution: %v", err))
+		} else {
+			_, result.AfterSample, _, err = ScanRows(rows, sampleRows)
+			rows.Close()
+			if err != nil {
+				result.Notes = append(result.Notes, fmt.Sprintf("failed to sample rows after execution: %v", err))
+			}
+		}
+	}
+	return result, nil
+}
+
+// previewSavepoint 包住预览查询的保存点名称
+const previewSavepoint = "dry_run_preview"
+
+// previewRows 统计匹配行数并采集执行前的样本。两个查询在保存点中执行：PostgreSQL 中任一查询失败
+// 都会使整个事务进入中止状态，回滚到保存点后才能继续执行语句本身
+func previewRows(ctx context.Context, tx *sql.Tx, stmt *DMLStatement, args []interface{}, sampleRows int, result *DryRunResult) error {
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT "+previewSavepoint); err != nil {
+		return fmt.Errorf("failed to create savepoint: %v", err)
+	}
+	failed := false
+	countSQL, _ := stmt.CountForDML()
+	var matched int64
+	if err := tx.QueryRowContext(ctx, countSQL, stmt.CountArgsFrom(args)...).Scan(&matched); err != nil {
+		failed = true
+		result.Notes = append(result.Notes, fmt.Sprintf("failed to count matched rows: %v", err))
+	} else {
+		result.RowsMatched = &matched
+	}
+	selectSQL, _ := stmt.SelectForDML(sampleRows)
+	if rows, err := tx.QueryContext(ctx, selectSQL, stmt.WhereArgsFrom(args)...); err != nil {
+		failed = true
+		result.Notes = append(result.Notes, fmt.Sprintf("failed to sample rows before execution: %v", err))
+	} else {
+		_, result.BeforeSample, _, err = ScanRows(rows, sampleRows)
+		rows.Close()
+		if err != nil {
+			failed = true
+			result.Notes = append(result.Notes, fmt.Sprintf("failed to sample rows before execution: %v", err))
+		}
+	}
+	if failed {
+		if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+previewSavepoint); err != nil {
+			return fmt.Errorf("failed to roll back to savepoint: %v", err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT "+previewSavepoint); err != nil {
+		return fmt.Errorf("failed to release savepoint: %v", err)
+	}
+	return nil
+}
+
+// transactionalEngines 修改可以回滚的 MySQL 存储引擎
+var transactionalEngines = map[string]bool{"INNODB": true, "NDB": true, "NDBCLUSTER": true, "TOKUDB": true, "ROCKSDB": true}
+
+// nonTransactionalReason 返回不能在 MySQL 上执行预演的原因，目标表使用事务引擎时返回空字符串
+func nonTransactionalReason(ctx context.Context, q Querier, stmt *DMLStatement) string {
+	if stmt.MultiTable {
+		return "multi-table statement: storage engines cannot be verified"
+	}
+	engine, err := TableEngine(ctx, q, stmt.Table)
+	if err != nil {
+		return fmt.Sprintf("failed to look up storage engine of %s: %v", stmt.Table, err)
+	}
+	if !transactionalEngines[strings.ToUpper(engine)] {
+		return fmt.Sprintf("table %s uses non-transactional engine %s", stmt.Table, engine)
+	}
+	return ""
+}
+
+// changedKeyColumn 返回 UPDATE 在 SET 子句中赋值的第一个主键列，没有时返回空字符串
+func changedKeyColumn(stmt *DMLStatement, pkColumns []string) string {
+	for _, col := range stmt.SetColumns {
+		for _, pk := range pkColumns {
+			if strings.EqualFold(col, pk) {
+				return pk
+			}
+		}
+	}
+	return ""
+}
+
+// selectByKeys 构造按主键回查样本行的SELECT语句（MySQL）
+func selectByKeys(table string, pkColumns []string, sample []map[string]interface{}) (string, []interface{}) {
+	quoted := make([]string, len(pkColumns))
+	for i, col := range pkColumns {
+		quoted[i] = QuoteIdent(DialectMySQL, col)
+	}
+	rowPlaceholder := "(" + strings.TrimSuffix(strings.Repeat("?,", len(pkColumns)), ",") + ")"
+	placeholders := make([]string, 0, len(sample))
+	args := make([]interface{}, 0, len(sample)*len(pkColumns))
+	for _, row := range sample {
+		placeholders = append(placeholders, rowPlaceholder)
+		for _, col := range pkColumns {
+			args = append(args, row[col])
+		}
+	}
+	query := fmt.Sprintf("SELECT * FROM %s WHERE (%s) IN (%s)",
+		table, strings.Join(quoted, ","), strings.Join(placeholders, ","))
+	return query, args
+}
+
+// SplitTableName 拆分 schema.table 形式的表名，并去除标识符引号
+func SplitTableName(table string) (schema, name string) {
+	parts := strings.SplitN(table, ".", 2)
+	if len(parts) == 2 {
+		return UnquoteIdent(parts[0]), UnquoteIdent(parts[1])
+	}
+	return "", UnquoteIdent(table)
+}
+
+// UnquoteIdent 去除标识符两侧的反引号或双引号
+func UnquoteIdent(ident string) string {
+	ident = strings.TrimSpace(ident)
+	if len(ident) >= 2 {
+		if (ident[0] == '`' && ident[len(ident)-1] == '`') || (ident[0] == '"' && ident[len(ident)-1] == '"') {
+			return ident[1 : len(ident)-1]
+		}
+	}
+	return ident
+}
+
+// QuoteIdent 按方言为标识符加引号
+func QuoteIdent(dialect, ident string) string {
+	if dialect == DialectMySQL {
+		return "`" + strings.ReplaceAll(ident, "`", "``") + "`"
+	}
+	return `"` + strings.ReplaceAll(ident, `"`, `""`) + `"`
+}
@@ -0,0 +1,135 @@
+package sqlutil
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"modernc.org/sqlite"
+)
+
+func TestDryRunSQLite(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open sqlite failed: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	for _, stmt := range []string{
+		"CREATE TABLE orders (id INTEGER PRIMARY KEY, status TEXT NOT NULL)",
+		"INSERT INTO orders (status) VALUES ('new'), ('new'), ('paid')",
+	} {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("setup failed: %v", err)
+		}
+	}
+
+	run := func(query string) *DryRunResult {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			t.Fatalf("begin failed: %v", err)
+		}
+		defer tx.Rollback()
+		result, err := DryRun(ctx, tx, DialectSQLite, query, nil, 1)
+		if err != nil {
+			t.Fatalf("DryRun(%q) failed: %v", query, err)
+		}
+		return result
+	}
+
+	result := run("UPDATE orders SET status = 'shipped' WHERE status = 'new'")
+	if result.RowsAffected != 2 || result.RowsMatched == nil || *result.RowsMatched != 2 {
+		t.Errorf("unexpected counts: %+v", result)
+	}
+	if len(result.BeforeSample) != 1 || result.BeforeSample[0]["status"] != "new" {
+		t.Errorf("unexpected before sample: %v", result.BeforeSample)
+	}
+	if len(result.AfterSample) != 1 || result.AfterSample[0]["status"] != "shipped" {
+		t.Errorf("unexpected after sample: %v", result.AfterSample)
+	}
+
+	result = run("UPDATE orders SET status = NULL WHERE id = 1")
+	if !result.WouldFail || result.Error == "" {
+		t.Errorf("expected constraint error, got %+v", result)
+	}
+
+	var count int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM orders WHERE status = 'new'").Scan(&count); err != nil || count != 2 {
+		t.Errorf("dry run should not change data, count=%d err=%v", count, err)
+	}
+}
+
+func TestDryRunMySQLEngine(t *testing.T) {
+	// 用 SQLite 模拟 MySQL 的 DATABASE() 和 INFORMATION_SCHEMA.TABLES
+	sqlite.MustRegisterScalarFunction("database", 0, func(*sqlite.FunctionContext, []driver.Value) (driver.Value, error) {
+		return "shop", nil
+	})
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open sqlite failed: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	for _, stmt := range []string{
+		"ATTACH DATABASE ':memory:' AS information_schema",
+		"CREATE TABLE information_schema.TABLES (TABLE_SCHEMA TEXT, TABLE_NAME TEXT, ENGINE TEXT)",
+		"INSERT INTO information_schema.TABLES VALUES ('shop', 'orders', 'InnoDB'), ('shop', 'hits', 'MyISAM'), ('shop', 'recent', NULL)",
+		"CREATE TABLE information_schema.KEY_COLUMN_USAGE (TABLE_SCHEMA TEXT, TABLE_NAME TEXT, COLUMN_NAME TEXT, CONSTRAINT_NAME TEXT, ORDINAL_POSITION INTEGER)",
+		"INSERT INTO information_schema.KEY_COLUMN_USAGE VALUES ('shop', 'orders', 'id', 'PRIMARY', 1)",
+		"CREATE TABLE orders (id INTEGER PRIMARY KEY, status TEXT)",
+		"CREATE TABLE hits (id INTEGER PRIMARY KEY)",
+		"INSERT INTO orders VALUES (1, 'new'), (2, 'new')",
+		"INSERT INTO hits VALUES (1), (2)",
+	} {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("setup failed: %v", err)
+		}
+	}
+
+	run := func(query string) *DryRunResult {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			t.Fatalf("begin failed: %v", err)
+		}
+		defer tx.Rollback()
+		result, err := DryRun(ctx, tx, DialectMySQL, query, nil, 1)
+		if err != nil {
+			t.Fatalf("DryRun(%q) failed: %v", query, err)
+		}
+		return result
+	}
+
+	result := run("DELETE FROM orders WHERE id = 1")
+	if !result.Executed || result.RowsAffected != 1 {
+		t.Errorf("InnoDB table should be executed: %+v", result)
+	}
+	result = run("UPDATE orders SET status = 'paid' WHERE id = 1")
+	if len(result.AfterSample) != 1 || result.AfterSample[0]["status"] != "paid" {
+		t.Errorf("unexpected after sample: %+v", result)
+	}
+	result = run("UPDATE orders SET `id` = id + 10 WHERE id = 1")
+	if !result.Executed || result.RowsAffected != 1 || len(result.AfterSample) != 0 {
+		t.Errorf("primary key update should have no after sample: %+v", result)
+	}
+	if len(result.Notes) != 1 || !strings.Contains(result.Notes[0], "primary key column id") {
+		t.Errorf("unexpected notes: %v", result.Notes)
+	}
+	result = run("DELETE FROM hits WHERE id = 1")
+	if result.Executed || result.RowsAffected != 0 || result.RowsMatched == nil || *result.RowsMatched != 1 || len(result.BeforeSample) != 1 {
+		t.Errorf("MyISAM table should only be previewed: %+v", result)
+	}
+	if len(result.Notes) != 1 || !strings.Contains(result.Notes[0], "non-transactional engine MyISAM") {
+		t.Errorf("unexpected notes: %v", result.Notes)
+	}
+	for _, query := range []string{"DELETE FROM recent WHERE id = 1", "DELETE FROM missing WHERE id = 1", "DELETE h FROM hits h JOIN orders o ON o.id = h.id"} {
+		if result := run(query); result.Executed {
+			t.Errorf("%q should not be executed: %+v", query, result)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package sqlutil
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Querier 可执行查询的对象（*sql.DB、*sql.Tx、*sql.Conn）
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// PrimaryKey 按方言查询表的主键列（按定义顺序）
+func PrimaryKey(ctx context.Context, q Querier, dialect, table string) ([]string, error) {
+	schema, name := SplitTableName(table)
+	var query string
+	var args []interface{}
+	switch dialect {
+	case DialectMySQL:
+		query = "SELECT COLUMN_NAME FROM INFORMATION_SCHEMA.KEY_COLUMN_USAGE WHERE TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) AND TABLE_NAME = ? AND CONSTRAINT_NAME = 'PRIMARY' ORDER BY ORDINAL_POSITION"
+		args = []interface{}{schema, name}
+	case DialectPostgres:
+		query = `SELECT a.attname FROM pg_index i
+			JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+			WHERE i.indrelid = $1::regclass AND i.indisprimary
+			ORDER BY array_position(i.indkey, a.attnum)`
+		args = []interface{}{QuoteTableName(DialectPostgres, table)}
+	case DialectSQLite:
+		query = "SELECT name FROM pragma_table_info(?) WHERE pk > 0 ORDER BY pk"
+		args = []interface{}{name}
+	default:
+		return nil, fmt.Errorf("unsupported dialect: %s", dialect)
+	}
+
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var columns []string
+	for rows.Next() {
+		var col string
+		if err := rows.Scan(&col); err != nil {
+			return nil, err
+		}
+		columns = append(columns, col)
+	}
+	return columns, rows.Err()
+}
+
+// TableEngine 查询 MySQL 表的存储引擎，视图等没有存储引擎的对象报错
+func TableEngine(ctx context.Context, q Querier, table string) (string, error) {
+	schema, name := SplitTableName(table)
+	rows, err := q.QueryContext(ctx, "SELECT ENGINE FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) AND TABLE_NAME = ?", schema, name)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return "", err
+		}
+		return "", fmt.Errorf("table %s not found", table)
+	}
+	var engine sql.NullString
+	if err := rows.Scan(&engine); err != nil {
+		return "", err
+	}
+	if !engine.Valid {
+		return "", fmt.Errorf("%s has no storage engine", table)
+	}
+	return engine.String, nil
+}
+
+// TableColumns 返回表的列名（通过不返回数据的查询获取）
+func TableColumns(ctx context.Context, q Querier, dialect, table string) ([]string, error) {
+	rows, err := q.QueryContext(ctx, fmt.Sprintf("SELECT * FROM %s WHERE 1 = 0", QuoteTableName(dialect, table)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return rows.Columns()
+}
@@ -0,0 +1,413 @@
+package sqlutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PlanNode 统一的执行计划节点
+type PlanNode struct {
+	Operation     string      `json:"operation"`
+	Table         string      `json:"table,omitempty"`
+	Index         string      `json:"index,omitempty"`
+	AccessType    string      `json:"access_type,omitempty"`
+	Condition     string      `json:"condition,omitempty"`
+	EstimatedRows *float64    `json:"estimated_rows,omitempty"`
+	ActualRows    *float64    `json:"actual_rows,omitempty"`
+	Loops         *float64    `json:"loops,omitempty"`
+	Cost          *float64    `json:"cost,omitempty"`
+	FullScan      bool        `json:"full_scan,omitempty"`
+	Filesort      bool        `json:"filesort,omitempty"`
+	TempTable     bool        `json:"temp_table,omitempty"`
+	Children      []*PlanNode `json:"children,omitempty"`
+}
+
+// PlanSummary 统一的执行计划摘要，便于跨数据库比较
+type PlanSummary struct {
+	Engine        string      `json:"engine"`
+	Analyzed      bool        `json:"analyzed"`
+	TotalCost     *float64    `json:"total_cost,omitempty"`
+	EstimatedRows *float64    `json:"estimated_rows,omitempty"`
+	ActualRows    *float64    `json:"actual_rows,omitempty"`
+	ExecutionTime *float64    `json:"execution_time_ms,omitempty"`
+	FullScans     []string    `json:"full_scans"`
+	Filesorts     int         `json:"filesorts"`
+	TempTables    int         `json:"temp_tables"`
+	IndexesUsed   []string    `json:"indexes_used"`
+	NodeCount     int         `json:"node_count"`
+	Nodes         []*PlanNode `json:"nodes"`
+	Notes         []string    `json:"notes,omitempty"`
+	Raw           interface{} `json:"raw,omitempty"`
+}
+
+// Summarize 遍历计划树，汇总全表扫描、排序、临时表和索引使用情况
+func Summarize(engine string, nodes []*PlanNode) *PlanSummary {
+	summary := &PlanSummary{
+		Engine:      engine,
+		Nodes:       nodes,
+		FullScans:   []string{},
+		IndexesUsed: []string{},
+	}
+	indexes := map[string]bool{}
+	scans := map[string]bool{}
+	var walk func(n *PlanNode)
+	walk = func(n *PlanNode) {
+		summary.NodeCount++
+		if n.FullScan && n.Table != "" && !scans[n.Table] {
+			scans[n.Table] = true
+			summary.FullScans = append(summary.FullScans, n.Table)
+		}
+		if n.Filesort {
+			summary.Filesorts++
+		}
+		if n.TempTable {
+			summary.TempTables++
+		}
+		if n.Index != "" {
+			key := n.Index
+			if n.Table != "" {
+				key = n.Table + "." + n.Index
+			}
+			indexes[key] = true
+		}
+		for _, c := range n.Children {
+			walk(c)
+		}
+	}
+	for _, n := range nodes {
+		walk(n)
+	}
+	for idx := range indexes {
+		summary.IndexesUsed = append(summary.IndexesUsed, idx)
+	}
+	sort.Strings(summary.IndexesUsed)
+	if len(nodes) == 1 {
+		summary.TotalCost = nodes[0].Cost
+		summary.EstimatedRows = nodes[0].EstimatedRows
+		summary.ActualRows = nodes[0].ActualRows
+	}
+	return summary
+}
+
+// Walk 深度优先遍历计划节点
+func Walk(nodes []*PlanNode, fn func(n *PlanNode)) {
+	for _, n := range nodes {
+		fn(n)
+		Walk(n.Children, fn)
+	}
+}
+
+// toFloat 将JSON中的数字或数字字符串转换为float64
+func toFloat(v interface{}) *float64 {
+	switch val := v.(type) {
+	case float64:
+		return &val
+	case json.Number:
+		if f, err := val.Float64(); err == nil {
+			return &f
+		}
+	case string:
+		if f, err := strconv.ParseFloat(val, 64); err == nil {
+			return &f
+		}
+	}
+	return nil
+}
+
+func toString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return ""
+}
+
+func toBool(v interface{}) bool {
+	b, _ := v.(bool)
+	return b
+}
+
+// ParseMySQLExplainJSON 解析 MySQL EXPLAIN FORMAT=JSON 的输出
+func ParseMySQLExplainJSON(data string) ([]*PlanNode, interface{}, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &raw); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse EXPLAIN JSON: %v", err)
+	}
+	return mysqlChildren(raw), raw, nil
+}
+
+// mysqlChildren 解析MySQL计划对象中的所有已知子操作
+func mysqlChildren(m map[string]interface{}) []*PlanNode {
+	var nodes []*PlanNode
+	// 按固定顺序遍历，保证输出稳定
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		switch val := m[key].(type) {
+		case map[string]interface{}:
+			if n := mysqlNode(key, val); n != nil {
+				nodes = append(nodes, n)
+			}
+		case []interface{}:
+			for _, item := range val {
+				if im, ok := item.(map[string]interface{}); ok {
+					if key == "nested_loop" || key == "query_specifications" || strings.HasSuffix(key, "subqueries") {
+						nodes = append(nodes, mysqlChildren(im)...)
+					}
+				}
+			}
+		}
+	}
+	return nodes
+}
+
+// mysqlNode 将MySQL计划中的单个对象转换为计划节点
+func mysqlNode(key string, m map[string]interface{}) *PlanNode {
+	switch key {
+	case "query_block":
+		n := &PlanNode{Operation: "query block"}
+		if ci, ok := m["cost_info"].(map[string]interface{}); ok {
+			n.Cost = toFloat(ci["query_cost"])
+		}
+		n.Children = mysqlChildren(m)
+		if len(n.Children) == 1 && n.Cost == nil {
+			return n.Children[0]
+		}
+		return n
+	case "table":
+		access := toString(m["access_type"])
+		n := &PlanNode{
+			Operation:     "table access",
+			Table:         toString(m["table_name"]),
+			Index:         toString(m["key"]),
+			AccessType:    access,
+			Condition:     toString(m["attached_condition"]),
+			EstimatedRows: toFloat(m["rows_examined_per_scan"]),
+			FullScan:      access == "ALL" || access == "index",
+		}
+		if access != "" {
+			n.Operation = "table access (" + access + ")"
+		}
+		if ci, ok := m["cost_info"].(map[string]interface{}); ok {
+			n.Cost = toFloat(ci["prefix_cost"])
+		}
+		if toBool(m["using_filesort"]) {
+			n.Filesort = true
+		}
+		if toBool(m["using_temporary_table"]) {
+			n.TempTable = true
+		}
+		n.Children = mysqlChildren(m)
+		return n
+	case "ordering_operation", "grouping_operation", "duplicates_removal", "union_result", "windowing", "buffer_result":
+		n := &PlanNode{
+			Operation: strings.ReplaceAll(key, "_", " "),
+			Filesort:  toBool(m["using_filesort"]),
+			TempTable: toBool(m["using_temporary_table"]),
+		}
+		n.Children = mysqlChildren(m)
+		return n
+	case "materialized_from_subquery":
+		n := &PlanNode{Operation: "materialized subquery", TempTable: toBool(m["using_temporary_table"])}
+		n.Children = mysqlChildren(m)
+		return n
+	}
+	return nil
+}
+
+var (
+	mysqlAnalyzeCost   = regexp.MustCompile(`\(cost=(\d+(?:\.\d+)?(?:e\+?\d+)?)(?:\.\.([\d.e+]+))? rows=([\d.e+]+)\)`)
+	mysqlAnalyzeActual = regexp.MustCompile(`\(actual time=[\d.e+]+\.\.[\d.e+]+ rows=([\d.e+]+) loops=([\d.e+]+)\)`)
+	mysqlAnalyzeTable  = regexp.MustCompile(` on (\S+)`)
+	mysqlAnalyzeIndex  = regexp.MustCompile(` using (\S+)`)
+)
+
+// ParseMySQLExplainAnalyze 解析 MySQL 8.0 EXPLAIN ANALYZE 的树形文本输出
+func ParseMySQLExplainAnalyze(text string) []*PlanNode {
+	var roots []*PlanNode
+	var stack []*PlanNode
+	var depths []int
+	for _, line := range strings.Split(text, "\n") {
+		idx := strings.Index(line, "-> ")
+		if idx < 0 {
+			continue
+		}
+		depth := idx
+		body := line[idx+3:]
+		n := &PlanNode{Operation: body}
+		if p := strings.Index(body, "  ("); p >= 0 {
+			n.Operation = body[:p]
+		}
+		if m := mysqlAnalyzeCost.FindStringSubmatch(body); m != nil {
+			// 成本为区间（首行成本..总成本）时与 PostgreSQL 一致取总成本
+			n.Cost = toFloat(m[1])
+			if m[2] != "" {
+				n.Cost = toFloat(m[2])
+			}
+			n.EstimatedRows = toFloat(m[3])
+		}
+		if m := mysqlAnalyzeActual.FindStringSubmatch(body); m != nil {
+			n.ActualRows = toFloat(m[1])
+			n.Loops = toFloat(m[2])
+		}
+		op := n.Operation
+		if m := mysqlAnalyzeTable.FindStringSubmatch(op); m != nil {
+			n.Table = m[1]
+		}
+		if m := mysqlAnalyzeIndex.FindStringSubmatch(op); m != nil && strings.Contains(strings.ToLower(op), "index") {
+			n.Index = m[1]
+		}
+		lower := strings.ToLower(op)
+		switch {
+		case strings.HasPrefix(lower, "table scan"):
+			n.FullScan = true
+			n.AccessType = "ALL"
+		case strings.HasPrefix(lower, "index scan") || strings.HasPrefix(lower, "covering index scan"):
+			n.FullScan = true
+			n.AccessType = "index"
+		case strings.HasPrefix(lower, "sort"):
+			n.Filesort = true
+		}
+		if strings.Contains(lower, "temporary") || strings.HasPrefix(lower, "materialize") {
+			n.TempTable = true
+		}
+
+		for len(depths) > 0 && depths[len(depths)-1] >= depth {
+			stack = stack[:len(stack)-1]
+			depths = depths[:len(depths)-1]
+		}
+		if len(stack) == 0 {
+			roots = append(roots, n)
+		} else {
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, n)
+		}
+		stack = append(stack, n)
+		depths = append(depths, depth)
+	}
+	return roots
+}
+
+// ParsePostgresExplainJSON 解析 PostgreSQL EXPLAIN (FORMAT JSON) 的输出
+func ParsePostgresExplainJSON(data string) ([]*PlanNode, *float64, interface{}, error) {
+	var raw []map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &raw); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to parse EXPLAIN JSON: %v", err)
+	}
+	var nodes []*PlanNode
+	var execTime *float64
+	for _, entry := range raw {
+		if plan, ok := entry["Plan"].(map[string]interface{}); ok {
+			nodes = append(nodes, postgresNode(plan))
+		}
+		if t := toFloat(entry["Execution Time"]); t != nil {
+			execTime = t
+		}
+	}
+	return nodes, execTime, raw, nil
+}
+
+// postgresNode 将PostgreSQL计划节点转换为统一结构
+func postgresNode(m map[string]interface{}) *PlanNode {
+	nodeType := toString(m["Node Type"])
+	n := &PlanNode{
+		Operation:     nodeType,
+		Table:         toString(m["Relation Name"]),
+		Index:         toString(m["Index Name"]),
+		EstimatedRows: toFloat(m["Plan Rows"]),
+		ActualRows:    toFloat(m["Actual Rows"]),
+		Loops:         toFloat(m["Actual Loops"]),
+		Cost:          toFloat(m["Total Cost"]),
+	}
+	if op := toString(m["Operation"]); op != "" {
+		n.Operation = nodeType + " (" + op + ")"
+	}
+	for _, key := range []string{"Index Cond", "Hash Cond", "Merge Cond", "Join Filter", "Filter", "Recheck Cond"} {
+		if cond := toString(m[key]); cond != "" {
+			n.Condition = cond
+			break
+		}
+	}
+	switch nodeType {
+	case "Seq Scan":
+		n.FullScan = true
+		n.AccessType = "seq"
+	case "Index Scan", "Index Only Scan", "Bitmap Index Scan":
+		n.AccessType = "index"
+	case "Sort", "Incremental Sort":
+		n.Filesort = true
+	case "Materialize":
+		n.TempTable = true
+	}
+	if toString(m["Sort Space Type"]) == "Disk" {
+		n.TempTable = true
+	}
+	if plans, ok := m["Plans"].([]interface{}); ok {
+		for _, p := range plans {
+			if pm, ok := p.(map[string]interface{}); ok {
+				n.Children = append(n.Children, postgresNode(pm))
+			}
+		}
+	}
+	return n
+}
+
+// SQLitePlanRow EXPLAIN QUERY PLAN 返回的一行
+type SQLitePlanRow struct {
+	ID     int64  `json:"id"`
+	Parent int64  `json:"parent"`
+	Detail string `json:"detail"`
+}
+
+var (
+	sqliteScan  = regexp.MustCompile(`^(?:SCAN|SEARCH)(?: TABLE)? (\S+)`)
+	sqliteIndex = regexp.MustCompile(`USING (?:COVERING |AUTOMATIC COVERING |AUTOMATIC PARTIAL COVERING )?INDEX (\S+)`)
+)
+
+// ParseSQLiteQueryPlan 将 EXPLAIN QUERY PLAN 的行构建为计划树
+func ParseSQLiteQueryPlan(rows []SQLitePlanRow) []*PlanNode {
+	byID := map[int64]*PlanNode{}
+	var roots []*PlanNode
+	for _, r := range rows {
+		detail := r.Detail
+		n := &PlanNode{Operation: detail}
+		if m := sqliteScan.FindStringSubmatch(detail); m != nil {
+			n.Table = m[1]
+		}
+		if m := sqliteIndex.FindStringSubmatch(detail); m != nil {
+			n.Index = m[1]
+		} else if strings.Contains(detail, "INTEGER PRIMARY KEY") {
+			n.Index = "PRIMARY KEY"
+		}
+		switch {
+		case strings.HasPrefix(detail, "SCAN"):
+			// SCAN ... USING INDEX 也是全索引扫描
+			n.FullScan = !strings.Contains(detail, "CO-ROUTINE") && !strings.HasPrefix(detail, "SCAN CONSTANT ROW")
+			n.AccessType = "scan"
+		case strings.HasPrefix(detail, "SEARCH"):
+			n.AccessType = "search"
+		}
+		if strings.Contains(detail, "TEMP B-TREE") {
+			n.TempTable = true
+			if strings.Contains(detail, "ORDER BY") {
+				n.Filesort = true
+			}
+		}
+		if strings.Contains(detail, "AUTOMATIC") || strings.HasPrefix(detail, "MATERIALIZE") {
+			n.TempTable = true
+		}
+		byID[r.ID] = n
+		if parent, ok := byID[r.Parent]; ok && r.Parent != r.ID {
+			parent.Children = append(parent.Children, n)
+		} else {
+			roots = append(roots, n)
+		}
+	}
+	return roots
+}
@@ -0,0 +1,108 @@
+package sqlutil
+
+import (
+	"reflect"
+	"testing"
+)
+
+const mysqlExplainFixture = `{
+  "query_block": {
+    "select_id": 1,
+    "cost_info": {"query_cost": "12.50"},
+    "ordering_operation": {
+      "using_filesort": true,
+      "nested_loop": [
+        {"table": {"table_name": "o", "access_type": "ALL", "rows_examined_per_scan": 100,
+                   "cost_info": {"prefix_cost": "10.25"}, "attached_condition": "(o.status = 'new')"}},
+        {"table": {"table_name": "c", "access_type": "eq_ref", "key": "PRIMARY", "rows_examined_per_scan": 1,
+                   "cost_info": {"prefix_cost": "12.50"}}}
+      ]
+    }
+  }
+}`
+
+func TestParseMySQLExplainJSON(t *testing.T) {
+	nodes, _, err := ParseMySQLExplainJSON(mysqlExplainFixture)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	summary := Summarize("mysql", nodes)
+	if summary.TotalCost == nil || *summary.TotalCost != 12.5 {
+		t.Errorf("unexpected total cost: %v", summary.TotalCost)
+	}
+	if !reflect.DeepEqual(summary.FullScans, []string{"o"}) {
+		t.Errorf("unexpected full scans: %v", summary.FullScans)
+	}
+	if summary.Filesorts != 1 || summary.NodeCount != 4 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+	if !reflect.DeepEqual(summary.IndexesUsed, []string{"c.PRIMARY"}) {
+		t.Errorf("unexpected indexes: %v", summary.IndexesUsed)
+	}
+}
+
+func TestParseMySQLExplainAnalyze(t *testing.T) {
+	tree := `-> Sort: o.created_at  (cost=10.25 rows=100) (actual time=0.5..0.6 rows=42 loops=1)
+    -> Filter: (o.status = 'new')  (cost=10.25 rows=100) (actual time=0.1..0.4 rows=42 loops=1)
+        -> Table scan on o  (cost=10.25 rows=100) (actual time=0.1..0.3 rows=100 loops=1)
+`
+	summary := Summarize("mysql", ParseMySQLExplainAnalyze(tree))
+	if summary.NodeCount != 3 || summary.Filesorts != 1 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+	if summary.ActualRows == nil || *summary.ActualRows != 42 {
+		t.Errorf("unexpected actual rows: %v", summary.ActualRows)
+	}
+	if !reflect.DeepEqual(summary.FullScans, []string{"o"}) {
+		t.Errorf("unexpected full scans: %v", summary.FullScans)
+	}
+
+	nodes := ParseMySQLExplainAnalyze(`-> Nested loop inner join  (cost=1.25..12.5 rows=10) (actual time=0.1..0.5 rows=8 loops=1)
+    -> Index lookup on c using PRIMARY (id=o.customer_id)  (cost=2.5e+3 rows=1) (actual time=0.01..0.01 rows=1 loops=8)
+`)
+	if len(nodes) != 1 || nodes[0].Cost == nil || *nodes[0].Cost != 12.5 || nodes[0].EstimatedRows == nil || *nodes[0].EstimatedRows != 10 {
+		t.Fatalf("cost range should be parsed: %+v", nodes)
+	}
+	if child := nodes[0].Children[0]; child.Cost == nil || *child.Cost != 2500 {
+		t.Errorf("exponent cost should be parsed: %+v", child)
+	}
+}
+
+func TestParsePostgresExplainJSON(t *testing.T) {
+	fixture := `[{"Plan": {"Node Type": "Sort", "Total Cost": 20.5, "Plan Rows": 10, "Actual Rows": 8, "Sort Space Type": "Disk",
+		"Plans": [{"Node Type": "Seq Scan", "Relation Name": "orders", "Total Cost": 18.0, "Plan Rows": 10, "Filter": "(status = 'new'::text)"},
+		          {"Node Type": "Index Scan", "Relation Name": "users", "Index Name": "users_pkey", "Total Cost": 0.3, "Plan Rows": 1}]},
+		"Execution Time": 1.25}]`
+	nodes, execTime, _, err := ParsePostgresExplainJSON(fixture)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	summary := Summarize("postgres", nodes)
+	if execTime == nil || *execTime != 1.25 {
+		t.Errorf("unexpected execution time: %v", execTime)
+	}
+	if summary.Filesorts != 1 || summary.TempTables != 1 || !reflect.DeepEqual(summary.FullScans, []string{"orders"}) {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+	if !reflect.DeepEqual(summary.IndexesUsed, []string{"users.users_pkey"}) {
+		t.Errorf("unexpected indexes: %v", summary.IndexesUsed)
+	}
+	if nodes[0].Children[0].Condition != "(status = 'new'::text)" {
+		t.Errorf("unexpected condition: %q", nodes[0].Children[0].Condition)
+	}
+}
+
+func TestParseSQLiteQueryPlan(t *testing.T) {
+	rows := []SQLitePlanRow{
+		{ID: 2, Parent: 0, Detail: "SCAN o"},
+		{ID: 5, Parent: 0, Detail: "SEARCH c USING INTEGER PRIMARY KEY (rowid=?)"},
+		{ID: 9, Parent: 0, Detail: "USE TEMP B-TREE FOR ORDER BY"},
+	}
+	summary := Summarize("sqlite", ParseSQLiteQueryPlan(rows))
+	if !reflect.DeepEqual(summary.FullScans, []string{"o"}) || summary.Filesorts != 1 || summary.TempTables != 1 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+	if !reflect.DeepEqual(summary.IndexesUsed, []string{"c.PRIMARY KEY"}) {
+		t.Errorf("unexpected indexes: %v", summary.IndexesUsed)
+	}
+}
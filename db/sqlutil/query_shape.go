@@ -0,0 +1,401 @@
+package sqlutil
+
+import (
+	"strings"
+)
+
+// 词法单元类型
+const (
+	tokIdent = iota
+	tokString
+	tokNumber
+	tokParam
+	tokOp
+	tokLParen
+	tokRParen
+	tokComma
+	tokDot
+)
+
+// sqlToken SQL词法单元
+type sqlToken struct {
+	kind   int
+	text   string // 原始文本（标识符已去除引号）
+	upper  string
+	quoted bool
+}
+
+// tokenize 将SQL拆分为词法单元，忽略注释
+func tokenize(sql string) []sqlToken {
+	var tokens []sqlToken
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				return tokens
+			}
+			i += end + 4
+		case c == '\'':
+			end := skipQuoted(sql, i)
+			tokens = append(tokens, sqlToken{kind: tokString, text: sql[i:end]})
+			i = end
+		case c == '`' || c == '"':
+			end := skipQuoted(sql, i)
+			text := UnquoteIdent(sql[i:end])
+			tokens = append(tokens, sqlToken{kind: tokIdent, text: text, upper: strings.ToUpper(text), quoted: true})
+			i = end
+		case isWordStart(c):
+			start := i
+			for i < len(sql) && isWordChar(sql[i]) {
+				i++
+			}
+			text := sql[start:i]
+			tokens = append(tokens, sqlToken{kind: tokIdent, text: text, upper: strings.ToUpper(text)})
+		case c >= '0' && c <= '9':
+			start := i
+			for i < len(sql) && (sql[i] == '.' || sql[i] == 'e' || sql[i] == 'E' || (sql[i] >= '0' && sql[i] <= '9')) {
+				i++
+			}
+			tokens = append(tokens, sqlToken{kind: tokNumber, text: sql[start:i]})
+		case c == '?':
+			tokens = append(tokens, sqlToken{kind: tokParam, text: "?"})
+			i++
+		case c == '$' && i+1 < len(sql) && sql[i+1] >= '0' && sql[i+1] <= '9':
+			start := i
+			i++
+			for i < len(sql) && sql[i] >= '0' && sql[i] <= '9' {
+				i++
+			}
+			tokens = append(tokens, sqlToken{kind: tokParam, text: sql[start:i]})
+		case c == '(':
+			tokens = append(tokens, sqlToken{kind: tokLParen, text: "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, sqlToken{kind: tokRParen, text: ")"})
+			i++
+		case c == ',':
+			tokens = append(tokens, sqlToken{kind: tokComma, text: ","})
+			i++
+		case c == '.':
+			tokens = append(tokens, sqlToken{kind: tokDot, text: "."})
+			i++
+		default:
+			// 两字符运算符
+			if i+1 < len(sql) {
+				two := sql[i : i+2]
+				if two == "<=" || two == ">=" || two == "<>" || two == "!=" || two == "::" || two == "||" {
+					tokens = append(tokens, sqlToken{kind: tokOp, text: two})
+					i += 2
+					continue
+				}
+			}
+			tokens = append(tokens, sqlToken{kind: tokOp, text: string(c)})
+			i++
+		}
+	}
+	return tokens
+}
+
+// TableRef 查询中引用的表
+type TableRef struct {
+	Name  string `json:"name"`
+	Alias string `json:"alias,omitempty"`
+}
+
+// ColumnRef 已解析到具体表的列引用
+type ColumnRef struct {
+	Table  string `json:"table"`
+	Column string `json:"column"`
+}
+
+// QueryShape 查询的结构特征，用于索引分析
+type QueryShape struct {
+	Tables       []TableRef  `json:"tables"`
+	EqualityCols []ColumnRef `json:"equality_columns,omitempty"`
+	RangeCols    []ColumnRef `json:"range_columns,omitempty"`
+	JoinCols     []ColumnRef `json:"join_columns,omitempty"`
+	OrderByCols  []ColumnRef `json:"order_by_columns,omitempty"`
+	GroupByCols  []ColumnRef `json:"group_by_columns,omitempty"`
+}
+
+// 结束 FROM 子句的关键字
+var fromTerminators = map[string]bool{
+	"WHERE": true, "GROUP": true, "ORDER": true, "LIMIT": true, "HAVING": true,
+	"UNION": true, "FOR": true, "WINDOW": true, "OFFSET": true, "SET": true,
+	"RETURNING": true, "INTERSECT": true, "EXCEPT": true,
+}
+
+// 表引用之后可能出现的关键字（不是别名）
+var joinKeywords = map[string]bool{
+	"JOIN": true, "INNER": true, "LEFT": true, "RIGHT": true, "FULL": true, "CROSS": true,
+	"OUTER": true, "NATURAL": true, "STRAIGHT_JOIN": true, "ON": true, "USING": true, "LATERAL": true,
+}
+
+// AnalyzeQuery 提取查询中的表、过滤列、连接列和排序列（仅分析最外层查询）
+func AnalyzeQuery(sql string) *QueryShape {
+	tokens := tokenize(TrimStatement(sql))
+	shape := &QueryShape{}
+	aliases := map[string]string{}
+
+	// 记录每个 token 的括号深度
+	depths := make([]int, len(tokens))
+	depth := 0
+	for i, t := range tokens {
+		if t.kind == tokRParen && depth > 0 {
+			depth--
+		}
+		depths[i] = depth
+		if t.kind == tokLParen {
+			depth++
+		}
+	}
+	isKeyword := func(i int, kw string) bool {
+		return i < len(tokens) && tokens[i].kind == tokIdent && !tokens[i].quoted && tokens[i].upper == kw && depths[i] == 0
+	}
+
+	// 1. 解析 FROM/UPDATE/INTO 后面的表引用
+	var conditions [][2]int // 条件区间 [start, end)
+	for i := 0; i < len(tokens); i++ {
+		if !(isKeyword(i, "FROM") || isKeyword(i, "UPDATE")) {
+			continue
+		}
+		j := i + 1
+		for j < len(tokens) {
+			if depths[j] == 0 && tokens[j].kind == tokIdent && !tokens[j].quoted && fromTerminators[tokens[j].upper] {
+				break
+			}
+			if tokens[j].kind == tokLParen {
+				// 子查询或派生表，跳过
+				j = skipParens(tokens, j)
+				j = skipAlias(tokens, depths, j, shape, aliases, "")
+				continue
+			}
+			if tokens[j].kind == tokComma || (tokens[j].kind == tokIdent && !tokens[j].quoted && joinKeywords[tokens[j].upper] && tokens[j].upper != "ON") {
+				j++
+				continue
+			}
+			if isKeyword(j, "ON") {
+				start := j + 1
+				k := start
+				for k < len(tokens) {
+					if depths[k] == 0 && tokens[k].kind == tokIdent && !tokens[k].quoted && (fromTerminators[tokens[k].upper] || joinKeywords[tokens[k].upper] && tokens[k].upper != "ON") {
+						break
+					}
+					if depths[k] == 0 && tokens[k].kind == tokComma {
+						break
+					}
+					k++
+				}
+				conditions = append(conditions, [2]int{start, k})
+				j = k
+				continue
+			}
+			if tokens[j].kind == tokIdent {
+				name := tokens[j].text
+				j++
+				for j+1 < len(tokens) && tokens[j].kind == tokDot && tokens[j+1].kind == tokIdent {
+					name += "." + tokens[j+1].text
+					j += 2
+				}
+				j = skipAlias(tokens, depths, j, shape, aliases, name)
+				continue
+			}
+			j++
+		}
+		i = j - 1
+	}
+
+	// 2. WHERE/HAVING 条件区间
+	for i := 0; i < len(tokens); i++ {
+		if isKeyword(i, "WHERE") {
+			k := i + 1
+			for k < len(tokens) && !(depths[k] == 0 && tokens[k].kind == tokIdent && !tokens[k].quoted && fromTerminators[tokens[k].upper] && tokens[k].upper != "SET") {
+				k++
+			}
+			conditions = append(conditions, [2]int{i + 1, k})
+		}
+	}
+
+	resolve := func(qualifier, column string) (ColumnRef, bool) {
+		if qualifier != "" {
+			if name, ok := aliases[strings.ToLower(qualifier)]; ok {
+				return ColumnRef{Table: name, Column: column}, true
+			}
+			return ColumnRef{}, false
+		}
+		if len(shape.Tables) == 1 {
+			return ColumnRef{Table: shape.Tables[0].Name, Column: column}, true
+		}
+		return ColumnRef{}, false
+	}
+	// 读取 [qualifier.]column 形式的列引用
+	readColumn := func(i int) (ColumnRef, int, bool) {
+		if i >= len(tokens) || tokens[i].kind != tokIdent {
+			return ColumnRef{}, i, false
+		}
+		if i+2 < len(tokens) && tokens[i+1].kind == tokDot && tokens[i+2].kind == tokIdent {
+			// schema.table.column 只取最后两段
+			if i+4 < len(tokens) && tokens[i+3].kind == tokDot && tokens[i+4].kind == tokIdent {
+				ref, ok := resolve(tokens[i+2].text, tokens[i+4].text)
+				return ref, i + 5, ok
+			}
+			ref, ok := resolve(tokens[i].text, tokens[i+2].text)
+			return ref, i + 3, ok
+		}
+		if !tokens[i].quoted && sqlReserved[tokens[i].upper] {
+			return ColumnRef{}, i + 1, false
+		}
+		if i+1 < len(tokens) && tokens[i+1].kind == tokLParen {
+			// 函数调用
+			return ColumnRef{}, i + 1, false
+		}
+		ref, ok := resolve("", tokens[i].text)
+		return ref, i + 1, ok
+	}
+
+	// 3. 在条件中识别 列 运算符 值 的模式
+	for _, cond := range conditions {
+		for i := cond[0]; i < cond[1]; {
+			left, next, ok := readColumn(i)
+			if !ok || next >= cond[1] {
+				if next <= i {
+					next = i + 1
+				}
+				i = next
+				continue
+			}
+			op := tokens[next]
+			opText := op.text
+			if op.kind == tokIdent {
+				opText = op.upper
+				if opText == "NOT" && next+1 < cond[1] {
+					opText = "NOT " + tokens[next+1].upper
+				}
+			}
+			switch opText {
+			case "=", "<=>":
+				if right, _, ok := readColumn(next + 1); ok && next+1 < cond[1] && tokens[next+1].kind == tokIdent {
+					shape.JoinCols = appendColumn(shape.JoinCols, left)
+					shape.JoinCols = appendColumn(shape.JoinCols, right)
+				} else {
+					shape.EqualityCols = appendColumn(shape.EqualityCols, left)
+				}
+			case "IN", "IS":
+				shape.EqualityCols = appendColumn(shape.EqualityCols, left)
+			case "<", ">", "<=", ">=", "BETWEEN":
+				shape.RangeCols = appendColumn(shape.RangeCols, left)
+			case "LIKE":
+				// 只有前缀匹配才能使用索引
+				if next+1 < cond[1] && tokens[next+1].kind == tokString && !strings.HasPrefix(tokens[next+1].text, "'%") {
+					shape.RangeCols = appendColumn(shape.RangeCols, left)
+				}
+			}
+			i = next
+		}
+	}
+
+	// 4. ORDER BY / GROUP BY
+	for i := 0; i+1 < len(tokens); i++ {
+		if !(isKeyword(i, "ORDER") || isKeyword(i, "GROUP")) || !isKeyword(i+1, "BY") {
+			continue
+		}
+		group := tokens[i].upper == "GROUP"
+		j := i + 2
+		for j < len(tokens) && depths[j] == 0 {
+			if tokens[j].kind == tokIdent && !tokens[j].quoted && fromTerminators[tokens[j].upper] {
+				break
+			}
+			ref, next, ok := readColumn(j)
+			if ok {
+				if group {
+					shape.GroupByCols = appendColumn(shape.GroupByCols, ref)
+				} else {
+					shape.OrderByCols = appendColumn(shape.OrderByCols, ref)
+				}
+			}
+			// 跳到下一个逗号
+			for next < len(tokens) && depths[next] == 0 && tokens[next].kind != tokComma &&
+				!(tokens[next].kind == tokIdent && !tokens[next].quoted && fromTerminators[tokens[next].upper]) {
+				next++
+			}
+			if next < len(tokens) && tokens[next].kind == tokComma {
+				next++
+			}
+			if next <= j {
+				next = j + 1
+			}
+			j = next
+		}
+	}
+	return shape
+}
+
+// skipParens 跳过匹配的括号，返回右括号之后的位置
+func skipParens(tokens []sqlToken, i int) int {
+	depth := 0
+	for ; i < len(tokens); i++ {
+		switch tokens[i].kind {
+		case tokLParen:
+			depth++
+		case tokRParen:
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return i
+}
+
+// skipAlias 读取可选的 [AS] alias，并登记表引用
+func skipAlias(tokens []sqlToken, depths []int, j int, shape *QueryShape, aliases map[string]string, name string) int {
+	alias := ""
+	if j < len(tokens) && tokens[j].kind == tokIdent && !tokens[j].quoted && tokens[j].upper == "AS" {
+		j++
+	}
+	if j < len(tokens) && depths[j] == 0 && tokens[j].kind == tokIdent &&
+		(tokens[j].quoted || (!fromTerminators[tokens[j].upper] && !joinKeywords[tokens[j].upper] && !sqlReserved[tokens[j].upper])) {
+		alias = tokens[j].text
+		j++
+	}
+	if name == "" {
+		return j
+	}
+	shape.Tables = append(shape.Tables, TableRef{Name: name, Alias: alias})
+	aliases[strings.ToLower(name)] = name
+	if _, short := SplitTableName(name); short != name {
+		aliases[strings.ToLower(short)] = name
+	}
+	if alias != "" {
+		aliases[strings.ToLower(alias)] = name
+	}
+	return j
+}
+
+func appendColumn(cols []ColumnRef, ref ColumnRef) []ColumnRef {
+	for _, c := range cols {
+		if c.Table == ref.Table && strings.EqualFold(c.Column, ref.Column) {
+			return cols
+		}
+	}
+	return append(cols, ref)
+}
+
+// sqlReserved 不能作为列名或别名的常见关键字
+var sqlReserved = map[string]bool{
+	"AND": true, "OR": true, "NOT": true, "NULL": true, "TRUE": true, "FALSE": true,
+	"IS": true, "IN": true, "LIKE": true, "BETWEEN": true, "EXISTS": true, "CASE": true,
+	"WHEN": true, "THEN": true, "ELSE": true, "END": true, "SELECT": true, "DISTINCT": true,
+	"ASC": true, "DESC": true, "INTERVAL": true, "ANY": true, "ALL": true, "SOME": true,
+	"CURRENT_DATE": true, "CURRENT_TIMESTAMP": true, "NOW": true, "AS": true,
+	"USE": true, "FORCE": true, "IGNORE": true, "INDEX": true, "KEY": true,
+	"PARTITION": true, "TABLESAMPLE": true, "ONLY": true, "NULLS": true, "FIRST": true, "LAST": true,
+}
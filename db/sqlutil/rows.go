@@ -0,0 +1,49 @@
+package sqlutil
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// ScanRows 读取结果集，最多保留 limit 行（limit<=0 表示全部保留），返回列名、行数据和总行数
+func ScanRows(rows *sql.Rows, limit int) ([]string, []map[string]interface{}, int, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("failed to get columns: %v", err)
+	}
+
+	var results []map[string]interface{}
+	total := 0
+	for rows.Next() {
+		total++
+		values := make([]interface{}, len(columns))
+		valuePtrs := make([]interface{}, len(columns))
+		for i := range columns {
+			valuePtrs[i] = &values[i]
+		}
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return nil, nil, 0, fmt.Errorf("failed to scan row: %v", err)
+		}
+		if limit > 0 && len(results) >= limit {
+			continue
+		}
+		results = append(results, RowToMap(columns, values))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, 0, fmt.Errorf("row iteration error: %v", err)
+	}
+	return columns, results, total, nil
+}
+
+// RowToMap 将一行扫描结果转换为 列名->值 的映射，[]byte 转为字符串
+func RowToMap(columns []string, values []interface{}) map[string]interface{} {
+	row := make(map[string]interface{}, len(columns))
+	for i, col := range columns {
+		if b, ok := values[i].([]byte); ok {
+			row[col] = string(b)
+		} else {
+			row[col] = values[i]
+		}
+	}
+	return row
+}
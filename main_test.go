@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"xz_mcp/db/sqlite_db"
+)
+
+func TestCopyTableCreatesSQLiteTarget(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	sandbox, err := sqlite_db.NewSandbox([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(old *sqlite_db.Sandbox) { sqliteSandbox = old }(sqliteSandbox)
+	sqliteSandbox = sandbox
+
+	src, err := sqlite_db.Open(filepath.Join(dir, "source.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)",
+		"INSERT INTO users VALUES (1, 'alice'), (2, 'bob')",
+	} {
+		if _, err := src.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	src.Close()
+
+	copyTable := func(createIfMissing bool) *mcp.CallToolResult {
+		var request mcp.CallToolRequest
+		request.Params.Arguments = map[string]interface{}{
+			"source_engine":     "sqlite",
+			"source_db_path":    "source.db",
+			"source_sql":        "SELECT id, name FROM users",
+			"target_engine":     "sqlite",
+			"target_db_path":    "archive/users.db",
+			"target_table":      "users",
+			"create_table":      true,
+			"create_if_missing": createIfMissing,
+		}
+		result, err := handleCopyTable(ctx, request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	if err := os.Mkdir(filepath.Join(dir, "archive"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if result := copyTable(false); !result.IsError {
+		t.Fatal("copy into a missing database should fail without create_if_missing")
+	}
+	if result := copyTable(true); result.IsError {
+		t.Fatalf("copy_table: %+v", result.Content)
+	}
+
+	dst, err := sqlite_db.Open(filepath.Join(dir, "archive", "users.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dst.Close()
+	var count int
+	if err := dst.Get(&count, "SELECT COUNT(*) FROM users"); err != nil || count != 2 {
+		t.Fatalf("copied rows = %d, %v", count, err)
+	}
+}